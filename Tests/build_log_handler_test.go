@@ -0,0 +1,60 @@
+package Tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+func TestBuildDockerImageWithHandlerForwardsMessages(t *testing.T) {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+	dc := newFakeBuildDaemon(t)
+
+	var messages []dockercli.BuildLog
+	imageID, err := dc.BuildDockerImageWithHandler(context.Background(), dockercli.BuildImageOptions{
+		ImageTag: "kasm/a:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir,
+	}, func(logMsg dockercli.BuildLog) {
+		messages = append(messages, logMsg)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, fakeBuildImageID, imageID)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "Step 1/2 : FROM scratch\n", messages[0].Stream)
+	assert.Equal(t, fakeBuildImageID, messages[1].ImageID())
+	assert.Empty(t, messages[2].ImageID())
+
+	_, err = dc.BuildDockerImageWithHandler(context.Background(), dockercli.BuildImageOptions{
+		ImageTag: "kasm/broken:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir,
+	}, func(dockercli.BuildLog) {})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "COPY failed: file not found")
+}
+
+func TestBuildDockerImageReturnsImageID(t *testing.T) {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+
+	imageID, err := newFakeBuildDaemon(t).BuildDockerImage(context.Background(), "kasm/a:1", "Dockerfile", contextDir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, fakeBuildImageID, imageID)
+}
+
+func TestJSONBuildLogHandler(t *testing.T) {
+	var out bytes.Buffer
+	handle := dockercli.NewJSONBuildLogHandler(&out)
+	handle(dockercli.BuildLog{Stream: "Step 1/2 : FROM scratch\n"})
+	handle(dockercli.BuildLog{Aux: json.RawMessage(`{"ID":"sha256:abc"}`)})
+	handle(dockercli.BuildLog{Error: "COPY failed"})
+
+	assert.Equal(t,
+		`{"stream":"Step 1/2 : FROM scratch\n"}`+"\n"+`{"aux":{"ID":"sha256:abc"}}`+"\n"+`{"error":"COPY failed"}`+"\n",
+		out.String())
+}
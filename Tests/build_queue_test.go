@@ -0,0 +1,87 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+// fakeBuildImageID is the image ID reported in the aux message of successful fake builds.
+const fakeBuildImageID = "sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6"
+
+// newFakeBuildDaemon answers image builds with a successful build output including an aux message, or an error message for tags
+// containing "broken".
+func newFakeBuildDaemon(t *testing.T) *dockercli.DockerClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/build") {
+			http.NotFound(w, r)
+			return
+		}
+		tag := r.URL.Query().Get("t")
+		if strings.Contains(tag, "broken") {
+			_, _ = w.Write([]byte(`{"stream":"Step 1/2 : FROM scratch\n"}` + "\n" + `{"error":"COPY failed: file not found"}` + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"stream":"Step 1/2 : FROM scratch\n"}` + "\n" +
+			`{"aux":{"ID":"` + fakeBuildImageID + `"}}` + "\n" +
+			`{"stream":"Successfully tagged ` + tag + `\n"}` + "\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.41"))
+	require.NoError(t, err)
+	return dockercli.NewDockerClient(cli, 1, 0, 1, 0, 0)
+}
+
+func TestBuildQueueReportsProgress(t *testing.T) {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+
+	queue := dockercli.NewBuildQueue(newFakeBuildDaemon(t), 2)
+	builds := []dockercli.BuildImageOptions{
+		{ImageTag: "kasm/a:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir},
+		{ImageTag: "kasm/broken:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir},
+		{ImageTag: "kasm/c:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir},
+	}
+
+	events := map[string][]dockercli.BuildEventType{}
+	imageIDs := map[string]string{}
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		for event := range queue.Events() {
+			events[event.ImageTag] = append(events[event.ImageTag], event.Type)
+			if event.Type == dockercli.BuildEventSucceeded {
+				imageIDs[event.ImageTag] = event.ImageID
+			}
+			if event.Type == dockercli.BuildEventLogLine {
+				lines = append(lines, event.Line)
+			}
+		}
+		close(done)
+	}()
+
+	err := queue.Run(context.Background(), builds)
+	<-done
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to build 1 of 3 images")
+	assert.Contains(t, err.Error(), "COPY failed")
+	assert.Equal(t, dockercli.BuildEventQueued, events["kasm/a:1"][0])
+	assert.Equal(t, dockercli.BuildEventStarted, events["kasm/a:1"][1])
+	assert.Equal(t, dockercli.BuildEventSucceeded, events["kasm/a:1"][len(events["kasm/a:1"])-1])
+	assert.Equal(t, dockercli.BuildEventSucceeded, events["kasm/c:1"][len(events["kasm/c:1"])-1])
+	assert.Equal(t, dockercli.BuildEventFailed, events["kasm/broken:1"][len(events["kasm/broken:1"])-1])
+	assert.Contains(t, lines, "Successfully tagged kasm/a:1")
+	assert.Equal(t, map[string]string{"kasm/a:1": fakeBuildImageID, "kasm/c:1": fakeBuildImageID}, imageIDs)
+	assert.Contains(t, lines, "Error: COPY failed: file not found")
+}
@@ -0,0 +1,41 @@
+package Tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+func TestBuildDockerImageWithSettingsRejectsInvalidSettings(t *testing.T) {
+	for name, settings := range map[string]dockercli.BuildSettings{
+		"unknown builder":       {Builder: "kaniko"},
+		"unknown progress":      {Progress: "fancy"},
+		"progress with classic": {Builder: dockercli.BuilderClassic, Progress: dockercli.BuildProgressPlain},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := dockercli.BuildDockerImageWithSettings(context.Background(), 1, "Dockerfile", "kasm/a:1", settings)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBuildDockerImageWithBuilder(t *testing.T) {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+	dc := newFakeBuildDaemon(t)
+
+	_, err := dc.BuildDockerImageWithHandler(context.Background(), dockercli.BuildImageOptions{
+		ImageTag: "kasm/a:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir, Builder: dockercli.BuilderClassic,
+	}, func(dockercli.BuildLog) {})
+	assert.NoError(t, err)
+
+	_, err = dc.BuildDockerImageWithHandler(context.Background(), dockercli.BuildImageOptions{
+		ImageTag: "kasm/a:1", DockerfilePath: "Dockerfile", BuildContextPath: contextDir, Builder: "kaniko",
+	}, func(dockercli.BuildLog) {})
+	assert.Error(t, err)
+}
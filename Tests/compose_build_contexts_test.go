@@ -0,0 +1,162 @@
+package Tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"kasmlink/pkg/dockercompose"
+	"kasmlink/pkg/procedures"
+)
+
+func TestRewriteBuildContexts(t *testing.T) {
+	dir := t.TempDir()
+	composeDir := filepath.Join(dir, "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(composeDir, "app", "docker"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(composeDir, "app", "docker", "Dockerfile.prod"), []byte("FROM alpine\n"), 0644))
+	sharedDockerfile := filepath.Join(dir, "shared.Dockerfile")
+	require.NoError(t, os.WriteFile(sharedDockerfile, []byte("FROM alpine\n"), 0644))
+
+	composePath := filepath.Join(composeDir, "docker-compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`
+services:
+  app:
+    build:
+      context: ./app
+      dockerfile: docker/Dockerfile.prod
+  worker:
+    build: ./app
+  tools:
+    build:
+      context: app
+      dockerfile: `+sharedDockerfile+`
+  remote:
+    build: https://github.com/example/repo.git
+  db:
+    image: postgres:16
+`), 0644))
+
+	document := loadYAMLDocument(t, composePath)
+
+	uploads, err := procedures.RewriteBuildContexts(document, composeDir, "/srv/kasm")
+	require.NoError(t, err)
+
+	assert.Equal(t, []procedures.BuildContextUpload{
+		{LocalPath: filepath.Join(composeDir, "app"), RemoteDir: "/srv/kasm/build-contexts/app", IsDir: true, ServiceRef: "app"},
+		{LocalPath: sharedDockerfile, RemoteDir: "/srv/kasm/build-contexts/tools-dockerfile", ServiceRef: "tools"},
+	}, uploads)
+
+	var composeFile dockercompose.ComposeFile
+	require.NoError(t, document.Decode(&composeFile))
+	assert.Equal(t, "/srv/kasm/build-contexts/app", composeFile.Services["app"].Build.Context)
+	assert.Equal(t, "docker/Dockerfile.prod", composeFile.Services["app"].Build.Dockerfile)
+	assert.Equal(t, "/srv/kasm/build-contexts/app", composeFile.Services["worker"].Build.Context, "shared contexts are uploaded once")
+	assert.Equal(t, "/srv/kasm/build-contexts/tools-dockerfile/shared.Dockerfile", composeFile.Services["tools"].Build.Dockerfile)
+	assert.Equal(t, "https://github.com/example/repo.git", composeFile.Services["remote"].Build.Context)
+	assert.Nil(t, composeFile.Services["db"].Build)
+}
+
+func TestRewriteBuildContextsMissingContext(t *testing.T) {
+	var document yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("services:\n  app:\n    build: ./missing\n"), &document))
+
+	_, err := procedures.RewriteBuildContexts(&document, t.TempDir(), "/srv/kasm")
+	assert.ErrorContains(t, err, "service app")
+}
+
+func TestRewriteBuildContextsKeepsUnmodeledKeys(t *testing.T) {
+	composeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(composeDir, "app"), 0755))
+	composePath := filepath.Join(composeDir, "docker-compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`
+x-build: &default-build
+  context: ./app
+  args:
+    VERSION: "1.15"
+services:
+  app:
+    build: *default-build
+    command: sleep infinity
+    shm_size: 2gb
+    ipc: host
+    runtime: nvidia
+    security_opt:
+      - seccomp=unconfined
+    deploy:
+      resources:
+        reservations:
+          devices:
+            - driver: nvidia
+              count: all
+              capabilities: [gpu]
+  worker:
+    build: *default-build
+`), 0644))
+	document := loadYAMLDocument(t, composePath)
+
+	uploads, err := procedures.RewriteBuildContexts(document, composeDir, "/srv/kasm")
+	require.NoError(t, err)
+	require.Len(t, uploads, 1)
+
+	var rewritten map[string]any
+	require.NoError(t, document.Decode(&rewritten))
+	app := rewritten["services"].(map[string]any)["app"].(map[string]any)
+	assert.Equal(t, "sleep infinity", app["command"])
+	assert.Equal(t, "2gb", app["shm_size"])
+	assert.Equal(t, "host", app["ipc"])
+	assert.Equal(t, "nvidia", app["runtime"])
+	assert.Equal(t, []any{"seccomp=unconfined"}, app["security_opt"])
+	assert.Equal(t, map[string]any{
+		"resources": map[string]any{"reservations": map[string]any{"devices": []any{
+			map[string]any{"driver": "nvidia", "count": "all", "capabilities": []any{"gpu"}},
+		}}},
+	}, app["deploy"])
+	assert.Equal(t, map[string]any{"context": "/srv/kasm/build-contexts/app", "args": map[string]any{"VERSION": "1.15"}}, app["build"])
+	assert.Equal(t, "/srv/kasm/build-contexts/app", rewritten["services"].(map[string]any)["worker"].(map[string]any)["build"].(map[string]any)["context"])
+	assert.Equal(t, "./app", rewritten["x-build"].(map[string]any)["context"], "the anchored extension is left untouched")
+}
+
+func TestDeployComposeFileUploadsBuildContexts(t *testing.T) {
+	server := newTestSSHServer(t, healthyComposeNode)
+	server.EnableSFTP()
+
+	composeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(composeDir, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(composeDir, "app", "Dockerfile"), []byte("FROM alpine\n"), 0644))
+	composePath := filepath.Join(composeDir, "stack.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte("services:\n  db:\n    build: ./app\n    command: sleep infinity\n    shm_size: 1gb\n"), 0644))
+	targetDir := filepath.Join(t.TempDir(), "kasm stack")
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+
+	options := procedures.ComposeDeployOptions{ProjectName: "kasm", StatusCheckDelay: time.Millisecond, SSH: server.config}
+	require.NoError(t, procedures.DeployComposeFile(context.Background(), composePath, targetDir, options))
+
+	remoteContext := filepath.Join(targetDir, "build-contexts", "db")
+	assert.Contains(t, server.Commands(), "mkdir -p '"+filepath.ToSlash(remoteContext)+"'")
+	assert.FileExists(t, filepath.Join(remoteContext, "Dockerfile"))
+
+	var deployed map[string]any
+	data, err := os.ReadFile(filepath.Join(targetDir, "stack.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &deployed))
+	assert.Equal(t, map[string]any{
+		"build":    filepath.ToSlash(remoteContext),
+		"command":  "sleep infinity",
+		"shm_size": "1gb",
+	}, deployed["services"].(map[string]any)["db"])
+}
+
+// loadYAMLDocument parses a file into a YAML node tree.
+func loadYAMLDocument(t *testing.T, filePath string) *yaml.Node {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	var document yaml.Node
+	require.NoError(t, yaml.Unmarshal(data, &document))
+	return &document
+}
@@ -0,0 +1,174 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"kasmlink/pkg/dockercompose"
+	"kasmlink/pkg/procedures"
+)
+
+func TestWriteServiceEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "docker-compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`
+services:
+  db:
+    image: postgres:16
+    env_file: common.env
+  app:
+    image: kasm/app:1
+`), 0644))
+	document := loadYAMLDocument(t, composePath)
+
+	paths, err := procedures.WriteServiceEnvFiles(document, map[string]map[string]string{
+		"db":  {"POSTGRES_PASSWORD": "s3cr3t $HOME # not a comment", "POSTGRES_DB": "kasm"},
+		"app": {"MODE": "prod"},
+	}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, ".env.app"), filepath.Join(dir, ".env.db")}, paths)
+
+	content, err := os.ReadFile(filepath.Join(dir, ".env.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "POSTGRES_DB='kasm'\nPOSTGRES_PASSWORD='s3cr3t $HOME # not a comment'\n", string(content))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, ".env.db"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	var composeFile dockercompose.ComposeFile
+	require.NoError(t, document.Decode(&composeFile))
+	assert.Equal(t, dockercompose.StringList{"common.env", ".env.db"}, composeFile.Services["db"].EnvFile)
+	assert.Equal(t, dockercompose.StringList{".env.app"}, composeFile.Services["app"].EnvFile)
+}
+
+func TestWriteServiceEnvFilesRejectsInvalidInput(t *testing.T) {
+	var document yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("services:\n  db:\n    image: postgres:16\n"), &document))
+
+	_, err := procedures.WriteServiceEnvFiles(&document, map[string]map[string]string{"cache": {"A": "b"}}, t.TempDir())
+	assert.ErrorContains(t, err, "not part of the compose file")
+
+	_, err = procedures.WriteServiceEnvFiles(&document, map[string]map[string]string{"db": {"A": "line\nbreak"}}, t.TempDir())
+	assert.Error(t, err)
+
+	_, err = procedures.WriteServiceEnvFiles(&document, map[string]map[string]string{"db": {"BAD KEY": "x"}}, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestWriteServiceEnvFilesKeepsUnmodeledKeys(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "docker-compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`
+x-env: &shared-env
+  - common.env
+services:
+  db:
+    image: postgres:16
+    command: postgres -c max_connections=200
+    shm_size: 256mb
+    env_file: *shared-env
+  app:
+    image: kasm/app:1
+    env_file:
+      - path: .env.app
+        required: false
+`), 0644))
+	document := loadYAMLDocument(t, composePath)
+
+	_, err := procedures.WriteServiceEnvFiles(document, map[string]map[string]string{
+		"db":  {"POSTGRES_DB": "kasm"},
+		"app": {"MODE": "prod"},
+	}, dir)
+	require.NoError(t, err)
+
+	var rewritten map[string]any
+	require.NoError(t, document.Decode(&rewritten))
+	services := rewritten["services"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"image":    "postgres:16",
+		"command":  "postgres -c max_connections=200",
+		"shm_size": "256mb",
+		"env_file": []any{"common.env", ".env.db"},
+	}, services["db"])
+	assert.Equal(t, []any{map[string]any{"path": ".env.app", "required": false}}, services["app"].(map[string]any)["env_file"],
+		"an env file already listed in the long syntax is not added twice")
+	assert.Equal(t, []any{"common.env"}, rewritten["x-env"], "the anchored list is left untouched")
+}
+
+func TestServiceEnvFilesKeepUnmodeledKeysOnDeploy(t *testing.T) {
+	server := newTestSSHServer(t, healthyComposeNode)
+	server.EnableSFTP()
+
+	composeFile := filepath.Join(t.TempDir(), "stack.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte("services:\n  db:\n    image: postgres:16\n    command: sleep infinity\n    shm_size: 1gb\n"), 0o644))
+	targetDir := t.TempDir()
+
+	options := procedures.ComposeDeployOptions{
+		ProjectName:      "kasm",
+		StatusCheckDelay: time.Millisecond,
+		ServiceEnv:       map[string]map[string]string{"db": {"POSTGRES_PASSWORD": "s3cret"}},
+		SSH:              server.config,
+	}
+	require.NoError(t, procedures.DeployComposeFile(context.Background(), composeFile, targetDir, options))
+
+	var deployed map[string]any
+	data, err := os.ReadFile(filepath.Join(targetDir, "stack.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &deployed))
+	assert.Equal(t, map[string]any{
+		"image":    "postgres:16",
+		"command":  "sleep infinity",
+		"shm_size": "1gb",
+		"env_file": []any{".env.db"},
+	}, deployed["services"].(map[string]any)["db"])
+}
+
+// healthyComposeNode answers the docker commands of DeployComposeFile and TeardownComposeFile like a node on
+// which every service starts, and runs the remaining commands, such as chmod and rm, on the local machine.
+func healthyComposeNode(command string, channel io.ReadWriter) (string, uint32) {
+	switch {
+	case strings.Contains(command, "ps --all --format json"):
+		return `{"Service":"db","Name":"kasm-db-1","State":"running","ExitCode":0,"Status":"Up 1 second"}` + "\n", 0
+	case strings.HasPrefix(command, "docker ") || strings.Contains(command, "sh -c 'docker "):
+		return "", 0
+	}
+	return runLocally(command, channel)
+}
+
+func TestServiceEnvFilesOnRemotePathWithSpaces(t *testing.T) {
+	server := newTestSSHServer(t, healthyComposeNode)
+	server.EnableSFTP()
+
+	composeFile := filepath.Join(t.TempDir(), "stack.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte("services:\n  db:\n    image: postgres:16\n"), 0o644))
+	targetDir := filepath.Join(t.TempDir(), "kasm stack")
+	require.NoError(t, os.MkdirAll(targetDir, 0o755))
+	remoteEnvFile := filepath.Join(targetDir, procedures.ServiceEnvFileName("db"))
+
+	options := procedures.ComposeDeployOptions{
+		ProjectName:      "kasm",
+		StatusCheckDelay: time.Millisecond,
+		ServiceEnv:       map[string]map[string]string{"db": {"POSTGRES_PASSWORD": "s3cret"}},
+		SSH:              server.config,
+	}
+	require.NoError(t, procedures.DeployComposeFile(context.Background(), composeFile, targetDir, options))
+	assert.Contains(t, server.Commands(), "chmod 600 '"+remoteEnvFile+"'")
+	info, err := os.Stat(remoteEnvFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, procedures.TeardownComposeFile(context.Background(), composeFile, targetDir, options, false))
+	assert.Contains(t, server.Commands(), "rm -f '"+remoteEnvFile+"'")
+	assert.NoFileExists(t, remoteEnvFile)
+}
@@ -170,3 +170,28 @@ func TestComposeFileWithPartialStructure(t *testing.T) {
 	// Assert that the unmarshaled data matches the original
 	assert.Equal(t, composeFile, unmarshaledComposeFile)
 }
+
+// TestComposeFileProfiles Tests that profiles are validated and select the services started by compose up.
+func TestComposeFileProfiles(t *testing.T) {
+	var composeFile dockercompose.ComposeFile
+	err := yaml.Unmarshal([]byte(`services:
+  web:
+    image: nginx
+  debug:
+    image: busybox
+    profiles: [debug]
+  prometheus:
+    image: prom/prometheus
+    profiles: [monitoring, debug]
+`), &composeFile)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"debug", "monitoring"}, composeFile.Profiles())
+	assert.Equal(t, []string{"web"}, composeFile.ActiveServices(nil))
+	assert.Equal(t, []string{"prometheus", "web"}, composeFile.ActiveServices([]string{"monitoring"}))
+
+	assert.NoError(t, composeFile.ValidateProfiles([]string{"monitoring"}))
+	err = composeFile.ValidateProfiles([]string{"monitoring", "metrics"})
+	assert.ErrorContains(t, err, "metrics")
+	assert.ErrorContains(t, err, "available profiles: [debug, monitoring]")
+}
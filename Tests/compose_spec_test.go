@@ -0,0 +1,111 @@
+package Tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+const servicesSpec = `network: kasm_custom
+services:
+  - name: db
+    image: kasm/db:1.0
+    environment:
+      POSTGRES_DB: kasm
+  - name: nfs
+    ports: ["2049:2049"]
+    depends_on: [db]
+`
+
+func writeServicesSpec(t *testing.T, spec string, dockerfiles map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range dockerfiles {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644))
+	}
+	specPath := filepath.Join(dir, "services.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0o644))
+	return specPath
+}
+
+func TestGenerateComposeFromSpec(t *testing.T) {
+	specPath := writeServicesSpec(t, servicesSpec, map[string]string{
+		"container/Dockerfile.db":  "FROM postgres:16\n",
+		"container/Dockerfile.nfs": "FROM alpine\n",
+	})
+
+	composeFile, err := procedures.GenerateComposeFromSpec(specPath, "")
+	require.NoError(t, err)
+
+	require.Len(t, composeFile.Services, 2)
+	db := composeFile.Services["db"]
+	require.NotNil(t, db.Build)
+	assert.Equal(t, "container", db.Build.Context)
+	assert.Equal(t, "Dockerfile.db", db.Build.Dockerfile)
+	assert.Equal(t, "kasm/db:1.0", db.Image)
+	assert.Equal(t, map[string]string{"POSTGRES_DB": "kasm"}, db.Environment)
+	assert.Equal(t, []string{"kasm_custom"}, db.NetworkConfig.Networks)
+
+	nfs := composeFile.Services["nfs"]
+	assert.Equal(t, "Dockerfile.nfs", nfs.Build.Dockerfile)
+	assert.Equal(t, []string{"db"}, nfs.DependsOn)
+	assert.True(t, composeFile.Networks["kasm_custom"].External)
+}
+
+func TestGenerateComposeFromSpecDockerfilesOverride(t *testing.T) {
+	specPath := writeServicesSpec(t, "services:\n  - name: web\n", map[string]string{
+		"images/web.Dockerfile": "FROM nginx\n",
+	})
+
+	_, err := procedures.GenerateComposeFromSpec(specPath, "")
+	assert.ErrorContains(t, err, "failed to find Dockerfile for service web")
+
+	composeFile, err := procedures.GenerateComposeFromSpec(specPath, "images")
+	require.NoError(t, err)
+	assert.Equal(t, "images", composeFile.Services["web"].Build.Context)
+	assert.Equal(t, "web.Dockerfile", composeFile.Services["web"].Build.Dockerfile)
+}
+
+func TestGenerateComposeFromSpecOverlappingServiceNames(t *testing.T) {
+	specPath := writeServicesSpec(t, "services:\n  - name: db\n  - name: dbadmin\n  - name: web\n", map[string]string{
+		"container/Dockerfile.db":      "FROM postgres:16\n",
+		"container/dbadmin/Dockerfile": "FROM dpage/pgadmin4\n",
+		"container/web-frontend.df":    "FROM nginx\n",
+	})
+
+	composeFile, err := procedures.GenerateComposeFromSpec(specPath, "")
+	require.NoError(t, err)
+	assert.Equal(t, "container", composeFile.Services["db"].Build.Context)
+	assert.Equal(t, "Dockerfile.db", composeFile.Services["db"].Build.Dockerfile)
+	assert.Equal(t, "container/dbadmin", composeFile.Services["dbadmin"].Build.Context)
+	assert.Equal(t, "Dockerfile", composeFile.Services["dbadmin"].Build.Dockerfile)
+	// Without a conventional name the glob fallback still finds a unique match.
+	assert.Equal(t, "web-frontend.df", composeFile.Services["web"].Build.Dockerfile)
+}
+
+func TestGenerateComposeFromSpecAmbiguousGlob(t *testing.T) {
+	specPath := writeServicesSpec(t, "services:\n  - name: db\n", map[string]string{
+		"container/db-primary.df": "FROM postgres:16\n",
+		"container/dbadmin.df":    "FROM dpage/pgadmin4\n",
+	})
+
+	_, err := procedures.GenerateComposeFromSpec(specPath, "")
+	assert.ErrorContains(t, err, "multiple Dockerfiles found for service 'db'")
+}
+
+func TestGenerateComposeFromSpecDockerfileOverride(t *testing.T) {
+	spec := "dockerfiles:\n  db: tools/postgres.Dockerfile\nservices:\n  - name: db\n"
+	specPath := writeServicesSpec(t, spec, map[string]string{
+		"container/Dockerfile.db":   "FROM postgres:15\n",
+		"tools/postgres.Dockerfile": "FROM postgres:16\n",
+	})
+
+	composeFile, err := procedures.GenerateComposeFromSpec(specPath, "")
+	require.NoError(t, err)
+	assert.Equal(t, "tools", composeFile.Services["db"].Build.Context)
+	assert.Equal(t, "postgres.Dockerfile", composeFile.Services["db"].Build.Dockerfile)
+}
@@ -0,0 +1,119 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/procedures"
+)
+
+func TestParseComposeStatusFormats(t *testing.T) {
+	ndjson := `{"Service":"db","Name":"kasm-db-1","State":"running","Health":"healthy","ExitCode":0,"Status":"Up 5 seconds (healthy)"}
+{"Service":"init","Name":"kasm-init-1","State":"exited","Health":"","ExitCode":0,"Status":"Exited (0) 3 seconds ago"}
+`
+	statuses, err := dockercli.ParseComposeStatus(ndjson)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "db", statuses[0].Service)
+	assert.Equal(t, "healthy", statuses[0].Health)
+	assert.False(t, statuses[0].Failed())
+	assert.False(t, statuses[1].Failed(), "one-shot services exiting with 0 are not failed")
+
+	array := `[{"Service":"api","Name":"kasm-api-1","State":"restarting","ExitCode":1,"Status":"Restarting (1) 2 seconds ago"}]`
+	statuses, err = dockercli.ParseComposeStatus(array)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Failed())
+
+	statuses, err = dockercli.ParseComposeStatus("\n")
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+
+	_, err = dockercli.ParseComposeStatus("not json")
+	assert.Error(t, err)
+}
+
+func TestComposeServiceStatusFailed(t *testing.T) {
+	assert.True(t, dockercli.ComposeServiceStatus{State: "exited", ExitCode: 137}.Failed())
+	assert.True(t, dockercli.ComposeServiceStatus{State: "dead"}.Failed())
+	assert.True(t, dockercli.ComposeServiceStatus{State: "running", Health: "unhealthy"}.Failed())
+	assert.False(t, dockercli.ComposeServiceStatus{State: "running", Health: "starting"}.Failed())
+}
+
+// crashingComposeNode answers the docker commands of DeployComposeFile like a node on which the api service
+// crash-loops after docker compose up succeeded.
+func crashingComposeNode(command string, _ io.ReadWriter) (string, uint32) {
+	switch {
+	case strings.Contains(command, " up -d"):
+		return " Container kasm-api-1  Started\n", 0
+	case strings.Contains(command, "ps --all --format json"):
+		return `{"Service":"db","Name":"kasm-db-1","State":"running","ExitCode":0,"Status":"Up 1 second"}
+{"Service":"api","Name":"kasm-api-1","State":"restarting","ExitCode":1,"Status":"Restarting (1) 1 second ago"}
+`, 0
+	case strings.Contains(command, "logs --no-color --tail 20 api"):
+		return "api-1  | panic: missing DATABASE_URL\n", 0
+	case strings.HasPrefix(command, "if [ -f"):
+		return "kasmlink-backup-created\n", 0
+	}
+	return "", 0
+}
+
+func TestDeployComposeFileReportsCrashedService(t *testing.T) {
+	server := newTestSSHServer(t, crashingComposeNode)
+	server.EnableSFTP()
+
+	composeFile := filepath.Join(t.TempDir(), "stack.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte("services:\n  db:\n    image: postgres:16\n  api:\n    image: kasm/api:1.0\n"), 0o644))
+
+	options := procedures.ComposeDeployOptions{ProjectName: "kasm", StatusCheckDelay: time.Millisecond, SSH: server.config}
+	err := procedures.DeployComposeFile(context.Background(), composeFile, t.TempDir(), options)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service api is restarting")
+	assert.Contains(t, err.Error(), "panic: missing DATABASE_URL")
+	assert.NotContains(t, err.Error(), "service db")
+
+	options.SkipStatusCheck = true
+	require.NoError(t, procedures.DeployComposeFile(context.Background(), composeFile, t.TempDir(), options))
+}
+
+func TestDeployComposeFileRollsBackCrashedDeployment(t *testing.T) {
+	server := newTestSSHServer(t, crashingComposeNode)
+	server.EnableSFTP()
+
+	composeFile := filepath.Join(t.TempDir(), "stack.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte("services:\n  api:\n    image: kasm/api:2.0\n"), 0o644))
+	// The space makes the paths in the backup and restore commands need shell quoting.
+	targetDir := filepath.Join(t.TempDir(), "kasm stack")
+	require.NoError(t, os.MkdirAll(targetDir, 0o755))
+
+	options := procedures.ComposeDeployOptions{ProjectName: "kasm", StatusCheckDelay: time.Millisecond, Rollback: true, SSH: server.config}
+	err := procedures.DeployComposeFile(context.Background(), composeFile, targetDir, options)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, procedures.ErrRolledBack)
+	assert.Contains(t, err.Error(), "service api is restarting")
+
+	remoteFile := filepath.Join(targetDir, "stack.yaml")
+	var backups, restores, ups int
+	for _, command := range server.Commands() {
+		switch {
+		case strings.Contains(command, fmt.Sprintf("cp -p '%s' '%s.bak'", remoteFile, remoteFile)):
+			backups++
+		case command == fmt.Sprintf("cp -p '%s.bak' '%s'", remoteFile, remoteFile):
+			restores++
+		case strings.Contains(command, " up -d"):
+			ups++
+		}
+	}
+	assert.Equal(t, 1, backups)
+	assert.Equal(t, 1, restores)
+	assert.Equal(t, 2, ups, "the restored compose file is started again")
+}
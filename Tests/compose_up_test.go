@@ -0,0 +1,106 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+// failingExecutor fails every command with the given output, like a remote command writing to stderr.
+type failingExecutor struct {
+	output   string
+	commands []string
+}
+
+func (e *failingExecutor) ExecuteCommand(ctx context.Context, command string) (string, error) {
+	e.commands = append(e.commands, command)
+	return e.output, fmt.Errorf("command execution failed: exit status 1, stderr: %s", e.output)
+}
+
+func TestComposeUpReturnsOutputAndService(t *testing.T) {
+	output := " Container kasm-web-1  Starting\n" +
+		"Error response from daemon: driver failed programming external connectivity on endpoint kasm-web-1: " +
+		"Bind for 0.0.0.0:443 failed: port is already allocated\n"
+	executor := &failingExecutor{output: output}
+	dc := dockercli.NewRemoteDockerClient(executor, 1)
+
+	got, err := dc.ComposeUp(context.Background(), dockercli.ComposeProject{File: "/opt/kasm/docker-compose.yaml"}, true, nil, []string{"db", "web"})
+	require.Error(t, err)
+	assert.Equal(t, output, got)
+	assert.Equal(t, []string{"docker compose -f /opt/kasm/docker-compose.yaml up -d --build"}, executor.commands)
+
+	var composeErr *dockercli.ComposeError
+	require.True(t, errors.As(err, &composeErr))
+	assert.Equal(t, "web", composeErr.Service)
+	assert.Contains(t, err.Error(), "for service web")
+	assert.Contains(t, err.Error(), "port is already allocated")
+}
+
+func TestFailedComposeService(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		services []string
+		expected string
+	}{
+		{"quoted service", `service "proxy" refers to undefined network backend: invalid compose project`, nil, "proxy"},
+		{"progress line", " ✘ api Error pull access denied for private/api, repository does not exist\n", nil, "api"},
+		{"container line is not a service", " ✘ Container kasm-db-1  Error\n", nil, ""},
+		{"container name", " ✘ Container kasm-db-1  Error\n", []string{"db", "web"}, "db"},
+		{"longest service wins", "Error response from daemon: container kasm-web-api-1 is unhealthy", []string{"web", "web-api"}, "web-api"},
+		{"no service", "unknown flag: --bogus", []string{"web"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, dockercli.FailedComposeService(tt.output, tt.services))
+		})
+	}
+}
+
+func TestComposeProjectArgsOnUpAndDown(t *testing.T) {
+	executor := &failingExecutor{output: "no such service"}
+	dc := dockercli.NewRemoteDockerClient(executor, 1)
+	project := dockercli.ComposeProject{File: "/opt/stacks/a/docker-compose.yaml", Name: "stack-a", EnvFile: "/opt/stacks/a/.env"}
+
+	_, _ = dc.ComposeUp(context.Background(), project, false, nil, nil)
+	_, _ = dc.ComposeDown(context.Background(), project, true)
+	assert.Equal(t, []string{
+		"docker compose -f /opt/stacks/a/docker-compose.yaml -p stack-a --env-file /opt/stacks/a/.env up -d",
+		"docker compose -f /opt/stacks/a/docker-compose.yaml -p stack-a --env-file /opt/stacks/a/.env down --volumes",
+	}, executor.commands)
+}
+
+func TestComposeUpPassesProfiles(t *testing.T) {
+	executor := &failingExecutor{output: "no such service"}
+	dc := dockercli.NewRemoteDockerClient(executor, 1)
+	project := dockercli.ComposeProject{File: "/opt/stacks/a/docker-compose.yaml", Name: "stack-a"}
+
+	_, _ = dc.ComposeUp(context.Background(), project, false, []string{"debug", "monitoring"}, nil)
+	assert.Equal(t, []string{
+		"docker compose -f /opt/stacks/a/docker-compose.yaml -p stack-a --profile debug --profile monitoring up -d",
+	}, executor.commands)
+}
+
+func TestComposeServiceCommands(t *testing.T) {
+	executor := &failingExecutor{output: "Error response from daemon: container kasm-web-1 is not running"}
+	dc := dockercli.NewRemoteDockerClient(executor, 1)
+	project := dockercli.ComposeProject{File: "/opt/kasm/docker-compose.yaml", Name: "kasm"}
+
+	_, _ = dc.ComposeStop(context.Background(), project)
+	_, _ = dc.ComposeStart(context.Background(), project, "db")
+	_, err := dc.ComposeRestart(context.Background(), project, "api", "web")
+	assert.Equal(t, []string{
+		"docker compose -f /opt/kasm/docker-compose.yaml -p kasm stop",
+		"docker compose -f /opt/kasm/docker-compose.yaml -p kasm start db",
+		"docker compose -f /opt/kasm/docker-compose.yaml -p kasm restart api web",
+	}, executor.commands)
+
+	var composeErr *dockercli.ComposeError
+	require.True(t, errors.As(err, &composeErr))
+	assert.Equal(t, "web", composeErr.Service)
+}
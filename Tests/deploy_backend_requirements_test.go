@@ -0,0 +1,85 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/procedures"
+)
+
+const backendCompose = `services:
+  db:
+    image: postgres:16
+  nfs:
+    image: kasm/nfs:1.0
+`
+
+// backendNode answers the docker commands of DeployBackendRequirements like a node with the given networks
+// and running compose services.
+func backendNode(networks, running string) sshExecHandler {
+	return func(command string, _ io.ReadWriter) (string, uint32) {
+		switch {
+		case strings.Contains(command, "network ls"):
+			return networks, 0
+		case strings.Contains(command, "network create"):
+			return "f00dfeed\n", 0
+		case strings.Contains(command, "ps --services"):
+			return running, 0
+		case strings.Contains(command, "ps --all --format json"):
+			return `{"Service":"db","Name":"kasm-db-1","State":"running","Health":"","ExitCode":0,"Status":"Up 1 second"}` + "\n", 0
+		case strings.Contains(command, " up -d"):
+			return " Container kasm-db-1  Started\n", 0
+		}
+		return "unexpected command", 1
+	}
+}
+
+func writeBackendCompose(t *testing.T) string {
+	composeFile := filepath.Join(t.TempDir(), "backend.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte(backendCompose), 0o644))
+	return composeFile
+}
+
+func TestDeployBackendRequirementsIsIdempotent(t *testing.T) {
+	server := newTestSSHServer(t, backendNode("kasm_custom_old\nkasm_custom\n", "nfs\ndb\n"))
+
+	err := procedures.DeployBackendRequirements(context.Background(), server.config, procedures.BackendSpec{
+		ComposeFilePath: writeBackendCompose(t),
+		TargetNodePath:  t.TempDir(),
+		ComposeOptions:  procedures.ComposeDeployOptions{ProjectName: "kasm-backend"},
+		Network:         dockercli.NetworkOptions{Name: "kasm_custom"},
+	})
+	require.NoError(t, err)
+
+	for _, command := range server.Commands() {
+		assert.NotContains(t, command, "network create")
+		assert.NotContains(t, command, " up -d")
+	}
+}
+
+func TestDeployBackendRequirementsStartsMissingServices(t *testing.T) {
+	server := newTestSSHServer(t, backendNode("kasm_custom_old\n", "db\n"))
+	server.EnableSFTP()
+	targetDir := t.TempDir()
+
+	err := procedures.DeployBackendRequirements(context.Background(), server.config, procedures.BackendSpec{
+		ComposeFilePath: writeBackendCompose(t),
+		TargetNodePath:  targetDir,
+		ComposeOptions:  procedures.ComposeDeployOptions{ProjectName: "kasm-backend", StatusCheckDelay: time.Millisecond},
+		Network:         dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16"},
+	})
+	require.NoError(t, err)
+
+	commands := strings.Join(server.Commands(), "\n")
+	assert.Contains(t, commands, "network create")
+	assert.Contains(t, commands, "-p kasm-backend up -d")
+	assert.FileExists(t, filepath.Join(targetDir, "backend.yaml"))
+}
@@ -0,0 +1,69 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+// imageLoadNode answers docker load with loadExit and runs df, rm and the other commands on the local machine.
+func imageLoadNode(loadExit uint32) sshExecHandler {
+	return func(command string, channel io.ReadWriter) (string, uint32) {
+		switch {
+		case strings.Contains(command, "docker load"):
+			if loadExit != 0 {
+				return "open /var/lib/docker/tmp: no space left on device\n", loadExit
+			}
+			return "Loaded image: kasm/workspace:1.0\n", 0
+		case strings.HasPrefix(command, "docker "):
+			return "", 1
+		}
+		return runLocally(command, channel)
+	}
+}
+
+func TestDeployKasmDockerImageRemovesTarAfterLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		loadExit uint32
+		tarKept  bool
+	}{
+		{name: "load succeeds", loadExit: 0, tarKept: false},
+		{name: "load fails", loadExit: 1, tarKept: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestSSHServer(t, imageLoadNode(tc.loadExit))
+			server.EnableSFTP()
+
+			localTar := filepath.Join(t.TempDir(), "workspace image.tar")
+			require.NoError(t, os.WriteFile(localTar, []byte("image layers"), 0o644))
+			targetDir := filepath.Join(t.TempDir(), "kasm images")
+			require.NoError(t, os.MkdirAll(targetDir, 0o755))
+			remoteTar := filepath.Join(targetDir, "workspace image.tar")
+
+			err := procedures.DeployKasmDockerImage(context.Background(), "kasm/workspace:1.0", "", targetDir, localTar,
+				procedures.ImageDeployOptions{SSH: server.config})
+
+			commands := strings.Join(server.Commands(), "\n")
+			assert.Contains(t, commands, "docker load -i '"+remoteTar+"'")
+			if tc.tarKept {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "tar kept at "+remoteTar)
+				assert.FileExists(t, remoteTar)
+				assert.NotContains(t, commands, "rm -f")
+				return
+			}
+			require.NoError(t, err)
+			assert.NoFileExists(t, remoteTar)
+			assert.Contains(t, server.Commands(), "rm -f '"+remoteTar+"'", "rm runs without sudo")
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package Tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/userParser"
+)
+
+const overlayBaseConfig = `user_details:
+  - target_user:
+      username: alice
+    role: student
+    assigned_container_tag: kasmweb/core:dev
+    environment_args:
+      LANG: en_US
+  - target_user:
+      username: bob
+    assigned_container_tag: kasmweb/core:dev
+groups:
+  - name: students
+    priority: 100
+images:
+  - name: kasmweb/core:dev
+    friendly_name: Core
+    cores: 1
+    memory: 1073741824
+    enabled: true
+manual_fields:
+  - user_details[alice].target_user.password
+`
+
+// writeDeploymentFiles writes the base configuration and overlay and returns their paths.
+func writeDeploymentFiles(t *testing.T, base, overlay string) (string, string) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "deployment.yaml")
+	overlayPath := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte(base), 0644))
+	require.NoError(t, os.WriteFile(overlayPath, []byte(overlay), 0644))
+	return basePath, overlayPath
+}
+
+func TestDeploymentOverlayOverridesWorkspaceTag(t *testing.T) {
+	basePath, overlayPath := writeDeploymentFiles(t, overlayBaseConfig, `user_details:
+  - target_user:
+      username: alice
+    assigned_container_tag: kasmweb/core:prod
+    environment_args:
+      TZ: UTC
+  - target_user:
+      username: carol
+    assigned_container_tag: kasmweb/core:prod
+images:
+  - name: kasmweb/core:dev
+    cores: 4
+`)
+
+	config, err := userParser.LoadDeploymentConfigWithOverlay(basePath, overlayPath)
+	require.NoError(t, err)
+
+	require.Len(t, config.UserDetails, 3)
+	alice := config.UserDetails[0]
+	assert.Equal(t, "alice", alice.TargetUser.Username)
+	assert.Equal(t, "kasmweb/core:prod", alice.AssignedContainerTag)
+	assert.Equal(t, "student", alice.Role, "fields missing from the overlay keep their base value")
+	assert.Equal(t, map[string]string{"LANG": "en_US", "TZ": "UTC"}, alice.EnvironmentArgs)
+	assert.Equal(t, "kasmweb/core:dev", config.UserDetails[1].AssignedContainerTag)
+	assert.Equal(t, "carol", config.UserDetails[2].TargetUser.Username)
+
+	require.Len(t, config.Images, 1)
+	assert.Equal(t, float64(4), config.Images[0].Cores)
+	assert.Equal(t, "Core", config.Images[0].FriendlyName)
+	assert.Equal(t, 1073741824, config.Images[0].Memory)
+
+	assert.Equal(t, []userParser.GroupDetails{{Name: "students", Priority: 100}}, config.Groups)
+}
+
+func TestDeploymentOverlayUnionsGroupsAndReplacesOtherLists(t *testing.T) {
+	basePath, overlayPath := writeDeploymentFiles(t, overlayBaseConfig, `groups:
+  - name: students
+    priority: 50
+  - name: teachers
+    priority: 10
+manual_fields: []
+`)
+
+	config, err := userParser.LoadDeploymentConfigWithOverlay(basePath, overlayPath)
+	require.NoError(t, err)
+	assert.Equal(t, []userParser.GroupDetails{{Name: "students", Priority: 50}, {Name: "teachers", Priority: 10}}, config.Groups)
+	assert.Empty(t, config.ManualFields)
+	assert.Len(t, config.UserDetails, 2)
+}
+
+func TestDeploymentOverlayEmptyPathLoadsBase(t *testing.T) {
+	basePath, _ := writeDeploymentFiles(t, overlayBaseConfig, "")
+
+	config, err := userParser.LoadDeploymentConfigWithOverlay(basePath, "")
+	require.NoError(t, err)
+	expected, err := userParser.LoadDeploymentConfig(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, config)
+}
+
+func TestDeploymentOverlayRejectsNonMapping(t *testing.T) {
+	basePath, overlayPath := writeDeploymentFiles(t, overlayBaseConfig, "- alice\n")
+
+	_, err := userParser.LoadDeploymentConfigWithOverlay(basePath, overlayPath)
+	assert.ErrorContains(t, err, "must both be YAML mappings")
+}
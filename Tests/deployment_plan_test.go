@@ -0,0 +1,106 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+// newPlanFixture seeds a fake deployment with one image, two users and a group.
+func newPlanFixture() *testutil.FakeKasmServer {
+	fake := testutil.NewFakeKasmServer()
+	fake.AddGroup("students")
+	fake.AddUser(webApi.TargetUser{Username: "alice", FirstName: "Alice"})
+	fake.AddUser(webApi.TargetUser{Username: "stale"})
+	fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", FriendlyName: "Core", Cores: 1, Memory: 1 << 30, Enabled: true, ImageType: "Container"})
+	fake.AddImage(webApi.TargetImage{Name: "kasmweb/old:1.0", FriendlyName: "Old", Cores: 1, Memory: 1 << 30, Enabled: true, ImageType: "Container"})
+	return fake
+}
+
+func desiredDeployment() *userParser.DeploymentConfig {
+	return &userParser.DeploymentConfig{
+		UsersConfig: userParser.UsersConfig{UserDetails: []userParser.UserDetails{
+			{TargetUser: webApi.TargetUser{Username: "Alice", LastName: "Smith"}, Role: "students"},
+			{TargetUser: webApi.TargetUser{Username: "bob"}, Role: "students"},
+		}},
+		Groups: []userParser.GroupDetails{{Name: "students"}},
+		Images: []webApi.TargetImage{
+			{Name: "kasmweb/core:1.0", FriendlyName: "Core", Cores: 2, Memory: 1 << 30, Enabled: true, ImageType: "Container"},
+			{Name: "kasmweb/new:1.0", FriendlyName: "New", Cores: 1, Memory: 1 << 30, Enabled: true, ImageType: "Container"},
+		},
+	}
+}
+
+func planSummary(plan *procedures.Plan) []string {
+	var summary []string
+	for _, change := range plan.Changes {
+		summary = append(summary, change.String())
+	}
+	return summary
+}
+
+func TestPlanDeployment(t *testing.T) {
+	fake := newPlanFixture()
+	defer fake.Close()
+
+	plan, err := procedures.PlanDeployment(context.Background(), fake.API(), desiredDeployment(), procedures.PlanOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"~ image kasmweb/core:1.0 (cores: 1 -> 2)",
+		"+ image kasmweb/new:1.0",
+		"~ user Alice (last_name:  -> Smith, role: <empty> -> students)",
+		"+ user bob",
+	}, planSummary(plan))
+
+	plan, err = procedures.PlanDeployment(context.Background(), fake.API(), desiredDeployment(), procedures.PlanOptions{Delete: true})
+	require.NoError(t, err)
+	summary := planSummary(plan)
+	assert.Equal(t, []string{"- user stale", "- image kasmweb/old:1.0"}, summary[len(summary)-2:])
+}
+
+func TestApplyPlanConverges(t *testing.T) {
+	fake := newPlanFixture()
+	defer fake.Close()
+	api := fake.API()
+	ctx := context.Background()
+
+	plan, err := procedures.PlanDeployment(ctx, api, desiredDeployment(), procedures.PlanOptions{Delete: true})
+	require.NoError(t, err)
+	credentials, err := procedures.ApplyPlan(ctx, api, plan)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, "bob", credentials[0].Username)
+	assert.NotEmpty(t, credentials[0].Password)
+
+	plan, err = procedures.PlanDeployment(ctx, api, desiredDeployment(), procedures.PlanOptions{Delete: true})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), "unexpected changes: %v", planSummary(plan))
+
+	alice, err := api.GetUser(ctx, "", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", alice.FirstName, "fields missing from the configuration are kept")
+	assert.Equal(t, "Smith", alice.LastName)
+}
+
+func TestApplyPlanRequiresExistingGroups(t *testing.T) {
+	fake := newPlanFixture()
+	defer fake.Close()
+
+	desired := desiredDeployment()
+	desired.Groups = append(desired.Groups, userParser.GroupDetails{Name: "teachers"})
+	plan, err := procedures.PlanDeployment(context.Background(), fake.API(), desired, procedures.PlanOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "+ group teachers", plan.Changes[0].String())
+
+	requests := len(fake.Requests())
+	_, err = procedures.ApplyPlan(context.Background(), fake.API(), plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "teachers")
+	assert.Len(t, fake.Requests(), requests, "nothing is applied")
+}
@@ -0,0 +1,93 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+const systemDfOutput = `{"Active":"3","Reclaimable":"1.2GB (50%)","Size":"2.4GB","TotalCount":"7","Type":"Images"}
+{"Active":"2","Reclaimable":"0B (0%)","Size":"512kB","TotalCount":"2","Type":"Containers"}
+{"Active":"1","Reclaimable":"104.9MB (100%)","Size":"104.9MB","TotalCount":"2","Type":"Local Volumes"}
+{"Active":"0","Reclaimable":"1.5TB","Size":"1.5TB","TotalCount":"12","Type":"Build Cache"}
+`
+
+func TestParseDiskUsage(t *testing.T) {
+	usage, err := dockercli.ParseDiskUsage(systemDfOutput)
+	require.NoError(t, err)
+
+	assert.Equal(t, dockercli.DiskUsageEntry{TotalCount: 7, Active: 3, Size: 2_400_000_000, Reclaimable: 1_200_000_000}, usage.Images)
+	assert.Equal(t, dockercli.DiskUsageEntry{TotalCount: 2, Active: 2, Size: 512_000, Reclaimable: 0}, usage.Containers)
+	assert.Equal(t, dockercli.DiskUsageEntry{TotalCount: 2, Active: 1, Size: 104_900_000, Reclaimable: 104_900_000}, usage.Volumes)
+	assert.Equal(t, dockercli.DiskUsageEntry{TotalCount: 12, Active: 0, Size: 1_500_000_000_000, Reclaimable: 1_500_000_000_000}, usage.BuildCache)
+	assert.Equal(t, int64(2_400_000_000+512_000+104_900_000+1_500_000_000_000), usage.TotalSize())
+	assert.Equal(t, int64(1_200_000_000+104_900_000+1_500_000_000_000), usage.TotalReclaimable())
+}
+
+func TestParseDiskUsageSizes(t *testing.T) {
+	cases := []struct {
+		size    string
+		bytes   int64
+		errText string
+	}{
+		{size: "1.2GB", bytes: 1_200_000_000},
+		{size: "512kB", bytes: 512_000},
+		{size: "512KB", bytes: 512_000},
+		{size: "0B", bytes: 0},
+		{size: "17B", bytes: 17},
+		{size: "3.5MB (12%)", bytes: 3_500_000},
+		{size: "", bytes: 0},
+		{size: "12 GB", bytes: 12_000_000_000},
+		{size: "12GiB", errText: "invalid size"},
+		{size: "lots", errText: "unknown size unit"},
+		{size: "x.yGB", errText: "invalid size"},
+	}
+	for _, tc := range cases {
+		output := fmt.Sprintf(`{"Type":"Images","TotalCount":"1","Active":"1","Size":%q,"Reclaimable":"0B"}`, tc.size)
+		usage, err := dockercli.ParseDiskUsage(output)
+		if tc.errText != "" {
+			assert.ErrorContains(t, err, tc.errText, tc.size)
+			continue
+		}
+		if assert.NoError(t, err, tc.size) {
+			assert.Equal(t, tc.bytes, usage.Images.Size, tc.size)
+		}
+	}
+}
+
+func TestParseDiskUsageMalformedLines(t *testing.T) {
+	cases := []struct {
+		name    string
+		output  string
+		errText string
+	}{
+		{name: "truncated json", output: `{"Type":"Images","Size":"1GB"`, errText: "failed to parse docker system df output"},
+		{name: "bad count", output: `{"Type":"Images","TotalCount":"many","Size":"1GB"}`, errText: "invalid total count"},
+		{name: "bad active count", output: `{"Type":"Images","Active":"-","Size":"1GB"}`, errText: "invalid active count"},
+		{name: "bad reclaimable", output: `{"Type":"Images","Size":"1GB","Reclaimable":"half (50%)"}`, errText: "invalid reclaimable size"},
+	}
+	for _, tc := range cases {
+		_, err := dockercli.ParseDiskUsage(tc.output)
+		assert.ErrorContains(t, err, tc.errText, tc.name)
+	}
+
+	// Warnings and unknown types are skipped.
+	usage, err := dockercli.ParseDiskUsage("WARNING: daemon is slow\n" + `{"Type":"Snapshots","Size":"1GB"}` + "\n")
+	require.NoError(t, err)
+	assert.Zero(t, usage.TotalSize())
+}
+
+func TestGetDiskUsageRemote(t *testing.T) {
+	usage, err := dockercli.GetDiskUsage(context.Background(), scriptedExecutor{
+		"docker system df --format '{{json .}}'": systemDfOutput,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2_400_000_000), usage.Images.Size)
+
+	_, err = dockercli.GetDiskUsage(context.Background(), scriptedExecutor{})
+	assert.ErrorContains(t, err, "failed to retrieve remote Docker disk usage")
+}
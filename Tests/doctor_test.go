@@ -0,0 +1,75 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestRunDoctorChecksReportsFailuresWithHints(t *testing.T) {
+	checks := []procedures.DoctorCheck{
+		{Name: "ok", Critical: true, Hint: "unused", Run: func(context.Context) (string, error) { return "fine", nil }},
+		{Name: "optional", Hint: "install it", Run: func(context.Context) (string, error) { return "", errors.New("missing") }},
+	}
+
+	results, healthy := procedures.RunDoctorChecks(context.Background(), checks)
+	assert.True(t, healthy, "non-critical failures do not fail the run")
+	assert.Equal(t, []procedures.DoctorResult{
+		{Name: "ok", Critical: true, Passed: true, Detail: "fine"},
+		{Name: "optional", Passed: false, Detail: "missing", Hint: "install it"},
+	}, results)
+
+	checks[1].Critical = true
+	_, healthy = procedures.RunDoctorChecks(context.Background(), checks)
+	assert.False(t, healthy)
+}
+
+func TestDockerDoctorCheck(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker version --format '{{.Server.Version}}'": "27.3.1\n",
+	}, 1)
+	detail, err := procedures.DockerDoctorCheck(dc).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "version 27.3.1", detail)
+
+	missing := dockercli.NewRemoteDockerClient(scriptedExecutor{}, 1)
+	_, err = procedures.DockerDoctorCheck(missing).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestKasmDoctorCheck(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+
+	detail, err := procedures.KasmDoctorCheck(fake.API()).Run(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, detail, fake.URL)
+
+	kApi := fake.API()
+	kApi.APIKeySecret = "wrong"
+	_, err = procedures.KasmDoctorCheck(kApi).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSSHDoctorCheck(t *testing.T) {
+	server := newTestSSHServer(t, func(command string, channel io.ReadWriter) (string, uint32) {
+		return "", 0
+	})
+
+	detail, err := procedures.SSHDoctorCheck(server.config).Run(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, detail, "tester@127.0.0.1")
+	assert.Equal(t, []string{"true"}, server.Commands())
+}
+
+func TestEmbeddedTemplatesDoctorCheck(t *testing.T) {
+	_, err := procedures.EmbeddedTemplatesDoctorCheck().Run(context.Background())
+	assert.NoError(t, err)
+}
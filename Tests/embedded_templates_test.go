@@ -0,0 +1,24 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/procedures"
+)
+
+func TestListEmbeddedTemplates(t *testing.T) {
+	assert.Contains(t, procedures.ListEmbeddedTemplates(), "postgres.yaml")
+	assert.Contains(t, procedures.ListEmbeddedDockerfiles(), "dockerfile-nfs-server")
+}
+
+func TestDescribeEmbeddedTemplates(t *testing.T) {
+	descriptions := map[string]string{}
+	for _, template := range procedures.DescribeEmbeddedTemplates() {
+		descriptions[template.Name] = template.Description
+	}
+
+	assert.Equal(t, "Use an official openSUSE image as the base", descriptions["dockerfile-nfs-server"])
+	assert.Empty(t, descriptions["example-service.yaml"], "a comment repeating the file name is no description")
+	assert.Empty(t, descriptions["nfs.yaml"])
+}
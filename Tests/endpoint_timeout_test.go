@@ -0,0 +1,54 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func newSlowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+func TestEndpointTimeoutShortensRequest(t *testing.T) {
+	server := newSlowServer(300 * time.Millisecond)
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	kApi.Retries = 1
+	kApi.EndpointTimeouts = map[string]time.Duration{"/api/public/get_users": 50 * time.Millisecond}
+
+	_, err := kApi.MakePostRequest(context.Background(), "/api/public/get_users", struct{}{})
+	assert.ErrorContains(t, err, "deadline exceeded")
+
+	// Endpoints without an override keep the client-wide timeout.
+	_, err = kApi.MakePostRequest(context.Background(), "/api/public/get_images", struct{}{})
+	assert.NoError(t, err)
+}
+
+func TestEndpointTimeoutExtendsClientTimeout(t *testing.T) {
+	server := newSlowServer(300 * time.Millisecond)
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 100*time.Millisecond)
+	kApi.Retries = 1
+	kApi.EndpointTimeouts = map[string]time.Duration{"api/public/request_kasm": time.Second}
+
+	_, err := kApi.MakePostRequest(context.Background(), "/api/public/request_kasm", struct{}{})
+	assert.NoError(t, err)
+
+	_, err = kApi.MakePostRequest(context.Background(), "/api/public/get_kasms", struct{}{})
+	assert.Error(t, err)
+}
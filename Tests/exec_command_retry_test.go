@@ -0,0 +1,86 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+// newExecServer answers exec_command_kasm with the scripted responses in order and repeats the last one.
+func newExecServer(t *testing.T, responses ...func(w http.ResponseWriter)) (*webApi.KasmAPI, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		responses[min(n, len(responses))-1](w)
+	}))
+	t.Cleanup(server.Close)
+	return webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second), &requests
+}
+
+func execReply(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestExecCommandRetriesUntilSessionIsReady(t *testing.T) {
+	kApi, requests := newExecServer(t,
+		execReply(http.StatusBadRequest, `{"error_message":"Kasm agent is not available"}`),
+		execReply(http.StatusOK, `{"error_message":"Session not ready"}`),
+		execReply(http.StatusOK, `{}`),
+	)
+
+	err := kApi.ExecCommand(context.Background(), webApi.ExecCommandRequest{KasmID: "k1", ExecConfig: webApi.ExecConfigRequest{Cmd: "touch /tmp/ready"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestExecCommandReturnsCommandErrorsImmediately(t *testing.T) {
+	kApi, requests := newExecServer(t, execReply(http.StatusOK, `{"error_message":"Command failed with exit code 127"}`))
+
+	err := kApi.ExecCommand(context.Background(), webApi.ExecCommandRequest{KasmID: "k1", ExecConfig: webApi.ExecConfigRequest{Cmd: "missing-binary"}})
+	assert.ErrorContains(t, err, "exit code 127")
+	assert.NotErrorIs(t, err, webApi.ErrSessionNotReady)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestExecCommandGivesUpWhenContextExpires(t *testing.T) {
+	kApi, requests := newExecServer(t, execReply(http.StatusBadRequest, `{"error_message":"Session not ready"}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	err := kApi.ExecCommand(ctx, webApi.ExecCommandRequest{KasmID: "k1", ExecConfig: webApi.ExecConfigRequest{Cmd: "true"}})
+	assert.ErrorIs(t, err, webApi.ErrSessionNotReady)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, requests.Load(), int32(2))
+}
+
+func TestExecCommandDoesNotRetryOtherUnavailableErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		reply func(w http.ResponseWriter)
+	}{
+		{name: "missing image", reply: execReply(http.StatusBadRequest, `{"error_message":"Image not available"}`)},
+		{name: "missing file", reply: execReply(http.StatusOK, `{"error_message":"File not available"}`)},
+		{name: "server error", reply: execReply(http.StatusServiceUnavailable, `{"error_message":"Service Unavailable"}`)},
+	}
+	for _, tc := range cases {
+		kApi, requests := newExecServer(t, tc.reply)
+		kApi.Retries = 1
+
+		start := time.Now()
+		err := kApi.ExecCommand(context.Background(), webApi.ExecCommandRequest{KasmID: "k1", ExecConfig: webApi.ExecConfigRequest{Cmd: "true"}})
+		assert.Error(t, err, tc.name)
+		assert.NotErrorIs(t, err, webApi.ErrSessionNotReady, tc.name)
+		assert.Equal(t, int32(1), requests.Load(), tc.name)
+		assert.Less(t, time.Since(start), time.Second, tc.name)
+	}
+}
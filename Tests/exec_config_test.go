@@ -0,0 +1,75 @@
+package Tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func TestExecConfigMatchesDocumentedShape(t *testing.T) {
+	var target webApi.TargetImage
+	err := webApi.NewExecConfig().
+		FirstLaunch(map[string]string{"LAUNCH_URL": "https://kasmweb.com"}, "bash -c 'firefox $LAUNCH_URL'").
+		Go("bash -c 'firefox --new-tab $LAUNCH_URL'").
+		ApplyTo(&target)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"first_launch": {"cmd": "bash -c 'firefox $LAUNCH_URL'", "environment": {"LAUNCH_URL": "https://kasmweb.com"}},
+		"go": {"cmd": "bash -c 'firefox --new-tab $LAUNCH_URL'"}
+	}`, target.ExecConfig)
+
+	// The string decodes into the exec_config returned by the API.
+	var execConfig webApi.ExecConfig
+	require.NoError(t, json.Unmarshal([]byte(target.ExecConfig), &execConfig))
+	assert.Equal(t, "bash -c 'firefox $LAUNCH_URL'", execConfig.FirstLaunch.Cmd)
+	assert.Equal(t, "https://kasmweb.com", execConfig.FirstLaunch.Environment["LAUNCH_URL"])
+	assert.Equal(t, "bash -c 'firefox --new-tab $LAUNCH_URL'", execConfig.Go.Cmd)
+	assert.JSONEq(t, target.ExecConfig, webApi.TargetImageFromImage(webApi.Image{ExecConfig: execConfig}).ExecConfig)
+}
+
+func TestExecConfigValidation(t *testing.T) {
+	cases := map[string]*webApi.ExecConfigBuilder{
+		"empty command":     webApi.NewExecConfig().Go(" "),
+		"invalid env name":  webApi.NewExecConfig().FirstLaunch(map[string]string{"A=B": "1"}, "true"),
+		"unknown section":   webApi.NewExecConfig().Action("assign", webApi.ExecAction{Cmd: "true"}),
+		"duplicate section": webApi.NewExecConfig().Go("true").Go("false"),
+	}
+	for name, builder := range cases {
+		_, err := builder.Build()
+		assert.Error(t, err, name)
+	}
+
+	empty, err := webApi.NewExecConfig().Build()
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestLaunchConfigBuilder(t *testing.T) {
+	var target webApi.TargetImage
+	err := webApi.NewLaunchConfig(webApi.LaunchFormField{Key: "url", Label: "URL", Required: true}).
+		Field(webApi.LaunchFormField{Key: "mode", Label: "Mode", InputType: webApi.LaunchInputSelect, Options: []string{"light", "dark"}}).
+		ApplyTo(&target)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"launch_form": [
+		{"key": "url", "label": "URL", "value": "", "placeholder": "", "input_type": "text", "allow_saving": false,
+		 "required": true, "help": "", "options": [], "validator_regex": "", "validator_regex_description": ""},
+		{"key": "mode", "label": "Mode", "value": "", "placeholder": "", "input_type": "select", "allow_saving": false,
+		 "required": false, "help": "", "options": ["light", "dark"], "validator_regex": "", "validator_regex_description": ""}
+	]}`, string(target.LaunchConfig))
+
+	cases := map[string]*webApi.LaunchConfigBuilder{
+		"missing key":         webApi.NewLaunchConfig(webApi.LaunchFormField{Label: "URL"}),
+		"duplicate key":       webApi.NewLaunchConfig(webApi.LaunchFormField{Key: "url", Label: "URL"}, webApi.LaunchFormField{Key: "url", Label: "Other"}),
+		"select without list": webApi.NewLaunchConfig(webApi.LaunchFormField{Key: "mode", Label: "Mode", InputType: webApi.LaunchInputSelect}),
+		"invalid regex":       webApi.NewLaunchConfig(webApi.LaunchFormField{Key: "url", Label: "URL", ValidatorRegex: "("}),
+	}
+	for name, builder := range cases {
+		_, err := builder.Build()
+		assert.Error(t, err, name)
+	}
+}
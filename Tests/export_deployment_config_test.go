@@ -0,0 +1,123 @@
+package Tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestExportDeploymentConfig(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	api := fake.API()
+
+	fake.AddGroup("students")
+	bob := fake.AddUser(webApi.TargetUser{Username: "bob"})
+	alice := fake.AddUser(webApi.TargetUser{Username: "alice", FirstName: "Alice"})
+	image := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", FriendlyName: "Core", Cores: 2, Memory: 2 << 30, Enabled: true})
+	_, err := api.RequestKasmSession(context.Background(), alice.UserID, image.ImageID, nil)
+	require.NoError(t, err)
+
+	config, err := procedures.ExportDeploymentConfig(context.Background(), api)
+	require.NoError(t, err)
+
+	require.Len(t, config.UserDetails, 2)
+	assert.Equal(t, "alice", config.UserDetails[0].TargetUser.Username)
+	assert.Equal(t, "Alice", config.UserDetails[0].TargetUser.FirstName)
+	assert.Empty(t, config.UserDetails[0].TargetUser.UserID)
+	assert.Empty(t, config.UserDetails[0].TargetUser.Password)
+	assert.Equal(t, "kasmweb/core:1.0", config.UserDetails[0].AssignedContainerTag)
+	assert.Equal(t, bob.Username, config.UserDetails[1].TargetUser.Username)
+	assert.Empty(t, config.UserDetails[1].AssignedContainerTag)
+
+	assert.Equal(t, []userParser.GroupDetails{{Name: "students", Priority: 100}}, config.Groups)
+
+	require.Len(t, config.Images, 1)
+	assert.Equal(t, "kasmweb/core:1.0", config.Images[0].Name)
+	assert.Equal(t, 2<<30, config.Images[0].Memory)
+	assert.Empty(t, config.Images[0].ImageID)
+
+	assert.Equal(t, []string{
+		"user_details[alice].target_user.password",
+		"user_details[bob].target_user.password",
+		"user_details[bob].assigned_container_tag",
+	}, config.ManualFields)
+}
+
+// TestDeploymentConfigRoundTrip Tests that an exported file is readable as user configuration and that recording
+// progress keeps the groups and images.
+func TestDeploymentConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	config := &userParser.DeploymentConfig{
+		UsersConfig: userParser.UsersConfig{UserDetails: []userParser.UserDetails{
+			{TargetUser: webApi.TargetUser{Username: "alice"}, AssignedContainerTag: "kasmweb/core:1.0"},
+		}},
+		Groups: []userParser.GroupDetails{{Name: "students", Priority: 100}},
+		Images: []webApi.TargetImage{{
+			Name:           "kasmweb/core:1.0",
+			FriendlyName:   "Core",
+			Cores:          2,
+			Memory:         2 << 30,
+			ImageType:      "Container",
+			VolumeMappings: `{"/data":{"bind":"/home/kasm-user/data","mode":"rw"}}`,
+		}},
+	}
+	require.NoError(t, userParser.SaveDeploymentConfig(path, config))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "friendly_name: Core")
+	assert.Contains(t, string(data), "memory: 2147483648")
+
+	parser := userParser.NewUserParser()
+	require.NoError(t, parser.UpdateUserConfig(path, "alice", "user-1", "kasm-1", ""))
+
+	users, err := parser.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", users.UserDetails[0].TargetUser.UserID)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var reloaded userParser.DeploymentConfig
+	require.NoError(t, yaml.Unmarshal(data, &reloaded))
+	assert.Equal(t, config.Groups, reloaded.Groups)
+	require.Len(t, reloaded.Images, 1)
+	assert.Equal(t, config.Images[0].Memory, reloaded.Images[0].Memory)
+	assert.JSONEq(t, config.Images[0].VolumeMappings, reloaded.Images[0].VolumeMappings)
+}
+
+// TestExportedZeroResourceImageRoundTrip Tests that images without cores or memory, such as Server and Link
+// workspaces, can be exported and read back for planning.
+func TestExportedZeroResourceImageRoundTrip(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	api := fake.API()
+	fake.AddImage(webApi.TargetImage{Name: "docs-link", FriendlyName: "Docs", Enabled: true, CPUAllocationMethod: webApi.DefaultCPUAllocationMethod})
+
+	config, err := procedures.ExportDeploymentConfig(context.Background(), api)
+	require.NoError(t, err)
+	require.Len(t, config.Images, 1)
+	assert.Zero(t, config.Images[0].Cores)
+
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	require.NoError(t, userParser.SaveDeploymentConfig(path, config))
+	reloaded, err := userParser.LoadDeploymentConfig(path)
+	require.NoError(t, err)
+	require.Len(t, reloaded.Images, 1)
+	assert.Equal(t, "docs-link", reloaded.Images[0].Name)
+	assert.Zero(t, reloaded.Images[0].Cores)
+	assert.Zero(t, reloaded.Images[0].Memory)
+
+	plan, err := procedures.PlanDeployment(context.Background(), api, reloaded, procedures.PlanOptions{})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.Changes)
+}
@@ -0,0 +1,94 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestFakeKasmServerUserLifecycle(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	created, err := kApi.CreateUser(ctx, webApi.TargetUser{Username: "alice", FirstName: "Alice", Password: "S3cure!pass"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.UserID)
+	assert.Equal(t, "alice", created.Username)
+
+	byName, err := kApi.GetUser(ctx, "", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, created.UserID, byName.UserID)
+	assert.Equal(t, "Alice", byName.FirstName)
+
+	users, err := kApi.GetUsers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	require.NoError(t, kApi.DeleteUser(ctx, created.UserID, false))
+	users, err = kApi.GetUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestFakeKasmServerImagesAndSessions(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	user := fake.AddUser(webApi.TargetUser{Username: "bob"})
+	response, err := kApi.CreateImage(ctx, webApi.CreateImageRequest{TargetImage: webApi.TargetImage{
+		Name: "kasmweb/chrome:1.16.0", FriendlyName: "Chrome", Cores: 2, Memory: 2768000000, Enabled: true,
+	}})
+	require.NoError(t, err)
+	imageID := response.Image.ImageID
+	assert.NotEmpty(t, imageID)
+
+	images, err := kApi.ListImages(ctx)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "Chrome", images[0].FriendlyName)
+
+	foundID, err := kApi.GetImageIDByFriendlyName(ctx, "chrome")
+	require.NoError(t, err)
+	assert.Equal(t, imageID, foundID)
+
+	session, err := kApi.RequestKasmSession(ctx, user.UserID, imageID, nil)
+	require.NoError(t, err)
+
+	status, err := kApi.GetKasmStatus(ctx, user.UserID, session.KasmID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "running", status.OperationalStatus)
+	require.NotNil(t, status.Kasm)
+	assert.Equal(t, imageID, status.Kasm.ImageID)
+
+	reused, wasReused, err := kApi.RequestOrReuseSession(ctx, user.UserID, imageID, nil)
+	require.NoError(t, err)
+	assert.True(t, wasReused)
+	assert.Equal(t, session.KasmID, reused.KasmID)
+
+	require.NoError(t, kApi.DestroyKasmSession(ctx, session.KasmID, user.UserID))
+
+	kasms, err := kApi.GetKasms(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, kasms)
+}
+
+func TestFakeKasmServerRejectsWrongCredentials(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+
+	kApi := fake.API()
+	kApi.APIKeySecret = "wrong"
+
+	err := kApi.Ping(context.Background())
+	assert.True(t, errors.Is(err, webApi.ErrUnauthorized))
+	assert.Equal(t, []string{"/api/public/get_users"}, fake.Requests())
+}
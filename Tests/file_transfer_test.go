@@ -0,0 +1,105 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	shadowscp "kasmlink/pkg/scp"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// runLocally executes an exec request with the local shell, streaming stdin and stdout through the channel.
+func runLocally(command string, channel io.ReadWriter) (string, uint32) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = channel
+	cmd.Stdout = channel
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", uint32(exitErr.ExitCode())
+		}
+		return err.Error(), 1
+	}
+	return "", 0
+}
+
+// writeTree creates a small directory tree for upload tests.
+func writeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "entrypoint.sh"), []byte("#!/bin/sh\necho hi\n"), 0755))
+	return dir
+}
+
+func assertTreeCopied(t *testing.T, remoteDir string) {
+	t.Helper()
+	dockerfile, err := os.ReadFile(filepath.Join(remoteDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine\n", string(dockerfile))
+
+	info, err := os.Stat(filepath.Join(remoteDir, "nested", "entrypoint.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
+
+func TestShadowCopyDirUsesSFTP(t *testing.T) {
+	server := newTestSSHServer(t, runLocally)
+	server.EnableSFTP()
+	server.config.FileTransferProtocol = shadowssh.TransferSFTP
+
+	remoteDir := filepath.Join(t.TempDir(), "context")
+	require.NoError(t, shadowscp.ShadowCopyDir(context.Background(), writeTree(t), remoteDir, server.config))
+
+	assertTreeCopied(t, remoteDir)
+	assert.Empty(t, server.Commands(), "SFTP needs no remote commands")
+}
+
+func TestShadowCopyFallsBackToSCP(t *testing.T) {
+	if _, err := exec.LookPath("scp"); err != nil {
+		t.Skip("scp is not installed")
+	}
+	server := newTestSSHServer(t, runLocally) // No SFTP subsystem.
+
+	local := writeTree(t)
+	remoteDir := filepath.Join(t.TempDir(), "context")
+	require.NoError(t, shadowscp.ShadowCopyDir(context.Background(), local, remoteDir, server.config))
+	assertTreeCopied(t, remoteDir)
+
+	fileDir := t.TempDir()
+	require.NoError(t, shadowscp.ShadowCopyFile(context.Background(), filepath.Join(local, "Dockerfile"), fileDir, server.config))
+	copied, err := os.ReadFile(filepath.Join(fileDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine\n", string(copied))
+}
+
+func TestShadowCopyWithClientKeepsConnectionOpen(t *testing.T) {
+	server := newTestSSHServer(t, runLocally)
+	server.EnableSFTP()
+	server.config.FileTransferProtocol = shadowssh.TransferSFTP
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	local := writeTree(t)
+	remoteDir := filepath.Join(t.TempDir(), "context")
+	require.NoError(t, shadowscp.ShadowCopyDirWithClient(context.Background(), local, remoteDir, sshClient))
+	assertTreeCopied(t, remoteDir)
+
+	fileDir := t.TempDir()
+	require.NoError(t, shadowscp.ShadowCopyFileWithClient(context.Background(), filepath.Join(local, "Dockerfile"), fileDir, sshClient))
+	assert.FileExists(t, filepath.Join(fileDir, "Dockerfile"))
+
+	// The connection is still usable after the uploads.
+	_, err = sshClient.ExecuteCommand(context.Background(), "true")
+	assert.NoError(t, err)
+}
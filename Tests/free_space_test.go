@@ -0,0 +1,55 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/procedures"
+)
+
+const dfOutput = "Filesystem        1-blocks        Used   Available Capacity Mounted on\n" +
+	"/dev/sda1     105089261568 52544630784 47162241024      53% /srv/kasm images\n"
+
+func TestCheckFreeSpace(t *testing.T) {
+	cases := []struct {
+		name      string
+		output    string
+		required  int64
+		available int64
+		errText   string
+	}{
+		{name: "enough space", output: dfOutput, required: 4 << 30, available: 47162241024},
+		{name: "exactly enough", output: dfOutput, required: 47162241024, available: 47162241024},
+		{name: "insufficient", output: dfOutput, required: 47162241025, available: 47162241024, errText: "insufficient free space"},
+		{name: "header only", output: "Filesystem 1-blocks Used Available Capacity Mounted on\n", errText: "unexpected df output"},
+		{name: "truncated line", output: "Filesystem 1-blocks Used Available\n/dev/sda1 100 50\n", errText: "unexpected df output"},
+		{name: "not a number", output: "Filesystem 1-blocks Used Available\n/dev/sda1 100 50 lots 50% /\n", errText: "unexpected available space"},
+		{name: "empty", output: "", errText: "unexpected df output"},
+	}
+	for _, tc := range cases {
+		available, err := procedures.CheckFreeSpace(tc.output, tc.required)
+		assert.Equal(t, tc.available, available, tc.name)
+		if tc.errText == "" {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.ErrorContains(t, err, tc.errText, tc.name)
+		}
+	}
+}
+
+func TestRequiredFreeSpace(t *testing.T) {
+	cases := []struct {
+		size     int64
+		factor   float64
+		required int64
+	}{
+		{size: 1 << 30, factor: 1.5, required: 3 << 29},
+		{size: 1 << 30, factor: 3, required: 3 << 30},
+		{size: 1 << 30, factor: 0, required: int64(float64(1<<30) * procedures.DefaultSpaceSafetyFactor)},
+		{size: 1 << 30, factor: -1, required: int64(float64(1<<30) * procedures.DefaultSpaceSafetyFactor)},
+		{size: 0, factor: 2, required: 0},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.required, procedures.RequiredFreeSpace(tc.size, tc.factor), "size %d factor %g", tc.size, tc.factor)
+	}
+}
@@ -0,0 +1,49 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestGetUserResponseShapes(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"envelope", `{"user":{"user_id":"user-1","username":"alice","groups":[{"name":"All Users","group_id":"g-1"}]}}`},
+		{"bare object", `{"user_id":"user-1","username":"alice","groups":[{"name":"All Users","group_id":"g-1"}]}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+
+			kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+			user, err := kApi.GetUser(context.Background(), "user-1", "")
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", user.UserID)
+			assert.Equal(t, "alice", user.Username)
+			assert.Len(t, user.Groups, 1)
+		})
+	}
+}
+
+func TestGetUserRejectsEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"user":{}}`))
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	user, err := kApi.GetUser(context.Background(), "user-1", "")
+	assert.Nil(t, user)
+	assert.ErrorContains(t, err, "contains no user")
+}
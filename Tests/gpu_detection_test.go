@@ -0,0 +1,62 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/dockercli"
+)
+
+// scriptedExecutor returns canned outputs per command and fails for unknown commands.
+type scriptedExecutor map[string]string
+
+func (e scriptedExecutor) ExecuteCommand(ctx context.Context, command string) (string, error) {
+	if output, ok := e[command]; ok {
+		return output, nil
+	}
+	return "bash: " + command + ": command not found", fmt.Errorf("exit status 127")
+}
+
+// ExecuteCommandWithStdin discards stdin and answers like ExecuteCommand.
+func (e scriptedExecutor) ExecuteCommandWithStdin(ctx context.Context, command string, stdin io.Reader) (string, error) {
+	if _, err := io.Copy(io.Discard, stdin); err != nil {
+		return "", err
+	}
+	return e.ExecuteCommand(ctx, command)
+}
+
+func TestDetectGPUsWithNvidiaRuntime(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker info --format '{{json .Runtimes}}'": `{"io.containerd.runc.v2":{"path":"runc"},"nvidia":{"path":"nvidia-container-runtime"},"runc":{"path":"runc"}}`,
+		"nvidia-smi -L": "GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-1)\nGPU 1: NVIDIA A100-SXM4-40GB (UUID: GPU-2)\n",
+	}, 1)
+
+	info, err := dc.DetectGPUs(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, info.NvidiaRuntime)
+	assert.Len(t, info.GPUs, 2)
+
+	supported, err := dc.HasGPUSupport(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, supported)
+}
+
+func TestDetectGPUsOnCPUOnlyNode(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker info --format '{{json .Runtimes}}'": `{"runc":{"path":"runc"}}`,
+	}, 1)
+
+	supported, err := dc.HasGPUSupport(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, supported)
+}
+
+func TestDetectGPUsFailsWithoutDocker(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{}, 1)
+
+	_, err := dc.HasGPUSupport(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func newGroupsServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		assert.Equal(t, "/api/public/get_groups", r.URL.Path)
+		_, _ = w.Write([]byte(`{"groups":[
+			{"group_id":"g-all","name":"All Users","is_system":true},
+			{"group_id":"g-students","name":"Students"},
+			{"group_id":"g-dup-1","name":"Staff"},
+			{"group_id":"g-dup-2","name":"staff"}
+		]}`))
+	}))
+}
+
+func TestGetGroupIDByName(t *testing.T) {
+	var requests int32
+	server := newGroupsServer(t, &requests)
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	ctx := context.Background()
+
+	groupID, err := kApi.GetGroupIDByName(ctx, "all users")
+	assert.NoError(t, err)
+	assert.Equal(t, "g-all", groupID)
+
+	_, err = kApi.GetGroupIDByName(ctx, "Teachers")
+	assert.ErrorContains(t, err, "no group found")
+
+	_, err = kApi.GetGroupIDByName(ctx, "Staff")
+	assert.ErrorContains(t, err, "ambiguous")
+}
+
+func TestGroupCacheFetchesGroupsOnce(t *testing.T) {
+	var requests int32
+	server := newGroupsServer(t, &requests)
+	defer server.Close()
+
+	cache := webApi.NewGroupCache(webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second))
+	ctx := context.Background()
+
+	for _, name := range []string{"Students", "All Users", "students"} {
+		_, err := cache.GroupIDByName(ctx, name)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,77 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/dockercli"
+)
+
+const imageListCommand = "docker image ls --format '{{json .}}'"
+
+const imageListOutput = `{"Repository":"kasmweb/core","Tag":"1.15.0","ID":"a1"}
+{"Repository":"kasmweb/core","Tag":"1.16.0","ID":"b2"}
+{"Repository":"kasmweb/chrome","Tag":"1.15.1","ID":"c3"}
+{"Repository":"postgres","Tag":"16","ID":"d4"}
+{"Repository":"<none>","Tag":"<none>","ID":"e5"}
+`
+
+// imageRemovalExecutor lists imageListOutput and records the images removed with docker image rm.
+type imageRemovalExecutor struct {
+	removed []string
+}
+
+func (e *imageRemovalExecutor) ExecuteCommand(_ context.Context, command string) (string, error) {
+	if command == imageListCommand {
+		return imageListOutput, nil
+	}
+	if ref, ok := strings.CutPrefix(command, "docker image rm "); ok {
+		e.removed = append(e.removed, ref)
+		return "Untagged: " + ref + "\n", nil
+	}
+	return "", fmt.Errorf("unexpected command %q", command)
+}
+
+func TestPruneImagesMatching(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		dryRun  bool
+		matches []string
+		errText string
+	}{
+		{name: "glob", pattern: "kasmweb/*:1.15*", matches: []string{"kasmweb/core:1.15.0", "kasmweb/chrome:1.15.1"}},
+		{name: "glob without match", pattern: "ubuntu:*"},
+		{name: "glob does not cross slashes", pattern: "*:1.15*"},
+		{name: "glob without slash", pattern: "*:16", matches: []string{"postgres:16"}},
+		{name: "regex", pattern: `regex:^kasmweb/core:1\.1[56]\.0$`, matches: []string{"kasmweb/core:1.15.0", "kasmweb/core:1.16.0"}},
+		{name: "regex is unanchored", pattern: "regex:postgres", matches: []string{"postgres:16"}},
+		{name: "dry run", pattern: "kasmweb/core:*", dryRun: true, matches: []string{"kasmweb/core:1.15.0", "kasmweb/core:1.16.0"}},
+		{name: "invalid regex", pattern: "regex:kasmweb/(core", errText: "invalid image regex"},
+		{name: "invalid glob", pattern: "kasmweb/[core", errText: "invalid image glob"},
+		{name: "empty pattern", pattern: "", errText: "cannot be empty"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &imageRemovalExecutor{}
+			dc := dockercli.NewRemoteDockerClient(executor, 1)
+
+			matches, err := dc.PruneImagesMatching(context.Background(), tc.pattern, tc.dryRun)
+			if tc.errText != "" {
+				assert.ErrorContains(t, err, tc.errText)
+				assert.Empty(t, executor.removed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.matches, matches)
+			if tc.dryRun {
+				assert.Empty(t, executor.removed, "a dry run removes nothing")
+			} else {
+				assert.ElementsMatch(t, tc.matches, executor.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package Tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestDiffTargetImageNormalizesTypes(t *testing.T) {
+	registry := "https://registry.internal"
+	current := webApi.ImageDetail{
+		ImageID:        "img-1",
+		Name:           "kasmweb/chrome:1.16.0",
+		FriendlyName:   "Chrome",
+		Cores:          2,
+		Memory:         2147483648,
+		Enabled:        true,
+		DockerRegistry: &registry,
+		Categories:     []string{"Browsers", "Web"},
+		VolumeMappings: json.RawMessage(`{"/data":{"bind":"/home/kasm-user/data","mode":"rw"}}`),
+		ImageType:      "Container",
+	}
+	desired := webApi.TargetImage{
+		Name:           "kasmweb/chrome:1.16.0",
+		FriendlyName:   "Chrome",
+		Cores:          2,
+		Memory:         2147483648,
+		Enabled:        true,
+		DockerRegistry: registry,
+		Categories:     "Browsers\nWeb\n",
+		VolumeMappings: `{"/data": {"mode": "rw", "bind": "/home/kasm-user/data"}}`,
+		ImageType:      "Container",
+		DockerToken:    "not-compared",
+	}
+
+	assert.Empty(t, webApi.DiffTargetImage(current, desired))
+
+	desired.Cores = 4
+	desired.Description = "Chrome browser"
+	desired.Categories = "Browsers"
+	changes := webApi.DiffTargetImage(current, desired)
+	require.Len(t, changes, 3)
+	assert.Equal(t, "categories", changes[0].Field)
+	assert.Equal(t, webApi.FieldChange{Field: "cores", Old: float64(2), New: float64(4)}, changes[1])
+	assert.Equal(t, "description: <empty> -> Chrome browser", changes[2].String())
+
+	// Empty desired strings are not managed, but booleans are always compared.
+	desired = webApi.TargetImage{Name: current.Name, Enabled: false}
+	changes = webApi.DiffTargetImage(current, desired)
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	assert.Equal(t, []string{"cores", "enabled", "memory"}, fields)
+}
+
+func TestReconcileImage(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	desired := webApi.TargetImage{Name: "kasmweb/chrome:1.16.0", FriendlyName: "Chrome", Cores: 2, Memory: 2147483648, Enabled: true}
+	current := fake.AddImage(desired)
+
+	changes, err := kApi.ReconcileImage(ctx, current, desired, false)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	desired.Cores = 4
+	changes, err = kApi.ReconcileImage(ctx, current, desired, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	stored, _ := fake.Image(current.ImageID)
+	assert.Equal(t, float64(2), stored.Cores, "dry run does not update")
+
+	changes, err = kApi.ReconcileImage(ctx, current, desired, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	stored, _ = fake.Image(current.ImageID)
+	assert.Equal(t, float64(4), stored.Cores)
+}
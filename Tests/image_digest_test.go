@@ -0,0 +1,75 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+const inspectDigestsCommand = `docker image inspect --format '{{.Id}} {{join .RepoDigests " "}}' kasm/core:latest`
+
+// missingImageExecutor answers every command like a Docker host without the requested image.
+type missingImageExecutor struct{}
+
+func (missingImageExecutor) ExecuteCommand(ctx context.Context, command string) (string, error) {
+	return "Error response from daemon: No such image: kasm/core:latest", fmt.Errorf("exit status 1")
+}
+
+func TestImageMatchesDigestByImageID(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		inspectDigestsCommand: "sha256:0123abcd registry.example.com/kasm/core@sha256:feedbeef\n",
+	}, 1)
+
+	matches, err := dc.ImageMatchesDigest(context.Background(), "kasm/core:latest", "sha256:0123abcd")
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	matches, err = dc.ImageMatchesDigest(context.Background(), "kasm/core:latest", "feedbeef")
+	require.NoError(t, err)
+	assert.True(t, matches, "repository digests match without the sha256 prefix")
+
+	matches, err = dc.ImageMatchesDigest(context.Background(), "kasm/core:latest", "sha256:99999999")
+	require.NoError(t, err)
+	assert.False(t, matches)
+}
+
+func TestImageMatchesDigestMissingImage(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(missingImageExecutor{}, 1)
+
+	digests, err := dc.ImageDigests(context.Background(), "kasm/core:latest")
+	require.NoError(t, err)
+	assert.Empty(t, digests)
+
+	matches, err := dc.ImageMatchesDigest(context.Background(), "kasm/core:latest", "sha256:0123abcd")
+	require.NoError(t, err)
+	assert.False(t, matches)
+}
+
+func TestImageMatchesDigestFailsWithoutDocker(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{}, 1)
+
+	_, err := dc.ImageMatchesDigest(context.Background(), "kasm/core:latest", "sha256:0123abcd")
+	assert.Error(t, err)
+}
+
+func TestImageExists(t *testing.T) {
+	present := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		inspectDigestsCommand: "sha256:0123abcd\n",
+	}, 1)
+	exists, err := present.ImageExists(context.Background(), "kasm/core:latest")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	absent := dockercli.NewRemoteDockerClient(missingImageExecutor{}, 1)
+	exists, err = absent.ImageExists(context.Background(), "kasm/core:latest")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	unreachable := dockercli.NewRemoteDockerClient(scriptedExecutor{}, 1)
+	_, err = unreachable.ImageExists(context.Background(), "kasm/core:latest")
+	assert.Error(t, err, "a failed inspect is not reported as a missing image")
+}
@@ -0,0 +1,101 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+func TestImageFlightGroupSharesConcurrentWork(t *testing.T) {
+	var group procedures.ImageFlightGroup
+	var builds atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 8
+	results := make([]string, callers)
+	shared := make([]bool, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], shared[i], errs[i] = group.Do(context.Background(), "kasm/core:1.16.0", func() (string, error) {
+				builds.Add(1)
+				<-release
+				return "tarfiles/kasm_core_1.16.0-0123456789ab.tar", nil
+			})
+		}(i)
+	}
+
+	// Let every caller reach the group before the first build finishes.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), builds.Load())
+	sharedCount := 0
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "tarfiles/kasm_core_1.16.0-0123456789ab.tar", results[i])
+		if shared[i] {
+			sharedCount++
+		}
+	}
+	assert.Equal(t, callers-1, sharedCount)
+
+	// Finished work is not cached: a later call runs again.
+	_, wasShared, err := group.Do(context.Background(), "kasm/core:1.16.0", func() (string, error) {
+		builds.Add(1)
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.False(t, wasShared)
+	assert.Equal(t, int32(2), builds.Load())
+}
+
+func TestImageFlightGroupSharesErrorsAndKeepsKeysApart(t *testing.T) {
+	var group procedures.ImageFlightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	buildErr := errors.New("build failed")
+
+	done := make(chan error)
+	go func() {
+		_, _, err := group.Do(context.Background(), "kasm/a:1", func() (string, error) {
+			close(started)
+			<-release
+			return "", buildErr
+		})
+		done <- err
+	}()
+	<-started
+
+	// Another tag is not blocked by the build in flight.
+	result, _, err := group.Do(context.Background(), "kasm/b:1", func() (string, error) { return "b", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "b", result)
+
+	// A waiter whose context ends stops waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = group.Do(ctx, "kasm/a:1", func() (string, error) { return "unexpected", nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	waiter := make(chan error)
+	go func() {
+		_, _, err := group.Do(context.Background(), "kasm/a:1", func() (string, error) { return "unexpected", nil })
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	assert.ErrorIs(t, <-done, buildErr)
+	assert.ErrorIs(t, <-waiter, buildErr)
+}
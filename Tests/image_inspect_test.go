@@ -0,0 +1,75 @@
+package Tests
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+const coreImageInspectOutput = `[
+    {
+        "Id": "sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6",
+        "RepoTags": ["kasm/core:1.16.0"],
+        "RepoDigests": ["kasm/core@sha256:aa11"],
+        "Created": "2024-10-01T12:00:00Z",
+        "Architecture": "amd64",
+        "Os": "linux",
+        "Size": 2147483648,
+        "Config": {
+            "User": "1000",
+            "Env": ["PATH=/usr/local/bin:/usr/bin", "KASM_VNC_PORT=6901"],
+            "Entrypoint": ["/dockerstartup/kasm_default_profile.sh", "/dockerstartup/vnc_startup.sh"],
+            "Cmd": ["--wait"],
+            "WorkingDir": "/home/kasm-user",
+            "ExposedPorts": {"6901/tcp": {}, "4901/tcp": {}},
+            "Labels": {"org.opencontainers.image.revision": "3f2c1d0", "com.kasmweb.image": "true"}
+        },
+        "RootFS": {"Type": "layers", "Layers": ["sha256:l1", "sha256:l2"]}
+    }
+]
+`
+
+func TestInspectImageRemote(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker image inspect kasm/core:1.16.0": coreImageInspectOutput,
+	}, 1)
+
+	inspect, err := dc.InspectImage(context.Background(), "kasm/core:1.16.0")
+	require.NoError(t, err)
+	assert.Equal(t, "kasm/core:1.16.0", inspect.Image)
+	assert.Equal(t, "sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6", inspect.ID)
+	assert.Equal(t, int64(2147483648), inspect.Size)
+	assert.Equal(t, "3f2c1d0", inspect.Labels["org.opencontainers.image.revision"])
+	assert.Contains(t, inspect.Env, "KASM_VNC_PORT=6901")
+	assert.Equal(t, []string{"/dockerstartup/kasm_default_profile.sh", "/dockerstartup/vnc_startup.sh"}, inspect.Entrypoint)
+	assert.Equal(t, []string{"4901/tcp", "6901/tcp"}, inspect.ExposedPorts)
+	assert.Equal(t, []string{"sha256:l1", "sha256:l2"}, inspect.Layers)
+
+	_, err = dc.InspectImage(context.Background(), "kasm/missing:1.0")
+	assert.Error(t, err)
+}
+
+func TestGenerateImageManifest(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker image inspect kasm/core:1.16.0": coreImageInspectOutput,
+	}, 1)
+
+	dir := filepath.Join(t.TempDir(), "manifests")
+	manifestPath, err := dc.GenerateImageManifest(context.Background(), "kasm/core:1.16.0", dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "kasm_core_1.16.0.json"), manifestPath)
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	var manifest map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "kasm/core:1.16.0", manifest["image"])
+	assert.Contains(t, manifest, "generated_at")
+	assert.Equal(t, map[string]interface{}{"org.opencontainers.image.revision": "3f2c1d0", "com.kasmweb.image": "true"}, manifest["labels"])
+}
@@ -0,0 +1,92 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+// newFakePullDaemon answers image pulls with the progress stream of a single layer, or an error message for
+// images containing "missing".
+func newFakePullDaemon(t *testing.T) *dockercli.DockerClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/images/create") {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.Contains(r.URL.Query().Get("fromImage"), "missing") {
+			_, _ = w.Write([]byte(`{"status":"Pulling from kasmweb/missing"}` + "\n" +
+				`{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}` + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"Pulling from kasmweb/core","id":"1.16.0"}` + "\n" +
+			`{"status":"Downloading","progressDetail":{"current":4000000,"total":8000000},"id":"a1b2c3"}` + "\n" +
+			`{"status":"Downloading","progressDetail":{"current":8000000,"total":8000000},"id":"a1b2c3"}` + "\n" +
+			`{"status":"Extracting","progressDetail":{"current":8000000,"total":8000000},"id":"a1b2c3"}` + "\n" +
+			`{"status":"Pull complete","progressDetail":{},"id":"a1b2c3"}` + "\n" +
+			`{"status":"Status: Downloaded newer image for kasmweb/core:1.16.0"}` + "\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.41"))
+	require.NoError(t, err)
+	return dockercli.NewDockerClient(cli, 1, 0, 1, 0, 0)
+}
+
+func TestPullImageWithProgressReportsLayers(t *testing.T) {
+	dc := newFakePullDaemon(t)
+
+	var events []dockercli.BuildEvent
+	pulled, err := dc.PullImageWithProgress(context.Background(), "kasmweb/core:1.16.0", nil, func(event dockercli.BuildEvent) {
+		events = append(events, event)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "kasmweb/core:1.16.0", pulled)
+
+	require.NotEmpty(t, events)
+	assert.Equal(t, dockercli.BuildEventStarted, events[0].Type)
+	assert.Equal(t, dockercli.BuildEventSucceeded, events[len(events)-1].Type)
+
+	var progress []dockercli.BuildEvent
+	var lines []string
+	for _, event := range events {
+		assert.Equal(t, "kasmweb/core:1.16.0", event.ImageTag)
+		switch event.Type {
+		case dockercli.BuildEventProgress:
+			progress = append(progress, event)
+		case dockercli.BuildEventLogLine:
+			lines = append(lines, event.Line)
+		}
+	}
+	require.Len(t, progress, 3)
+	assert.Equal(t, "Downloading", progress[0].Line)
+	assert.Equal(t, "a1b2c3", progress[0].Layer)
+	assert.Equal(t, int64(4000000), progress[0].Current)
+	assert.Equal(t, int64(8000000), progress[0].Total)
+	assert.Equal(t, "Extracting", progress[2].Line)
+	assert.Contains(t, lines, "a1b2c3: Pull complete")
+}
+
+func TestPullImageWithProgressReportsStreamErrors(t *testing.T) {
+	dc := newFakePullDaemon(t)
+
+	var last dockercli.BuildEvent
+	_, err := dc.PullImageWithProgress(context.Background(), "kasmweb/missing:1.0", nil, func(event dockercli.BuildEvent) {
+		last = event
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifest unknown")
+	assert.Equal(t, dockercli.BuildEventFailed, last.Type)
+	assert.Equal(t, err, last.Err)
+
+	// PullImage discards the progress but reports the same error.
+	_, err = dc.PullImage(context.Background(), "kasmweb/missing:1.0", nil)
+	assert.ErrorContains(t, err, "manifest unknown")
+}
@@ -0,0 +1,102 @@
+package Tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestSetImageEnabledKeepsOtherFields(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	image := fake.AddImage(webApi.TargetImage{
+		Name:                 "kasmweb/core:1.0",
+		FriendlyName:         "Core",
+		Description:          "Course workspace",
+		Cores:                2,
+		Memory:               2 << 30,
+		Enabled:              true,
+		GPUCount:             1,
+		RequireGPU:           true,
+		RestrictNetworkNames: []string{"kasm_course"},
+		CPUAllocationMethod:  "Quotas",
+	})
+	kApi := fake.API()
+
+	require.NoError(t, kApi.SetImageEnabled(context.Background(), image.ImageID, false))
+	updated, ok := fake.Image(image.ImageID)
+	require.True(t, ok)
+	assert.False(t, updated.Enabled)
+	image.Enabled = false
+	assert.Equal(t, image, updated)
+
+	require.NoError(t, kApi.SetImageHidden(context.Background(), image.ImageID, true))
+	updated, _ = fake.Image(image.ImageID)
+	assert.True(t, updated.Hidden)
+	assert.False(t, updated.Enabled)
+}
+
+func TestSetImageEnabledSkipsUnchangedImage(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	image := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", Cores: 1, Memory: 1 << 30, Enabled: true})
+
+	require.NoError(t, fake.API().SetImageEnabled(context.Background(), image.ImageID, true))
+	assert.Equal(t, []string{"/api/public/get_images"}, fake.Requests())
+}
+
+func TestSetImageEnabledUnknownImage(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+
+	err := fake.API().SetImageEnabled(context.Background(), "missing", false)
+	assert.ErrorContains(t, err, "image missing not found")
+}
+
+func TestSetImageEnabledSendsImageAsReturned(t *testing.T) {
+	var updatePayload map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/public/get_images":
+			_, _ = io.WriteString(w, `{"images": [{
+				"image_id": "img-1", "name": "kasmweb/core:1.0", "friendly_name": "Core", "enabled": true,
+				"cores": 2, "memory": 2147483648, "available": true, "zone_name": "default",
+				"docker_token": "**********", "categories": ["Dev", "Course"],
+				"run_config": {"hostname": "kasm"}, "volume_mappings": {"/srv/data": {"bind": "/data", "mode": "ro"}},
+				"session_time_limit": "3600", "notes": null
+			}]}`)
+		case "/api/public/update_image":
+			var request struct {
+				TargetImage map[string]json.RawMessage `json:"target_image"`
+			}
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &request)
+			updatePayload = request.TargetImage
+			_, _ = io.WriteString(w, `{"image": {"image_id": "img-1"}}`)
+		}
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	require.NoError(t, kApi.SetImageEnabled(context.Background(), "img-1", false))
+
+	require.NotNil(t, updatePayload)
+	assert.JSONEq(t, `false`, string(updatePayload["enabled"]))
+	assert.JSONEq(t, `2147483648`, string(updatePayload["memory"]))
+	assert.JSONEq(t, `"Dev\nCourse"`, string(updatePayload["categories"]))
+	assert.JSONEq(t, `"{\"hostname\": \"kasm\"}"`, string(updatePayload["run_config"]))
+	assert.JSONEq(t, `"3600"`, string(updatePayload["session_time_limit"]))
+	assert.NotContains(t, updatePayload, "docker_token", "the masked token must not overwrite the real one")
+	assert.NotContains(t, updatePayload, "available", "response-only fields are not sent")
+	assert.NotContains(t, updatePayload, "zone_name")
+}
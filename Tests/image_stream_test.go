@@ -0,0 +1,88 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestForEachImageVisitsEveryImage(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	core := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", Cores: 1, Memory: 1 << 30, Enabled: true})
+	desk := fake.AddImage(webApi.TargetImage{Name: "kasmweb/desktop:1.0", Cores: 2, Memory: 2 << 30, Enabled: true})
+
+	details, err := fake.API().ListImageDetails(context.Background())
+	require.NoError(t, err)
+
+	var visited []webApi.ImageDetail
+	require.NoError(t, fake.API().ForEachImage(context.Background(), func(image webApi.ImageDetail) error {
+		visited = append(visited, image)
+		return nil
+	}))
+	assert.Equal(t, details, visited)
+	assert.ElementsMatch(t, []string{core.ImageID, desk.ImageID}, []string{visited[0].ImageID, visited[1].ImageID})
+}
+
+func TestForEachImageStopsEarly(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", Cores: 1, Memory: 1 << 30})
+	fake.AddImage(webApi.TargetImage{Name: "kasmweb/desktop:1.0", Cores: 1, Memory: 1 << 30})
+
+	calls := 0
+	err := fake.API().ForEachImage(context.Background(), func(webApi.ImageDetail) error {
+		calls++
+		return webApi.ErrStopIteration
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	failure := errors.New("reconcile failed")
+	err = fake.API().ForEachImage(context.Background(), func(webApi.ImageDetail) error { return failure })
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestForEachImageSkipsOtherFieldsAndReportsErrorMessage(t *testing.T) {
+	body := `{"meta": {"images": [1]}, "images": [{"image_id": "a", "name": "kasmweb/core:1.0"}, {"image_id": "b"}], "error_message": null}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+
+	var ids []string
+	require.NoError(t, kApi.ForEachImage(context.Background(), func(image webApi.ImageDetail) error {
+		ids = append(ids, image.ImageID)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	body = `{"images": null, "error_message": "Access denied"}`
+	err := kApi.ForEachImage(context.Background(), func(webApi.ImageDetail) error { return nil })
+	assert.ErrorContains(t, err, "Access denied")
+
+	body = `{"images": [{"image_id": "a"}, {"image_id": 5}]}`
+	err = kApi.ForEachImage(context.Background(), func(webApi.ImageDetail) error { return nil })
+	assert.ErrorContains(t, err, "failed to decode image")
+}
+
+func TestForEachImageFailsOnRejectedCredentials(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	kApi.APIKeySecret = "wrong"
+
+	err := kApi.ForEachImage(context.Background(), func(webApi.ImageDetail) error { return nil })
+	assert.ErrorIs(t, err, webApi.ErrUnauthorized)
+}
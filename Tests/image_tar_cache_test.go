@@ -0,0 +1,71 @@
+package Tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+const (
+	cachedImageID = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	rebuiltID     = "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+)
+
+func writeTar(content string) func(path string) error {
+	return func(path string) error {
+		return os.WriteFile(path, []byte(content), 0644)
+	}
+}
+
+func TestImageTarCacheReusesTarOfSameImageID(t *testing.T) {
+	cache := procedures.ImageTarCache{Dir: t.TempDir()}
+
+	_, ok := cache.Get("kasm/app:1.0", cachedImageID)
+	assert.False(t, ok)
+
+	path, err := cache.Put("kasm/app:1.0", cachedImageID, writeTar("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cache.Dir, "kasm_app_1.0-0123456789ab.tar"), path)
+
+	cached, ok := cache.Get("kasm/app:1.0", cachedImageID)
+	assert.True(t, ok)
+	assert.Equal(t, path, cached)
+}
+
+func TestImageTarCacheInvalidatesOnNewImageID(t *testing.T) {
+	cache := procedures.ImageTarCache{Dir: t.TempDir()}
+	oldPath, err := cache.Put("kasm/app:1.0", cachedImageID, writeTar("v1"))
+	require.NoError(t, err)
+	otherTag, err := cache.Put("kasm/app:1.0-rc", cachedImageID, writeTar("rc"))
+	require.NoError(t, err)
+
+	_, ok := cache.Get("kasm/app:1.0", rebuiltID)
+	assert.False(t, ok)
+	newPath, err := cache.Put("kasm/app:1.0", rebuiltID, writeTar("v2"))
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, oldPath)
+	assert.FileExists(t, newPath)
+	assert.FileExists(t, otherTag, "tars of other tags sharing the prefix are kept")
+}
+
+func TestImageTarCacheDiscardsFailedExport(t *testing.T) {
+	cache := procedures.ImageTarCache{Dir: t.TempDir()}
+
+	_, err := cache.Put("kasm/app:1.0", cachedImageID, func(path string) error {
+		require.NoError(t, os.WriteFile(path, []byte("trunc"), 0644))
+		return errors.New("daemon went away")
+	})
+	require.Error(t, err)
+
+	_, ok := cache.Get("kasm/app:1.0", cachedImageID)
+	assert.False(t, ok)
+	entries, err := os.ReadDir(cache.Dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
 	"testing"
 	"time"
 )
@@ -27,3 +29,20 @@ func TestListImages(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(imagesAvailable))
 }
+
+func TestGetImageForNetwork(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+
+	fake.AddImage(webApi.TargetImage{Name: "kasm/core:1.0", FriendlyName: "Core"})
+	custom := fake.AddImage(webApi.TargetImage{Name: "kasm/app:1.2", FriendlyName: "App", RestrictNetworkNames: []string{"kasm_custom"}})
+
+	image, err := kApi.GetImageForNetwork(context.Background(), "kasm_custom")
+	require.NoError(t, err)
+	assert.Equal(t, custom.ImageID, image.ImageID)
+	assert.Equal(t, "kasm/app:1.2", image.Name)
+
+	_, err = kApi.GetImageForNetwork(context.Background(), "other")
+	assert.ErrorContains(t, err, "Image not found")
+}
@@ -0,0 +1,36 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestGetImageIDByFriendlyName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"images":[
+			{"image_id":"id-chrome","friendly_name":"Chrome"},
+			{"image_id":"id-ubuntu-1","friendly_name":"Ubuntu Desktop"},
+			{"image_id":"id-ubuntu-2","friendly_name":"ubuntu desktop"}
+		]}`))
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	ctx := context.Background()
+
+	imageID, err := kApi.GetImageIDByFriendlyName(ctx, "chrome")
+	assert.NoError(t, err)
+	assert.Equal(t, "id-chrome", imageID)
+
+	_, err = kApi.GetImageIDByFriendlyName(ctx, "Firefox")
+	assert.ErrorContains(t, err, "no image found")
+
+	_, err = kApi.GetImageIDByFriendlyName(ctx, "Ubuntu Desktop")
+	assert.ErrorContains(t, err, "ambiguous")
+}
@@ -0,0 +1,65 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestAuthInBodyInjectsCredentials(t *testing.T) {
+	kApi, stub := newStubbedKasmAPI(`{"users":[]}`)
+
+	_, err := kApi.GetUsers(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, stub.payloads, 1)
+	assert.Equal(t, "key", stub.payloads[0]["api_key"])
+	assert.Equal(t, "secret", stub.payloads[0]["api_key_secret"])
+	assert.Equal(t, "Bearer key:secret", stub.requests[0].Header.Get("Authorization"))
+}
+
+func TestAuthInHeaderKeepsCredentialsOutOfBody(t *testing.T) {
+	kApi, stub := newStubbedKasmAPI(`{"users":[]}`)
+	kApi.AuthMode = webApi.AuthInHeader
+
+	// Credentials set by the caller are removed as well.
+	_, err := kApi.MakePostRequest(context.Background(), "/api/public/get_users", webApi.GetUsersRequest{APIKey: "key", APIKeySecret: "secret"})
+	require.NoError(t, err)
+
+	require.Len(t, stub.payloads, 1)
+	assert.NotContains(t, stub.payloads[0], "api_key")
+	assert.NotContains(t, stub.payloads[0], "api_key_secret")
+	assert.Equal(t, "Bearer key:secret", stub.requests[0].Header.Get("Authorization"))
+}
+
+func TestAuthInHeaderAgainstFakeServer(t *testing.T) {
+	server := testutil.NewFakeKasmServer()
+	defer server.Close()
+
+	kApi := server.API()
+	kApi.AuthMode = webApi.AuthInHeader
+	_, err := kApi.CreateUser(context.Background(), webApi.TargetUser{Username: "alice"})
+	require.NoError(t, err)
+
+	kApi.APIKeySecret = "wrong"
+	kApi.Retries = 1
+	_, err = kApi.GetUsers(context.Background())
+	assert.ErrorIs(t, err, webApi.ErrUnauthorized)
+}
+
+func TestParseAuthMode(t *testing.T) {
+	mode, err := webApi.ParseAuthMode("")
+	require.NoError(t, err)
+	assert.Equal(t, webApi.AuthInBody, mode)
+
+	mode, err = webApi.ParseAuthMode(" Header ")
+	require.NoError(t, err)
+	assert.Equal(t, webApi.AuthInHeader, mode)
+
+	_, err = webApi.ParseAuthMode("cookie")
+	assert.Error(t, err)
+}
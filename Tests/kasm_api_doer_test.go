@@ -0,0 +1,141 @@
+package Tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+// stubDoer records the requests sent by KasmAPI and answers each with a fixed response body.
+type stubDoer struct {
+	responseBody string
+	requests     []*http.Request
+	payloads     []map[string]interface{}
+}
+
+func (s *stubDoer) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	s.requests = append(s.requests, req)
+	s.payloads = append(s.payloads, payload)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(s.responseBody)),
+		Request:    req,
+	}, nil
+}
+
+// newStubbedKasmAPI returns a KasmAPI that sends its requests to a stubDoer.
+func newStubbedKasmAPI(responseBody string) (*webApi.KasmAPI, *stubDoer) {
+	stub := &stubDoer{responseBody: responseBody}
+	kApi := &webApi.KasmAPI{
+		BaseURL:      "https://kasm.example.com",
+		APIKey:       "key",
+		APIKeySecret: "secret",
+		Client:       stub,
+	}
+	return kApi, stub
+}
+
+func TestCreateUserRequest(t *testing.T) {
+	cases := []struct {
+		name       string
+		user       webApi.TargetUser
+		targetUser map[string]interface{}
+	}{
+		{
+			name:       "minimal user",
+			user:       webApi.TargetUser{Username: "alice", Password: "S3cure!pass"},
+			targetUser: map[string]interface{}{"username": "alice", "password": "S3cure!pass"},
+		},
+		{
+			name: "full user",
+			user: webApi.TargetUser{Username: "bob", FirstName: "Bob", LastName: "Berg", Organization: "Lab", Phone: "123", Locked: true},
+			targetUser: map[string]interface{}{
+				"username": "bob", "first_name": "Bob", "last_name": "Berg", "organization": "Lab", "phone": "123", "locked": true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kApi, stub := newStubbedKasmAPI(`{"user":{"user_id":"u-1","username":"` + c.user.Username + `"}}`)
+
+			user, err := kApi.CreateUser(context.Background(), c.user)
+			assert.NoError(t, err)
+			assert.Equal(t, "u-1", user.UserID)
+
+			assert.Len(t, stub.requests, 1)
+			req := stub.requests[0]
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, "https://kasm.example.com/api/public/create_user", req.URL.String())
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+			assert.Equal(t, map[string]interface{}{
+				"api_key":        "key",
+				"api_key_secret": "secret",
+				"target_user":    c.targetUser,
+			}, stub.payloads[0])
+		})
+	}
+}
+
+func TestCreateImageRequest(t *testing.T) {
+	cases := []struct {
+		name     string
+		image    webApi.TargetImage
+		expected map[string]interface{}
+	}{
+		{
+			name:  "container image",
+			image: webApi.TargetImage{Name: "kasmweb/chrome:1.16.0", FriendlyName: "Chrome", Cores: 2, Memory: 2768000000, Enabled: true, ImageType: "Container"},
+			expected: map[string]interface{}{
+				"name": "kasmweb/chrome:1.16.0", "friendly_name": "Chrome", "cores": float64(2), "memory": float64(2768000000),
+				"enabled": true, "image_type": "Container",
+			},
+		},
+		{
+			name:  "gpu image",
+			image: webApi.TargetImage{Name: "kasmweb/blender:1.16.0", FriendlyName: "Blender", RequireGPU: true, GPUCount: 1},
+			expected: map[string]interface{}{
+				"name": "kasmweb/blender:1.16.0", "friendly_name": "Blender", "require_gpu": true, "gpu_count": float64(1),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kApi, stub := newStubbedKasmAPI(`{"image":{"image_id":"img-1","name":"` + c.image.Name + `"}}`)
+
+			// Credentials are filled in by CreateImage.
+			response, err := kApi.CreateImage(context.Background(), webApi.CreateImageRequest{TargetImage: c.image})
+			assert.NoError(t, err)
+			assert.Equal(t, "img-1", response.Image.ImageID)
+
+			assert.Len(t, stub.requests, 1)
+			assert.Equal(t, "https://kasm.example.com/api/public/create_image", stub.requests[0].URL.String())
+			assert.Equal(t, "key", stub.payloads[0]["api_key"])
+			assert.Equal(t, "secret", stub.payloads[0]["api_key_secret"])
+
+			targetImage := stub.payloads[0]["target_image"].(map[string]interface{})
+			for key, value := range c.expected {
+				assert.Equal(t, value, targetImage[key], key)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestEndpointURLJoinsBasePath(t *testing.T) {
+	cases := []struct {
+		baseURL, basePath, expected string
+	}{
+		{"https://kasm.example.com", "", "https://kasm.example.com/api/public/get_users"},
+		{"https://kasm.example.com/", "", "https://kasm.example.com/api/public/get_users"},
+		{"https://kasm.example.com", "/kasm", "https://kasm.example.com/kasm/api/public/get_users"},
+		{"https://kasm.example.com/", "kasm/", "https://kasm.example.com/kasm/api/public/get_users"},
+		{"https://kasm.example.com//", "//proxy/kasm//", "https://kasm.example.com/proxy/kasm/api/public/get_users"},
+	}
+
+	for _, c := range cases {
+		kApi := &webApi.KasmAPI{BaseURL: c.baseURL, BasePath: c.basePath}
+		assert.Equal(t, c.expected, kApi.EndpointURL("/api/public/get_users"))
+	}
+}
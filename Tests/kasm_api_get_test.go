@@ -0,0 +1,55 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func TestMakeGetRequestSendsQueryAndHeaderAuth(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"users":[]}`))
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	body, err := kApi.MakeGetRequest(context.Background(), "/api/v2/users", url.Values{"username": {"alice smith"}, "limit": {"10"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"users":[]}`, string(body))
+
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodGet, received.Method)
+	assert.Equal(t, "/api/v2/users", received.URL.Path)
+	assert.Equal(t, "alice smith", received.URL.Query().Get("username"))
+	assert.Equal(t, "10", received.URL.Query().Get("limit"))
+	assert.Equal(t, "Bearer key:secret", received.Header.Get("Authorization"))
+	assert.Empty(t, receivedBody)
+	assert.NotContains(t, received.URL.RawQuery, "secret")
+}
+
+func TestMakeGetRequestDoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error_message":"User not found"}`))
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	_, err := kApi.MakeGetRequest(context.Background(), "/api/v2/users", nil)
+	assert.True(t, webApi.IsNotFound(err))
+	assert.Equal(t, 1, requests)
+}
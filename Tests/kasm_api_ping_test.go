@@ -0,0 +1,57 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestPing(t *testing.T) {
+	cases := map[string]struct {
+		status       int
+		body         string
+		unauthorized bool
+		ok           bool
+	}{
+		"accepted":         {status: http.StatusOK, body: `{"users":[]}`, ok: true},
+		"forbidden":        {status: http.StatusForbidden, body: `{}`, unauthorized: true},
+		"unauthorized":     {status: http.StatusUnauthorized, body: ``, unauthorized: true},
+		"error message":    {status: http.StatusOK, body: `{"error_message":"Access Denied"}`, unauthorized: true},
+		"server error":     {status: http.StatusInternalServerError, body: `boom`},
+		"not the Kasm API": {status: http.StatusOK, body: `<html></html>`},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+
+			err := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second).Ping(context.Background())
+			if c.ok {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, c.unauthorized, errors.Is(err, webApi.ErrUnauthorized))
+		})
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	err := webApi.NewKasmAPI(url, "key", "secret", false, time.Second).Ping(context.Background())
+	assert.ErrorContains(t, err, "cannot reach Kasm API")
+	assert.False(t, errors.Is(err, webApi.ErrUnauthorized))
+}
@@ -0,0 +1,90 @@
+package Tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+// newStatusServer answers every request with status and counts the requests.
+func newStatusServer(t *testing.T, status int) (*webApi.KasmAPI, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error_message":"try again later"}`))
+	}))
+	t.Cleanup(server.Close)
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	kApi.RetryBackoff = time.Millisecond
+	return kApi, &requests
+}
+
+func TestKasmAPIAttempts(t *testing.T) {
+	cases := []struct {
+		name     string
+		retries  int
+		status   int
+		requests int32
+	}{
+		{name: "default", retries: 0, status: http.StatusServiceUnavailable, requests: 3},
+		{name: "negative falls back to default", retries: -1, status: http.StatusServiceUnavailable, requests: 3},
+		{name: "single attempt", retries: 1, status: http.StatusServiceUnavailable, requests: 1},
+		{name: "more attempts", retries: 5, status: http.StatusBadGateway, requests: 5},
+		{name: "rate limited", retries: 2, status: http.StatusTooManyRequests, requests: 2},
+		{name: "client errors are final", retries: 5, status: http.StatusBadRequest, requests: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			kApi, requests := newStatusServer(t, tc.status)
+			kApi.Retries = tc.retries
+
+			_, err := kApi.MakePostRequest(context.Background(), "/api/public/get_users", struct{}{})
+			assert.Error(t, err)
+			assert.Equal(t, tc.requests, requests.Load())
+
+			// Streaming requests retry the same way.
+			requests.Store(0)
+			err = kApi.ForEachImage(context.Background(), func(webApi.ImageDetail) error { return nil })
+			assert.Error(t, err)
+			assert.Equal(t, tc.requests, requests.Load())
+		})
+	}
+}
+
+func TestKasmAPINoBackoffAfterLastAttempt(t *testing.T) {
+	kApi, requests := newStatusServer(t, http.StatusServiceUnavailable)
+	kApi.Retries = 1
+	kApi.RetryBackoff = time.Minute
+
+	start := time.Now()
+	_, err := kApi.MakePostRequest(context.Background(), "/api/public/get_users", struct{}{})
+	assert.ErrorContains(t, err, "failed after retries")
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestKasmAPILogsResponseBodyOnceRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"kasm_id":"k1","session_token":"tok-123"}`))
+	}))
+	t.Cleanup(server.Close)
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+	logs := captureLogs(t)
+
+	body, err := kApi.MakePostRequest(context.Background(), "/api/public/request_kasm", map[string]string{})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "tok-123", "callers receive the unredacted body")
+
+	assert.Equal(t, 1, strings.Count(logs.String(), `"response_body"`))
+	assert.NotContains(t, logs.String(), "tok-123")
+	assert.Contains(t, logs.String(), `"kasm_id":"k1"`)
+}
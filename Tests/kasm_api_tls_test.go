@@ -0,0 +1,105 @@
+package Tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+// writeTestCertificate creates a self-signed certificate usable as CA, server and client certificate.
+func writeTestCertificate(t *testing.T, dir, name string) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile, cert
+}
+
+func TestNewKasmAPIWithTLSUsesClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	serverCertFile, serverKeyFile, _ := writeTestCertificate(t, dir, "server")
+	clientCertFile, clientKeyFile, clientCert := writeTestCertificate(t, dir, "client")
+
+	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	assert.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"users":[]}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	kApi, err := webApi.NewKasmAPIWithTLS(server.URL, "key", "secret", webApi.TLSOptions{
+		CAFile:         serverCertFile,
+		ClientCertFile: clientCertFile,
+		ClientKeyFile:  clientKeyFile,
+	}, 5*time.Second)
+	assert.NoError(t, err)
+
+	users, err := kApi.GetUsers(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestNewKasmAPIWithTLSRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _, _ := writeTestCertificate(t, dir, "client")
+	_, otherKeyFile, _ := writeTestCertificate(t, dir, "other")
+	garbage := filepath.Join(dir, "garbage.pem")
+	assert.NoError(t, os.WriteFile(garbage, []byte("not a certificate"), 0600))
+
+	for name, options := range map[string]webApi.TLSOptions{
+		"missing CA file":     {CAFile: filepath.Join(dir, "missing.pem")},
+		"CA without PEM":      {CAFile: garbage},
+		"certificate only":    {ClientCertFile: certFile},
+		"mismatched key pair": {ClientCertFile: certFile, ClientKeyFile: otherKeyFile},
+	} {
+		_, err := webApi.NewKasmAPIWithTLS("https://127.0.0.1", "key", "secret", options, time.Second)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,30 @@
+package Tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestParseKasmTime(t *testing.T) {
+	expected := time.Date(2024, 5, 1, 13, 37, 0, 123456000, time.UTC)
+
+	for _, value := range []string{"2024-05-01 13:37:00.123456", "2024-05-01T13:37:00.123456", "2024-05-01T13:37:00.123456Z"} {
+		parsed, err := webApi.ParseKasmTime(value)
+		assert.NoError(t, err, value)
+		assert.True(t, expected.Equal(parsed), value)
+	}
+
+	_, err := webApi.ParseKasmTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestKasmInfoIsExpired(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, webApi.KasmInfo{ExpirationDate: "2024-05-01 11:59:59"}.IsExpired(now))
+	assert.False(t, webApi.KasmInfo{ExpirationDate: "2024-05-01 12:00:01"}.IsExpired(now))
+	assert.False(t, webApi.KasmInfo{}.IsExpired(now))
+}
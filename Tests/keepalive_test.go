@@ -0,0 +1,60 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestKeepaliveSession(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	user := fake.AddUser(webApi.TargetUser{Username: "alice"})
+	image := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", FriendlyName: "Core"})
+	session, err := kApi.RequestKasmSession(ctx, user.UserID, image.ImageID, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, kApi.KeepaliveSession(ctx, user.UserID, session.KasmID))
+	assert.ErrorContains(t, kApi.KeepaliveSession(ctx, user.UserID, "missing"), "Kasm not found")
+}
+
+func TestKeepAliveLoopPingsUntilCancelled(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+
+	user := fake.AddUser(webApi.TargetUser{Username: "alice"})
+	image := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", FriendlyName: "Core"})
+	session, err := kApi.RequestKasmSession(context.Background(), user.UserID, image.ImageID, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		kApi.KeepAliveLoop(ctx, user.UserID, session.KasmID, 20*time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("KeepAliveLoop did not stop after cancellation")
+	}
+
+	keepalives := 0
+	for _, endpoint := range fake.Requests() {
+		if endpoint == "/api/public/keepalive" {
+			keepalives++
+		}
+	}
+	assert.GreaterOrEqual(t, keepalives, 3)
+}
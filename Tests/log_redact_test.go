@@ -0,0 +1,54 @@
+package Tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/logRedact"
+)
+
+func TestRedactWriterScrubsRawJSONPayloads(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(logRedact.NewWriter(&out))
+
+	payload := []byte(`{"api_key":"key123","api_key_secret":"secret456","target_user":{"username":"alice","password":"hunter2"}}`)
+	logger.Info().RawJSON("payload", payload).Str("user", "alice").Msg("Sending POST request")
+
+	logged := out.String()
+	assert.NotContains(t, logged, "key123")
+	assert.NotContains(t, logged, "secret456")
+	assert.NotContains(t, logged, "hunter2")
+	assert.Contains(t, logged, "alice")
+	assert.True(t, strings.HasSuffix(logged, "\n"))
+
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &event))
+	target := event["payload"].(map[string]interface{})["target_user"].(map[string]interface{})
+	assert.Equal(t, logRedact.Placeholder, target["password"])
+}
+
+func TestRedactWriterScrubsEmbeddedSecrets(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(logRedact.NewWriter(&out))
+
+	logger.Info().
+		Str("user", "{UserID:1 Username:alice Password:hunter2 Phone:}").
+		Str("body", `{"session_token": "tok-789"}`).
+		Str("header", "Bearer key123:secret456").
+		Msg("Debug")
+
+	logged := out.String()
+	assert.NotContains(t, logged, "hunter2")
+	assert.NotContains(t, logged, "tok-789")
+	assert.NotContains(t, logged, "secret456")
+	assert.Contains(t, logged, "Username:alice")
+}
+
+func TestRedactKeepsPlainText(t *testing.T) {
+	assert.Equal(t, "nothing to hide\n", string(logRedact.Redact([]byte("nothing to hide\n"))))
+	assert.Equal(t, "password="+logRedact.Placeholder+" user=bob", logRedact.RedactString("password=s3cr3t user=bob"))
+}
@@ -0,0 +1,38 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/dockercli"
+)
+
+func TestNetworkOptionsValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		options dockercli.NetworkOptions
+		errText string
+	}{
+		{name: "name only", options: dockercli.NetworkOptions{Name: "kasm_custom"}},
+		{name: "full ipam", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", Gateway: "172.30.0.1", IPRange: "172.30.5.0/24"}},
+		{name: "ipv6", options: dockercli.NetworkOptions{Name: "kasm_v6", Subnet: "fd00:dead:beef::/48", Gateway: "fd00:dead:beef::1"}},
+		{name: "missing name", options: dockercli.NetworkOptions{Subnet: "172.30.0.0/16"}, errText: "network name cannot be empty"},
+		{name: "bad cidr", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/33"}, errText: "invalid subnet"},
+		{name: "subnet without mask", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0"}, errText: "invalid subnet"},
+		{name: "gateway not an ip", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", Gateway: "gateway.local"}, errText: "not an IP address"},
+		{name: "gateway outside subnet", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", Gateway: "172.31.0.1"}, errText: "is not inside subnet"},
+		{name: "gateway without subnet", options: dockercli.NetworkOptions{Name: "kasm_custom", Gateway: "172.30.0.1"}, errText: "requires a subnet"},
+		{name: "bad ip range", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", IPRange: "172.30.5.0/40"}, errText: "invalid ip range"},
+		{name: "ip range outside subnet", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", IPRange: "10.0.0.0/24"}, errText: "is not inside subnet"},
+		{name: "ip range wider than subnet", options: dockercli.NetworkOptions{Name: "kasm_custom", Subnet: "172.30.0.0/16", IPRange: "172.30.0.0/8"}, errText: "is not inside subnet"},
+		{name: "ip range without subnet", options: dockercli.NetworkOptions{Name: "kasm_custom", IPRange: "172.30.5.0/24"}, errText: "requires a subnet"},
+	}
+	for _, tc := range cases {
+		err := tc.options.Validate()
+		if tc.errText == "" {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.ErrorContains(t, err, tc.errText, tc.name)
+		}
+	}
+}
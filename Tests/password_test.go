@@ -0,0 +1,33 @@
+package Tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestGeneratePasswordContainsEveryClass(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		password := webApi.GeneratePassword(webApi.DefaultPasswordPolicy())
+
+		assert.Len(t, password, 20)
+		assert.True(t, strings.ContainsAny(password, "ABCDEFGHJKLMNPQRSTUVWXYZ"), "missing upper case letter in %q", password)
+		assert.True(t, strings.ContainsAny(password, "abcdefghijkmnopqrstuvwxyz"), "missing lower case letter in %q", password)
+		assert.True(t, strings.ContainsAny(password, "23456789"), "missing digit in %q", password)
+		assert.True(t, strings.ContainsAny(password, "!@#$%^&*-_=+"), "missing symbol in %q", password)
+	}
+}
+
+func TestGeneratePasswordEnforcesMinimumLength(t *testing.T) {
+	password := webApi.GeneratePassword(webApi.PasswordPolicy{Length: 3, Digits: true})
+
+	assert.Len(t, password, 8)
+	assert.Equal(t, "", strings.Trim(password, "23456789"))
+}
+
+func TestGeneratePasswordIsRandom(t *testing.T) {
+	policy := webApi.DefaultPasswordPolicy()
+	assert.NotEqual(t, webApi.GeneratePassword(policy), webApi.GeneratePassword(policy))
+}
@@ -0,0 +1,98 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/webApi"
+)
+
+func TestRegistryLoginUsesPasswordStdin(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker login --username deploy --password-stdin registry.internal": "Login Succeeded",
+	}, 1)
+
+	require.NoError(t, dc.RegistryLogin(context.Background(), "registry.internal", "deploy", "pa ss"))
+	assert.Error(t, dc.RegistryLogin(context.Background(), "registry.internal", "", "pa ss"))
+}
+
+func TestRegistryLoginKeepsPasswordOutOfCommandAndLogs(t *testing.T) {
+	const registryPassword = "reg1stry-$ecret"
+	var mutex sync.Mutex
+	stdin := make(map[string]string)
+	server := newTestSSHServer(t, func(command string, in io.ReadWriter) (string, uint32) {
+		data, _ := io.ReadAll(in)
+		mutex.Lock()
+		stdin[command] = string(data)
+		mutex.Unlock()
+		return "Login Succeeded", 0
+	})
+	server.config.UseSudo = true
+	server.config.SudoPassword = "sudo-pass"
+	logs := captureLogs(t)
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	dc := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	require.NoError(t, dc.RegistryLogin(context.Background(), "registry.internal", "deploy", registryPassword))
+
+	login := "sudo -n sh -c 'docker login --username deploy --password-stdin registry.internal'"
+	assert.Equal(t, []string{"sudo -S -p '' -v", login}, server.Commands())
+	assert.Equal(t, "sudo-pass\n", stdin["sudo -S -p '' -v"], "sudo is authenticated on its own")
+	assert.Equal(t, registryPassword, stdin[login], "docker receives only the registry password")
+	assert.NotContains(t, logs.String(), registryPassword)
+	assert.NotContains(t, logs.String(), "sudo-pass")
+
+	// Executors that cannot feed stdin are refused instead of falling back to the command line.
+	dc = dockercli.NewRemoteDockerClient(&failingExecutor{}, 1)
+	assert.ErrorContains(t, dc.RegistryLogin(context.Background(), "registry.internal", "deploy", registryPassword), "stdin")
+}
+
+func TestRegistryLoginFailsWhenSudoRejectsPassword(t *testing.T) {
+	server := newTestSSHServer(t, func(command string, in io.ReadWriter) (string, uint32) {
+		_, _ = io.ReadAll(in)
+		if strings.Contains(command, " -v") {
+			return "Sorry, try again.", 1
+		}
+		return "Login Succeeded", 0
+	})
+	server.config.UseSudo = true
+	server.config.SudoPassword = "wrong"
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	dc := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	assert.ErrorContains(t, dc.RegistryLogin(context.Background(), "registry.internal", "deploy", "secret"), "authenticate sudo")
+	assert.Equal(t, []string{"sudo -S -p '' -v"}, server.Commands(), "docker login is not run")
+}
+
+func TestPushImageWithTargetImageCredentials(t *testing.T) {
+	auth, ok := dockercli.RegistryAuthFromTargetImage(webApi.TargetImage{DockerUser: "deploy", DockerToken: "token"})
+	require.True(t, ok)
+
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker login --username deploy --password-stdin registry.internal:5000": "Login Succeeded",
+		"docker push registry.internal:5000/kasm/core:1.16.0":                    "latest: digest: sha256:abc",
+	}, 1)
+
+	require.NoError(t, dc.PushImage(context.Background(), "registry.internal:5000/kasm/core:1.16.0", &auth))
+
+	_, ok = dockercli.RegistryAuthFromTargetImage(webApi.TargetImage{})
+	assert.False(t, ok)
+}
+
+func TestPushImageFailure(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{}, 1)
+	assert.Error(t, dc.PushImage(context.Background(), "registry.internal:5000/kasm/core:1.16.0", nil))
+}
@@ -0,0 +1,104 @@
+package Tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+)
+
+func TestPullConfigRewrite(t *testing.T) {
+	config := &dockercli.PullConfig{Mirrors: map[string]string{
+		"docker.io": "mirror.internal/dockerhub/",
+		"ghcr.io":   "mirror.internal/ghcr",
+	}}
+
+	assert.Equal(t, "mirror.internal/dockerhub/library/alpine:3.20", config.Rewrite("alpine:3.20"))
+	assert.Equal(t, "mirror.internal/dockerhub/kasmweb/core:1.16.0", config.Rewrite("kasmweb/core:1.16.0"))
+	assert.Equal(t, "mirror.internal/dockerhub/kasmweb/core:1.16.0", config.Rewrite("docker.io/kasmweb/core:1.16.0"))
+	assert.Equal(t, "mirror.internal/ghcr/org/tool:v1", config.Rewrite("ghcr.io/org/tool:v1"))
+	assert.Equal(t, "quay.io/org/tool:v1", config.Rewrite("quay.io/org/tool:v1"), "registries without mirror are untouched")
+	assert.Equal(t, "localhost:5000/app", config.Rewrite("localhost:5000/app"))
+
+	var empty *dockercli.PullConfig
+	assert.Equal(t, "alpine", empty.Rewrite("alpine"))
+}
+
+func TestLoadPullConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pull.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`mirrors:
+  docker.io: nexus.internal:8443/dockerhub
+auths:
+  nexus.internal:8443:
+    username: deploy
+    password: s3cret
+`), 0600))
+
+	config, err := dockercli.LoadPullConfig(path)
+	require.NoError(t, err)
+
+	auth, ok := config.AuthFor(config.Rewrite("alpine"))
+	require.True(t, ok)
+	assert.Equal(t, dockercli.RegistryAuth{Username: "deploy", Password: "s3cret"}, auth)
+}
+
+func TestPullImageThroughMirror(t *testing.T) {
+	config := &dockercli.PullConfig{
+		Mirrors: map[string]string{"docker.io": "nexus.internal:8443/dockerhub"},
+		Auths:   map[string]dockercli.RegistryAuth{"nexus.internal:8443": {Username: "deploy", Password: "s3cret"}},
+	}
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker login --username deploy --password-stdin nexus.internal:8443":              "Login Succeeded",
+		"docker pull nexus.internal:8443/dockerhub/kasmweb/core:1.16.0":                    "Status: Downloaded newer image",
+		"docker tag nexus.internal:8443/dockerhub/kasmweb/core:1.16.0 kasmweb/core:1.16.0": "",
+	}, 1)
+
+	pulled, err := dc.PullImage(context.Background(), "kasmweb/core:1.16.0", config)
+	require.NoError(t, err)
+	assert.Equal(t, "nexus.internal:8443/dockerhub/kasmweb/core:1.16.0", pulled)
+}
+
+func TestPullImageWithoutMirror(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker pull kasmweb/core:1.16.0": "Status: Image is up to date",
+	}, 1)
+
+	pulled, err := dc.PullImage(context.Background(), "kasmweb/core:1.16.0", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "kasmweb/core:1.16.0", pulled)
+}
+
+func TestRegistryReference(t *testing.T) {
+	tests := []struct {
+		image, registry, expected string
+	}{
+		{"kasm/core:1.16.0", "registry.internal:5000", "registry.internal:5000/kasm/core:1.16.0"},
+		{"alpine", "https://registry.internal/", "registry.internal/alpine"},
+		{"old.registry:5000/kasm/core:1.16.0", "nexus.internal/kasm", "nexus.internal/kasm/kasm/core:1.16.0"},
+		{"docker.io/library/alpine:3", "localhost:5000", "localhost:5000/library/alpine:3"},
+	}
+	for _, tt := range tests {
+		got, err := dockercli.RegistryReference(tt.image, tt.registry)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, got)
+	}
+
+	_, err := dockercli.RegistryReference("alpine", "")
+	assert.Error(t, err)
+}
+
+func TestRetagForRegistryRemote(t *testing.T) {
+	dc := dockercli.NewRemoteDockerClient(scriptedExecutor{
+		"docker tag kasm/core:1.16.0 registry.internal:5000/kasm/core:1.16.0": "",
+	}, 1)
+
+	newTag, err := dc.RetagForRegistry(context.Background(), "kasm/core:1.16.0", "registry.internal:5000")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.internal:5000/kasm/core:1.16.0", newTag)
+
+	assert.Error(t, dc.TagImage(context.Background(), "kasm/core:1.16.0", ""))
+}
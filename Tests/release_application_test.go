@@ -0,0 +1,61 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestReleaseApplicationStartsSessionsForGroup(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	image := fake.AddImage(webApi.TargetImage{Name: "kasm/app:1.2", FriendlyName: "App"})
+	fake.AddImage(webApi.TargetImage{Name: "kasm/app:1.1", FriendlyName: "App (old)"})
+	group := fake.AddGroup("Class A")
+	alice := fake.AddUser(webApi.TargetUser{Username: "alice"})
+	bob := fake.AddUser(webApi.TargetUser{Username: "bob"})
+	fake.AddUser(webApi.TargetUser{Username: "carol"})
+	require.NoError(t, kApi.AddUserToGroup(ctx, alice.UserID, group.GroupID))
+	existing, err := kApi.RequestKasmSession(ctx, alice.UserID, image.ImageID, nil)
+	require.NoError(t, err)
+
+	result, err := procedures.ReleaseApplication(ctx, kApi, procedures.ReleaseSpec{
+		ImageTag:  "kasm/app:1.2",
+		GroupName: "Class A",
+		Usernames: []string{"bob"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, image.ImageID, result.ImageID)
+	assert.Equal(t, group.GroupID, result.GroupID)
+	require.Len(t, result.Sessions, 2)
+	sessions := map[string]procedures.ReleaseSession{}
+	for _, session := range result.Sessions {
+		sessions[session.Username] = session
+	}
+	assert.True(t, sessions["alice"].Reused)
+	assert.Equal(t, existing.KasmID, sessions["alice"].KasmID)
+	assert.False(t, sessions["bob"].Reused)
+	assert.Equal(t, bob.UserID, sessions["bob"].UserID)
+	assert.NotEmpty(t, sessions["bob"].KasmID)
+}
+
+func TestReleaseApplicationRequiresKnownImage(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	fake.AddGroup("Class A")
+
+	_, err := procedures.ReleaseApplication(context.Background(), fake.API(), procedures.ReleaseSpec{
+		ImageTag:  "kasm/app:9.9",
+		GroupName: "Class A",
+	})
+	assert.ErrorContains(t, err, "no Kasm workspace image using kasm/app:9.9")
+}
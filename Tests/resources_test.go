@@ -0,0 +1,77 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"kasmlink/pkg/webApi"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	cases := map[string]int{
+		"2g":       2 << 30,
+		"2G":       2 << 30,
+		"512m":     512 << 20,
+		"512 MiB":  512 << 20,
+		"1.5gb":    3 << 29,
+		"1024k":    1 << 20,
+		"10485760": 10485760,
+	}
+	for input, expected := range cases {
+		bytes, err := webApi.ParseMemorySize(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, bytes, input)
+	}
+
+	for _, input := range []string{"", "g", "-1g", "2x", "two gigs"} {
+		_, err := webApi.ParseMemorySize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseCPUCores(t *testing.T) {
+	cases := map[string]float64{"2": 2, "1.5": 1.5, "1500m": 1.5, "250m": 0.25}
+	for input, expected := range cases {
+		cores, err := webApi.ParseCPUCores(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, cores, input)
+	}
+
+	_, err := webApi.ParseCPUCores("two")
+	assert.Error(t, err)
+}
+
+func TestValidateResources(t *testing.T) {
+	assert.NoError(t, webApi.ValidateResources(2, 2<<30))
+
+	err := webApi.ValidateResources(2, 2048)
+	assert.ErrorContains(t, err, "invalid memory 2048")
+	err = webApi.ValidateResources(0, 2<<30)
+	assert.ErrorContains(t, err, "invalid cores")
+}
+
+func TestTargetImageYAMLResources(t *testing.T) {
+	var image webApi.TargetImage
+	err := yaml.Unmarshal([]byte(`
+name: kasmweb/chrome:1.16.0
+friendly_name: Chrome
+cores: 1500m
+memory: 2g
+enabled: true
+`), &image)
+	assert.NoError(t, err)
+	assert.Equal(t, "Chrome", image.FriendlyName)
+	assert.Equal(t, 1.5, image.Cores)
+	assert.Equal(t, 2<<30, image.Memory)
+	assert.True(t, image.Enabled)
+
+	// Limits are enforced by the loaders, not by the decoder.
+	err = yaml.Unmarshal([]byte("name: x\ncores: 2\nmemory: 1m\n"), &image)
+	assert.NoError(t, err)
+	assert.Equal(t, 1<<20, image.Memory)
+	assert.ErrorContains(t, webApi.ValidateResources(image.Cores, image.Memory), "invalid memory")
+
+	err = yaml.Unmarshal([]byte("name: x\ncores: 2\nmemory: 2 bananas\n"), &image)
+	assert.ErrorContains(t, err, `field memory: invalid memory size "2 bananas"`)
+}
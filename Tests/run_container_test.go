@@ -0,0 +1,118 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/webApi"
+)
+
+// recordingExecutor records every command and answers it with output.
+type recordingExecutor struct {
+	output   string
+	commands []string
+}
+
+func (e *recordingExecutor) ExecuteCommand(_ context.Context, command string) (string, error) {
+	e.commands = append(e.commands, command)
+	return e.output, nil
+}
+
+func TestRunContainerArgs(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  webApi.DockerRunConfig
+		command string
+	}{
+		{
+			name:    "image only",
+			config:  webApi.DockerRunConfig{Image: "kasmweb/core:1.16.0"},
+			command: "docker run --detach kasmweb/core:1.16.0",
+		},
+		{
+			name: "ports",
+			config: webApi.DockerRunConfig{Image: "nginx:1.27", Ports: map[string]interface{}{
+				"80/tcp":   8080,
+				"443/tcp":  "127.0.0.1:8443",
+				"9000/udp": nil,
+				"53/udp":   []interface{}{float64(5353), "10.0.0.1:53"},
+			}},
+			command: "docker run --detach --publish 127.0.0.1:8443:443/tcp --publish 5353:53/udp --publish 10.0.0.1:53:53/udp --publish 8080:80/tcp --publish 9000/udp nginx:1.27",
+		},
+		{
+			name: "volumes and mounts",
+			config: webApi.DockerRunConfig{
+				Image: "postgres:16",
+				Volumes: map[string]webApi.VolumeMapping{
+					"/srv/kasm/db":   {Bind: "/var/lib/postgresql/data", Mode: "rw"},
+					"/srv/kasm/conf": {Bind: "/etc/postgresql"},
+				},
+				Mounts: []webApi.MountConfig{{Type: "bind", Source: "/srv/kasm certs", Target: "/certs", ReadOnly: true}},
+			},
+			command: "docker run --detach --volume /srv/kasm/conf:/etc/postgresql --volume /srv/kasm/db:/var/lib/postgresql/data:rw --mount 'type=bind,source=/srv/kasm certs,target=/certs,readonly' postgres:16",
+		},
+		{
+			name: "environment",
+			config: webApi.DockerRunConfig{Image: "kasm/api:1.0", Environment: map[string]string{
+				"LOG_LEVEL":    "debug",
+				"DATABASE_URL": "postgres://kasm:s3cret@db/kasm",
+				"GREETING":     "hello world",
+			}},
+			command: "docker run --detach --env DATABASE_URL=postgres://kasm:s3cret@db/kasm --env 'GREETING=hello world' --env LOG_LEVEL=debug kasm/api:1.0",
+		},
+		{
+			name: "gpus",
+			config: webApi.DockerRunConfig{Image: "kasmweb/cuda:12", DeviceRequests: []webApi.DeviceRequest{
+				{DeviceIDs: []string{"0", "1"}},
+				{Count: 2},
+				{},
+			}},
+			command: `docker run --detach --gpus '"device=0,1"' --gpus 2 --gpus all kasmweb/cuda:12`,
+		},
+		{
+			name: "entrypoint and command",
+			config: webApi.DockerRunConfig{
+				Image:         "busybox:1.36",
+				Name:          "probe",
+				Entrypoint:    []string{"/bin/sh", "-c"},
+				Command:       []string{"echo ready"},
+				RestartPolicy: &webApi.RestartPolicy{Condition: "on-failure", MaximumRetryCount: 3},
+			},
+			command: "docker run --detach --name probe --entrypoint /bin/sh --restart on-failure:3 busybox:1.36 -c 'echo ready'",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &recordingExecutor{output: "Unable to find image locally\n3f2a9c1b\n"}
+			dc := dockercli.NewRemoteDockerClient(executor, 1)
+
+			id, err := dc.RunContainer(context.Background(), tc.config)
+			require.NoError(t, err)
+			assert.Equal(t, "3f2a9c1b", id)
+			assert.Equal(t, []string{tc.command}, executor.commands)
+		})
+	}
+}
+
+func TestRunContainerArgsRejectsInvalidConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  webApi.DockerRunConfig
+		errText string
+	}{
+		{name: "missing image", config: webApi.DockerRunConfig{}, errText: "image cannot be empty"},
+		{name: "network and mode", config: webApi.DockerRunConfig{Image: "a", Network: "kasm", NetworkMode: "host"}, errText: "mutually exclusive"},
+		{name: "volume without bind", config: webApi.DockerRunConfig{Image: "a", Volumes: map[string]webApi.VolumeMapping{"/data": {}}}, errText: "has no bind path"},
+		{name: "bad port", config: webApi.DockerRunConfig{Image: "a", Ports: map[string]interface{}{"80/tcp": true}}, errText: "unsupported host port"},
+		{name: "bad restart policy", config: webApi.DockerRunConfig{Image: "a", RestartPolicy: &webApi.RestartPolicy{Condition: "sometimes"}}, errText: "unsupported restart policy"},
+	}
+	for _, tc := range cases {
+		executor := &recordingExecutor{}
+		_, err := dockercli.NewRemoteDockerClient(executor, 1).RunContainer(context.Background(), tc.config)
+		assert.ErrorContains(t, err, tc.errText, tc.name)
+		assert.Empty(t, executor.commands, tc.name)
+	}
+}
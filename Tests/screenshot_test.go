@@ -0,0 +1,46 @@
+package Tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func TestGetKasmScreenshot(t *testing.T) {
+	pngHeader := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	var received webApi.GetKasmScreenshotRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/public/get_kasm_screenshot", r.URL.Path)
+		received = webApi.GetKasmScreenshotRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.KasmID == "starting" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"error_message":"Kasm is not running"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngHeader)
+	}))
+	defer server.Close()
+
+	kApi := webApi.NewKasmAPI(server.URL, "key", "secret", false, 5*time.Second)
+
+	image, err := kApi.GetKasmScreenshot(context.Background(), "user-1", "kasm-1", 320, 180)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, image)
+	assert.Equal(t, 320, received.Width)
+	assert.Equal(t, 180, received.Height)
+
+	_, err = kApi.GetKasmScreenshot(context.Background(), "user-1", "starting", 0, 0)
+	assert.True(t, errors.Is(err, webApi.ErrScreenshotNotAvailable))
+	assert.ErrorContains(t, err, "Kasm is not running")
+	assert.Zero(t, received.Width)
+}
@@ -0,0 +1,50 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestDestroySessionsByImageAndUser(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	alice := fake.AddUser(webApi.TargetUser{Username: "alice"})
+	bob := fake.AddUser(webApi.TargetUser{Username: "bob"})
+	core := fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.0", FriendlyName: "Core"})
+	chrome := fake.AddImage(webApi.TargetImage{Name: "kasmweb/chrome:1.0", FriendlyName: "Chrome"})
+	for _, session := range []struct{ userID, imageID string }{
+		{alice.UserID, core.ImageID},
+		{bob.UserID, core.ImageID},
+		{alice.UserID, chrome.ImageID},
+		{bob.UserID, chrome.ImageID},
+	} {
+		_, err := kApi.RequestKasmSession(ctx, session.userID, session.imageID, nil)
+		require.NoError(t, err)
+	}
+
+	destroyed, err := kApi.DestroySessionsByImage(ctx, core.ImageID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, destroyed)
+
+	destroyed, err = kApi.DestroyUserSessions(ctx, alice.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, destroyed)
+
+	kasms, err := kApi.GetKasms(ctx)
+	require.NoError(t, err)
+	require.Len(t, kasms, 1)
+	assert.Equal(t, bob.UserID, kasms[0].UserID)
+	assert.Equal(t, chrome.ImageID, kasms[0].ImageID)
+
+	destroyed, err = kApi.DestroyUserSessions(ctx, alice.UserID)
+	assert.NoError(t, err)
+	assert.Zero(t, destroyed)
+}
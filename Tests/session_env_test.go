@@ -0,0 +1,52 @@
+package Tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func TestValidateSessionEnvironment(t *testing.T) {
+	defaults := webApi.SessionEnvLimits{}
+	require.NoError(t, webApi.ValidateSessionEnvironment(map[string]string{"LAUNCH_URL": "https://kasmweb.com", "EMPTY": ""}, defaults))
+	require.NoError(t, webApi.ValidateSessionEnvironment(nil, defaults))
+
+	cases := map[string]struct {
+		env    map[string]string
+		limits webApi.SessionEnvLimits
+		key    string
+	}{
+		"reserved prefix":    {env: map[string]string{"kasm_user": "x"}, key: "kasm_user"},
+		"custom prefix":      {env: map[string]string{"CORP_TOKEN": "x"}, limits: webApi.SessionEnvLimits{ReservedPrefixes: []string{"CORP_"}}, key: "CORP_TOKEN"},
+		"invalid name":       {env: map[string]string{"A=B": "x"}, key: "A=B"},
+		"invalid utf-8":      {env: map[string]string{"BLOB": "\xff\xfe"}, key: "BLOB"},
+		"oversized variable": {env: map[string]string{"SMALL": "x", "BIG": strings.Repeat("a", 100)}, limits: webApi.SessionEnvLimits{MaxSize: 64}, key: "BIG"},
+	}
+	for name, tc := range cases {
+		err := webApi.ValidateSessionEnvironment(tc.env, tc.limits)
+		assert.ErrorIs(t, err, webApi.ErrInvalidSessionEnv, name)
+		assert.ErrorContains(t, err, tc.key, name)
+	}
+
+	// The reserved prefixes and the size limit can be disabled.
+	require.NoError(t, webApi.ValidateSessionEnvironment(
+		map[string]string{"KASM_USER": strings.Repeat("a", webApi.DefaultMaxSessionEnvSize)},
+		webApi.SessionEnvLimits{MaxSize: -1, ReservedPrefixes: []string{}}))
+}
+
+func TestRequestKasmSessionRejectsInvalidEnvironment(t *testing.T) {
+	kApi, stub := newStubbedKasmAPI(`{"kasm_id":"k1","status":"starting"}`)
+
+	_, err := kApi.RequestKasmSession(context.Background(), "user", "image", map[string]string{"KASM_VNC_PORT": "1"})
+	assert.ErrorIs(t, err, webApi.ErrInvalidSessionEnv)
+	assert.Empty(t, stub.payloads, "nothing is sent")
+
+	_, err = kApi.RequestKasmSession(context.Background(), "user", "image", map[string]string{"LAUNCH_URL": "https://kasmweb.com"})
+	require.NoError(t, err)
+	require.Len(t, stub.payloads, 1)
+	assert.Equal(t, map[string]interface{}{"LAUNCH_URL": "https://kasmweb.com"}, stub.payloads[0]["environment"])
+}
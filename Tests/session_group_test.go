@@ -0,0 +1,68 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestStartSessionsForGroup(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	image := fake.AddImage(webApi.TargetImage{Name: "kasm/app:1.2", FriendlyName: "App"})
+	group := fake.AddGroup("Class A")
+	var members []webApi.UserResponse
+	for _, name := range []string{"dave", "alice", "carol", "bob", "erin"} {
+		user := fake.AddUser(webApi.TargetUser{Username: name})
+		require.NoError(t, kApi.AddUserToGroup(ctx, user.UserID, group.GroupID))
+		members = append(members, user)
+	}
+	outsider := fake.AddUser(webApi.TargetUser{Username: "mallory"})
+	existing, err := kApi.RequestKasmSession(ctx, members[2].UserID, image.ImageID, nil)
+	require.NoError(t, err)
+
+	result, err := kApi.StartSessionsForGroup(ctx, group.GroupID, image.ImageID, map[string]string{"RELEASE": "1.2"})
+	require.NoError(t, err)
+
+	var started []string
+	for _, session := range result.Started {
+		started = append(started, session.Username)
+		assert.NotEmpty(t, session.Session.KasmID)
+		assert.NotEqual(t, outsider.UserID, session.UserID)
+	}
+	assert.Equal(t, []string{"alice", "bob", "dave", "erin"}, started)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, existing.KasmID, result.Skipped[0].KasmID)
+	assert.Empty(t, result.Failed)
+
+	// A second run finds every member running already.
+	result, err = kApi.StartSessionsForGroup(ctx, group.GroupID, image.ImageID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Started)
+	assert.Len(t, result.Skipped, 5)
+}
+
+func TestStartSessionsForGroupReportsFailures(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	ctx := context.Background()
+
+	group := fake.AddGroup("Class A")
+	user := fake.AddUser(webApi.TargetUser{Username: "alice"})
+	require.NoError(t, kApi.AddUserToGroup(ctx, user.UserID, group.GroupID))
+	kApi.Retries = 1
+
+	result, err := kApi.StartSessionsForGroup(ctx, group.GroupID, "missing-image", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start 1 of 1 sessions")
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, "alice", result.Failed[0].Username)
+}
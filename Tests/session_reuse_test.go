@@ -0,0 +1,104 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+// reuseFixture is a fake Kasm deployment with two users and two images.
+type reuseFixture struct {
+	fake                     *testutil.FakeKasmServer
+	api                      *webApi.KasmAPI
+	alice, bob, core, chrome string
+}
+
+// startSession requests a session of userID for imageID and returns its ID.
+func (f reuseFixture) startSession(t *testing.T, userID, imageID string) string {
+	session, err := f.api.RequestKasmSession(context.Background(), userID, imageID, nil)
+	require.NoError(t, err)
+	return session.KasmID
+}
+
+func TestRequestOrReuseSession(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		// seed starts the sessions present beforehand and returns the one expected to be reused by alice for core.
+		seed   func(t *testing.T, f reuseFixture) string
+		reused bool
+	}{
+		{
+			name: "no sessions",
+			seed: func(*testing.T, reuseFixture) string { return "" },
+		},
+		{
+			name:   "running session of the user and image",
+			seed:   func(t *testing.T, f reuseFixture) string { return f.startSession(t, f.alice, f.core) },
+			reused: true,
+		},
+		{
+			name: "session of another image",
+			seed: func(t *testing.T, f reuseFixture) string {
+				f.startSession(t, f.alice, f.chrome)
+				return ""
+			},
+		},
+		{
+			name: "session of another user",
+			seed: func(t *testing.T, f reuseFixture) string {
+				f.startSession(t, f.bob, f.core)
+				return ""
+			},
+		},
+		{
+			name: "session that is not running",
+			seed: func(t *testing.T, f reuseFixture) string {
+				require.True(t, f.fake.SetKasmStatus(f.startSession(t, f.alice, f.core), "stopped"))
+				return ""
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := testutil.NewFakeKasmServer()
+			defer fake.Close()
+			f := reuseFixture{
+				fake:   fake,
+				api:    fake.API(),
+				alice:  fake.AddUser(webApi.TargetUser{Username: "alice"}).UserID,
+				bob:    fake.AddUser(webApi.TargetUser{Username: "bob"}).UserID,
+				core:   fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.16.0", FriendlyName: "Core", Enabled: true}).ImageID,
+				chrome: fake.AddImage(webApi.TargetImage{Name: "kasmweb/chrome:1.16.0", FriendlyName: "Chrome", Enabled: true}).ImageID,
+			}
+
+			existing := tc.seed(t, f)
+			requestsBefore := countRequests(fake, "/api/public/request_kasm")
+
+			found, err := f.api.FindRunningSession(ctx, f.alice, f.core)
+			require.NoError(t, err)
+			if existing == "" {
+				assert.Nil(t, found)
+			} else if assert.NotNil(t, found) {
+				assert.Equal(t, existing, found.KasmID)
+			}
+
+			session, reused, err := f.api.RequestOrReuseSession(ctx, f.alice, f.core, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.reused, reused)
+			assert.Equal(t, f.alice, session.UserID)
+			if tc.reused {
+				assert.Equal(t, existing, session.KasmID)
+				assert.Equal(t, requestsBefore, countRequests(fake, "/api/public/request_kasm"), "a reused session is not requested")
+				return
+			}
+			assert.NotEmpty(t, session.KasmID)
+			assert.NotEmpty(t, session.KasmURL)
+			assert.Equal(t, requestsBefore+1, countRequests(fake, "/api/public/request_kasm"))
+		})
+	}
+}
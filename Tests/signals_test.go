@@ -0,0 +1,42 @@
+package Tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/cmd"
+)
+
+func TestShutdownContextCancelledBySignal(t *testing.T) {
+	ctx, cancel := cmd.ShutdownContext(context.Background())
+	defer cancel()
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send SIGINT on this platform: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled after SIGINT")
+	}
+}
+
+func TestShutdownContextCancelWithoutSignal(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	ctx, cancel := cmd.ShutdownContext(parent)
+	assert.NoError(t, ctx.Err())
+	cancel()
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.NoError(t, parent.Err(), "cancelling the shutdown context leaves the parent alone")
+}
@@ -0,0 +1,62 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+func TestClusterExecutorRun(t *testing.T) {
+	ok := func(command string, _ io.ReadWriter) (string, uint32) { return "done: " + command, 0 }
+	failing := func(string, io.ReadWriter) (string, uint32) { return "permission denied", 1 }
+
+	first := newTestSSHServer(t, ok)
+	second := newTestSSHServer(t, failing)
+	third := newTestSSHServer(t, ok)
+
+	unreachable := *first.config
+	unreachable.Host = "127.0.0.1"
+	unreachable.Port = 1
+	unreachable.ConnectionTimeout = time.Second
+
+	configs := []*shadowssh.SSHConfig{first.config, second.config, &unreachable, third.config}
+	results := shadowssh.NewClusterExecutor(configs, 2).Run(context.Background(), "docker image prune -f")
+
+	require.Len(t, results, 4)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "done: docker image prune -f", results[0].Output)
+	assert.Error(t, results[1].Err)
+	assert.Error(t, results[2].Err)
+	assert.NoError(t, results[3].Err, "failures do not abort the other nodes")
+
+	assert.Len(t, shadowssh.FailedResults(results), 2)
+	err := shadowssh.ResultsError(results)
+	require.Error(t, err)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		assert.True(t, strings.HasPrefix(line, "127.0.0.1: "), line)
+	}
+}
+
+func TestClusterExecutorRunSudo(t *testing.T) {
+	var stdin string
+	server := newTestSSHServer(t, func(_ string, in io.ReadWriter) (string, uint32) {
+		buffer := make([]byte, 64)
+		n, _ := in.Read(buffer)
+		stdin = string(buffer[:n])
+		return "", 0
+	})
+	server.config.UseSudo = true
+	server.config.SudoPassword = "sudo-pass"
+
+	results := shadowssh.NewClusterExecutor([]*shadowssh.SSHConfig{server.config}, 0).RunSudo(context.Background(), "docker ps")
+	require.NoError(t, shadowssh.ResultsError(results))
+
+	assert.Equal(t, []string{"sudo -S -p '' sh -c 'docker ps'"}, server.Commands())
+	assert.Equal(t, "sudo-pass\n", stdin)
+}
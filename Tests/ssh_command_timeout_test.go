@@ -0,0 +1,44 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+func TestExecuteCommandWithOutputTimesOut(t *testing.T) {
+	server := newTestSSHServer(t, func(_ string, channel io.ReadWriter) (string, uint32) {
+		io.WriteString(channel, "loading\n")
+		time.Sleep(2 * time.Second)
+		return "", 0
+	})
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	started := time.Now()
+	output, err := sshClient.ExecuteCommandWithOutput(context.Background(), "docker load", time.Minute, 100*time.Millisecond)
+	assert.ErrorIs(t, err, shadowssh.ErrCommandTimeout)
+	assert.Contains(t, output, "loading")
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+func TestExecuteCommandWithOutputOutlivesLogDuration(t *testing.T) {
+	server := newTestSSHServer(t, func(_ string, channel io.ReadWriter) (string, uint32) {
+		io.WriteString(channel, "first\n")
+		time.Sleep(100 * time.Millisecond)
+		return "second\n", 0
+	})
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	output, err := sshClient.ExecuteCommandWithOutput(context.Background(), "docker load", 10*time.Millisecond, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", output)
+}
@@ -0,0 +1,68 @@
+package Tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+func TestLoadSSHConfigPriority(t *testing.T) {
+	t.Setenv("SSH_USERNAME", "env-user")
+	t.Setenv("SSH_HOST", "env-host")
+	t.Setenv("SSH_PORT", "2200")
+	t.Setenv("SSH_CONNECTION_TIMEOUT", "5s")
+
+	configFile := filepath.Join(t.TempDir(), "ssh.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("host: file-host\nport: 2222\nuse_sudo: true\n"), 0600))
+
+	sshConfig, err := procedures.LoadSSHConfig(procedures.SSHConfigOptions{ConfigFile: configFile, Username: "explicit-user"})
+	require.NoError(t, err)
+	assert.Equal(t, "explicit-user", sshConfig.Username)
+	assert.Equal(t, "file-host", sshConfig.Host)
+	assert.Equal(t, 2222, sshConfig.Port)
+	assert.Equal(t, 5*time.Second, sshConfig.ConnectionTimeout)
+	assert.True(t, sshConfig.UseSudo)
+}
+
+func TestLoadSSHConfigDefaults(t *testing.T) {
+	t.Setenv("SSH_USERNAME", "user")
+	t.Setenv("SSH_HOST", "host")
+	t.Setenv("SSH_PORT", "")
+	t.Setenv("SSH_CONNECTION_TIMEOUT", "")
+
+	sshConfig, err := procedures.LoadSSHConfig(procedures.SSHConfigOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, procedures.DefaultSSHPort, sshConfig.Port)
+	assert.Equal(t, procedures.DefaultSSHConnectionTimeout, sshConfig.ConnectionTimeout)
+}
+
+func TestLoadSSHConfigRejectsInvalidValues(t *testing.T) {
+	t.Setenv("SSH_USERNAME", "user")
+	t.Setenv("SSH_HOST", "host")
+
+	t.Setenv("SSH_PORT", "ssh")
+	_, err := procedures.LoadSSHConfig(procedures.SSHConfigOptions{})
+	assert.ErrorContains(t, err, "SSH_PORT")
+
+	t.Setenv("SSH_PORT", "70000")
+	_, err = procedures.LoadSSHConfig(procedures.SSHConfigOptions{})
+	assert.ErrorContains(t, err, "SSH_PORT")
+
+	t.Setenv("SSH_PORT", "22")
+	t.Setenv("SSH_CONNECTION_TIMEOUT", "ten seconds")
+	_, err = procedures.LoadSSHConfig(procedures.SSHConfigOptions{})
+	assert.ErrorContains(t, err, "SSH_CONNECTION_TIMEOUT")
+
+	t.Setenv("SSH_CONNECTION_TIMEOUT", "")
+	_, err = procedures.LoadSSHConfig(procedures.SSHConfigOptions{FileTransferProtocol: "ftp"})
+	assert.ErrorContains(t, err, "file transfer protocol")
+
+	t.Setenv("SSH_HOST", "")
+	_, err = procedures.LoadSSHConfig(procedures.SSHConfigOptions{})
+	assert.ErrorContains(t, err, "host")
+}
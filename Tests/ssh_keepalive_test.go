@@ -0,0 +1,42 @@
+package Tests
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+func TestSSHClientSendsKeepalives(t *testing.T) {
+	server := newTestSSHServer(t, func(string, io.ReadWriter) (string, uint32) { return "", 0 })
+	server.config.KeepaliveInterval = 20 * time.Millisecond
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(server.GlobalRequests()) >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Contains(t, server.GlobalRequests(), "keepalive@openssh.com")
+
+	// Keepalives answered with a failure reply keep the connection open.
+	_, err = sshClient.ExecuteCommand(context.Background(), "true")
+	assert.NoError(t, err)
+}
+
+func TestSSHClientKeepalivesCanBeDisabled(t *testing.T) {
+	server := newTestSSHServer(t, func(string, io.ReadWriter) (string, uint32) { return "", 0 })
+	server.config.KeepaliveInterval = -1
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, server.GlobalRequests())
+}
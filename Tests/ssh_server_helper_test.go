@@ -0,0 +1,177 @@
+package Tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// testSSHPassword is the only password accepted by testSSHServer.
+const testSSHPassword = "secret"
+
+// sshExecHandler answers an exec request with output and an exit status.
+// The channel can be used to read stdin and to stream output while the command runs.
+type sshExecHandler func(command string, channel io.ReadWriter) (string, uint32)
+
+// testSSHServer is a minimal SSH server that answers exec requests with a handler.
+type testSSHServer struct {
+	listener net.Listener
+	config   *shadowssh.SSHConfig
+
+	mutex          sync.Mutex
+	commands       []string
+	globalRequests []string
+	sftp           bool
+}
+
+// newTestSSHServer starts an SSH server on localhost and returns a client configuration trusting its host key.
+func newTestSSHServer(t *testing.T, handler sshExecHandler) *testSSHServer {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != testSSHPassword {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	address := listener.Addr().(*net.TCPAddr)
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(address.String())}, signer.PublicKey())
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600))
+
+	config, err := shadowssh.NewSSHConfig("tester", testSSHPassword, "127.0.0.1", address.Port, knownHostsFile, 5*time.Second)
+	require.NoError(t, err)
+
+	server := &testSSHServer{listener: listener, config: config}
+	go server.serve(serverConfig, handler)
+	return server
+}
+
+// Commands returns the commands received so far.
+func (s *testSSHServer) Commands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// GlobalRequests returns the types of the global requests received so far, e.g. keepalives.
+func (s *testSSHServer) GlobalRequests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.globalRequests...)
+}
+
+// EnableSFTP makes the server accept the sftp subsystem, serving the local file system.
+func (s *testSSHServer) EnableSFTP() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sftp = true
+}
+
+func (s *testSSHServer) serve(config *ssh.ServerConfig, handler sshExecHandler) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			_, channels, requests, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			go func() {
+				for request := range requests {
+					s.mutex.Lock()
+					s.globalRequests = append(s.globalRequests, request.Type)
+					s.mutex.Unlock()
+					if request.WantReply {
+						request.Reply(false, nil)
+					}
+				}
+			}()
+			for newChannel := range channels {
+				if newChannel.ChannelType() != "session" {
+					newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+					continue
+				}
+				channel, channelRequests, err := newChannel.Accept()
+				if err != nil {
+					continue
+				}
+				go s.handleSession(channel, channelRequests, handler)
+			}
+		}()
+	}
+}
+
+func (s *testSSHServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request, handler sshExecHandler) {
+	defer channel.Close()
+	for request := range requests {
+		if request.Type == "subsystem" {
+			s.mutex.Lock()
+			enabled := s.sftp
+			s.mutex.Unlock()
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil || payload.Name != "sftp" || !enabled {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err == nil {
+				server.Serve()
+			}
+			return
+		}
+		if request.Type != "exec" {
+			request.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(request.Payload, &payload); err != nil {
+			request.Reply(false, nil)
+			return
+		}
+		request.Reply(true, nil)
+
+		s.mutex.Lock()
+		s.commands = append(s.commands, payload.Command)
+		s.mutex.Unlock()
+
+		output, status := handler(payload.Command, channel)
+		io.WriteString(channel, output)
+
+		exitStatus := make([]byte, 4)
+		binary.BigEndian.PutUint32(exitStatus, status)
+		channel.SendRequest("exit-status", false, exitStatus)
+		return
+	}
+}
@@ -0,0 +1,69 @@
+package Tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// captureLogs redirects the global logger into the returned buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&out).Level(zerolog.DebugLevel)
+	t.Cleanup(func() { log.Logger = previous })
+	return &out
+}
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"/srv/kasm/data":  "/srv/kasm/data",
+		"":                "''",
+		"/srv/my data":    "'/srv/my data'",
+		"$(reboot)":       "'$(reboot)'",
+		"`id`":            "'`id`'",
+		`C:\path`:         `'C:\path'`,
+		"it's":            `'it'"'"'s'`,
+		"a;rm -rf /":      "'a;rm -rf /'",
+		"user@host:5000":  "user@host:5000",
+		"key=value,x+y%z": "key=value,x+y%z",
+	}
+	for value, expected := range cases {
+		assert.Equal(t, expected, shadowssh.ShellQuote(value), value)
+	}
+	assert.Equal(t, "docker image inspect 'my image'", shadowssh.ShellJoin("docker", "image", "inspect", "my image"))
+}
+
+func TestExecuteCommandSudoSendsPasswordOnStdin(t *testing.T) {
+	var stdin string
+	server := newTestSSHServer(t, func(_ string, in io.ReadWriter) (string, uint32) {
+		buffer := make([]byte, 64)
+		n, _ := in.Read(buffer)
+		stdin = string(buffer[:n])
+		return "", 0
+	})
+	server.config.UseSudo = true
+	server.config.SudoPassword = "sudo-s3cret"
+	logs := captureLogs(t)
+
+	sshClient, err := shadowssh.NewSSHClient(context.Background(), server.config)
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	_, err = sshClient.ExecuteCommandSudo(context.Background(), "docker load -i /tmp/image.tar")
+	require.NoError(t, err)
+
+	assert.Equal(t, "sudo-s3cret\n", stdin)
+	require.Len(t, server.Commands(), 1)
+	assert.Equal(t, "sudo -S -p '' sh -c 'docker load -i /tmp/image.tar'", server.Commands()[0])
+	assert.NotContains(t, server.Commands()[0], "sudo-s3cret")
+	assert.NotContains(t, logs.String(), "sudo-s3cret")
+}
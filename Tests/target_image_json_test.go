@@ -0,0 +1,52 @@
+package Tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+// marshalTargetImageFields encodes a target image and returns its top-level JSON fields.
+func marshalTargetImageFields(t *testing.T, target webApi.TargetImage) map[string]json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(webApi.CreateImageRequest{TargetImage: target})
+	require.NoError(t, err)
+
+	var request struct {
+		TargetImage map[string]json.RawMessage `json:"target_image"`
+	}
+	require.NoError(t, json.Unmarshal(data, &request))
+	return request.TargetImage
+}
+
+func TestTargetImageOmitsEmptyStringifiedFields(t *testing.T) {
+	fields := marshalTargetImageFields(t, webApi.TargetImage{Name: "kasmweb/chrome:1.16.0"})
+
+	for _, field := range []string{"run_config", "exec_config", "volume_mappings", "launch_config"} {
+		assert.NotContains(t, fields, field, "empty %s must be omitted, not sent as \"\"", field)
+	}
+}
+
+func TestTargetImageEncodesStringifiedObject(t *testing.T) {
+	fields := marshalTargetImageFields(t, webApi.TargetImage{
+		RunConfig:    `{"hostname":"kasm"}`,
+		LaunchConfig: json.RawMessage(`{"args":["--incognito"]}`),
+	})
+
+	// run_config is a JSON string containing the object; launch_config is embedded as-is.
+	assert.JSONEq(t, `"{\"hostname\":\"kasm\"}"`, string(fields["run_config"]))
+	assert.JSONEq(t, `{"args":["--incognito"]}`, string(fields["launch_config"]))
+}
+
+func TestTargetImageEncodesStringifiedArray(t *testing.T) {
+	fields := marshalTargetImageFields(t, webApi.TargetImage{
+		ExecConfig:   `[{"cmd":"echo hi"}]`,
+		LaunchConfig: json.RawMessage(`[]`),
+	})
+
+	assert.JSONEq(t, `"[{\"cmd\":\"echo hi\"}]"`, string(fields["exec_config"]))
+	assert.JSONEq(t, `[]`, string(fields["launch_config"]))
+}
@@ -0,0 +1,80 @@
+package Tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func writeWorkspaceFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadTargetImageYAMLWithDefaults(t *testing.T) {
+	path := writeWorkspaceFile(t, "chrome.yaml", `name: kasmweb/chrome:1.16.0
+friendly_name: Chrome
+cores: 1500m
+memory: 2g
+run_config:
+  hostname: kasm
+volume_mappings:
+  /data:
+    bind: /home/kasm-user/data
+    mode: rw
+`)
+
+	target, err := webApi.LoadTargetImage(path)
+	require.NoError(t, err)
+	assert.True(t, target.Enabled)
+	assert.Equal(t, webApi.DefaultImageType, target.ImageType)
+	assert.Equal(t, webApi.DefaultCPUAllocationMethod, target.CPUAllocationMethod)
+	assert.Equal(t, 1.5, target.Cores)
+	assert.Equal(t, 2<<30, target.Memory)
+	assert.JSONEq(t, `{"hostname":"kasm"}`, target.RunConfig)
+	assert.JSONEq(t, `{"/data":{"bind":"/home/kasm-user/data","mode":"rw"}}`, target.VolumeMappings)
+}
+
+func TestLoadTargetImageJSONKeepsExplicitValues(t *testing.T) {
+	path := writeWorkspaceFile(t, "server.json", `{
+  "name": "rdp-server",
+  "friendly_name": "RDP",
+  "cores": 2,
+  "memory": 4294967296,
+  "enabled": false,
+  "image_type": "Server",
+  "run_config": "{\"hostname\": \"rdp\"}"
+}`)
+
+	target, err := webApi.LoadTargetImage(path)
+	require.NoError(t, err)
+	assert.False(t, target.Enabled)
+	assert.Equal(t, "Server", target.ImageType)
+	assert.True(t, json.Valid([]byte(target.RunConfig)))
+}
+
+func TestLoadTargetImageFieldErrors(t *testing.T) {
+	path := writeWorkspaceFile(t, "broken.yaml", `cores: 0
+memory: 2g
+image_type: Desktop
+exec_config: "{not json"
+`)
+
+	_, err := webApi.LoadTargetImage(path)
+	var validationErrors webApi.ValidationErrors
+	require.True(t, errors.As(err, &validationErrors), "got %v", err)
+
+	fields := make([]string, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{"cores", "exec_config", "friendly_name", "image_type", "name"}, fields)
+}
@@ -0,0 +1,81 @@
+package Tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+)
+
+var templateFS = fstest.MapFS{
+	"dockerfiles/dockerfile-app.tmpl": {Data: []byte("FROM {{ .BaseImage }}\nLABEL version=\"{{ .Version }}\"\n")},
+	"dockerfiles/entrypoint.sh":       {Data: []byte("echo {{ .NotATemplate }}\n")},
+}
+
+func TestInitFolderWithDataRendersTemplates(t *testing.T) {
+	dir := t.TempDir()
+	err := procedures.InitFolderWithData(dir, "dockerfiles", "dockerfiles", templateFS, map[string]interface{}{
+		"BaseImage": "opensuse/leap:15.6",
+		"Version":   "1.2.3",
+	}, procedures.OverwriteSkipExisting)
+	require.NoError(t, err)
+
+	rendered, err := os.ReadFile(filepath.Join(dir, "dockerfiles", "dockerfile-app"))
+	require.NoError(t, err)
+	assert.Equal(t, "FROM opensuse/leap:15.6\nLABEL version=\"1.2.3\"\n", string(rendered))
+
+	untouched, err := os.ReadFile(filepath.Join(dir, "dockerfiles", "entrypoint.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "echo {{ .NotATemplate }}\n", string(untouched), "non-template files are copied verbatim")
+}
+
+func TestInitFolderWithDataMissingValue(t *testing.T) {
+	err := procedures.InitFolderWithData(t.TempDir(), "dockerfiles", "dockerfiles", templateFS, map[string]interface{}{
+		"BaseImage": "opensuse/leap:15.6",
+	}, procedures.OverwriteSkipExisting)
+	assert.ErrorContains(t, err, "Version")
+}
+
+func TestInitFolderCopiesTemplatesVerbatim(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, procedures.InitFolder(dir, "dockerfiles", "dockerfiles", templateFS, procedures.OverwriteSkipExisting))
+
+	content, err := os.ReadFile(filepath.Join(dir, "dockerfiles", "dockerfile-app.tmpl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "{{ .BaseImage }}")
+}
+
+func TestInitFolderOverwritePolicies(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dockerfiles", "entrypoint.sh")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, os.WriteFile(target, []byte("user edit\n"), 0644))
+
+	require.NoError(t, procedures.InitFolder(dir, "dockerfiles", "dockerfiles", templateFS, ""))
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "user edit\n", string(content), "existing files are skipped by default")
+
+	require.NoError(t, procedures.InitFolder(dir, "dockerfiles", "dockerfiles", templateFS, procedures.OverwriteBackup))
+	content, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "echo {{ .NotATemplate }}\n", string(content))
+	backups, err := filepath.Glob(target + ".bak-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	backup, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "user edit\n", string(backup))
+
+	require.NoError(t, os.WriteFile(target, []byte("second edit\n"), 0644))
+	require.NoError(t, procedures.InitFolder(dir, "dockerfiles", "dockerfiles", templateFS, procedures.OverwriteReplace))
+	content, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "echo {{ .NotATemplate }}\n", string(content))
+
+	assert.Error(t, procedures.InitFolder(dir, "dockerfiles", "dockerfiles", templateFS, "clobber"))
+}
@@ -0,0 +1,164 @@
+package Tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+// imagePresentNode answers the image checks of CreateTestEnvironment as a node that already has every image.
+func imagePresentNode(command string, _ io.ReadWriter) (string, uint32) {
+	if strings.HasPrefix(command, "docker image inspect") {
+		return "sha256:4f1c2d sha256:9a8b7c\n", 0
+	}
+	return "", 0
+}
+
+// newTestEnvironmentKasm starts a fake Kasm server with the image and group used by writeTestEnvironmentUsers.
+func newTestEnvironmentKasm(t *testing.T) *testutil.FakeKasmServer {
+	fake := testutil.NewFakeKasmServer()
+	t.Cleanup(fake.Close)
+	fake.AddImage(webApi.TargetImage{Name: "kasmweb/core:1.16.0", FriendlyName: "Core", Enabled: true})
+	fake.AddGroup("developers")
+	return fake
+}
+
+// writeTestEnvironmentUsers writes a users configuration with the given users and returns its path.
+func writeTestEnvironmentUsers(t *testing.T, users []userParser.UserDetails) string {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	require.NoError(t, userParser.SaveConfig(path, &userParser.UsersConfig{UserDetails: users}))
+	return path
+}
+
+// countRequests returns how often endpoint was requested from the fake server.
+func countRequests(fake *testutil.FakeKasmServer, endpoint string) int {
+	count := 0
+	for _, request := range fake.Requests() {
+		if request == endpoint {
+			count++
+		}
+	}
+	return count
+}
+
+func testEnvironmentUser(username, role string) userParser.UserDetails {
+	return userParser.UserDetails{
+		TargetUser:           webApi.TargetUser{Username: username, FirstName: username},
+		Role:                 role,
+		AssignedContainerTag: "kasmweb/core:1.16.0",
+	}
+}
+
+func TestCreateTestEnvironmentProvisionsAllUsers(t *testing.T) {
+	fake := newTestEnvironmentKasm(t)
+	server := newTestSSHServer(t, imagePresentNode)
+
+	var users []userParser.UserDetails
+	for i := 0; i < 8; i++ {
+		users = append(users, testEnvironmentUser(fmt.Sprintf("student%d", i), "developers"))
+	}
+	path := writeTestEnvironmentUsers(t, users)
+
+	err := procedures.CreateTestEnvironment(context.Background(), path, server.config, fake.API(), procedures.TestEnvironmentOptions{Workers: 3})
+	require.NoError(t, err)
+
+	config, err := userParser.NewUserParser().LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, config.UserDetails, len(users))
+	for _, user := range config.UserDetails {
+		assert.NotEmpty(t, user.TargetUser.UserID, user.TargetUser.Username)
+		assert.NotEmpty(t, user.KasmSessionOfContainer, user.TargetUser.Username)
+	}
+
+	created, err := fake.API().GetUsers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, created, len(users))
+	assert.Equal(t, len(users), countRequests(fake, "/api/public/create_user"))
+	assert.Equal(t, len(users), countRequests(fake, "/api/public/request_kasm"))
+}
+
+func TestCreateTestEnvironmentReportsFailedUser(t *testing.T) {
+	fake := newTestEnvironmentKasm(t)
+	server := newTestSSHServer(t, imagePresentNode)
+
+	users := []userParser.UserDetails{
+		testEnvironmentUser("alice", "developers"),
+		testEnvironmentUser("mallory", "auditors"),
+		testEnvironmentUser("bob", "developers"),
+		testEnvironmentUser("carol", "developers"),
+	}
+	path := writeTestEnvironmentUsers(t, users)
+
+	err := procedures.CreateTestEnvironment(context.Background(), path, server.config, fake.API(), procedures.TestEnvironmentOptions{Workers: 2})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to provision 1 of 4 users")
+	assert.Contains(t, err.Error(), "role auditors of user mallory")
+	for _, name := range []string{"alice", "bob", "carol"} {
+		assert.NotContains(t, err.Error(), "user "+name)
+	}
+
+	config, err := userParser.NewUserParser().LoadConfig(path)
+	require.NoError(t, err)
+	for _, user := range config.UserDetails {
+		if user.TargetUser.Username == "mallory" {
+			assert.Empty(t, user.KasmSessionOfContainer)
+			continue
+		}
+		assert.NotEmpty(t, user.TargetUser.UserID, user.TargetUser.Username)
+		assert.NotEmpty(t, user.KasmSessionOfContainer, user.TargetUser.Username)
+	}
+	assert.Equal(t, 3, countRequests(fake, "/api/public/request_kasm"))
+}
+
+func TestCreateTestEnvironmentResumesFromCheckpoint(t *testing.T) {
+	fake := newTestEnvironmentKasm(t)
+	server := newTestSSHServer(t, imagePresentNode)
+
+	var users []userParser.UserDetails
+	for i := 0; i < 6; i++ {
+		user := testEnvironmentUser(fmt.Sprintf("student%d", i), "developers")
+		if i%2 == 0 {
+			// Checkpointed by a previous run. These users are unknown to the fake server, so any request made
+			// for them would create them there.
+			user.TargetUser.UserID = fmt.Sprintf("previous-user-%d", i)
+			user.KasmSessionOfContainer = fmt.Sprintf("previous-kasm-%d", i)
+		}
+		users = append(users, user)
+	}
+	path := writeTestEnvironmentUsers(t, users)
+
+	err := procedures.CreateTestEnvironment(context.Background(), path, server.config, fake.API(), procedures.TestEnvironmentOptions{Workers: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, countRequests(fake, "/api/public/create_user"))
+	assert.Equal(t, 3, countRequests(fake, "/api/public/request_kasm"))
+	created, err := fake.API().GetUsers(context.Background())
+	require.NoError(t, err)
+	var createdNames []string
+	for _, user := range created {
+		createdNames = append(createdNames, user.Username)
+	}
+	assert.ElementsMatch(t, []string{"student1", "student3", "student5"}, createdNames)
+
+	config, err := userParser.NewUserParser().LoadConfig(path)
+	require.NoError(t, err)
+	for i, user := range config.UserDetails {
+		if i%2 == 0 {
+			assert.Equal(t, fmt.Sprintf("previous-user-%d", i), user.TargetUser.UserID)
+			assert.Equal(t, fmt.Sprintf("previous-kasm-%d", i), user.KasmSessionOfContainer)
+			continue
+		}
+		assert.NotEmpty(t, user.TargetUser.UserID, user.TargetUser.Username)
+		assert.NotEmpty(t, user.KasmSessionOfContainer, user.TargetUser.Username)
+	}
+}
@@ -0,0 +1,74 @@
+package Tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+	"kasmlink/pkg/webApi/testutil"
+)
+
+func TestUserExistsFound(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	alice := fake.AddUser(webApi.TargetUser{Username: "alice"})
+
+	exists, user, err := fake.API().UserExists(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	require.NotNil(t, user)
+	assert.Equal(t, alice.UserID, user.UserID)
+}
+
+func TestUserExistsNotFound(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+
+	exists, user, err := fake.API().UserExists(context.Background(), "nobody")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Nil(t, user)
+	assert.Equal(t, []string{"/api/public/get_user"}, fake.Requests(), "client errors are not retried")
+}
+
+func TestUserExistsReportsOtherErrors(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+	kApi.APIKeySecret = "wrong"
+
+	exists, _, err := kApi.UserExists(context.Background(), "alice")
+	require.Error(t, err)
+	assert.False(t, exists)
+	assert.False(t, webApi.IsNotFound(err))
+	assert.True(t, errors.Is(err, webApi.ErrUnauthorized))
+
+	var apiErr *webApi.KasmAPIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "Access Denied", apiErr.Message)
+}
+
+func TestNormalizeUsernames(t *testing.T) {
+	fake := testutil.NewFakeKasmServer()
+	defer fake.Close()
+	kApi := fake.API()
+
+	assert.Equal(t, "User@Example.com", kApi.NormalizeUsername(" User@Example.com "), "usernames keep their case by default")
+
+	kApi.NormalizeUsernames = true
+	created, err := kApi.CreateUser(context.Background(), webApi.TargetUser{Username: "User@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", created.Username)
+
+	user, err := kApi.GetUserByUsername(context.Background(), "USER@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.UserID, user.UserID)
+
+	_, err = kApi.GetUserByUsername(context.Background(), " ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package Tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/userParser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleUsersConfig = `user_details:
+  - target_user:
+      username: alice
+    role: user
+    assigned_container_tag: kasmweb/core:1.0
+`
+
+// writeSampleUsersConfig writes the sample configuration into a fresh temp directory and returns its path.
+func writeSampleUsersConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(sampleUsersConfig), 0600))
+	return path
+}
+
+// TestUpdateUserConfigWritesAtomically Tests that an update replaces the file in place and leaves no temporary files.
+func TestUpdateUserConfigWritesAtomically(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	parser := userParser.NewUserParser()
+
+	err := parser.UpdateUserConfig(path, "alice", "user-1", "kasm-1", "container-1")
+	assert.NoError(t, err)
+
+	config, err := parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", config.UserDetails[0].TargetUser.UserID)
+	assert.Equal(t, "kasm-1", config.UserDetails[0].KasmSessionOfContainer)
+	assert.Equal(t, "container-1", config.UserDetails[0].AssignedContainerId)
+
+	// Original permissions are preserved and no temporary files are left behind.
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestUpdateUserConfigInterruptedBeforeRename Tests that a write failing at the rename leaves the target intact
+// and removes its temporary file.
+func TestUpdateUserConfigInterruptedBeforeRename(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	dir := filepath.Dir(path)
+	parser := userParser.NewUserParser()
+
+	config, err := parser.LoadConfig(path)
+	assert.NoError(t, err)
+	config.UserDetails[0].TargetUser.UserID = "user-1"
+
+	// A non-empty directory cannot be replaced by a file, so the rename is the step that fails.
+	target := filepath.Join(dir, "users.d")
+	assert.NoError(t, os.Mkdir(target, 0o755))
+	kept := filepath.Join(target, "users.yaml")
+	assert.NoError(t, os.WriteFile(kept, []byte(sampleUsersConfig), 0600))
+
+	err = userParser.SaveConfig(target, config)
+	assert.ErrorContains(t, err, "failed to rename temporary file")
+
+	data, err := os.ReadFile(kept)
+	assert.NoError(t, err)
+	assert.Equal(t, sampleUsersConfig, string(data))
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, sampleUsersConfig, string(data))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"users.d", "users.yaml"}, names, "the temporary file is removed")
+}
+
+// TestUpdateUserConfigFailureKeepsOriginal Tests that a failed update does not modify the configuration file.
+func TestUpdateUserConfigFailureKeepsOriginal(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	parser := userParser.NewUserParser()
+
+	err := parser.UpdateUserConfig(path, "bob", "user-2", "kasm-2", "container-2")
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, sampleUsersConfig, string(data))
+
+	// Saving into a missing directory fails without creating anything.
+	err = userParser.SaveConfig(filepath.Join(filepath.Dir(path), "missing", "users.yaml"), &userParser.UsersConfig{})
+	assert.Error(t, err)
+}
+
+// TestLoadConfigReloadsChangedFile Tests that the cached configuration is refreshed after the file changes on disk.
+func TestLoadConfigReloadsChangedFile(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	parser := userParser.NewUserParser()
+
+	config, err := parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", config.UserDetails[0].Role)
+
+	// Modifying a returned configuration does not leak into the cache.
+	config.UserDetails[0].Role = "mutated"
+	config, err = parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", config.UserDetails[0].Role)
+
+	edited := strings.Replace(sampleUsersConfig, "role: user", "role: admin", 1)
+	assert.NoError(t, os.WriteFile(path, []byte(edited), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, later, later))
+
+	config, err = parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", config.UserDetails[0].Role)
+}
+
+// TestInvalidateCache Tests that an invalidated path is read again even if its modification time is unchanged.
+func TestInvalidateCache(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	parser := userParser.NewUserParser()
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	_, err = parser.LoadConfig(path)
+	assert.NoError(t, err)
+
+	// Same size and modification time: only an explicit invalidation reveals the edit.
+	edited := strings.Replace(sampleUsersConfig, "role: user", "role: boss", 1)
+	assert.NoError(t, os.WriteFile(path, []byte(edited), 0600))
+	assert.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+
+	config, err := parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", config.UserDetails[0].Role)
+
+	parser.InvalidateCache(path)
+	config, err = parser.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "boss", config.UserDetails[0].Role)
+}
+
+// TestWatchConfigReportsChanges Tests that in-place edits and rename-on-save edits are both reported.
+func TestWatchConfigReportsChanges(t *testing.T) {
+	path := writeSampleUsersConfig(t)
+	parser := userParser.NewUserParser()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan *userParser.UsersConfig, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- parser.WatchConfig(ctx, path, 10*time.Millisecond, func(config *userParser.UsersConfig) {
+			changes <- config
+		})
+	}()
+
+	waitForRole := func(role string) {
+		t.Helper()
+		select {
+		case config := <-changes:
+			assert.Equal(t, role, config.UserDetails[0].Role)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no change reported for role %s", role)
+		}
+	}
+
+	// Give the watcher time to record the initial state of the file.
+	time.Sleep(50 * time.Millisecond)
+
+	// In-place write.
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Replace(sampleUsersConfig, "role: user", "role: admin", 1)), 0600))
+	waitForRole("admin")
+
+	// Atomic save: write a new file and rename it over the original.
+	replacement := filepath.Join(filepath.Dir(path), "users.yaml.new")
+	assert.NoError(t, os.WriteFile(replacement, []byte(strings.Replace(sampleUsersConfig, "role: user", "role: viewer", 1)), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(replacement, later, later))
+	assert.NoError(t, os.Rename(replacement, path))
+	waitForRole("viewer")
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("watcher did not stop after cancellation")
+	}
+}
@@ -0,0 +1,50 @@
+package Tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kasmlink/pkg/webApi"
+)
+
+func TestLoadRosterCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.csv")
+	content := "Username,First_Name,Last_Name,Group_ID\n" +
+		"alice,Alice,Anders,group-1\n" +
+		"bob,Bob,Berg,\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	entries, err := webApi.LoadRoster(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []webApi.RosterEntry{
+		{Username: "alice", FirstName: "Alice", LastName: "Anders", GroupID: "group-1"},
+		{Username: "bob", FirstName: "Bob", LastName: "Berg"},
+	}, entries)
+}
+
+func TestLoadRosterYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.yaml")
+	content := `users:
+  - username: carol
+    first_name: Carol
+    last_name: Cruz
+    password: S3cure!pass
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	entries, err := webApi.LoadRoster(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []webApi.RosterEntry{
+		{Username: "carol", FirstName: "Carol", LastName: "Cruz", Password: "S3cure!pass"},
+	}, entries)
+}
+
+func TestLoadRosterRejectsMissingUsername(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("username,first_name\n,Dave\n"), 0644))
+
+	_, err := webApi.LoadRoster(path)
+	assert.Error(t, err)
+}
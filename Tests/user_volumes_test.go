@@ -0,0 +1,68 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+)
+
+func studentWithVolumes() userParser.UserDetails {
+	return userParser.UserDetails{
+		TargetUser: webApi.TargetUser{Username: "alice"},
+		VolumeMounts: map[string]string{
+			"/srv/kasm/homes/alice": "/home/kasm-user:rw",
+			"/srv/kasm/datasets":    "/data:ro",
+		},
+	}
+}
+
+func TestUserVolumeMappings(t *testing.T) {
+	mappings, err := procedures.UserVolumeMappings(studentWithVolumes())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"/srv/kasm/datasets": {"bind": "/data", "mode": "ro", "uid": 1000, "gid": 1000},
+		"/srv/kasm/homes/alice": {"bind": "/home/kasm-user", "mode": "rw", "uid": 1000, "gid": 1000}
+	}`, mappings)
+
+	mappings, err = procedures.UserVolumeMappings(userParser.UserDetails{})
+	require.NoError(t, err)
+	assert.Empty(t, mappings)
+}
+
+func TestUserVolumeMappingsRejectsInvalidMounts(t *testing.T) {
+	for _, mount := range []string{"/home/kasm-user", "/home/kasm-user:rwx", "home:rw"} {
+		_, err := procedures.UserVolumeMappings(userParser.UserDetails{VolumeMounts: map[string]string{"/srv/home": mount}})
+		assert.Error(t, err, mount)
+	}
+}
+
+func TestProvisionUserVolumesCreatesOwnedDirectories(t *testing.T) {
+	executor := scriptedExecutor{
+		"mkdir -p /srv/kasm/datasets && chown 1000:1000 /srv/kasm/datasets":       "",
+		"mkdir -p /srv/kasm/homes/alice && chown 1000:1000 /srv/kasm/homes/alice": "",
+	}
+
+	require.NoError(t, procedures.ProvisionUserVolumes(context.Background(), executor, studentWithVolumes()))
+}
+
+func TestProvisionUserVolumesReportsFailedDirectory(t *testing.T) {
+	executor := &failingExecutor{output: "mkdir: cannot create directory '/srv/kasm/datasets': Permission denied"}
+
+	err := procedures.ProvisionUserVolumes(context.Background(), executor, studentWithVolumes())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/srv/kasm/datasets")
+	assert.Len(t, executor.commands, 1, "provisioning stops at the first failure")
+}
+
+func TestProvisionUserVolumesValidatesBeforeRunningCommands(t *testing.T) {
+	executor := &failingExecutor{}
+	user := userParser.UserDetails{VolumeMounts: map[string]string{"/srv/home": "/home/kasm-user:rwx"}}
+
+	assert.Error(t, procedures.ProvisionUserVolumes(context.Background(), executor, user))
+	assert.Empty(t, executor.commands)
+}
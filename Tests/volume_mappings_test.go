@@ -0,0 +1,42 @@
+package Tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+func TestVolumeMappingsSerialization(t *testing.T) {
+	var target webApi.TargetImage
+	err := webApi.NewVolumeMappings().
+		Add("/home/kasm-user/shared", "/mnt/shared", webApi.VolumeModeReadOnly, 0, 0).
+		Add("/home/kasm-user/data", "/mnt/data/alice", webApi.VolumeModeReadWrite, 1000, 1000).
+		ApplyTo(&target)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`{"/mnt/data/alice":{"bind":"/home/kasm-user/data","mode":"rw","gid":1000,"uid":1000},"/mnt/shared":{"bind":"/home/kasm-user/shared","mode":"ro"}}`,
+		target.VolumeMappings)
+}
+
+func TestVolumeMappingsValidation(t *testing.T) {
+	cases := map[string]*webApi.VolumeMappings{
+		"relative container path": webApi.NewVolumeMappings().Add("data", "/mnt/data", "rw", 0, 0),
+		"relative host path":      webApi.NewVolumeMappings().Add("/data", "mnt/data", "rw", 0, 0),
+		"invalid mode":            webApi.NewVolumeMappings().Add("/data", "/mnt/data", "readwrite", 0, 0),
+		"negative uid":            webApi.NewVolumeMappings().Add("/data", "/mnt/data", "rw", -1, 0),
+		"duplicate host path": webApi.NewVolumeMappings().
+			Add("/data", "/mnt/data", "rw", 0, 0).
+			Add("/other", "/mnt/data", "ro", 0, 0),
+	}
+	for name, mappings := range cases {
+		_, err := mappings.Build()
+		assert.Error(t, err, name)
+	}
+
+	empty, err := webApi.NewVolumeMappings().Build()
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
@@ -0,0 +1,71 @@
+package Tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kasmlink/pkg/webApi"
+)
+
+const liveChromeImage = `{"image_id":"abc","name":"kasmweb/chrome:1.15.0","friendly_name":"Chrome","description":"",
+"cores":2,"memory":2048000000,"gpu_count":0,"enabled":true,"image_type":"Container","cpu_allocation_method":"Inherit",
+"categories":["Browsers"],"launch_config":{"allow_resume":true}}`
+
+func desiredChromeImage() webApi.TargetImage {
+	return webApi.TargetImage{
+		ImageID:             "abc",
+		Name:                "kasmweb/chrome:1.15.0",
+		FriendlyName:        "Chrome",
+		Cores:               2,
+		Memory:              2048000000,
+		Enabled:             true,
+		ImageType:           "Container",
+		CPUAllocationMethod: "Inherit",
+	}
+}
+
+func TestGetWorkspaceAcceptsSingleImageEnvelope(t *testing.T) {
+	kApi, _ := newStubbedKasmAPI(`{"image":` + liveChromeImage + `}`)
+
+	workspace, err := kApi.GetWorkspace(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "kasmweb/chrome:1.15.0", workspace.Name)
+	assert.Equal(t, "Browsers", workspace.Categories)
+	assert.JSONEq(t, `"{\"allow_resume\":true}"`, string(workspace.LaunchConfig))
+
+	_, err = kApi.GetWorkspace(context.Background(), "missing")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestUpdateWorkspaceSendsOnlyChangedFields(t *testing.T) {
+	kApi, stub := newStubbedKasmAPI(`{"images":[` + liveChromeImage + `]}`)
+
+	desired := desiredChromeImage()
+	desired.Name = "kasmweb/chrome:1.16.0"
+	changes, err := kApi.UpdateWorkspace(context.Background(), desired)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "name", changes[0].Field)
+
+	require.Len(t, stub.payloads, 2)
+	assert.Equal(t, "/api/public/update_image", stub.requests[1].URL.Path)
+	target := stub.payloads[1]["target_image"].(map[string]interface{})
+	assert.Equal(t, "kasmweb/chrome:1.16.0", target["name"])
+	// Unchanged fields are sent back as Kasm returned them.
+	assert.Equal(t, `{"allow_resume":true}`, target["launch_config"])
+	assert.Equal(t, "Browsers", target["categories"])
+}
+
+func TestUpdateWorkspaceSkipsUpToDateImage(t *testing.T) {
+	kApi, stub := newStubbedKasmAPI(`{"images":[` + liveChromeImage + `]}`)
+
+	changes, err := kApi.UpdateWorkspace(context.Background(), desiredChromeImage())
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Len(t, stub.payloads, 1, "no update is sent")
+
+	_, err = kApi.UpdateWorkspace(context.Background(), webApi.TargetImage{Name: "kasmweb/chrome:1.16.0"})
+	assert.ErrorContains(t, err, "image_id")
+}
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
 	"kasmlink/pkg/dockercompose"
 	"kasmlink/pkg/procedures"
 	"os"
+	"path/filepath"
 	"strconv"
 )
 
@@ -21,6 +24,13 @@ func init() {
 
 	// Add subcommands for generating Docker Compose files
 	composeCmd.AddCommand(createPopulateComposeWithTemplateCommand())
+	composeCmd.AddCommand(createComposeFromSpecCommand())
+
+	// Add subcommands for managing the services of a running compose project
+	composeCmd.AddCommand(createComposeUpCommand())
+	composeCmd.AddCommand(createComposeServiceCommand("start", "Start stopped services of a compose project"))
+	composeCmd.AddCommand(createComposeServiceCommand("stop", "Stop services of a compose project without removing them"))
+	composeCmd.AddCommand(createComposeServiceCommand("restart", "Restart services of a compose project"))
 
 	// Add "compose" to the root command
 	RootCmd.AddCommand(composeCmd)
@@ -111,6 +121,123 @@ the count of service instances to create, and optional service names for seriali
 	}
 }
 
+// createComposeFromSpecCommand generates a compose file with build sections from a services spec YAML.
+func createComposeFromSpecCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "from-spec [specPath] [outputPath]",
+		Short: "Generate a Docker Compose file from a services spec YAML",
+		Long: `This command reads the services listed in a spec YAML and generates a compose file building every service
+from the Dockerfile in the Dockerfiles folder (default "container" next to the spec) whose name contains the
+service name. The compose file is written to outputPath, or to docker-compose.yaml next to the spec.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			specPath := args[0]
+			outputPath := filepath.Join(filepath.Dir(specPath), "docker-compose.yaml")
+			if len(args) > 1 {
+				outputPath = args[1]
+			}
+			dockerfilesDir, _ := cmd.Flags().GetString("dockerfiles-dir")
+
+			composeFile, err := procedures.GenerateComposeFromSpec(specPath, dockerfilesDir)
+			HandleError(err)
+
+			// Build contexts are relative to the spec; keep them valid when the compose file is written elsewhere.
+			for name, service := range composeFile.Services {
+				if service.Build == nil {
+					continue
+				}
+				contextPath := filepath.Join(filepath.Dir(specPath), service.Build.Context)
+				if rel, err := filepath.Rel(filepath.Dir(outputPath), contextPath); err == nil {
+					service.Build.Context = filepath.ToSlash(rel)
+				}
+				composeFile.Services[name] = service
+			}
+
+			HandleError(procedures.WriteComposeFile(&composeFile, outputPath))
+			fmt.Printf("Compose file with %d service(s) written to %s\n", len(composeFile.Services), outputPath)
+		},
+	}
+
+	cmd.Flags().String("dockerfiles-dir", "", "Folder with the Dockerfiles of the services, relative to the spec (default from the spec or \"container\")")
+
+	return cmd
+}
+
+// createComposeUpCommand starts the services of a compose project, optionally including services of profiles.
+func createComposeUpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "up [composeFilePath]",
+		Short: "Start the services of a compose project in the background",
+		Long: `This command runs docker compose up -d on the Docker host configured through the environment (DOCKER_HOST).
+Services assigned to profiles only start when one of their profiles is enabled with --profile.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			projectName, _ := cmd.Flags().GetString("project-name")
+			envFile, _ := cmd.Flags().GetString("env-file")
+			profiles, _ := cmd.Flags().GetStringSlice("profile")
+			build, _ := cmd.Flags().GetBool("build")
+
+			composeFile, err := dockercompose.LoadComposeFile(args[0])
+			HandleError(err)
+			HandleError(composeFile.ValidateProfiles(profiles))
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			project := dockercli.ComposeProject{File: args[0], Name: projectName, EnvFile: envFile}
+			output, err := dc.ComposeUp(ctx, project, build, profiles, composeFile.ActiveServices(profiles))
+			HandleError(err)
+			fmt.Print(output)
+		},
+	}
+
+	cmd.Flags().String("project-name", "", "Compose project name (-p)")
+	cmd.Flags().String("env-file", "", "Env file passed via --env-file")
+	cmd.Flags().StringSlice("profile", nil, "Compose profile to enable (repeatable); services in other profiles are not started")
+	cmd.Flags().Bool("build", false, "Build images before starting the services")
+
+	return cmd
+}
+
+// createComposeServiceCommand creates a subcommand running start, stop or restart for the given services of a
+// compose project, or for all of its services when none are given.
+func createComposeServiceCommand(action, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " [composeFilePath] [services...]",
+		Short: short,
+		Long: fmt.Sprintf(`This command runs docker compose %s on the Docker host configured through the environment (DOCKER_HOST).
+Pass service names to %s only those services; without names all services of the project are affected.
+Use --project-name and --env-file with the same values as the deployment.`, action, action),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			projectName, _ := cmd.Flags().GetString("project-name")
+			envFile, _ := cmd.Flags().GetString("env-file")
+			project := dockercli.ComposeProject{File: args[0], Name: projectName, EnvFile: envFile}
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			run := map[string]func(context.Context, dockercli.ComposeProject, ...string) (string, error){
+				"start":   dc.ComposeStart,
+				"stop":    dc.ComposeStop,
+				"restart": dc.ComposeRestart,
+			}[action]
+			output, err := run(ctx, project, args[1:]...)
+			HandleError(err)
+			fmt.Print(output)
+		},
+	}
+
+	cmd.Flags().String("project-name", "", "Compose project name (-p) used for the deployment")
+	cmd.Flags().String("env-file", "", "Env file (--env-file) used for the deployment")
+
+	return cmd
+}
+
 // parseServiceNames parses the service names from the input arguments.
 // If the number of service names is less than the count, it generates default service names.
 func parseServiceNames(inputNames []string, count int) []string {
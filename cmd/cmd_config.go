@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Init initializes the config command.
+func init() {
+	// Define "config" command
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Export and apply Kasm deployment configuration",
+		Long:  `Commands to snapshot a Kasm deployment and reconcile it with a deployment YAML through the Kasm API. Connection settings are read from flags or the KASM_URL, KASM_API_KEY and KASM_API_SECRET environment variables.`,
+	}
+	addKasmAPIFlags(configCmd)
+
+	configCmd.AddCommand(createExportConfigCommand())
+	configCmd.AddCommand(createPlanConfigCommand())
+	configCmd.AddCommand(createApplyConfigCommand())
+
+	// Add "config" to the root command
+	RootCmd.AddCommand(configCmd)
+}
+
+// createExportConfigCommand exports users, groups and images into a deployment YAML.
+func createExportConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [outputFile]",
+		Short: "Export users, groups and images to a deployment YAML",
+		Long: `Reads the users, groups and workspace images of the Kasm deployment and writes them to outputFile,
+which can be passed to "test api" to recreate the deployment. Passwords and other values the API does not
+return are left empty and listed under manual_fields.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			config, err := procedures.ExportDeploymentConfig(ctx, kApi)
+			HandleError(err)
+			HandleError(userParser.SaveDeploymentConfig(args[0], config))
+
+			fmt.Printf("Exported %d user(s), %d group(s) and %d image(s) to %s\n", len(config.UserDetails), len(config.Groups), len(config.Images), args[0])
+			if len(config.ManualFields) > 0 {
+				fmt.Printf("%d field(s) need to be filled in manually, see manual_fields\n", len(config.ManualFields))
+			}
+		},
+	}
+}
+
+// createPlanConfigCommand shows the changes needed to reconcile the deployment with a deployment YAML.
+func createPlanConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan [configFile]",
+		Short: "Show the changes needed to match a deployment YAML",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			plan, _ := planDeployment(cmd, args[0])
+			printPlan(cmd, plan)
+		},
+	}
+	cmd.Flags().Bool("delete", false, "Plan the deletion of users and images missing from the file")
+	cmd.Flags().String("overlay", "", "Environment overlay YAML merged onto the configuration file")
+	return cmd
+}
+
+// createApplyConfigCommand reconciles the deployment with a deployment YAML.
+func createApplyConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply [configFile]",
+		Short: "Create, update and optionally delete users and images to match a deployment YAML",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			plan, kApi := planDeployment(cmd, args[0])
+			printPlan(cmd, plan)
+			if plan.Empty() {
+				return
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			credentials, err := procedures.ApplyPlan(ctx, kApi, plan)
+			for _, credential := range credentials {
+				fmt.Printf("Generated password for %s: %s\n", credential.Username, credential.Password)
+			}
+			HandleError(err)
+			fmt.Printf("Applied %d change(s)\n", len(plan.Changes))
+		},
+	}
+	cmd.Flags().Bool("delete", false, "Delete users and images missing from the file")
+	cmd.Flags().String("overlay", "", "Environment overlay YAML merged onto the configuration file")
+	return cmd
+}
+
+// planDeployment loads the deployment YAML and plans it against the deployment selected by the API flags.
+func planDeployment(cmd *cobra.Command, path string) (*procedures.Plan, *webApi.KasmAPI) {
+	overlay, _ := cmd.Flags().GetString("overlay")
+	desired, err := userParser.LoadDeploymentConfigWithOverlay(path, overlay)
+	HandleError(err)
+	kApi, err := newKasmAPI(cmd)
+	HandleError(err)
+	deleteMissing, _ := cmd.Flags().GetBool("delete")
+
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+	plan, err := procedures.PlanDeployment(ctx, kApi, desired, procedures.PlanOptions{Delete: deleteMissing})
+	HandleError(err)
+	return plan, kApi
+}
+
+// printPlan prints one line per planned change.
+func printPlan(cmd *cobra.Command, plan *procedures.Plan) {
+	if plan.Empty() {
+		fmt.Println("No changes, the deployment matches the configuration")
+		return
+	}
+
+	t := table{headers: []string{"ACTION", "KIND", "NAME", "CHANGES"}}
+	for _, change := range plan.Changes {
+		fields := make([]string, len(change.Fields))
+		for i, field := range change.Fields {
+			fields[i] = field.String()
+		}
+		t.rows = append(t.rows, []string{change.Action, change.Kind, change.Name, strings.Join(fields, "; ")})
+	}
+	HandleError(printOutput(cmd, plan, t))
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/procedures"
+)
+
+func init() {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the prerequisites of deployments",
+		Long: `This command checks the local Docker daemon, the SSH connection to the node configured through --ssh-config
+and the SSH_* environment variables, the Kasm API connection and credentials, and the templates embedded in the
+binary. It prints a checklist with hints for every failed check and exits with status 1 if a critical check failed.`,
+		Args: cobra.NoArgs,
+		// The Kasm API is checked like every other prerequisite instead of being verified up front.
+		Annotations: map[string]string{noKasmAPIAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			skipSSH, _ := cmd.Flags().GetBool("skip-ssh")
+			skipKasm, _ := cmd.Flags().GetBool("skip-kasm")
+
+			var checks []procedures.DoctorCheck
+			// A single attempt per check, so missing prerequisites are reported right away.
+			if cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation()); err != nil {
+				checks = append(checks, failedDoctorCheck(procedures.DockerDoctorCheck(nil), err))
+			} else {
+				checks = append(checks, procedures.DockerDoctorCheck(dockercli.NewDockerClient(cli, 1, 0, 0, 0, 0)))
+			}
+			if !skipSSH {
+				if sshConfig, err := loadSSHConfig(cmd); err != nil {
+					checks = append(checks, failedDoctorCheck(procedures.SSHDoctorCheck(nil), err))
+				} else {
+					checks = append(checks, procedures.SSHDoctorCheck(sshConfig))
+				}
+			}
+			if !skipKasm {
+				if kApi, err := buildKasmAPI(cmd); err != nil {
+					checks = append(checks, failedDoctorCheck(procedures.KasmDoctorCheck(nil), err))
+				} else {
+					checks = append(checks, procedures.KasmDoctorCheck(kApi))
+				}
+			}
+			checks = append(checks, procedures.EmbeddedTemplatesDoctorCheck())
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			results, healthy := procedures.RunDoctorChecks(ctx, checks)
+
+			t := table{headers: []string{"CHECK", "STATUS", "DETAIL", "HINT"}}
+			for _, result := range results {
+				status := "pass"
+				if !result.Passed {
+					status = "FAIL"
+					if !result.Critical {
+						status = "warn"
+					}
+				}
+				t.rows = append(t.rows, []string{result.Name, status, result.Detail, result.Hint})
+			}
+			HandleError(printOutput(cmd, results, t))
+			if !healthy {
+				os.Exit(1)
+			}
+		},
+	}
+
+	doctorCmd.Flags().Bool("skip-ssh", false, "Do not check the SSH connection to the node")
+	doctorCmd.Flags().Bool("skip-kasm", false, "Do not check the Kasm API connection")
+	addKasmAPIFlags(doctorCmd)
+
+	RootCmd.AddCommand(doctorCmd)
+}
+
+// failedDoctorCheck turns a check that could not be set up, e.g. because of missing settings, into a failing check.
+func failedDoctorCheck(check procedures.DoctorCheck, err error) procedures.DoctorCheck {
+	check.Run = func(context.Context) (string, error) {
+		return "", fmt.Errorf("not configured: %w", err)
+	}
+	return check
+}
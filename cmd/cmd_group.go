@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// Init initializes the group command.
+func init() {
+	// Define "group" command
+	groupCmd := &cobra.Command{
+		Use:   "group",
+		Short: "Manage Kasm groups",
+		Long:  `Commands to inspect user groups through the Kasm API. Connection settings are read from flags or the KASM_URL, KASM_API_KEY and KASM_API_SECRET environment variables.`,
+	}
+	addKasmAPIFlags(groupCmd)
+
+	// Add subcommands for group management
+	groupCmd.AddCommand(createListGroupsCommand())
+
+	// Add "group" to the root command
+	RootCmd.AddCommand(groupCmd)
+}
+
+// createListGroupsCommand lists all user groups.
+func createListGroupsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all groups",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			groups, err := kApi.GetGroups(ctx)
+			HandleError(err)
+
+			t := table{headers: []string{"GROUP ID", "NAME", "PRIORITY", "SYSTEM", "DESCRIPTION"}}
+			for _, group := range groups {
+				t.rows = append(t.rows, []string{
+					group.GroupID,
+					group.Name,
+					strconv.Itoa(group.Priority),
+					strconv.FormatBool(group.IsSystem),
+					group.Description,
+				})
+			}
+			HandleError(printOutput(cmd, groups, t))
+		},
+	}
+}
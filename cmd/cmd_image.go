@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/webApi"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Init initializes the image command.
+func init() {
+	// Define "image" command
+	imageCmd := &cobra.Command{
+		Use:   "image",
+		Short: "Manage Kasm workspace images and Docker images",
+		Long: `Commands to list the workspace images registered in Kasm and to clean up Docker images on the Docker host
+configured through the environment (DOCKER_HOST). Kasm connection settings are read from flags or the KASM_URL,
+KASM_API_KEY and KASM_API_SECRET environment variables.`,
+	}
+	addKasmAPIFlags(imageCmd)
+
+	// Add subcommands for image management
+	imageCmd.AddCommand(createListKasmImagesCommand())
+	imageCmd.AddCommand(createCreateKasmImageCommand())
+	imageCmd.AddCommand(createImageStateCommand("enable", "Enable a workspace image", "enabled", (*webApi.KasmAPI).SetImageEnabled, true))
+	imageCmd.AddCommand(createImageStateCommand("disable", "Disable a workspace image, e.g. during maintenance", "disabled", (*webApi.KasmAPI).SetImageEnabled, false))
+	imageCmd.AddCommand(createImageStateCommand("hide", "Hide a workspace image from the dashboard", "hidden", (*webApi.KasmAPI).SetImageHidden, true))
+	imageCmd.AddCommand(createImageStateCommand("unhide", "Show a hidden workspace image on the dashboard", "shown", (*webApi.KasmAPI).SetImageHidden, false))
+	imageCmd.AddCommand(createPruneImagesMatchingCommand())
+	imageCmd.AddCommand(createPullImageCommand())
+	imageCmd.AddCommand(createInspectImageCommand())
+	imageCmd.AddCommand(createTagImageCommand())
+	imageCmd.AddCommand(createPushImageCommand())
+
+	// Add "image" to the root command
+	RootCmd.AddCommand(imageCmd)
+}
+
+// createListKasmImagesCommand lists the workspace images registered in Kasm.
+func createListKasmImagesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List workspace images registered in Kasm",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			images, err := kApi.ListImages(ctx)
+			HandleError(err)
+
+			t := table{headers: []string{"IMAGE ID", "FRIENDLY NAME", "IMAGE", "CORES", "MEMORY", "ENABLED", "AVAILABLE"}}
+			for _, image := range images {
+				t.rows = append(t.rows, []string{
+					image.ImageID,
+					image.FriendlyName,
+					image.ImageTag,
+					strconv.FormatFloat(image.Cores, 'f', -1, 64),
+					strconv.FormatInt(image.Memory, 10),
+					strconv.FormatBool(image.Enabled),
+					strconv.FormatBool(image.Available),
+				})
+			}
+			HandleError(printOutput(cmd, images, t))
+		},
+	}
+}
+
+// createCreateKasmImageCommand registers a workspace image defined in a YAML or JSON file.
+func createCreateKasmImageCommand() *cobra.Command {
+	var fromFile string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a workspace image from a definition file",
+		Long: `This command registers a workspace image in Kasm from a YAML or JSON file using the API field names,
+for example name, friendly_name, cores and memory ("2g"). enabled, image_type and cpu_allocation_method default to
+true, Container and Inherit. run_config, exec_config and volume_mappings may be written as objects.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			target, err := webApi.LoadTargetImage(fromFile)
+			HandleError(err)
+
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			response, err := kApi.CreateImage(ctx, webApi.CreateImageRequest{TargetImage: target})
+			HandleError(err)
+
+			t := table{headers: []string{"IMAGE ID", "FRIENDLY NAME", "IMAGE"}}
+			t.rows = append(t.rows, []string{response.Image.ImageID, response.Image.FriendlyName, response.Image.Name})
+			HandleError(printOutput(cmd, response.Image, t))
+		},
+	}
+
+	cmd.Flags().StringVarP(&fromFile, "from-file", "f", "", "YAML or JSON file with the workspace image definition")
+	_ = cmd.MarkFlagRequired("from-file")
+
+	return cmd
+}
+
+// createImageStateCommand toggles a single flag of a workspace image without touching its other fields.
+func createImageStateCommand(use, short, done string, set func(*webApi.KasmAPI, context.Context, string, bool) error, value bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " [imageID]",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			HandleError(set(kApi, ctx, args[0], value))
+			fmt.Printf("Workspace image %s %s\n", args[0], done)
+		},
+	}
+}
+
+// createPruneImagesMatchingCommand removes image tags matching a glob or regex pattern.
+func createPruneImagesMatchingCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:         "prune-matching [pattern]",
+		Annotations: map[string]string{noKasmAPIAnnotation: ""},
+		Short:       "Remove image tags matching a glob or regex pattern",
+		Long: `This command removes every image tag whose "repository:tag" matches the given pattern.
+The pattern is a glob (e.g. "kasmweb/*:1.15*") unless prefixed with "regex:", for example "regex:^kasmweb/.*-rc[0-9]+$".
+Use --dry-run to only list the tags that would be removed.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			images, err := dc.PruneImagesMatching(ctx, args[0], dryRun)
+			for _, img := range images {
+				fmt.Println(img)
+			}
+			HandleError(err)
+
+			if dryRun {
+				fmt.Printf("%d image(s) would be removed\n", len(images))
+			} else {
+				fmt.Printf("%d image(s) removed\n", len(images))
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list matching images without removing them")
+
+	return cmd
+}
+
+// createPullImageCommand pulls an image, optionally through a registry mirror with registry credentials.
+func createPullImageCommand() *cobra.Command {
+	var pullConfigPath string
+
+	cmd := &cobra.Command{
+		Use:         "pull [image]",
+		Annotations: map[string]string{noKasmAPIAnnotation: ""},
+		Short:       "Pull an image, optionally through a registry mirror",
+		Long: `This command pulls an image on the Docker host. With --pull-config, pulls are routed through the
+registry mirrors of the given YAML file (e.g. "docker.io: nexus.internal/dockerhub") and the configured registry
+credentials are used. A mirrored image is tagged with its original name after the pull.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var pullConfig *dockercli.PullConfig
+			if pullConfigPath != "" {
+				var err error
+				pullConfig, err = dockercli.LoadPullConfig(pullConfigPath)
+				HandleError(err)
+			}
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			pulled, err := dc.PullImageWithProgress(ctx, args[0], pullConfig, pullProgressPrinter(os.Stderr))
+			HandleError(err)
+
+			fmt.Printf("Pulled %s from %s\n", args[0], pulled)
+		},
+	}
+
+	cmd.Flags().StringVar(&pullConfigPath, "pull-config", "", "YAML file with registry mirrors and credentials")
+
+	return cmd
+}
+
+// pullProgressPrinter returns a progress handler writing the downloaded bytes of all layers to w at most once
+// per second, so large pulls do not look hung.
+func pullProgressPrinter(w io.Writer) func(dockercli.BuildEvent) {
+	type layerProgress struct{ current, total int64 }
+	layers := map[string]layerProgress{}
+	var lastPrint time.Time
+
+	return func(event dockercli.BuildEvent) {
+		if event.Type != dockercli.BuildEventProgress || event.Line != "Downloading" {
+			return
+		}
+		layers[event.Layer] = layerProgress{current: event.Current, total: event.Total}
+		if time.Since(lastPrint) < time.Second {
+			return
+		}
+		lastPrint = time.Now()
+
+		var current, total int64
+		for _, layer := range layers {
+			current += layer.current
+			total += layer.total
+		}
+		fmt.Fprintf(w, "Downloading %s: %.1f / %.1f MB (%d layers)\n", event.ImageTag, float64(current)/1e6, float64(total)/1e6, len(layers))
+	}
+}
+
+// createInspectImageCommand prints the metadata of Docker images and optionally writes a manifest per image.
+func createInspectImageCommand() *cobra.Command {
+	var manifestDir string
+
+	cmd := &cobra.Command{
+		Use:         "inspect [images...]",
+		Annotations: map[string]string{noKasmAPIAnnotation: ""},
+		Short:       "Show the labels, environment, entrypoint, ports, size and layers of images",
+		Long: `This command inspects images on the Docker host and prints their metadata, including the labels carrying
+build metadata. With --manifest-dir, a JSON manifest per image is written to the directory for audits.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			images := make([]*dockercli.ImageInspect, 0, len(args))
+			for _, imageTag := range args {
+				inspect, err := dc.InspectImage(ctx, imageTag)
+				HandleError(err)
+				images = append(images, inspect)
+				if manifestDir != "" {
+					_, err := dockercli.WriteImageManifest(inspect, manifestDir)
+					HandleError(err)
+				}
+			}
+
+			t := table{headers: []string{"IMAGE", "ID", "SIZE", "LAYERS", "PORTS", "LABELS"}}
+			for _, image := range images {
+				t.rows = append(t.rows, []string{
+					image.Image,
+					image.ID,
+					strconv.FormatInt(image.Size, 10),
+					strconv.Itoa(len(image.Layers)),
+					strings.Join(image.ExposedPorts, ","),
+					strconv.Itoa(len(image.Labels)),
+				})
+			}
+			HandleError(printOutput(cmd, images, t))
+		},
+	}
+
+	cmd.Flags().StringVar(&manifestDir, "manifest-dir", "", "Directory receiving a JSON manifest per image")
+
+	return cmd
+}
+
+// createTagImageCommand tags an image with a new reference or for a registry before pushing it.
+func createTagImageCommand() *cobra.Command {
+	var registry string
+
+	cmd := &cobra.Command{
+		Use:         "tag [image] [target]",
+		Annotations: map[string]string{noKasmAPIAnnotation: ""},
+		Short:       "Tag an image, e.g. for pushing it to a private registry",
+		Long: `This command tags an image on the Docker host with the target reference. With --registry instead of a
+target, the image is tagged for the registry by prefixing (or replacing) its registry host, e.g.
+"kasm/core:1.16.0" becomes "registry.internal:5000/kasm/core:1.16.0".`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if (len(args) == 2) == (registry != "") {
+				HandleError(fmt.Errorf("pass either a target reference or --registry"))
+			}
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			target := ""
+			if registry != "" {
+				target, err = dc.RetagForRegistry(ctx, args[0], registry)
+				HandleError(err)
+			} else {
+				target = args[1]
+				HandleError(dc.TagImage(ctx, args[0], target))
+			}
+
+			fmt.Printf("Tagged %s as %s\n", args[0], target)
+		},
+	}
+
+	cmd.Flags().StringVar(&registry, "registry", "", "Registry host, optionally with a namespace, to tag the image for")
+
+	return cmd
+}
+
+// createPushImageCommand pushes an image to its registry, logging in with the given credentials first.
+func createPushImageCommand() *cobra.Command {
+	var (
+		registryUser  string
+		passwordStdin bool
+		workspaceFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:         "push [image]",
+		Annotations: map[string]string{noKasmAPIAnnotation: ""},
+		Short:       "Push an image to its registry",
+		Long: `This command pushes an image from the Docker host to the registry in its reference.
+Credentials are taken from --registry-user with the password read from stdin (--password-stdin), or from the
+docker_user and docker_token of a workspace image definition (--workspace-file). Without credentials the push
+relies on an existing docker login.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var auth *dockercli.RegistryAuth
+			if workspaceFile != "" {
+				data, err := os.ReadFile(workspaceFile)
+				HandleError(err)
+				var target webApi.TargetImage
+				HandleError(yaml.Unmarshal(data, &target))
+				if credentials, ok := dockercli.RegistryAuthFromTargetImage(target); ok {
+					auth = &credentials
+				}
+			}
+			if registryUser != "" {
+				credentials := dockercli.RegistryAuth{Username: registryUser}
+				if passwordStdin {
+					password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+					if err != nil && password == "" {
+						HandleError(fmt.Errorf("failed to read registry password from stdin: %w", err))
+					}
+					credentials.Password = strings.TrimRight(password, "\r\n")
+				}
+				auth = &credentials
+			}
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			HandleError(dc.PushImage(ctx, args[0], auth))
+
+			fmt.Printf("Pushed %s\n", args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&registryUser, "registry-user", "", "Registry user to log in with")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the registry password from stdin")
+	cmd.Flags().StringVar(&workspaceFile, "workspace-file", "", "Workspace image YAML whose docker_user and docker_token are used")
+
+	return cmd
+}
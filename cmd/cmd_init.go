@@ -17,18 +17,28 @@ func init() {
 		Long:  `Use this command to run various procedures such as initializing folders and populating Docker Compose files.`,
 	}
 
+	initCmd.PersistentFlags().String("overwrite", string(procedures.OverwriteSkipExisting),
+		"What to do with existing files: skip, overwrite or backup")
+
 	// Add subcommands for procedures functionalities
 	initCmd.AddCommand(
 		createInitFolderStructureCommand(),
 		createInitTemplatesFolderCommand(),
 		createInitDockerfilesFolderCommand(),
 		createInitAllTemplatesCommand(),
+		createListTemplatesCommand(),
 	)
 
 	// Add "procedures" to the root command
 	RootCmd.AddCommand(initCmd)
 }
 
+// overwritePolicy returns the policy selected with the --overwrite flag of the init command.
+func overwritePolicy(cmd *cobra.Command) (procedures.OverwritePolicy, error) {
+	name, _ := cmd.Flags().GetString("overwrite")
+	return procedures.ParseOverwritePolicy(name)
+}
+
 // createInitTemplatesFolderCommand initializes the templates folder with embedded templates.
 func createInitTemplatesFolderCommand() *cobra.Command {
 	return &cobra.Command{
@@ -39,7 +49,10 @@ func createInitTemplatesFolderCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			folderPath := args[0]
 
-			err := procedures.InitTemplatesFolder(folderPath)
+			policy, err := overwritePolicy(cmd)
+			HandleError(err)
+
+			err = procedures.InitTemplatesFolder(folderPath, policy)
 			if err != nil {
 				HandleError(err)
 				return
@@ -52,15 +65,29 @@ func createInitTemplatesFolderCommand() *cobra.Command {
 
 // createInitDockerfilesFolderCommand initializes the Dockerfiles folder with embedded Dockerfile templates.
 func createInitDockerfilesFolderCommand() *cobra.Command {
-	return &cobra.Command{
+	var values map[string]string
+
+	cmd := &cobra.Command{
 		Use:   "dockerfiles-templates [folderPath]",
 		Short: "Initialize the Dockerfiles folder with embedded Dockerfile templates",
-		Long:  `This command initializes the Dockerfiles folder by copying embedded Dockerfile templates into the specified folder path.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `This command initializes the Dockerfiles folder by copying embedded Dockerfile templates into the specified folder path.
+Files ending in .tmpl are rendered with the values given by --set (e.g. --set BaseImage=opensuse/leap:15.6).`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			folderPath := args[0]
 
-			err := procedures.InitDockerfilesFolder(folderPath)
+			policy, err := overwritePolicy(cmd)
+			HandleError(err)
+
+			if len(values) > 0 {
+				data := make(map[string]interface{}, len(values))
+				for key, value := range values {
+					data[key] = value
+				}
+				err = procedures.InitDockerfilesFolderWithData(folderPath, data, policy)
+			} else {
+				err = procedures.InitDockerfilesFolder(folderPath, policy)
+			}
 			if err != nil {
 				HandleError(err)
 				return
@@ -69,6 +96,10 @@ func createInitDockerfilesFolderCommand() *cobra.Command {
 			log.Info().Msg("Dockerfiles folder initialized successfully")
 		},
 	}
+
+	cmd.Flags().StringToStringVar(&values, "set", nil, "Template values as key=value pairs")
+
+	return cmd
 }
 
 // createInitFolderStructureCommand initializes a folder structure with 'services' and 'dockerfiles' subdirectories.
@@ -112,8 +143,11 @@ copying embedded templates for each into the specified folder path.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			folderPath := args[0]
 
+			policy, err := overwritePolicy(cmd)
+			HandleError(err)
+
 			// Initialize the service templates folder
-			err := procedures.InitTemplatesFolder(filepath.Join(folderPath))
+			err = procedures.InitTemplatesFolder(filepath.Join(folderPath), policy)
 			if err != nil {
 				HandleError(fmt.Errorf("failed to initialize service templates: %v", err))
 				return
@@ -121,7 +155,7 @@ copying embedded templates for each into the specified folder path.`,
 			log.Info().Msg("Service templates folder initialized successfully")
 
 			// Initialize the Dockerfiles folder
-			err = procedures.InitDockerfilesFolder(filepath.Join(folderPath))
+			err = procedures.InitDockerfilesFolder(filepath.Join(folderPath), policy)
 			if err != nil {
 				HandleError(fmt.Errorf("failed to initialize Dockerfiles: %v", err))
 				return
@@ -131,3 +165,23 @@ copying embedded templates for each into the specified folder path.`,
 		},
 	}
 }
+
+// createListTemplatesCommand lists the service templates and Dockerfiles embedded in KasmLink.
+func createListTemplatesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list-templates",
+		Short: "List the embedded service templates and Dockerfiles",
+		Long: `This command lists the service templates and Dockerfiles that ship with KasmLink, with the description
+from the leading comment of each file, without extracting them.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			templates := procedures.DescribeEmbeddedTemplates()
+
+			t := table{headers: []string{"KIND", "NAME", "DESCRIPTION"}}
+			for _, template := range templates {
+				t.rows = append(t.rows, []string{template.Kind, template.Name, template.Description})
+			}
+			HandleError(printOutput(cmd, templates, t))
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
+)
+
+// Init initializes the network command.
+func init() {
+	// Define "network" command
+	networkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "Manage Docker networks",
+		Long:  `Commands to manage Docker networks on the Docker host configured through the environment (DOCKER_HOST).`,
+	}
+
+	// Add subcommands for network management
+	networkCmd.AddCommand(createNetworkSubCommand())
+
+	// Add "network" to the root command
+	RootCmd.AddCommand(networkCmd)
+}
+
+// createNetworkSubCommand creates a Docker network with optional IPAM settings.
+func createNetworkSubCommand() *cobra.Command {
+	var opts dockercli.NetworkOptions
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a Docker network",
+		Long: `This command creates a Docker network. Use --subnet, --gateway and --ip-range to pin the address space
+so container IPs are predictable, e.g. for the Kasm backend network.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Name = args[0]
+			HandleError(opts.Validate())
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			networkID, err := dc.CreateDockerNetwork(ctx, opts)
+			HandleError(err)
+			fmt.Printf("Network %s created: %s\n", opts.Name, networkID)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Driver, "driver", "bridge", "Network driver")
+	cmd.Flags().StringVar(&opts.Subnet, "subnet", "", "Subnet in CIDR notation (e.g. 172.28.0.0/16)")
+	cmd.Flags().StringVar(&opts.Gateway, "gateway", "", "Gateway address inside the subnet")
+	cmd.Flags().StringVar(&opts.IPRange, "ip-range", "", "Allocate container IPs from this CIDR range inside the subnet")
+	cmd.Flags().BoolVar(&opts.Internal, "internal", false, "Restrict external access to the network")
+	cmd.Flags().BoolVar(&opts.Attachable, "attachable", false, "Allow standalone containers to attach to the network")
+	cmd.Flags().StringToStringVar(&opts.Labels, "label", nil, "Network labels (key=value)")
+
+	return cmd
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
 	"kasmlink/pkg/procedures"
+	"kasmlink/pkg/userParser"
 	"os"
 )
 
@@ -16,7 +18,9 @@ var buildCoreImageCmd = &cobra.Command{
 		imageTag := args[0]
 		baseImage := args[1]
 
-		err := procedures.BuildCoreImageKasm(imageTag, baseImage)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		err := procedures.BuildCoreImageKasm(ctx, imageTag, baseImage, buildSettings(cmd))
 		if err != nil {
 			fmt.Printf("Error building Docker image: %v\n", err)
 			os.Exit(1)
@@ -42,8 +46,38 @@ var deployImageCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		spaceSafetyFactor, err := cmd.Flags().GetFloat64("space-safety-factor")
+		if err != nil {
+			fmt.Printf("Error reading space-safety-factor flag: %v\n", err)
+			os.Exit(1)
+		}
+
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			fmt.Printf("Error reading force flag: %v\n", err)
+			os.Exit(1)
+		}
+
+		tarCacheDir, err := cmd.Flags().GetString("tar-cache-dir")
+		if err != nil {
+			fmt.Printf("Error reading tar-cache-dir flag: %v\n", err)
+			os.Exit(1)
+		}
+
+		sshConfig, err := loadSSHConfig(cmd)
+		HandleError(err)
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
 		// Call the deploy function with the optional localTarFilePath
-		err = procedures.DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath)
+		err = procedures.DeployKasmDockerImage(ctx, imageTag, baseImage, targetNodePath, localTarFilePath, procedures.ImageDeployOptions{
+			SpaceSafetyFactor: spaceSafetyFactor,
+			Force:             force,
+			TarCacheDir:       tarCacheDir,
+			Build:             buildSettings(cmd),
+			SSH:               sshConfig,
+		})
 		if err != nil {
 			fmt.Printf("Error deploying Docker image: %v\n", err)
 			os.Exit(1)
@@ -55,6 +89,24 @@ var deployImageCmd = &cobra.Command{
 func init() {
 	// Register the local-tar-file flag for optional local file path
 	deployImageCmd.Flags().String("local-tar-file", "", "Optional path to a local tar file to use instead of building a new image")
+	deployImageCmd.Flags().Float64("space-safety-factor", procedures.DefaultSpaceSafetyFactor, "Multiple of the tar size that must be free on the remote node before uploading")
+	deployImageCmd.Flags().Bool("force", false, "Transfer the image even if the remote node already has it")
+	deployImageCmd.Flags().String("tar-cache-dir", procedures.DefaultTarCacheDir, "Local directory caching exported image tars")
+
+	for _, cmd := range []*cobra.Command{buildCoreImageCmd, deployImageCmd} {
+		cmd.Flags().String("builder", string(dockercli.BuilderBuildKit), "Image builder: buildkit or classic (classic ignores RUN --mount)")
+		cmd.Flags().String("progress", "", "BuildKit progress output: auto, plain or tty (default plain)")
+	}
+}
+
+// buildSettings returns the builder and progress format selected with --builder and --progress.
+func buildSettings(cmd *cobra.Command) dockercli.BuildSettings {
+	builder, _ := cmd.Flags().GetString("builder")
+	progress, _ := cmd.Flags().GetString("progress")
+	return dockercli.BuildSettings{
+		Builder:  dockercli.Builder(builder),
+		Progress: dockercli.BuildProgress(progress),
+	}
 }
 
 // Command to deploy a Docker Compose file to a remote node.
@@ -66,7 +118,27 @@ var deployComposeCmd = &cobra.Command{
 		composeFilePath := args[0]
 		targetNodePath := args[1]
 
-		err := procedures.DeployComposeFile(composeFilePath, targetNodePath)
+		projectName, _ := cmd.Flags().GetString("project-name")
+		envFile, _ := cmd.Flags().GetString("env-file")
+		profiles, _ := cmd.Flags().GetStringSlice("profile")
+		skipStatusCheck, _ := cmd.Flags().GetBool("skip-status-check")
+		rollback, _ := cmd.Flags().GetBool("rollback")
+		serviceEnv, err := composeServiceEnv(cmd)
+		HandleError(err)
+		sshConfig, err := loadSSHConfig(cmd)
+		HandleError(err)
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		err = procedures.DeployComposeFile(ctx, composeFilePath, targetNodePath, procedures.ComposeDeployOptions{
+			ProjectName:     projectName,
+			EnvFilePath:     envFile,
+			ServiceEnv:      serviceEnv,
+			Profiles:        profiles,
+			SkipStatusCheck: skipStatusCheck,
+			Rollback:        rollback,
+			SSH:             sshConfig,
+		})
 		if err != nil {
 			fmt.Printf("Error deploying Docker Compose file: %v\n", err)
 			os.Exit(1)
@@ -75,9 +147,70 @@ var deployComposeCmd = &cobra.Command{
 	},
 }
 
+// Command to remove Docker Compose services deployed with deploy-compose.
+var teardownComposeCmd = &cobra.Command{
+	Use:   "teardown-compose [composeFilePath] [targetNodePath]",
+	Short: "Stop and remove Docker Compose services on a remote node",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		composeFilePath := args[0]
+		targetNodePath := args[1]
+		projectName, _ := cmd.Flags().GetString("project-name")
+		envFile, _ := cmd.Flags().GetString("env-file")
+		removeVolumes, _ := cmd.Flags().GetBool("volumes")
+		serviceEnv, err := composeServiceEnv(cmd)
+		HandleError(err)
+		sshConfig, err := loadSSHConfig(cmd)
+		HandleError(err)
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		err = procedures.TeardownComposeFile(ctx, composeFilePath, targetNodePath, procedures.ComposeDeployOptions{
+			ProjectName: projectName,
+			EnvFilePath: envFile,
+			ServiceEnv:  serviceEnv,
+			SSH:         sshConfig,
+		}, removeVolumes)
+		if err != nil {
+			fmt.Printf("Error removing Docker Compose services: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Docker Compose services removed successfully from remote node")
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{deployComposeCmd, teardownComposeCmd} {
+		cmd.Flags().String("project-name", "", "Compose project name (-p), needed to run several stacks on one node")
+		cmd.Flags().String("env-file", "", "Optional local env file copied next to the compose file and passed via --env-file")
+		cmd.Flags().String("deployment-config", "", "Deployment YAML whose service_env section is written to a .env.<service> file per service")
+		cmd.Flags().String("overlay", "", "Environment overlay YAML merged onto the deployment configuration")
+	}
+	deployComposeCmd.Flags().StringSlice("profile", nil, "Compose profile to enable (repeatable); services in other profiles are not started")
+	deployComposeCmd.Flags().Bool("skip-status-check", false, "Do not check with docker compose ps that the services are running after starting them")
+	deployComposeCmd.Flags().Bool("rollback", false, "Restore and restart the previously deployed compose file if the deployment fails")
+	teardownComposeCmd.Flags().Bool("volumes", false, "Also remove the named volumes of the project")
+}
+
+// composeServiceEnv returns the service_env section of the deployment configuration selected with
+// --deployment-config and --overlay, or nil without a configuration.
+func composeServiceEnv(cmd *cobra.Command) (map[string]map[string]string, error) {
+	path, _ := cmd.Flags().GetString("deployment-config")
+	if path == "" {
+		return nil, nil
+	}
+	overlay, _ := cmd.Flags().GetString("overlay")
+	config, err := userParser.LoadDeploymentConfigWithOverlay(path, overlay)
+	if err != nil {
+		return nil, err
+	}
+	return config.ServiceEnv, nil
+}
+
 // Initialize and add all commands to root.
 func init() {
 	RootCmd.AddCommand(buildCoreImageCmd)
 	RootCmd.AddCommand(deployImageCmd)
 	RootCmd.AddCommand(deployComposeCmd)
+	RootCmd.AddCommand(teardownComposeCmd)
 }
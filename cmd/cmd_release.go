@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"kasmlink/pkg/procedures"
+
+	"github.com/spf13/cobra"
+)
+
+// Init initializes the release command.
+func init() {
+	RootCmd.AddCommand(createReleaseCommand())
+}
+
+// createReleaseCommand releases an application image and starts sessions of it for a group.
+func createReleaseCommand() *cobra.Command {
+	var spec procedures.ReleaseSpec
+
+	cmd := &cobra.Command{
+		Use:   "release [imageTag] [groupName]",
+		Short: "Release an application image and start sessions for a group",
+		Long: `This command builds and transfers the application image to the node, creates the network and deploys
+the backend compose file, then starts a session of the Kasm workspace image using imageTag for every member of
+groupName. Steps without their flags (--target-node-path, --network, --compose-file) are skipped. The node is
+reached through the --ssh-config file and the SSH_* environment variables; Kasm connection settings are read from
+flags or the KASM_URL, KASM_API_KEY and KASM_API_SECRET environment variables.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec.ImageTag = args[0]
+			spec.GroupName = args[1]
+
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+			if spec.TargetNodePath != "" || spec.Network.Name != "" || spec.ComposeFilePath != "" {
+				spec.SSH, err = loadSSHConfig(cmd)
+				HandleError(err)
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			result, err := procedures.ReleaseApplication(ctx, kApi, spec)
+			HandleError(err)
+
+			rows := make([][]string, 0, len(result.Sessions))
+			for _, session := range result.Sessions {
+				rows = append(rows, []string{session.Username, session.UserID, session.KasmID, fmt.Sprintf("%t", session.Reused)})
+			}
+			HandleError(printOutput(cmd, result, table{
+				headers: []string{"USERNAME", "USER_ID", "KASM_ID", "REUSED"},
+				rows:    rows,
+			}))
+		},
+	}
+	addKasmAPIFlags(cmd)
+
+	cmd.Flags().StringVar(&spec.BaseImage, "base-image", "", "Base image used to build the application image")
+	cmd.Flags().StringVar(&spec.TargetNodePath, "target-node-path", "", "Directory on the node receiving the image tar and compose file")
+	cmd.Flags().StringVar(&spec.LocalTarFilePath, "local-tar-file", "", "Prebuilt image tar to transfer instead of building the image")
+	cmd.Flags().Float64Var(&spec.ImageOptions.SpaceSafetyFactor, "space-safety-factor", procedures.DefaultSpaceSafetyFactor, "Multiple of the tar size that must be free on the node")
+	cmd.Flags().BoolVar(&spec.ImageOptions.Force, "force", false, "Transfer the image even if the node already has it")
+	cmd.Flags().StringVar(&spec.ImageOptions.TarCacheDir, "tar-cache-dir", procedures.DefaultTarCacheDir, "Local directory caching exported image tars")
+	cmd.Flags().StringVar(&spec.Network.Name, "network", "", "Network to create on the node for the release")
+	cmd.Flags().StringVar(&spec.Network.Subnet, "network-subnet", "", "Subnet of the release network in CIDR notation")
+	cmd.Flags().StringVar(&spec.ComposeFilePath, "compose-file", "", "Compose file of the Kasm backend to deploy")
+	cmd.Flags().StringVar(&spec.ComposeOptions.ProjectName, "project-name", "", "Compose project name of the backend")
+	cmd.Flags().StringVar(&spec.ComposeOptions.EnvFilePath, "env-file", "", "Env file of the backend compose file")
+	cmd.Flags().StringSliceVar(&spec.Usernames, "user", nil, "Username to add to the group before starting sessions (repeatable)")
+	cmd.Flags().StringToStringVar(&spec.EnvArgs, "env", nil, "Environment variables of new sessions (key=value)")
+
+	return cmd
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"kasmlink/pkg/webApi"
+	"time"
+)
+
+// Init initializes the session command.
+func init() {
+	// Define "session" command
+	sessionCmd := &cobra.Command{
+		Use:   "session",
+		Short: "Manage Kasm sessions",
+		Long:  `Commands to inspect and destroy Kasm sessions through the Kasm API. Connection settings are read from flags or the KASM_URL, KASM_API_KEY and KASM_API_SECRET environment variables.`,
+	}
+	addKasmAPIFlags(sessionCmd)
+
+	// Add subcommands for session management
+	sessionCmd.AddCommand(createListSessionsCommand())
+	sessionCmd.AddCommand(createDestroySessionsCommand())
+
+	// Add "session" to the root command
+	RootCmd.AddCommand(sessionCmd)
+}
+
+// createListSessionsCommand lists the running Kasm sessions.
+func createListSessionsCommand() *cobra.Command {
+	var userID string
+	var stale bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Kasm sessions",
+		Long: `This command lists all Kasm sessions with their user, image, status and expiration date.
+Use --user_id to only show the sessions of one user and --stale to only show sessions past their expiration date.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			kasms, err := kApi.GetKasms(ctx)
+			HandleError(err)
+
+			now := time.Now()
+			sessions := make([]webApi.KasmInfo, 0, len(kasms))
+			for _, kasm := range kasms {
+				if userID != "" && kasm.UserID != userID {
+					continue
+				}
+				if stale && !kasm.IsExpired(now) {
+					continue
+				}
+				sessions = append(sessions, kasm)
+			}
+
+			t := table{headers: []string{"KASM ID", "USER", "IMAGE", "STATUS", "EXPIRES"}}
+			for _, kasm := range sessions {
+				user := kasm.UserID
+				if kasm.User != nil && kasm.User.Username != "" {
+					user = kasm.User.Username
+				}
+				image := kasm.ImageID
+				if kasm.Image != nil && kasm.Image.FriendlyName != "" {
+					image = kasm.Image.FriendlyName
+				}
+				t.rows = append(t.rows, []string{kasm.KasmID, user, image, kasm.OperationalStatus, kasm.ExpirationDate})
+			}
+			HandleError(printOutput(cmd, sessions, t))
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user_id", "", "Only list sessions of this user ID")
+	cmd.Flags().BoolVar(&stale, "stale", false, "Only list sessions past their expiration date")
+
+	return cmd
+}
+
+// createDestroySessionsCommand destroys all sessions of a user or of a workspace image.
+func createDestroySessionsCommand() *cobra.Command {
+	var userID string
+	var imageID string
+
+	cmd := &cobra.Command{
+		Use:   "destroy",
+		Short: "Destroy all sessions of a user or image",
+		Long:  `This command destroys every running session of the user given with --user_id or of the image given with --image_id.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if (userID == "") == (imageID == "") {
+				HandleError(fmt.Errorf("exactly one of --user_id and --image_id is required"))
+			}
+
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			var destroyed int
+			if userID != "" {
+				destroyed, err = kApi.DestroyUserSessions(ctx, userID)
+			} else {
+				destroyed, err = kApi.DestroySessionsByImage(ctx, imageID)
+			}
+			fmt.Printf("%d session(s) destroyed\n", destroyed)
+			HandleError(err)
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user_id", "", "Destroy the sessions of this user ID")
+	cmd.Flags().StringVar(&imageID, "image_id", "", "Destroy the sessions of this image ID")
+
+	return cmd
+}
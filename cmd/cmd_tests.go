@@ -32,7 +32,9 @@ func init() {
 }
 
 func createTestEnv() *cobra.Command {
-	return &cobra.Command{
+	var workers int
+
+	cmd := &cobra.Command{
 		Use:  "api",
 		Args: cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -85,10 +87,14 @@ func createTestEnv() *cobra.Command {
 			kApi := webApi.NewKasmAPI("https://192.168.120.5", "C6QmU5ohTUIE", "91MRn9E7FyBSPJ5HtexWrubIG3SYLkB5", true, 50*time.Second)
 
 			ctx, _ := context.WithTimeout(context.Background(), 10000*time.Second)
-			err = procedures.CreateTestEnvironment(ctx, tempFile.Name(), sshConfig, kApi)
+			err = procedures.CreateTestEnvironment(ctx, tempFile.Name(), sshConfig, kApi, procedures.TestEnvironmentOptions{Workers: workers})
 			if err != nil {
 				return
 			}
 		},
 	}
+
+	cmd.Flags().IntVar(&workers, "workers", procedures.DefaultProvisioningWorkers, "Number of users to provision concurrently")
+
+	return cmd
 }
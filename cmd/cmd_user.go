@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Init initializes the user command.
+func init() {
+	// Define "user" command
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage Kasm users",
+		Long:  `Commands to manage users through the Kasm API. Connection settings are read from flags or the KASM_URL, KASM_API_KEY and KASM_API_SECRET environment variables.`,
+	}
+	addKasmAPIFlags(userCmd)
+
+	// Add subcommands for user management
+	userCmd.AddCommand(createListUsersCommand())
+	userCmd.AddCommand(createGetUserCommand())
+	userCmd.AddCommand(createDeleteUserCommand())
+	userCmd.AddCommand(createRotatePasswordCommand())
+
+	// Add "user" to the root command
+	RootCmd.AddCommand(userCmd)
+}
+
+// createRotatePasswordCommand resets a user's password to a newly generated one.
+func createRotatePasswordCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-password [userID]",
+		Short: "Reset a user's password to a generated one",
+		Long: `This command replaces the password of the given user with a newly generated strong password
+and prints it once to stdout. Use it to force-reset a compromised account.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			password, err := kApi.RotateUserPassword(ctx, args[0])
+			HandleError(err)
+
+			fmt.Println(password)
+		},
+	}
+}
+
+// createListUsersCommand lists all users.
+func createListUsersCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all users",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			users, err := kApi.GetUsers(ctx)
+			HandleError(err)
+
+			t := table{headers: []string{"USER ID", "USERNAME", "NAME", "GROUPS", "SESSIONS", "DISABLED", "LOCKED"}}
+			for _, user := range users {
+				groups := make([]string, 0, len(user.Groups))
+				for _, group := range user.Groups {
+					groups = append(groups, group.Name)
+				}
+				t.rows = append(t.rows, []string{
+					user.UserID,
+					user.Username,
+					strings.TrimSpace(user.FirstName + " " + user.LastName),
+					strings.Join(groups, ","),
+					strconv.Itoa(len(user.Kasms)),
+					strconv.FormatBool(user.Disabled),
+					strconv.FormatBool(user.Locked),
+				})
+			}
+			HandleError(printOutput(cmd, users, t))
+		},
+	}
+}
+
+// createGetUserCommand prints a single user with its groups and active sessions.
+func createGetUserCommand() *cobra.Command {
+	var userID, username string
+
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show a user with its groups and active sessions",
+		Long:  `This command fetches a single user by --user_id or --username and prints its details, groups and active sessions. Use -o json or -o yaml for the full record.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if userID == "" && username == "" {
+				HandleError(fmt.Errorf("either --user_id or --username is required"))
+			}
+
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			user, err := kApi.GetUser(ctx, userID, username)
+			HandleError(err)
+
+			groups := make([]string, 0, len(user.Groups))
+			for _, group := range user.Groups {
+				groups = append(groups, group.Name)
+			}
+			sessions := make([]string, 0, len(user.Kasms))
+			for _, kasm := range user.Kasms {
+				sessions = append(sessions, kasm.KasmID)
+			}
+			t := table{headers: []string{"USER ID", "USERNAME", "NAME", "GROUPS", "SESSIONS", "DISABLED", "LOCKED", "CREATED"}}
+			t.rows = append(t.rows, []string{
+				user.UserID,
+				user.Username,
+				strings.TrimSpace(user.FirstName + " " + user.LastName),
+				strings.Join(groups, ","),
+				strings.Join(sessions, ","),
+				strconv.FormatBool(user.Disabled),
+				strconv.FormatBool(user.Locked),
+				user.Created,
+			})
+			HandleError(printOutput(cmd, user, t))
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user_id", "", "ID of the user")
+	cmd.Flags().StringVar(&username, "username", "", "Username of the user")
+
+	return cmd
+}
+
+// createDeleteUserCommand deletes a user, asking for confirmation unless --force is set.
+func createDeleteUserCommand() *cobra.Command {
+	var userID string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a user",
+		Long: `This command deletes the user given by --user_id.
+Without --force the deletion must be confirmed interactively and fails if the user still has sessions.
+With --force no confirmation is asked and the user is deleted together with its sessions.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			kApi, err := newKasmAPI(cmd)
+			HandleError(err)
+
+			if !force && !confirm(fmt.Sprintf("Delete user %s?", userID)) {
+				fmt.Println("Aborted")
+				return
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			HandleError(kApi.DeleteUser(ctx, userID, force))
+			fmt.Printf("User %s deleted\n", userID)
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user_id", "", "ID of the user to delete")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete without confirmation, including the user's sessions")
+	_ = cmd.MarkFlagRequired("user_id")
+
+	return cmd
+}
+
+// confirm asks a yes/no question on stdin and reports whether it was answered with yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
+	"strings"
+)
+
+// Init initializes the volume command.
+func init() {
+	// Define "volume" command
+	volumeCmd := &cobra.Command{
+		Use:   "volume",
+		Short: "Manage Docker volumes",
+		Long:  `Commands to manage Docker volumes on the Docker host configured through the environment (DOCKER_HOST).`,
+	}
+
+	// Add subcommands for volume management
+	volumeCmd.AddCommand(createVolumeSubCommand())
+
+	// Add "volume" to the root command
+	RootCmd.AddCommand(volumeCmd)
+}
+
+// createVolumeSubCommand creates a Docker volume with optional driver options and labels.
+func createVolumeSubCommand() *cobra.Command {
+	var (
+		opts       dockercli.VolumeOptions
+		driverOpts []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a Docker volume",
+		Long: `This command creates a Docker volume. NFS-backed volumes can be created with
+--opt type=nfs --opt o=addr=10.0.0.5,rw --opt device=:/exports/data`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Name = args[0]
+
+			// Driver options are parsed manually because values such as "addr=10.0.0.5,rw" contain commas.
+			opts.DriverOpts = make(map[string]string, len(driverOpts))
+			for _, opt := range driverOpts {
+				key, value, ok := strings.Cut(opt, "=")
+				if !ok || key == "" {
+					HandleError(fmt.Errorf("invalid driver option %q, expected key=value", opt))
+				}
+				opts.DriverOpts[key] = value
+			}
+
+			dc, err := newDockerClient(cmd)
+			HandleError(err)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			volumeName, err := dc.CreateVolumeWithOptions(ctx, opts)
+			HandleError(err)
+			fmt.Printf("Volume %s created\n", volumeName)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Driver, "driver", "local", "Volume driver")
+	cmd.Flags().StringArrayVar(&driverOpts, "opt", nil, "Driver option (key=value), may be repeated")
+	cmd.Flags().StringToStringVar(&opts.Labels, "label", nil, "Volume labels (key=value)")
+
+	return cmd
+}
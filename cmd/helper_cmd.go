@@ -1,7 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/procedures"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/webApi"
 	"os"
 )
 
@@ -12,3 +19,134 @@ func HandleError(err error) {
 		os.Exit(1) // Properly exit with an error code.
 	}
 }
+
+// commandContext returns the context of a command, limited by the persistent --timeout flag when it is set.
+// The returned cancel function must be called once the command is done.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// commandRetries returns the number of attempts set by the persistent --retries flag.
+func commandRetries(cmd *cobra.Command) int {
+	retries, _ := cmd.Flags().GetInt("retries")
+	return retries
+}
+
+// newDockerClient creates a DockerClient for the local Docker daemon using the environment configuration (DOCKER_HOST etc.)
+// and the --retries flag of the command.
+func newDockerClient(cmd *cobra.Command) (*dockercli.DockerClient, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("could not create Docker client: %w", err)
+	}
+	return dockercli.NewDockerClient(cli, commandRetries(cmd), 0, 0, 0, 0), nil
+}
+
+// loadSSHConfig loads the SSH configuration of the target node from the file of the persistent --ssh-config
+// flag and the SSH_* environment variables.
+func loadSSHConfig(cmd *cobra.Command) (*shadowssh.SSHConfig, error) {
+	configFile, _ := cmd.Flags().GetString("ssh-config")
+	return procedures.LoadSSHConfig(procedures.SSHConfigOptions{ConfigFile: configFile})
+}
+
+// noKasmAPIAnnotation marks subcommands of a Kasm API command group that do not talk to the Kasm API.
+const noKasmAPIAnnotation = "kasmlink/no-kasm-api"
+
+// kasmAPIContextKey stores the KasmAPI verified in PersistentPreRunE in the command context.
+type kasmAPIContextKey struct{}
+
+// addKasmAPIFlags registers the persistent flags used to connect to the Kasm API on a command group.
+// Unset flags fall back to the KASM_URL, KASM_BASE_PATH, KASM_API_KEY and KASM_API_SECRET environment variables.
+// The group's PersistentPreRunE verifies the connection and credentials once before any subcommand runs,
+// unless --skip-ping is set or the subcommand is annotated with noKasmAPIAnnotation.
+func addKasmAPIFlags(cmd *cobra.Command) {
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if _, ok := cmd.Annotations[noKasmAPIAnnotation]; ok {
+			return nil
+		}
+		if skip, _ := cmd.Flags().GetBool("skip-ping"); skip {
+			return nil
+		}
+
+		// Flags and arguments are valid at this point; connection errors should not print the usage.
+		cmd.SilenceUsage = true
+
+		kApi, err := buildKasmAPI(cmd)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		if err := kApi.Ping(ctx); err != nil {
+			return err
+		}
+		cmd.SetContext(context.WithValue(cmd.Context(), kasmAPIContextKey{}, kApi))
+		return nil
+	}
+
+	cmd.PersistentFlags().Bool("skip-ping", false, "Do not verify the Kasm API credentials before running the command")
+	cmd.PersistentFlags().String("kasm-url", "", "Base URL of the Kasm API (env KASM_URL)")
+	cmd.PersistentFlags().String("base-path", "", "Path prefix of the Kasm API behind a reverse proxy, e.g. /kasm (env KASM_BASE_PATH)")
+	cmd.PersistentFlags().String("api-key", "", "Kasm API key (env KASM_API_KEY)")
+	cmd.PersistentFlags().String("api-secret", "", "Kasm API key secret (env KASM_API_SECRET)")
+	cmd.PersistentFlags().Bool("insecure", false, "Skip TLS certificate verification")
+	cmd.PersistentFlags().String("ca-cert", "", "PEM bundle of additional CAs trusted for the Kasm API")
+	cmd.PersistentFlags().String("client-cert", "", "PEM client certificate for mutual TLS")
+	cmd.PersistentFlags().String("client-key", "", "PEM private key of the client certificate")
+	cmd.PersistentFlags().Bool("lowercase-usernames", false, "Lowercase usernames when creating and looking up users (env KASM_LOWERCASE_USERNAMES=true)")
+	cmd.PersistentFlags().String("auth-mode", "", "Send the API credentials in the request \"body\" (default) or only in the Authorization \"header\" (env KASM_AUTH_MODE)")
+}
+
+// newKasmAPI returns the KasmAPI verified by PersistentPreRunE or creates one from the flags registered by addKasmAPIFlags.
+func newKasmAPI(cmd *cobra.Command) (*webApi.KasmAPI, error) {
+	if cmd.Context() != nil {
+		if kApi, ok := cmd.Context().Value(kasmAPIContextKey{}).(*webApi.KasmAPI); ok {
+			return kApi, nil
+		}
+	}
+	return buildKasmAPI(cmd)
+}
+
+// buildKasmAPI creates a KasmAPI from the flags registered by addKasmAPIFlags.
+func buildKasmAPI(cmd *cobra.Command) (*webApi.KasmAPI, error) {
+	value := func(flag, env string) string {
+		if v, _ := cmd.Flags().GetString(flag); v != "" {
+			return v
+		}
+		return os.Getenv(env)
+	}
+
+	baseURL := value("kasm-url", "KASM_URL")
+	apiKey := value("api-key", "KASM_API_KEY")
+	apiSecret := value("api-secret", "KASM_API_SECRET")
+	if baseURL == "" || apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("kasm url, api key and api secret are required (flags --kasm-url, --api-key, --api-secret or env KASM_URL, KASM_API_KEY, KASM_API_SECRET)")
+	}
+
+	tlsOptions := webApi.TLSOptions{}
+	tlsOptions.SkipVerification, _ = cmd.Flags().GetBool("insecure")
+	tlsOptions.CAFile, _ = cmd.Flags().GetString("ca-cert")
+	tlsOptions.ClientCertFile, _ = cmd.Flags().GetString("client-cert")
+	tlsOptions.ClientKeyFile, _ = cmd.Flags().GetString("client-key")
+	kApi, err := webApi.NewKasmAPIWithTLS(baseURL, apiKey, apiSecret, tlsOptions, 0)
+	if err != nil {
+		return nil, err
+	}
+	kApi.BasePath = value("base-path", "KASM_BASE_PATH")
+	kApi.Retries = commandRetries(cmd)
+	kApi.NormalizeUsernames, _ = cmd.Flags().GetBool("lowercase-usernames")
+	if !kApi.NormalizeUsernames {
+		kApi.NormalizeUsernames = os.Getenv("KASM_LOWERCASE_USERNAMES") == "true"
+	}
+	if kApi.AuthMode, err = webApi.ParseAuthMode(value("auth-mode", "KASM_AUTH_MODE")); err != nil {
+		return nil, err
+	}
+	return kApi, nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Supported values of the global --output flag.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// table holds the tabular representation of command output.
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+// outputFormat returns the format selected with --output.
+// Without the flag, tables are printed to terminals and JSON to pipes and files.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	switch strings.ToLower(format) {
+	case "":
+		if isTerminal(os.Stdout) {
+			return outputTable, nil
+		}
+		return outputJSON, nil
+	case outputTable, outputJSON, outputYAML:
+		return strings.ToLower(format), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q, expected table, json or yaml", format)
+	}
+}
+
+// isTerminal reports whether the file is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printOutput writes data in the format selected with --output.
+// JSON and YAML render data itself using its JSON field names; the table format renders t.
+func printOutput(cmd *cobra.Command, data interface{}, t table) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case outputYAML:
+		// Round-trip through JSON so YAML keys match the API field names.
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		var generic interface{}
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return fmt.Errorf("failed to convert output: %w", err)
+		}
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(generic); err != nil {
+			return fmt.Errorf("failed to write yaml output: %w", err)
+		}
+		return encoder.Close()
+	default:
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
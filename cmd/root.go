@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -23,8 +24,12 @@ var RootCmd = &cobra.Command{
 }
 
 // Execute runs the RootCmd and handles any top-level errors.
+// Commands run with a context that is cancelled on SIGINT or SIGTERM, see ShutdownContext.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	ctx, cancel := ShutdownContext(context.Background())
+	err := RootCmd.ExecuteContext(ctx)
+	cancel()
+	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 }
@@ -33,6 +38,16 @@ func init() {
 	// Persistent flag for setting verbosity
 	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
 
+	// Persistent flag for the output format of list and get commands
+	RootCmd.PersistentFlags().StringP("output", "o", "", "Output format: table, json or yaml (default table on a terminal, json otherwise)")
+
+	// Persistent flags for the overall command timeout and the retries of Docker and Kasm API calls
+	RootCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration of the command, e.g. 90s or 10m (0 disables the limit)")
+	RootCmd.PersistentFlags().Int("retries", 3, "Number of attempts for Docker and Kasm API calls")
+
+	// Persistent flag for the SSH settings of the target node; unset keys fall back to the SSH_* environment variables
+	RootCmd.PersistentFlags().String("ssh-config", "", "YAML file with the SSH settings of the target node (host, port, username, ...)")
+
 	// Version flag to print the version
 	RootCmd.PersistentFlags().Bool("version", false, "Display the version of Kasm Link CLI")
 
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ShutdownContext returns a context that is cancelled on the first SIGINT or SIGTERM, so in-flight SSH,
+// Docker and Kasm API calls abort and deferred cleanups run. A second signal exits immediately.
+// The returned cancel function stops the signal handling and must be called once the command is done.
+func ShutdownContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			log.Warn().
+				Str("signal", sig.String()).
+				Msg("Received signal, shutting down; send it again to exit immediately")
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case sig := <-signals:
+			log.Error().
+				Str("signal", sig.String()).
+				Msg("Received second signal, exiting without cleanup")
+			os.Exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+		})
+	}
+}
+
+// signalExitCode returns the conventional exit status of a process terminated by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
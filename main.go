@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"kasmlink/cmd"
+	"kasmlink/pkg/logRedact"
 )
 
 var Version = "dev"
@@ -55,21 +56,23 @@ func main() {
 	}
 
 	// Configure zerolog with the specified settings
+	// Logs and the banner go to stderr so command output on stdout can be piped (e.g. --output json | jq).
 	zerolog.SetGlobalLevel(zerologLevel)
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+	// Every event passes the redacting writer first, so API keys and passwords never reach the console.
+	log.Logger = log.Output(logRedact.NewWriter(zerolog.ConsoleWriter{
+		Out:        os.Stderr,
 		TimeFormat: time.RFC3339,
 		NoColor:    noColor,
-	})
+	}))
 
 	// Load and print the ASCII logo
 	logo, err := LoadLogo("kasmlink.txt")
 	if err != nil {
 		log.Error().Msgf("Error loading logo: %v", err)
 	} else {
-		fmt.Printf("\n%s\n", logo)
+		fmt.Fprintf(os.Stderr, "\n%s\n", logo)
 	}
-	fmt.Printf("---\nKasm Link CLI Version: %s\n---\n", Version)
+	fmt.Fprintf(os.Stderr, "---\nKasm Link CLI Version: %s\n---\n", Version)
 
 	// Execute the main CLI command
 	cmd.Execute()
@@ -41,7 +41,7 @@ func SetupRegistry(ctx context.Context, targetSSH *sshmanager.SSHConfig, registr
 
 	// Check if image exists on the remote node
 	imageCheckCommand := "docker images --format '{{.Repository}}:{{.Tag}}'"
-	output, err := client.ExecuteCommand(ctx, imageCheckCommand)
+	output, err := client.ExecuteCommandSudo(ctx, imageCheckCommand)
 	if err != nil {
 		return fmt.Errorf("failed to check images on remote node: %w", err)
 	}
@@ -85,7 +85,7 @@ func SetupRegistry(ctx context.Context, targetSSH *sshmanager.SSHConfig, registr
 
 		log.Info().Msg("Loading tar file on remote node...")
 		loadCommand := fmt.Sprintf("docker load < %s", remoteTarPath)
-		if _, err := client.ExecuteCommand(ctx, loadCommand); err != nil {
+		if _, err := client.ExecuteCommandSudo(ctx, loadCommand); err != nil {
 			return fmt.Errorf("failed to load image on remote node: %w", err)
 		}
 	}
@@ -100,7 +100,7 @@ func SetupRegistry(ctx context.Context, targetSSH *sshmanager.SSHConfig, registr
 		registryConfig.RegistryImageToPull,
 	)
 
-	if _, err := client.ExecuteCommand(ctx, runCommand); err != nil {
+	if _, err := client.ExecuteCommandSudo(ctx, runCommand); err != nil {
 		return fmt.Errorf("failed to start registry container: %w", err)
 	}
 
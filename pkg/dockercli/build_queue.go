@@ -0,0 +1,181 @@
+package dockercli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BuildEventType is the kind of progress reported by a BuildQueue.
+type BuildEventType string
+
+const (
+	// BuildEventQueued is emitted for every build when the queue starts.
+	BuildEventQueued BuildEventType = "queued"
+	// BuildEventStarted is emitted when a worker picks up a build.
+	BuildEventStarted BuildEventType = "started"
+	// BuildEventLogLine is emitted for every line of build output.
+	BuildEventLogLine BuildEventType = "log-line"
+	// BuildEventProgress is emitted by image pulls for the download and extract progress of a layer.
+	BuildEventProgress BuildEventType = "progress"
+	// BuildEventSucceeded is emitted when a build finished without errors.
+	BuildEventSucceeded BuildEventType = "succeeded"
+	// BuildEventFailed is emitted when a build failed after its retries.
+	BuildEventFailed BuildEventType = "failed"
+)
+
+// DefaultBuildConcurrency is the number of builds a BuildQueue runs at once when no concurrency is given.
+const DefaultBuildConcurrency = 2
+
+// BuildEvent reports the progress of a single build in a BuildQueue, or of an image pull.
+type BuildEvent struct {
+	Type     BuildEventType
+	ImageTag string
+	// Line is the build output line of BuildEventLogLine events, or the layer status of BuildEventProgress
+	// events, e.g. "Downloading" or "Extracting".
+	Line string
+	// Layer is the layer ID of BuildEventProgress events.
+	Layer string
+	// Current and Total are the processed and total bytes of the layer of BuildEventProgress events.
+	Current int64
+	Total   int64
+	// Err is the failure of BuildEventFailed events.
+	Err error
+	// ImageID is the ID of the built image of BuildEventSucceeded events, if the daemon reported it.
+	ImageID string
+	// Duration is the build time of BuildEventSucceeded and BuildEventFailed events.
+	Duration time.Duration
+	Time     time.Time
+}
+
+// BuildQueue builds several images with bounded concurrency and reports their progress as BuildEvents.
+// Every build keeps the retry behaviour of BuildDockerImage.
+type BuildQueue struct {
+	dc          *DockerClient
+	concurrency int
+	events      chan BuildEvent
+}
+
+// NewBuildQueue creates a BuildQueue building with the given DockerClient.
+// Parameters:
+// - dc: DockerClient with a local Docker SDK client.
+// - concurrency: Maximum number of concurrent builds; DefaultBuildConcurrency when <= 0.
+// Returns:
+// - The BuildQueue. Its Events channel must be drained while Run is in progress.
+func NewBuildQueue(dc *DockerClient, concurrency int) *BuildQueue {
+	if concurrency <= 0 {
+		concurrency = DefaultBuildConcurrency
+	}
+	return &BuildQueue{
+		dc:          dc,
+		concurrency: concurrency,
+		events:      make(chan BuildEvent, 64),
+	}
+}
+
+// Events returns the channel of progress events. It is closed when Run returns.
+func (q *BuildQueue) Events() <-chan BuildEvent {
+	return q.events
+}
+
+// Run builds all images and blocks until every build has finished or ctx is done.
+// A failed build does not stop the others.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - builds: The images to build.
+// Returns:
+// - An error joining the failure of every build that did not succeed.
+func (q *BuildQueue) Run(ctx context.Context, builds []BuildImageOptions) error {
+	defer close(q.events)
+
+	for _, build := range builds {
+		q.emit(BuildEvent{Type: BuildEventQueued, ImageTag: build.ImageTag})
+	}
+	log.Info().
+		Int("builds", len(builds)).
+		Int("concurrency", q.concurrency).
+		Msg("Starting build queue")
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  []error
+	)
+	semaphore := make(chan struct{}, q.concurrency)
+	for _, build := range builds {
+		wg.Add(1)
+		go func(build BuildImageOptions) {
+			defer wg.Done()
+
+			var err error
+			select {
+			case semaphore <- struct{}{}:
+				err = q.build(ctx, build)
+				<-semaphore
+			case <-ctx.Done():
+				err = ctx.Err()
+				q.emit(BuildEvent{Type: BuildEventFailed, ImageTag: build.ImageTag, Err: err})
+			}
+
+			if err != nil {
+				mutex.Lock()
+				errs = append(errs, fmt.Errorf("image %s: %w", build.ImageTag, err))
+				mutex.Unlock()
+			}
+		}(build)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		log.Error().
+			Int("failed", len(errs)).
+			Int("builds", len(builds)).
+			Msg("Build queue finished with failures")
+		return fmt.Errorf("failed to build %d of %d images: %w", len(errs), len(builds), errors.Join(errs...))
+	}
+
+	log.Info().Int("builds", len(builds)).Msg("Build queue finished")
+	return nil
+}
+
+// build runs a single build and emits its started, log-line and result events.
+func (q *BuildQueue) build(ctx context.Context, build BuildImageOptions) error {
+	start := time.Now()
+	q.emit(BuildEvent{Type: BuildEventStarted, ImageTag: build.ImageTag})
+
+	// Build output reporting an error fails the build, even though the daemon completed the request.
+	var buildErrors []string
+	imageID, err := q.dc.buildDockerImage(ctx, build, func(logMsg BuildLog) {
+		line := logMsg.Stream
+		if logMsg.Error != "" {
+			buildErrors = append(buildErrors, logMsg.Error)
+			line = "Error: " + logMsg.Error
+		}
+		for _, part := range strings.Split(strings.TrimRight(line, "\n"), "\n") {
+			if strings.TrimSpace(part) != "" {
+				q.emit(BuildEvent{Type: BuildEventLogLine, ImageTag: build.ImageTag, Line: part})
+			}
+		}
+	})
+	if err == nil && len(buildErrors) > 0 {
+		err = fmt.Errorf("build reported errors: %s", strings.Join(buildErrors, "; "))
+	}
+
+	if err != nil {
+		q.emit(BuildEvent{Type: BuildEventFailed, ImageTag: build.ImageTag, Err: err, Duration: time.Since(start)})
+		return err
+	}
+	q.emit(BuildEvent{Type: BuildEventSucceeded, ImageTag: build.ImageTag, ImageID: imageID, Duration: time.Since(start)})
+	return nil
+}
+
+// emit sends an event stamped with the current time.
+func (q *BuildQueue) emit(event BuildEvent) {
+	event.Time = time.Now()
+	q.events <- event
+}
@@ -0,0 +1,179 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ComposeStart starts existing containers of a compose project, e.g. after ComposeStop.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// - services: Services to start; all services of the project when empty.
+// Returns:
+// - The combined stdout and stderr of docker compose.
+// - A *ComposeError with the output and failing service if the command fails.
+func (dc *DockerClient) ComposeStart(ctx context.Context, project ComposeProject, services ...string) (string, error) {
+	return dc.composeServiceCommand(ctx, project, "start", services)
+}
+
+// ComposeStop stops running containers of a compose project without removing them.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// - services: Services to stop; all services of the project when empty.
+// Returns:
+// - The combined stdout and stderr of docker compose.
+// - A *ComposeError with the output and failing service if the command fails.
+func (dc *DockerClient) ComposeStop(ctx context.Context, project ComposeProject, services ...string) (string, error) {
+	return dc.composeServiceCommand(ctx, project, "stop", services)
+}
+
+// ComposeRestart restarts containers of a compose project, e.g. to bounce a single backend service.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// - services: Services to restart; all services of the project when empty.
+// Returns:
+// - The combined stdout and stderr of docker compose.
+// - A *ComposeError with the output and failing service if the command fails.
+func (dc *DockerClient) ComposeRestart(ctx context.Context, project ComposeProject, services ...string) (string, error) {
+	return dc.composeServiceCommand(ctx, project, "restart", services)
+}
+
+// ComposeRunningServices lists the services of a compose project that have a running container.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// Returns:
+// - The names of the running services.
+// - A *ComposeError if docker compose fails, e.g. because the compose file does not exist.
+func (dc *DockerClient) ComposeRunningServices(ctx context.Context, project ComposeProject) ([]string, error) {
+	args := append(project.args(), "ps", "--services", "--filter", "status=running")
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		return nil, NewComposeError("docker "+strings.Join(args, " "), output, err, nil)
+	}
+
+	var services []string
+	for _, line := range strings.Split(output, "\n") {
+		if service := strings.TrimSpace(line); service != "" {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// ComposeServiceStatus is the state of a container of a compose service as reported by docker compose ps.
+type ComposeServiceStatus struct {
+	Service  string `json:"Service"`
+	Name     string `json:"Name"`
+	State    string `json:"State"`  // e.g. "running", "restarting" or "exited"
+	Health   string `json:"Health"` // "healthy", "unhealthy", "starting" or empty without a healthcheck
+	ExitCode int    `json:"ExitCode"`
+	Status   string `json:"Status"` // e.g. "Up 5 seconds" or "Restarting (1) 2 seconds ago"
+}
+
+// Failed reports whether the container crashed: it is restarting, dead, unhealthy or exited with a non-zero
+// code. Containers of one-shot services that exited with code 0 are not failed.
+func (s ComposeServiceStatus) Failed() bool {
+	switch s.State {
+	case "restarting", "dead":
+		return true
+	case "exited":
+		return s.ExitCode != 0
+	}
+	return s.Health == "unhealthy"
+}
+
+// ComposeStatus lists the containers of a compose project, including stopped ones.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// Returns:
+// - The state of every container of the project.
+// - A *ComposeError if docker compose fails, or an error if its output cannot be parsed.
+func (dc *DockerClient) ComposeStatus(ctx context.Context, project ComposeProject) ([]ComposeServiceStatus, error) {
+	args := append(project.args(), "ps", "--all", "--format", "json")
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		return nil, NewComposeError("docker "+strings.Join(args, " "), output, err, nil)
+	}
+	return ParseComposeStatus(output)
+}
+
+// ParseComposeStatus parses the output of docker compose ps --format json, which is a JSON array before
+// Compose 2.21 and one JSON object per line since.
+func ParseComposeStatus(output string) ([]ComposeServiceStatus, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+
+	var statuses []ComposeServiceStatus
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &statuses); err != nil {
+			return nil, fmt.Errorf("failed to parse docker compose ps output: %w", err)
+		}
+		return statuses, nil
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		var status ComposeServiceStatus
+		if err := json.Unmarshal([]byte(line), &status); err != nil {
+			return nil, fmt.Errorf("failed to parse docker compose ps output line %q: %w", line, err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ComposeLogs returns the last log lines of services of a compose project.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// - tail: Number of lines per container.
+// - services: Services whose logs are returned; all services of the project when empty.
+// Returns:
+// - The combined logs without colors.
+// - A *ComposeError if docker compose fails.
+func (dc *DockerClient) ComposeLogs(ctx context.Context, project ComposeProject, tail int, services ...string) (string, error) {
+	args := append(project.args(), "logs", "--no-color", "--tail", strconv.Itoa(tail))
+	args = append(args, services...)
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		return output, NewComposeError("docker "+strings.Join(args, " "), output, err, services)
+	}
+	return output, nil
+}
+
+// composeServiceCommand runs `docker compose <action> [services]` for a project.
+func (dc *DockerClient) composeServiceCommand(ctx context.Context, project ComposeProject, action string, services []string) (string, error) {
+	args := append(project.args(), action)
+	args = append(args, services...)
+	command := "docker " + strings.Join(args, " ")
+
+	log.Info().Str("command", command).Strs("services", services).Msgf("Running Docker Compose %s", action)
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		composeErr := NewComposeError(command, output, err, services)
+		log.Error().
+			Err(err).
+			Str("command", command).
+			Str("service", composeErr.Service).
+			Str("output", output).
+			Msgf("Docker Compose %s failed", action)
+		return output, composeErr
+	}
+
+	log.Info().Str("composeFile", project.File).Str("project", project.Name).Strs("services", services).
+		Msgf("Docker Compose %s completed", action)
+	return output, nil
+}
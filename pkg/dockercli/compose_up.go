@@ -0,0 +1,209 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// composeErrorOutputLines is the number of trailing output lines included in a ComposeError message.
+const composeErrorOutputLines = 20
+
+var (
+	// composeServicePattern matches messages naming a service, e.g. `service "web" refers to undefined network`.
+	composeServicePattern = regexp.MustCompile(`service "([^"]+)"`)
+	// composeProgressErrorPattern matches failed progress lines, e.g. " ✘ web Error pull access denied".
+	composeProgressErrorPattern = regexp.MustCompile(`✘\s+(\S+)\s+Error`)
+)
+
+// ComposeError is returned when a docker compose command fails. It carries the combined output so the
+// reason (port conflicts, pull failures, build errors) reaches the user.
+type ComposeError struct {
+	// Command is the compose command that failed.
+	Command string
+	// Service is the failing service if it could be determined from the output.
+	Service string
+	// Output is the combined stdout and stderr of the command.
+	Output string
+	Err    error
+}
+
+func (e *ComposeError) Error() string {
+	message := fmt.Sprintf("%s failed", e.Command)
+	if e.Service != "" {
+		message += fmt.Sprintf(" for service %s", e.Service)
+	}
+	message += fmt.Sprintf(": %v", e.Err)
+	// Remote executors already include stderr in the error, so only append output that is not shown yet.
+	if tail := tailLines(e.Output, composeErrorOutputLines); tail != "" && !strings.Contains(message, tail) {
+		message += "\n" + tail
+	}
+	return message
+}
+
+func (e *ComposeError) Unwrap() error {
+	return e.Err
+}
+
+// NewComposeError builds a ComposeError for a failed command, determining the failing service from output.
+// Parameters:
+// - command: The compose command that failed.
+// - output: Its combined stdout and stderr.
+// - err: The execution error.
+// - services: Optional service names of the compose file, used to recognize container names in the output.
+// Returns:
+// - The ComposeError.
+func NewComposeError(command, output string, err error, services []string) *ComposeError {
+	service := FailedComposeService(output, services)
+	if service == "" && err != nil {
+		// Local docker commands report their output only as part of the error.
+		service = FailedComposeService(err.Error(), services)
+	}
+	return &ComposeError{
+		Command: command,
+		Service: service,
+		Output:  output,
+		Err:     err,
+	}
+}
+
+// FailedComposeService returns the service a compose failure refers to, or an empty string if the output
+// does not name one. Container names such as "project-web-1" are recognized when services are given.
+func FailedComposeService(output string, services []string) string {
+	if match := composeServicePattern.FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+
+	// Check longer names first so "web-api" wins over "web".
+	candidates := append([]string(nil), services...)
+	sort.Slice(candidates, func(i, j int) bool { return len(candidates[i]) > len(candidates[j]) })
+
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(strings.ToLower(line), "error") {
+			continue
+		}
+		for _, service := range candidates {
+			if strings.Contains(line, "-"+service+"-") || strings.Contains(line, " "+service+" ") {
+				return service
+			}
+		}
+		if match := composeProgressErrorPattern.FindStringSubmatch(line); match != nil {
+			switch match[1] {
+			case "Container", "Network", "Volume", "Image":
+			default:
+				return match[1]
+			}
+		}
+	}
+	return ""
+}
+
+// ComposeProject identifies a compose deployment on the Docker host. Commands of the same deployment must use
+// the same project so up and down address the same containers.
+type ComposeProject struct {
+	// File is the path of the compose file on the Docker host.
+	File string
+	// Name is the compose project name passed via -p. Empty uses the compose default (the file's directory name).
+	Name string
+	// EnvFile is an optional env file on the Docker host passed via --env-file.
+	EnvFile string
+}
+
+// args returns the global compose arguments selecting the project.
+func (p ComposeProject) args() []string {
+	args := []string{"compose", "-f", p.File}
+	if p.Name != "" {
+		args = append(args, "-p", p.Name)
+	}
+	if p.EnvFile != "" {
+		args = append(args, "--env-file", p.EnvFile)
+	}
+	return args
+}
+
+// ComposeUp starts the services of a compose project in the background on the Docker host and returns the
+// combined compose output. Locally the command is retried like other docker CLI commands.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host.
+// - build: Whether to build images before starting the services.
+// - profiles: Profiles passed via --profile; services assigned to other profiles are not started.
+// - services: Optional service names of the compose file, used to name the failing service in errors.
+// Returns:
+// - The combined stdout and stderr of docker compose.
+// - A *ComposeError with the output and failing service if the command fails.
+func (dc *DockerClient) ComposeUp(ctx context.Context, project ComposeProject, build bool, profiles, services []string) (string, error) {
+	args := project.args()
+	for _, profile := range profiles {
+		args = append(args, "--profile", profile)
+	}
+	args = append(args, "up", "-d")
+	if build {
+		args = append(args, "--build")
+	}
+	command := "docker " + strings.Join(args, " ")
+
+	log.Info().Str("command", command).Msg("Starting Docker Compose services")
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		composeErr := NewComposeError(command, output, err, services)
+		log.Error().
+			Err(err).
+			Str("command", command).
+			Str("service", composeErr.Service).
+			Str("output", output).
+			Msg("Docker Compose up failed")
+		return output, composeErr
+	}
+
+	log.Info().Str("composeFile", project.File).Str("project", project.Name).Strs("profiles", profiles).Msg("Docker Compose services started")
+	return output, nil
+}
+
+// ComposeDown stops and removes the containers and networks of a compose project on the Docker host.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - project: Compose file, project name and env file on the Docker host; must match the ones used for up.
+// - removeVolumes: Whether to also remove the named volumes of the project.
+// Returns:
+// - The combined stdout and stderr of docker compose.
+// - A *ComposeError with the output if the command fails.
+func (dc *DockerClient) ComposeDown(ctx context.Context, project ComposeProject, removeVolumes bool) (string, error) {
+	args := append(project.args(), "down")
+	if removeVolumes {
+		args = append(args, "--volumes")
+	}
+	command := "docker " + strings.Join(args, " ")
+
+	log.Info().Str("command", command).Msg("Stopping Docker Compose services")
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("command", command).
+			Str("output", output).
+			Msg("Docker Compose down failed")
+		return output, NewComposeError(command, output, err, nil)
+	}
+
+	log.Info().Str("composeFile", project.File).Str("project", project.Name).Msg("Docker Compose services removed")
+	return output, nil
+}
+
+// tailLines returns the last n non-empty lines of output.
+func tailLines(output string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
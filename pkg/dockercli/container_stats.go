@@ -0,0 +1,128 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/rs/zerolog/log"
+)
+
+// ContainerStat is a single resource usage sample of a container.
+type ContainerStat struct {
+	Read          time.Time // Time the sample was taken by the daemon.
+	CPUPercent    float64   // CPU usage in percent of one core times the number of online CPUs, as shown by `docker stats`.
+	MemoryUsage   uint64    // Memory usage in bytes, excluding the page cache.
+	MemoryLimit   uint64    // Memory limit in bytes.
+	MemoryPercent float64   // MemoryUsage in percent of MemoryLimit.
+	NetworkRx     uint64    // Total bytes received over all networks.
+	NetworkTx     uint64    // Total bytes sent over all networks.
+	PIDs          uint64    // Number of processes in the container.
+}
+
+// ContainerStats streams live resource usage samples of a container.
+// The returned channel receives roughly one sample per second and is closed when ctx is cancelled,
+// the container stops or the stream fails.
+// Parameters:
+// - ctx: Context for managing cancellation; cancel it to stop streaming.
+// - containerID: ID or name of the container.
+// Returns:
+// - A channel of ContainerStat samples.
+// - An error if the stats stream cannot be opened.
+func (dc *DockerClient) ContainerStats(ctx context.Context, containerID string) (<-chan ContainerStat, error) {
+	if err := dc.requireSDK("streaming container stats"); err != nil {
+		return nil, err
+	}
+
+	response, err := dc.cli.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Msg("Failed to open container stats stream")
+		return nil, fmt.Errorf("failed to open stats stream for container %s: %w", containerID, err)
+	}
+
+	stats := make(chan ContainerStat)
+	go func() {
+		defer close(stats)
+		defer response.Body.Close()
+
+		decoder := json.NewDecoder(response.Body)
+		var previous *container.StatsResponse
+		for {
+			var sample container.StatsResponse
+			if err := decoder.Decode(&sample); err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					log.Error().Err(err).Str("container_id", containerID).Msg("Failed to decode container stats")
+				}
+				return
+			}
+
+			stat := newContainerStat(&sample, previous)
+			previous = &sample
+
+			select {
+			case stats <- stat:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stats, nil
+}
+
+// newContainerStat converts a raw stats sample into a ContainerStat.
+// The CPU percentage is computed from the delta to the previous sample, falling back to the
+// daemon-provided previous CPU figures for the first sample, the same way the docker CLI does.
+func newContainerStat(sample, previous *container.StatsResponse) ContainerStat {
+	preCPU := sample.PreCPUStats
+	if previous != nil {
+		preCPU = previous.CPUStats
+	}
+
+	stat := ContainerStat{
+		Read:        sample.Read,
+		CPUPercent:  calculateCPUPercent(preCPU, sample.CPUStats),
+		MemoryUsage: memoryUsageWithoutCache(sample.MemoryStats),
+		MemoryLimit: sample.MemoryStats.Limit,
+		PIDs:        sample.PidsStats.Current,
+	}
+	if stat.MemoryLimit > 0 {
+		stat.MemoryPercent = float64(stat.MemoryUsage) / float64(stat.MemoryLimit) * 100.0
+	}
+	for _, network := range sample.Networks {
+		stat.NetworkRx += network.RxBytes
+		stat.NetworkTx += network.TxBytes
+	}
+	return stat
+}
+
+// calculateCPUPercent computes the CPU usage between two samples in percent.
+func calculateCPUPercent(previous, current container.CPUStats) float64 {
+	cpuDelta := float64(current.CPUUsage.TotalUsage) - float64(previous.CPUUsage.TotalUsage)
+	systemDelta := float64(current.SystemUsage) - float64(previous.SystemUsage)
+
+	onlineCPUs := float64(current.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(current.CPUUsage.PercpuUsage))
+	}
+
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100.0
+}
+
+// memoryUsageWithoutCache subtracts the page cache from the memory usage like `docker stats` does
+// ("inactive_file" on cgroup v2, "total_inactive_file" on cgroup v1).
+func memoryUsageWithoutCache(mem container.MemoryStats) uint64 {
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if cache, ok := mem.Stats[key]; ok && cache < mem.Usage {
+			return mem.Usage - cache
+		}
+	}
+	return mem.Usage
+}
@@ -0,0 +1,200 @@
+package dockercli
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+)
+
+// CopyToContainerStream writes the content of r to destPath inside the container without a temporary file on the host.
+// The content is wrapped into a single-entry tar archive as required by the Docker API. Because a tar header
+// needs the file size up front, the content is buffered in memory, so this is meant for configuration files
+// and similar small payloads rather than large archives.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - containerID: ID or name of the target container.
+// - destPath: Absolute file path inside the container, e.g. "/home/kasm-user/.config/app.json".
+// - r: Reader providing the file content.
+// Returns:
+// - An error if the content cannot be read or the copy fails.
+func (dc *DockerClient) CopyToContainerStream(ctx context.Context, containerID, destPath string, r io.Reader) error {
+	if err := dc.requireSDK("copying to containers"); err != nil {
+		return err
+	}
+	if destPath == "" || !path.IsAbs(destPath) {
+		return fmt.Errorf("destination path must be absolute, got %q", destPath)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read content for %s: %w", destPath, err)
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	header := &tar.Header{
+		Name:    path.Base(destPath),
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", destPath, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write tar content for %s: %w", destPath, err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar archive for %s: %w", destPath, err)
+	}
+
+	destDir := path.Dir(destPath)
+	if err := dc.cli.CopyToContainer(ctx, containerID, destDir, &archive, container.CopyToContainerOptions{}); err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Str("dest_path", destPath).Msg("Failed to copy content to container")
+		return fmt.Errorf("failed to copy content to %s in container %s: %w", destPath, containerID, err)
+	}
+
+	log.Info().Str("container_id", containerID).Str("dest_path", destPath).Int("bytes", len(content)).Msg("Content copied to container")
+	return nil
+}
+
+// CopyFromContainerStream returns a reader for the content of the file at srcPath inside the container.
+// The tar framing used by the Docker API is removed, so the reader yields the raw file content.
+// The caller must close the returned reader.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - containerID: ID or name of the source container.
+// - srcPath: Path of a regular file inside the container.
+// Returns:
+// - A ReadCloser streaming the file content.
+// - An error if the copy fails or srcPath is not a regular file.
+func (dc *DockerClient) CopyFromContainerStream(ctx context.Context, containerID, srcPath string) (io.ReadCloser, error) {
+	if err := dc.requireSDK("copying from containers"); err != nil {
+		return nil, err
+	}
+
+	reader, stat, err := dc.cli.CopyFromContainer(ctx, containerID, srcPath)
+	if err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Str("src_path", srcPath).Msg("Failed to copy content from container")
+		return nil, fmt.Errorf("failed to copy %s from container %s: %w", srcPath, containerID, err)
+	}
+	if stat.Mode.IsDir() {
+		reader.Close()
+		return nil, fmt.Errorf("%s in container %s is a directory, expected a file", srcPath, containerID)
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			reader.Close()
+			return nil, fmt.Errorf("%s not found in archive returned by container %s", srcPath, containerID)
+		}
+		if err != nil {
+			reader.Close()
+			return nil, fmt.Errorf("failed to read archive for %s from container %s: %w", srcPath, containerID, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			log.Debug().Str("container_id", containerID).Str("src_path", srcPath).Int64("bytes", header.Size).Msg("Streaming content from container")
+			return &streamReadCloser{Reader: tr, closer: reader}, nil
+		}
+	}
+}
+
+// streamReadCloser pairs a reader with the closer of the underlying stream, e.g. a single tar entry
+// and the archive stream it is read from.
+type streamReadCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+// Close closes the underlying stream.
+func (t *streamReadCloser) Close() error {
+	return t.closer.Close()
+}
+
+// LogOptions controls which container logs are returned by ContainerLogs.
+type LogOptions struct {
+	Follow     bool      // Keep streaming new log lines until the context is cancelled.
+	Since      time.Time // Only return logs written after this time. Zero means from the start.
+	Tail       int       // Number of lines to return from the end of the logs. Zero or negative returns all lines.
+	Timestamps bool      // Prefix every line with its timestamp.
+}
+
+// ContainerLogs returns the combined stdout and stderr logs of a container.
+// The multiplexed stream framing used for non-TTY containers is removed, so the reader yields plain log text.
+// The caller must close the returned reader; with Follow set, closing it or cancelling ctx stops the stream.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - containerID: ID or name of the container.
+// - opts: Follow, Since, Tail and Timestamps settings.
+// Returns:
+// - A ReadCloser streaming the log text.
+// - An error if the container cannot be inspected or its logs cannot be retrieved.
+func (dc *DockerClient) ContainerLogs(ctx context.Context, containerID string, opts LogOptions) (io.ReadCloser, error) {
+	if err := dc.requireSDK("reading container logs"); err != nil {
+		return nil, err
+	}
+
+	// TTY containers write a raw stream without the stdout/stderr multiplexing headers.
+	info, err := dc.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+
+	logsOptions := container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Timestamps: opts.Timestamps,
+		Tail:       "all",
+	}
+	if opts.Tail > 0 {
+		logsOptions.Tail = strconv.Itoa(opts.Tail)
+	}
+	if !opts.Since.IsZero() {
+		logsOptions.Since = strconv.FormatInt(opts.Since.Unix(), 10)
+	}
+
+	reader, err := dc.cli.ContainerLogs(ctx, containerID, logsOptions)
+	if err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Msg("Failed to retrieve container logs")
+		return nil, fmt.Errorf("failed to retrieve logs of container %s: %w", containerID, err)
+	}
+
+	if info.Config != nil && info.Config.Tty {
+		return reader, nil
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pipeWriter, pipeWriter, reader)
+		reader.Close()
+		pipeWriter.CloseWithError(err)
+	}()
+	return &streamReadCloser{Reader: pipeReader, closer: multiCloser{pipeReader, reader}}, nil
+}
+
+// multiCloser closes all contained closers and returns the first error.
+type multiCloser []io.Closer
+
+// Close closes every closer in order.
+func (m multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
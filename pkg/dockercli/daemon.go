@@ -0,0 +1,26 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ServerVersion returns the version of the Docker daemon, which also verifies that the docker CLI is installed
+// and can reach the daemon.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// Returns:
+// - The daemon version, e.g. "27.3.1".
+// - An error if the docker CLI is missing or the daemon cannot be reached.
+func (dc *DockerClient) ServerVersion(ctx context.Context) (string, error) {
+	output, err := dc.runDocker(ctx, "version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		return "", fmt.Errorf("failed to query Docker daemon version: %w", err)
+	}
+	version := strings.TrimSpace(output)
+	if version == "" {
+		return "", fmt.Errorf("docker daemon returned no version")
+	}
+	return version, nil
+}
@@ -0,0 +1,216 @@
+package dockercli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CommandExecutor runs a shell command on a (typically remote) Docker host and returns its combined output.
+// *shadowssh.SSHClient satisfies this interface.
+type CommandExecutor interface {
+	ExecuteCommand(ctx context.Context, command string) (string, error)
+}
+
+// StdinCommandExecutor is a CommandExecutor that can feed data to the stdin of a command, which keeps secrets
+// out of the command line. *shadowssh.SSHClient and shadowssh.SudoExecutor implement it.
+type StdinCommandExecutor interface {
+	CommandExecutor
+	ExecuteCommandWithStdin(ctx context.Context, command string, stdin io.Reader) (string, error)
+}
+
+// DiskUsageEntry holds the `docker system df` figures for a single resource type.
+type DiskUsageEntry struct {
+	TotalCount  int
+	Active      int
+	Size        int64 // Size in bytes.
+	Reclaimable int64 // Reclaimable size in bytes.
+}
+
+// DiskUsage summarizes the Docker disk usage of a host by resource type.
+type DiskUsage struct {
+	Images     DiskUsageEntry
+	Containers DiskUsageEntry
+	Volumes    DiskUsageEntry
+	BuildCache DiskUsageEntry
+}
+
+// TotalSize returns the combined size of all resource types in bytes.
+func (d DiskUsage) TotalSize() int64 {
+	return d.Images.Size + d.Containers.Size + d.Volumes.Size + d.BuildCache.Size
+}
+
+// TotalReclaimable returns the combined reclaimable size of all resource types in bytes.
+func (d DiskUsage) TotalReclaimable() int64 {
+	return d.Images.Reclaimable + d.Containers.Reclaimable + d.Volumes.Reclaimable + d.BuildCache.Reclaimable
+}
+
+// diskUsageLine mirrors a single JSON line emitted by `docker system df --format '{{json .}}'`.
+type diskUsageLine struct {
+	Type        string `json:"Type"`
+	TotalCount  string `json:"TotalCount"`
+	Active      string `json:"Active"`
+	Size        string `json:"Size"`
+	Reclaimable string `json:"Reclaimable"`
+}
+
+// GetDiskUsage runs `docker system df` and returns the parsed disk usage.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - executor: Executor used to run the command remotely. If nil, the command runs against the local Docker host.
+// Returns:
+// - The parsed DiskUsage.
+// - An error if the command fails or its output cannot be parsed.
+func GetDiskUsage(ctx context.Context, executor CommandExecutor) (*DiskUsage, error) {
+	var output string
+	if executor != nil {
+		out, err := executor.ExecuteCommand(ctx, "docker system df --format '{{json .}}'")
+		if err != nil {
+			log.Error().Err(err).Str("output", out).Msg("Failed to retrieve remote Docker disk usage")
+			return nil, fmt.Errorf("failed to retrieve remote Docker disk usage: %w", err)
+		}
+		output = out
+	} else {
+		out, err := executeDockerCommand(ctx, 1, "docker", "system", "df", "--format", "{{json .}}")
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to retrieve Docker disk usage")
+			return nil, fmt.Errorf("failed to retrieve Docker disk usage: %w", err)
+		}
+		output = string(out)
+	}
+
+	usage, err := ParseDiskUsage(output)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().
+		Int64("images_size", usage.Images.Size).
+		Int64("images_reclaimable", usage.Images.Reclaimable).
+		Int64("containers_size", usage.Containers.Size).
+		Int64("containers_reclaimable", usage.Containers.Reclaimable).
+		Int64("volumes_size", usage.Volumes.Size).
+		Int64("volumes_reclaimable", usage.Volumes.Reclaimable).
+		Int64("build_cache_size", usage.BuildCache.Size).
+		Int64("build_cache_reclaimable", usage.BuildCache.Reclaimable).
+		Msg("Docker disk usage retrieved")
+	return usage, nil
+}
+
+// ParseDiskUsage parses the line-delimited JSON output of `docker system df --format '{{json .}}'`.
+func ParseDiskUsage(output string) (*DiskUsage, error) {
+	usage := &DiskUsage{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var raw diskUsageLine
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse docker system df output %q: %w", line, err)
+		}
+
+		entry, err := raw.toEntry()
+		if err != nil {
+			return nil, err
+		}
+
+		switch raw.Type {
+		case "Images":
+			usage.Images = entry
+		case "Containers":
+			usage.Containers = entry
+		case "Local Volumes":
+			usage.Volumes = entry
+		case "Build Cache":
+			usage.BuildCache = entry
+		default:
+			log.Debug().Str("type", raw.Type).Msg("Ignoring unknown docker system df entry")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read docker system df output: %w", err)
+	}
+	return usage, nil
+}
+
+// toEntry converts the textual docker system df figures into a DiskUsageEntry.
+func (l diskUsageLine) toEntry() (DiskUsageEntry, error) {
+	var entry DiskUsageEntry
+	var err error
+
+	if entry.TotalCount, err = atoiOrZero(l.TotalCount); err != nil {
+		return entry, fmt.Errorf("invalid total count %q for %s: %w", l.TotalCount, l.Type, err)
+	}
+	if entry.Active, err = atoiOrZero(l.Active); err != nil {
+		return entry, fmt.Errorf("invalid active count %q for %s: %w", l.Active, l.Type, err)
+	}
+	if entry.Size, err = parseDockerSize(l.Size); err != nil {
+		return entry, fmt.Errorf("invalid size %q for %s: %w", l.Size, l.Type, err)
+	}
+	if entry.Reclaimable, err = parseDockerSize(l.Reclaimable); err != nil {
+		return entry, fmt.Errorf("invalid reclaimable size %q for %s: %w", l.Reclaimable, l.Type, err)
+	}
+	return entry, nil
+}
+
+// atoiOrZero parses an integer, treating an empty string as zero.
+func atoiOrZero(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// dockerSizeUnits maps the decimal units used by the Docker CLI to their byte multipliers.
+var dockerSizeUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"TB", 1e12},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"kB", 1e3},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+// parseDockerSize parses human readable sizes such as "1.2GB" or "512MB (40%)" into bytes.
+func parseDockerSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	// Reclaimable figures carry a trailing percentage, e.g. "1.2GB (50%)".
+	if idx := strings.Index(s, " ("); idx >= 0 {
+		s = s[:idx]
+	}
+	if s == "" {
+		return 0, nil
+	}
+
+	for _, unit := range dockerSizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(value * unit.multiplier), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown size unit in %q", s)
+}
+
+// DiskUsage returns the Docker disk usage of the host the client talks to.
+func (dc *DockerClient) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	if dc.isRemote() {
+		return GetDiskUsage(ctx, dc.executor)
+	}
+	return GetDiskUsage(ctx, nil)
+}
@@ -2,16 +2,31 @@
 package dockercli
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/client"
 	"github.com/fatih/color"
+	"github.com/rs/zerolog/log"
+	shadowssh "kasmlink/pkg/sshmanager"
 )
 
 // DockerClient encapsulates the Docker client and retry configurations.
+//
+// A DockerClient talks to the Docker host in one of two ways:
+//   - SDK: when created with NewDockerClient and a non-nil *client.Client, operations with structured
+//     results (ListImages, RemoveImage, PruneImagesMatching, builds, exports, container copies) use the
+//     Docker Engine API directly, so no CLI output has to be parsed.
+//   - Exec: when created with NewRemoteDockerClient, operations are executed as docker CLI commands through
+//     a CommandExecutor (usually an SSH client). Output is requested as JSON where the CLI supports it.
+//
+// Operations that only exist on the SDK path (builds, exports, container copies) return an error on a remote client.
 type DockerClient struct {
 	cli               *client.Client
+	executor          CommandExecutor
 	retries           int
 	initialRetryDelay time.Duration
 	backoffMultiplier int
@@ -61,3 +76,45 @@ func NewDockerClient(cli *client.Client, retries int, initialRetryDelay time.Dur
 		errorColor:        color.New(color.FgRed),
 	}
 }
+
+// NewRemoteDockerClient returns a DockerClient that runs docker CLI commands through the given executor,
+// typically an SSH client connected to a remote node.
+// Parameters:
+// - executor: Executor running the docker commands on the target host.
+// - retries: Number of retry attempts for operations.
+func NewRemoteDockerClient(executor CommandExecutor, retries int) *DockerClient {
+	dc := NewDockerClient(nil, retries, 0, 0, 0, 0)
+	dc.executor = executor
+	return dc
+}
+
+// isRemote reports whether the client executes commands through an executor instead of the SDK.
+func (dc *DockerClient) isRemote() bool {
+	return dc.executor != nil
+}
+
+// requireSDK returns an error if the operation needs the Docker SDK but the client has none.
+func (dc *DockerClient) requireSDK(operation string) error {
+	if dc.cli == nil {
+		return fmt.Errorf("%s requires a local Docker SDK client", operation)
+	}
+	return nil
+}
+
+// runDocker runs a docker CLI command with the given arguments, either through the executor or locally.
+func (dc *DockerClient) runDocker(ctx context.Context, args ...string) (string, error) {
+	if dc.isRemote() {
+		command := "docker " + shadowssh.ShellJoin(args...)
+		log.Debug().Str("command", command).Msg("Executing remote Docker command")
+		return dc.executor.ExecuteCommand(ctx, command)
+	}
+	output, err := executeDockerCommand(ctx, dc.retries, "docker", args...)
+	return string(output), err
+}
+
+// lastLine returns the last non-empty line of a command output, which is where the docker CLI prints
+// created object IDs after any warnings.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -28,8 +28,42 @@ const (
 
 // BuildLog represents the structure of Docker build log messages.
 type BuildLog struct {
-	Stream string `json:"stream"`
-	Error  string `json:"error"`
+	Stream string `json:"stream,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Aux carries auxiliary data of the daemon, e.g. {"ID":"sha256:..."} with the ID of the built image.
+	Aux json.RawMessage `json:"aux,omitempty"`
+}
+
+// ImageID returns the image ID carried by an aux message, or an empty string for other messages.
+func (l BuildLog) ImageID() string {
+	if len(l.Aux) == 0 {
+		return ""
+	}
+	var aux struct {
+		ID string `json:"ID"`
+	}
+	if err := json.Unmarshal(l.Aux, &aux); err != nil {
+		return ""
+	}
+	return aux.ID
+}
+
+// BuildLogHandler receives every message of a Docker build output.
+type BuildLogHandler func(BuildLog)
+
+// BuildImageOptions describes a single image build from a local build context directory.
+type BuildImageOptions struct {
+	// ImageTag is the tag assigned to the built image (e.g., "myapp:latest").
+	ImageTag string
+	// DockerfilePath is the path of the Dockerfile within the build context directory.
+	DockerfilePath string
+	// BuildContextPath is the path of the build context directory.
+	BuildContextPath string
+	// BuildArgs are optional build arguments passed to the Docker build.
+	BuildArgs map[string]*string
+	// Builder selects the builder of the daemon; empty uses the daemon default. BuildKit reports its
+	// progress as aux trace messages instead of stream messages.
+	Builder Builder
 }
 
 // BuildDockerImage builds a Docker image from a specified build context directory and Dockerfile.
@@ -41,8 +75,61 @@ type BuildLog struct {
 // - buildContextPath: Path to the build context directory.
 // - buildArgs: Optional build arguments to pass to the Docker build.
 // Returns:
+// - The ID of the built image (e.g. "sha256:..."), read from the daemon's aux message; empty if it sent none.
 // - An error if the build process fails or is aborted.
-func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfilePath, buildContextPath string, buildArgs map[string]*string) error {
+func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfilePath, buildContextPath string, buildArgs map[string]*string) (string, error) {
+	return dc.buildDockerImage(ctx, BuildImageOptions{
+		ImageTag:         imageTag,
+		DockerfilePath:   dockerfilePath,
+		BuildContextPath: buildContextPath,
+		BuildArgs:        buildArgs,
+	}, dc.PrintBuildLog)
+}
+
+// BuildDockerImageWithHandler builds a Docker image like BuildDockerImage, but passes every build message
+// to handle instead of printing it, so callers can render progress or capture the image ID of aux messages.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - opts: The image tag, Dockerfile, build context and build arguments of the build.
+// - handle: Handler receiving the stream, error and aux messages in the order the daemon sends them.
+// Returns:
+// - The ID of the built image, as returned by BuildDockerImage.
+// - An error if the build process fails, is aborted or the build output reports an error.
+func (dc *DockerClient) BuildDockerImageWithHandler(ctx context.Context, opts BuildImageOptions, handle BuildLogHandler) (string, error) {
+	var buildErrors []string
+	imageID, err := dc.buildDockerImage(ctx, opts, func(logMsg BuildLog) {
+		if logMsg.Error != "" {
+			buildErrors = append(buildErrors, logMsg.Error)
+		}
+		handle(logMsg)
+	})
+	if err == nil && len(buildErrors) > 0 {
+		return "", fmt.Errorf("build of %s reported errors: %s", opts.ImageTag, strings.Join(buildErrors, "; "))
+	}
+	return imageID, err
+}
+
+// NewJSONBuildLogHandler returns a BuildLogHandler writing every build message as a JSON line to w,
+// for tools consuming the build progress programmatically.
+func NewJSONBuildLogHandler(w io.Writer) BuildLogHandler {
+	encoder := json.NewEncoder(w)
+	return func(logMsg BuildLog) {
+		if err := encoder.Encode(logMsg); err != nil {
+			log.Error().
+				Err(err).
+				Msg("Failed to write Docker build log as JSON")
+		}
+	}
+}
+
+// buildDockerImage builds an image with the retry logic of BuildDockerImage, hands every build message to
+// handle and returns the image ID of the aux message.
+func (dc *DockerClient) buildDockerImage(ctx context.Context, opts BuildImageOptions, handle BuildLogHandler) (string, error) {
+	imageTag, dockerfilePath, buildContextPath, buildArgs := opts.ImageTag, opts.DockerfilePath, opts.BuildContextPath, opts.BuildArgs
+	if err := dc.requireSDK("building images"); err != nil {
+		return "", err
+	}
+
 	log.Info().
 		Str("imageTag", imageTag).
 		Str("dockerfilePath", dockerfilePath).
@@ -55,13 +142,13 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 		log.Error().
 			Str("dockerfilePath", dockerfileFullPath).
 			Msg("Dockerfile does not exist in build context")
-		return fmt.Errorf("dockerfile does not exist at path %s in build context", dockerfileFullPath)
+		return "", fmt.Errorf("dockerfile does not exist at path %s in build context", dockerfileFullPath)
 	} else if err != nil {
 		log.Error().
 			Err(err).
 			Str("dockerfilePath", dockerfileFullPath).
 			Msg("Error accessing Dockerfile in build context")
-		return fmt.Errorf("error accessing Dockerfile in build context: %w", err)
+		return "", fmt.Errorf("error accessing Dockerfile in build context: %w", err)
 	}
 
 	// Create a tar archive from the build context directory
@@ -71,7 +158,7 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 			Err(err).
 			Str("buildContextPath", buildContextPath).
 			Msg("Failed to create tar archive from build context")
-		return fmt.Errorf("failed to create tar archive from build context: %w", err)
+		return "", fmt.Errorf("failed to create tar archive from build context: %w", err)
 	}
 
 	// Prepare build options
@@ -82,6 +169,15 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 		BuildArgs:  buildArgs,
 		// Set other build options as needed
 	}
+	switch opts.Builder {
+	case "":
+	case BuilderBuildKit:
+		buildOptions.Version = types.BuilderBuildKit
+	case BuilderClassic:
+		buildOptions.Version = types.BuilderV1
+	default:
+		return "", fmt.Errorf("unsupported builder %q, expected buildkit or classic", opts.Builder)
+	}
 
 	// Attempt to build the image with retry logic
 	var imageBuildResponse types.ImageBuildResponse
@@ -95,7 +191,7 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 			log.Error().
 				Err(ctx.Err()).
 				Msg("BuildDockerImage aborted due to context cancellation before attempting")
-			return fmt.Errorf("build image aborted due to context cancellation: %w", ctx.Err())
+			return "", fmt.Errorf("build image aborted due to context cancellation: %w", ctx.Err())
 		default:
 			// Continue
 		}
@@ -115,7 +211,7 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 					Err(err).
 					Str("imageTag", imageTag).
 					Msg("Permanent error encountered during Docker image build. Not retrying.")
-				return fmt.Errorf("permanent error during Docker image build: %w", err)
+				return "", fmt.Errorf("permanent error during Docker image build: %w", err)
 			}
 
 			// If not the last attempt, wait before retrying
@@ -140,7 +236,7 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 					log.Error().
 						Err(ctx.Err()).
 						Msg("BuildDockerImage aborted during retry delay due to context cancellation")
-					return fmt.Errorf("build image aborted during retry delay: %w", ctx.Err())
+					return "", fmt.Errorf("build image aborted during retry delay: %w", ctx.Err())
 				}
 
 				// Exponential backoff
@@ -158,7 +254,7 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to initiate Docker image build for %s after %d attempts: %w", imageTag, dc.retries, err)
+		return "", fmt.Errorf("failed to initiate Docker image build for %s after %d attempts: %w", imageTag, dc.retries, err)
 	}
 
 	defer func() {
@@ -169,19 +265,27 @@ func (dc *DockerClient) BuildDockerImage(ctx context.Context, imageTag, dockerfi
 		}
 	}()
 
-	// Process build logs
-	if err := dc.PrintBuildLogs(ctx, imageBuildResponse.Body); err != nil {
+	// Process build logs, keeping the image ID of the aux message
+	var imageID string
+	err = decodeBuildLogs(ctx, imageBuildResponse.Body, func(logMsg BuildLog) {
+		if id := logMsg.ImageID(); id != "" {
+			imageID = id
+		}
+		handle(logMsg)
+	})
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str("imageTag", imageTag).
 			Msg("Error occurred during Docker build logs processing")
-		return fmt.Errorf("error occurred during Docker build logs processing: %w", err)
+		return "", fmt.Errorf("error occurred during Docker build logs processing: %w", err)
 	}
 
 	log.Info().
 		Str("imageTag", imageTag).
+		Str("imageID", imageID).
 		Msg("Docker image built successfully")
-	return nil
+	return imageID, nil
 }
 
 // isPermanentError determines whether an error is permanent (should not be retried).
@@ -207,9 +311,37 @@ func isPermanentError(err error) bool {
 // Returns:
 // - An error if log processing fails or is aborted.
 func (dc *DockerClient) PrintBuildLogs(ctx context.Context, reader io.Reader) error {
-	decoder := json.NewDecoder(reader)
+	if err := decodeBuildLogs(ctx, reader, dc.PrintBuildLog); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Docker build process completed successfully")
+	return nil
+}
+
+// PrintBuildLog prints a single build message in color. It is the BuildLogHandler of the CLI.
+func (dc *DockerClient) PrintBuildLog(logMsg BuildLog) {
+	// Handle error messages in the build logs
+	if logMsg.Error != "" {
+		log.Error().
+			Str("error", logMsg.Error).
+			Msg("Docker build encountered an error")
+		fmt.Println(dc.errorColor.Sprintf("Error: %s", logMsg.Error))
+		return
+	}
+
+	// Handle standard build stream messages
+	if logMsg.Stream != "" {
+		log.Debug().
+			Msgf("Docker build log: %s", logMsg.Stream)
+		fmt.Print(dc.successColor.Sprintf("%s", logMsg.Stream))
+	}
+}
 
-	var logMsg BuildLog
+// decodeBuildLogs decodes the JSON messages of a Docker build output and passes each one to handle.
+// Returns an error if decoding fails or ctx is done.
+func decodeBuildLogs(ctx context.Context, reader io.Reader, handle func(BuildLog)) error {
+	decoder := json.NewDecoder(reader)
 
 	for {
 		// Check for context cancellation
@@ -224,35 +356,18 @@ func (dc *DockerClient) PrintBuildLogs(ctx context.Context, reader io.Reader) er
 		}
 
 		// Decode the next JSON object from the build logs
+		var logMsg BuildLog
 		if err := decoder.Decode(&logMsg); err != nil {
 			if errors.Is(err, io.EOF) {
-				break // No more logs to process
+				return nil // No more logs to process
 			}
 			log.Error().
 				Err(err).
 				Msg("Error decoding Docker build logs")
 			return fmt.Errorf("error decoding build logs: %w", err)
 		}
-
-		// Handle error messages in the build logs
-		if logMsg.Error != "" {
-			log.Error().
-				Str("error", logMsg.Error).
-				Msg("Docker build encountered an error")
-			fmt.Println(dc.errorColor.Sprintf("Error: %s", logMsg.Error))
-			continue
-		}
-
-		// Handle standard build stream messages
-		if logMsg.Stream != "" {
-			log.Debug().
-				Msgf("Docker build log: %s", logMsg.Stream)
-			fmt.Print(dc.successColor.Sprintf("%s", logMsg.Stream))
-		}
+		handle(logMsg)
 	}
-
-	log.Info().Msg("Docker build process completed successfully")
-	return nil
 }
 
 // CreateTarFromDirectory creates a tar archive from a filesystem directory.
@@ -466,6 +581,10 @@ func CreateTarFromEmbedded(embeddedFS fs.FS, srcDir string) (io.ReadCloser, erro
 // - The file path to the exported tar file.
 // - An error if the export fails.
 func (dc *DockerClient) ExportImageToTar(ctx context.Context, imageTag string) (string, error) {
+	if err := dc.requireSDK("exporting images"); err != nil {
+		return "", err
+	}
+
 	log.Info().
 		Str("imageTag", imageTag).
 		Msg("Exporting Docker image to tar file")
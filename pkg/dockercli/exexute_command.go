@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"os"
 	"os/exec"
 )
 
@@ -22,6 +23,12 @@ const (
 // executeDockerCommand executes a Docker command with retry and timeout mechanisms.
 // It employs exponential backoff with jitter to handle transient errors gracefully.
 func executeDockerCommand(ctx context.Context, retries int, command string, args ...string) ([]byte, error) {
+	return executeDockerCommandWithEnv(ctx, retries, nil, command, args...)
+}
+
+// executeDockerCommandWithEnv executes a Docker command like executeDockerCommand with additional
+// environment variables ("KEY=value") on top of the environment of the current process.
+func executeDockerCommandWithEnv(ctx context.Context, retries int, env []string, command string, args ...string) ([]byte, error) {
 	var lastErr error
 	retryDelay := initialRetryDelay
 
@@ -43,6 +50,9 @@ func executeDockerCommand(ctx context.Context, retries int, command string, args
 
 		// Create the command with context
 		cmd := exec.CommandContext(ctx, command, args...)
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
 		startTime := time.Now()
 
 		// Log the execution attempt
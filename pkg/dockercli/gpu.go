@@ -0,0 +1,104 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// nvidiaRuntime is the name of the Docker runtime registered by the NVIDIA container toolkit.
+const nvidiaRuntime = "nvidia"
+
+// GPUInfo describes the GPU capabilities of a Docker host.
+type GPUInfo struct {
+	NvidiaRuntime bool     // The nvidia runtime is registered with the Docker daemon.
+	GPUs          []string // GPUs listed by `nvidia-smi -L`, e.g. "GPU 0: NVIDIA A100 (UUID: GPU-...)".
+}
+
+// Supported reports whether containers on the host can use at least one GPU.
+func (g GPUInfo) Supported() bool {
+	return g.NvidiaRuntime && len(g.GPUs) > 0
+}
+
+// DetectGPUs inspects the Docker runtimes and the NVIDIA driver of the Docker host.
+// A missing or failing nvidia-smi is reported as a host without GPUs, not as an error.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// Returns:
+// - The GPU capabilities of the host.
+// - An error if the Docker daemon cannot be queried.
+func (dc *DockerClient) DetectGPUs(ctx context.Context) (*GPUInfo, error) {
+	output, err := dc.runDocker(ctx, "info", "--format", "{{json .Runtimes}}")
+	if err != nil {
+		log.Error().Err(err).Str("output", output).Msg("Failed to query Docker runtimes")
+		return nil, fmt.Errorf("failed to query Docker runtimes: %w", err)
+	}
+
+	runtimes, err := parseRuntimes(output)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &GPUInfo{}
+	for _, runtime := range runtimes {
+		if runtime == nvidiaRuntime {
+			info.NvidiaRuntime = true
+		}
+	}
+
+	smiOutput, err := dc.runCommand(ctx, "nvidia-smi", "-L")
+	if err != nil {
+		log.Debug().Err(err).Str("output", smiOutput).Msg("nvidia-smi not available, assuming no GPUs")
+	} else {
+		info.GPUs = parseNvidiaSmiList(smiOutput)
+	}
+
+	log.Info().
+		Bool("nvidia_runtime", info.NvidiaRuntime).
+		Int("gpu_count", len(info.GPUs)).
+		Msg("Detected GPU capabilities of Docker host")
+	return info, nil
+}
+
+// HasGPUSupport reports whether the Docker host has the nvidia runtime and at least one GPU.
+func (dc *DockerClient) HasGPUSupport(ctx context.Context) (bool, error) {
+	info, err := dc.DetectGPUs(ctx)
+	if err != nil {
+		return false, err
+	}
+	return info.Supported(), nil
+}
+
+// runCommand runs a non-docker command on the Docker host, through the executor or locally, without retries.
+func (dc *DockerClient) runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	if dc.isRemote() {
+		return dc.executor.ExecuteCommand(ctx, shadowssh.ShellJoin(append([]string{name}, args...)...))
+	}
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return string(output), err
+}
+
+// parseRuntimes returns the runtime names from the output of `docker info --format '{{json .Runtimes}}'`.
+func parseRuntimes(output string) ([]string, error) {
+	var runtimes map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(lastLine(output)), &runtimes); err != nil {
+		return nil, fmt.Errorf("failed to parse Docker runtimes %q: %w", output, err)
+	}
+	return sortedKeys(runtimes), nil
+}
+
+// parseNvidiaSmiList returns the GPU lines of `nvidia-smi -L` output.
+func parseNvidiaSmiList(output string) []string {
+	var gpus []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "GPU ") {
+			gpus = append(gpus, line)
+		}
+	}
+	return gpus
+}
@@ -0,0 +1,159 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/rs/zerolog/log"
+)
+
+// regexPatternPrefix marks a PruneImagesMatching pattern as a regular expression instead of a glob.
+const regexPatternPrefix = "regex:"
+
+// PruneImagesMatching removes every image tag whose "repository:tag" reference matches pattern.
+// The pattern is a glob (e.g. "kasmweb/*:1.15*") unless it is prefixed with "regex:", in which case
+// the remainder is compiled as a regular expression. Only matching tags are removed; images whose
+// last tag is removed are deleted by the daemon as usual. Works for local and remote clients.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - pattern: Glob or "regex:"-prefixed regular expression matched against "repository:tag".
+// - dryRun: When true, matching references are only returned and nothing is removed.
+// Returns:
+// - The references that were removed (or would be removed in dry-run mode).
+// - An error if listing images fails, the pattern is invalid or any removal fails.
+func (dc *DockerClient) PruneImagesMatching(ctx context.Context, pattern string, dryRun bool) ([]string, error) {
+	match, err := imageReferenceMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := dc.ListImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, ref := range refs {
+		if match(ref) {
+			matches = append(matches, ref)
+		}
+	}
+
+	if dryRun {
+		log.Info().Str("pattern", pattern).Strs("images", matches).Msg("Dry run: images matching pattern")
+		return matches, nil
+	}
+
+	removed := make([]string, 0, len(matches))
+	for _, ref := range matches {
+		if err := dc.RemoveImage(ctx, ref); err != nil {
+			log.Error().Err(err).Str("image", ref).Msg("Failed to remove Docker image")
+			return removed, err
+		}
+		log.Info().Str("image", ref).Msg("Docker image removed")
+		removed = append(removed, ref)
+	}
+
+	log.Info().Str("pattern", pattern).Int("removed_count", len(removed)).Msg("Pruned images matching pattern")
+	return removed, nil
+}
+
+// imageReferenceMatcher builds a matcher for a glob or "regex:"-prefixed pattern.
+func imageReferenceMatcher(pattern string) (func(string) bool, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("image pattern cannot be empty")
+	}
+
+	if strings.HasPrefix(pattern, regexPatternPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, regexPatternPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid image regex %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	// Validate the glob once so a malformed pattern is reported instead of silently matching nothing.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid image glob %q: %w", pattern, err)
+	}
+	return func(ref string) bool {
+		ok, _ := path.Match(pattern, ref)
+		return ok
+	}, nil
+}
+
+// ListImages returns the "repository:tag" references of all tagged images on the Docker host.
+// Locally the Docker SDK is used; remote clients parse the JSON output of `docker image ls`.
+func (dc *DockerClient) ListImages(ctx context.Context) ([]string, error) {
+	if !dc.isRemote() && dc.cli != nil {
+		images, err := dc.cli.ImageList(ctx, image.ListOptions{})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list Docker images")
+			return nil, fmt.Errorf("failed to list Docker images: %w", err)
+		}
+
+		var refs []string
+		for _, img := range images {
+			for _, ref := range img.RepoTags {
+				if ref != "<none>:<none>" {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		return refs, nil
+	}
+
+	output, err := dc.runDocker(ctx, "image", "ls", "--format", "{{json .}}")
+	if err != nil {
+		log.Error().Err(err).Str("output", output).Msg("Failed to list Docker images")
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+	return parseImageListOutput(output)
+}
+
+// RemoveImage removes the image reference (tag or ID) from the Docker host.
+func (dc *DockerClient) RemoveImage(ctx context.Context, ref string) error {
+	if !dc.isRemote() && dc.cli != nil {
+		if _, err := dc.cli.ImageRemove(ctx, ref, image.RemoveOptions{PruneChildren: true}); err != nil {
+			return fmt.Errorf("failed to remove image %s: %w", ref, err)
+		}
+		return nil
+	}
+
+	if output, err := dc.runDocker(ctx, "image", "rm", ref); err != nil {
+		return fmt.Errorf("failed to remove image %s: %w, output: %s", ref, err, output)
+	}
+	return nil
+}
+
+// imageListLine mirrors the fields of `docker image ls --format '{{json .}}'` used by ListImages.
+type imageListLine struct {
+	Repository string `json:"Repository"`
+	Tag        string `json:"Tag"`
+}
+
+// parseImageListOutput parses the line-delimited JSON output of `docker image ls --format '{{json .}}'`.
+func parseImageListOutput(output string) ([]string, error) {
+	var refs []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var entry imageListLine
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse docker image ls output %q: %w", line, err)
+		}
+		if entry.Repository == "<none>" || entry.Tag == "<none>" {
+			continue
+		}
+		refs = append(refs, entry.Repository+":"+entry.Tag)
+	}
+	return refs, nil
+}
@@ -0,0 +1,88 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/rs/zerolog/log"
+)
+
+// ImageDigests returns the image ID and the repository digests of an image on the Docker host,
+// all in the "sha256:<hex>" form. An image that does not exist yields no digests and no error.
+func (dc *DockerClient) ImageDigests(ctx context.Context, ref string) ([]string, error) {
+	if !dc.isRemote() && dc.cli != nil {
+		inspect, _, err := dc.cli.ImageInspectWithRaw(ctx, ref)
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+		}
+		return append([]string{inspect.ID}, repoDigests(inspect.RepoDigests)...), nil
+	}
+
+	output, err := dc.runDocker(ctx, "image", "inspect", "--format", `{{.Id}} {{join .RepoDigests " "}}`, ref)
+	if err != nil {
+		if strings.Contains(strings.ToLower(output), "no such image") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to inspect image %s: %w, output: %s", ref, err, output)
+	}
+
+	fields := strings.Fields(lastLine(output))
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return append([]string{fields[0]}, repoDigests(fields[1:])...), nil
+}
+
+// ImageExists reports whether the Docker host has the image ref. A missing image is not an error; failing to
+// inspect the image, e.g. because Docker is not reachable, is.
+func (dc *DockerClient) ImageExists(ctx context.Context, ref string) (bool, error) {
+	digests, err := dc.ImageDigests(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	return len(digests) > 0, nil
+}
+
+// ImageMatchesDigest reports whether the Docker host has the image ref with the given digest.
+// The digest may be the image ID, which `docker save`/`docker load` preserve, or a repository digest;
+// the "sha256:" prefix is optional.
+func (dc *DockerClient) ImageMatchesDigest(ctx context.Context, ref, digest string) (bool, error) {
+	digests, err := dc.ImageDigests(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+
+	want := normalizeDigest(digest)
+	for _, candidate := range digests {
+		if normalizeDigest(candidate) == want {
+			log.Debug().Str("image", ref).Str("digest", digest).Msg("Image with matching digest found")
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// repoDigests strips the repository from "repo@sha256:<hex>" references.
+func repoDigests(refs []string) []string {
+	digests := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if i := strings.LastIndex(ref, "@"); i >= 0 {
+			digests = append(digests, ref[i+1:])
+		}
+	}
+	return digests
+}
+
+// normalizeDigest returns a digest in the "sha256:<hex>" form.
+func normalizeDigest(digest string) string {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if digest != "" && !strings.Contains(digest, ":") {
+		digest = "sha256:" + digest
+	}
+	return digest
+}
@@ -0,0 +1,179 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ImageInspect is the metadata of an image recorded for audits: what the image is, how it starts and which
+// layers it consists of. Labels carry the build metadata embedded in the image.
+type ImageInspect struct {
+	// Image is the reference the image was inspected with.
+	Image        string            `json:"image"`
+	ID           string            `json:"id"`
+	RepoTags     []string          `json:"repo_tags,omitempty"`
+	RepoDigests  []string          `json:"repo_digests,omitempty"`
+	Created      string            `json:"created,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+	Os           string            `json:"os,omitempty"`
+	Size         int64             `json:"size"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Env          []string          `json:"env,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty"`
+	Cmd          []string          `json:"cmd,omitempty"`
+	User         string            `json:"user,omitempty"`
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	// ExposedPorts are sorted, e.g. ["443/tcp", "6901/tcp"].
+	ExposedPorts []string `json:"exposed_ports,omitempty"`
+	// Layers are the digests of the filesystem layers, from the base layer up.
+	Layers []string `json:"layers,omitempty"`
+}
+
+// dockerImageInspect is the part of the docker image inspect output read by ParseImageInspect.
+type dockerImageInspect struct {
+	ID           string   `json:"Id"`
+	RepoTags     []string `json:"RepoTags"`
+	RepoDigests  []string `json:"RepoDigests"`
+	Created      string   `json:"Created"`
+	Architecture string   `json:"Architecture"`
+	Os           string   `json:"Os"`
+	Size         int64    `json:"Size"`
+	Config       *struct {
+		Labels       map[string]string   `json:"Labels"`
+		Env          []string            `json:"Env"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		User         string              `json:"User"`
+		WorkingDir   string              `json:"WorkingDir"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	} `json:"Config"`
+	RootFS struct {
+		Layers []string `json:"Layers"`
+	} `json:"RootFS"`
+}
+
+// InspectImage returns the metadata of an image on the Docker host, parsed from docker image inspect.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageTag: The image reference or ID.
+// Returns:
+// - The metadata of the image.
+// - An error if the image does not exist or cannot be inspected.
+func (dc *DockerClient) InspectImage(ctx context.Context, imageTag string) (*ImageInspect, error) {
+	var raw []byte
+	if !dc.isRemote() && dc.cli != nil {
+		_, body, err := dc.cli.ImageInspectWithRaw(ctx, imageTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", imageTag, err)
+		}
+		raw = body
+	} else {
+		output, err := dc.runDocker(ctx, "image", "inspect", imageTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w, output: %s", imageTag, err, output)
+		}
+		raw = []byte(output)
+	}
+
+	inspect, err := ParseImageInspect(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", imageTag, err)
+	}
+	inspect.Image = imageTag
+	return inspect, nil
+}
+
+// ParseImageInspect parses the output of docker image inspect for a single image, either the JSON array
+// printed by the CLI or the object returned by the Docker API.
+func ParseImageInspect(data []byte) (*ImageInspect, error) {
+	data = []byte(strings.TrimSpace(string(data)))
+	var images []dockerImageInspect
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &images); err != nil {
+			return nil, fmt.Errorf("failed to parse image inspect output: %w", err)
+		}
+	} else {
+		var image dockerImageInspect
+		if err := json.Unmarshal(data, &image); err != nil {
+			return nil, fmt.Errorf("failed to parse image inspect output: %w", err)
+		}
+		images = append(images, image)
+	}
+	if len(images) != 1 {
+		return nil, fmt.Errorf("expected the inspect output of one image, got %d", len(images))
+	}
+
+	image := images[0]
+	inspect := &ImageInspect{
+		ID:           image.ID,
+		RepoTags:     image.RepoTags,
+		RepoDigests:  image.RepoDigests,
+		Created:      image.Created,
+		Architecture: image.Architecture,
+		Os:           image.Os,
+		Size:         image.Size,
+		Layers:       image.RootFS.Layers,
+	}
+	if config := image.Config; config != nil {
+		inspect.Labels = config.Labels
+		inspect.Env = config.Env
+		inspect.Entrypoint = config.Entrypoint
+		inspect.Cmd = config.Cmd
+		inspect.User = config.User
+		inspect.WorkingDir = config.WorkingDir
+		for port := range config.ExposedPorts {
+			inspect.ExposedPorts = append(inspect.ExposedPorts, port)
+		}
+		sort.Strings(inspect.ExposedPorts)
+	}
+	return inspect, nil
+}
+
+// imageManifest is the content of a file written by GenerateImageManifest.
+type imageManifest struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	*ImageInspect
+}
+
+// GenerateImageManifest inspects an image and writes its metadata as JSON to a file named after the image in
+// outputDir, e.g. "kasm_core_1.16.0.json", so the deployed images can be reported to auditors.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageTag: The image reference or ID.
+// - outputDir: Directory receiving the manifest; it is created if missing.
+// Returns:
+// - The path of the written manifest.
+// - An error if the image cannot be inspected or the manifest cannot be written.
+func (dc *DockerClient) GenerateImageManifest(ctx context.Context, imageTag, outputDir string) (string, error) {
+	inspect, err := dc.InspectImage(ctx, imageTag)
+	if err != nil {
+		return "", err
+	}
+	return WriteImageManifest(inspect, outputDir)
+}
+
+// WriteImageManifest writes the metadata of an inspected image like GenerateImageManifest.
+func WriteImageManifest(inspect *ImageInspect, outputDir string) (string, error) {
+	data, err := json.MarshalIndent(imageManifest{GeneratedAt: time.Now().UTC(), ImageInspect: inspect}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest of image %s: %w", inspect.Image, err)
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create manifest directory %s: %w", outputDir, err)
+	}
+	manifestPath := filepath.Join(outputDir, sanitizeImageTag(inspect.Image)+".json")
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write manifest of image %s: %w", inspect.Image, err)
+	}
+
+	log.Info().Str("image", inspect.Image).Str("manifest", manifestPath).Msg("Image manifest written")
+	return manifestPath, nil
+}
@@ -0,0 +1,73 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TagImage adds the tag target to the image source on the Docker host, like docker tag.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - source: The existing image reference or ID.
+// - target: The new reference, e.g. "registry.internal:5000/kasm/core:1.16.0".
+// Returns:
+// - An error if either reference is empty or tagging fails.
+func (dc *DockerClient) TagImage(ctx context.Context, source, target string) error {
+	if source == "" || target == "" {
+		return fmt.Errorf("source and target image must be set to tag an image")
+	}
+	log.Info().Str("source", source).Str("target", target).Msg("Tagging Docker image")
+
+	if !dc.isRemote() && dc.cli != nil {
+		if err := dc.cli.ImageTag(ctx, source, target); err != nil {
+			return fmt.Errorf("failed to tag image %s as %s: %w", source, target, err)
+		}
+	} else if output, err := dc.runDocker(ctx, "tag", source, target); err != nil {
+		return fmt.Errorf("failed to tag image %s as %s: %w, output: %s", source, target, err, output)
+	}
+
+	log.Info().Str("source", source).Str("target", target).Msg("Docker image tagged successfully")
+	return nil
+}
+
+// RetagForRegistry tags image for registry so it can be pushed there, replacing the registry host of image
+// if it names one. "kasm/core:1.16.0" becomes "registry.internal:5000/kasm/core:1.16.0".
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - image: The local image reference.
+// - registry: The registry host with an optional namespace, e.g. "registry.internal:5000" or "nexus.internal/kasm".
+// Returns:
+// - The new reference.
+// - An error if the registry is empty or tagging fails.
+func (dc *DockerClient) RetagForRegistry(ctx context.Context, image, registry string) (string, error) {
+	newTag, err := RegistryReference(image, registry)
+	if err != nil {
+		return "", err
+	}
+	if err := dc.TagImage(ctx, image, newTag); err != nil {
+		return "", err
+	}
+	return newTag, nil
+}
+
+// RegistryReference returns the reference of image in registry. The registry host of image is replaced if it
+// names one; a scheme or trailing slash of registry, e.g. from a docker_registry URL, is ignored.
+func RegistryReference(image, registry string) (string, error) {
+	registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+	if registry == "" {
+		return "", fmt.Errorf("registry must be set to retag image %s", image)
+	}
+	if image == "" {
+		return "", fmt.Errorf("image must be set to retag it for registry %s", registry)
+	}
+
+	// The path of SplitImageReference is a suffix of image only if image names a registry host.
+	repository := image
+	if _, path := SplitImageReference(image); strings.HasSuffix(image, "/"+path) {
+		repository = path
+	}
+	return registry + "/" + repository, nil
+}
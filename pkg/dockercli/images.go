@@ -25,32 +25,6 @@ func PullImage(ctx context.Context, retries int, imageName string) error {
 	return nil
 }
 
-// RemoveImage removes a Docker image by name or ID with retry mechanism.
-func RemoveImage(ctx context.Context, retries int, imageName string) error {
-	log.Info().Str("image_name", imageName).Msg("Removing Docker image")
-	output, err := executeDockerCommand(ctx, retries, "docker", "rmi", imageName)
-	if err != nil {
-		log.Error().Err(err).Str("output", string(output)).Str("image_name", imageName).Msg("Failed to remove Docker image")
-		return fmt.Errorf("failed to remove image %s: %w", imageName, err)
-	}
-	log.Info().Str("image_name", imageName).Msg("Docker image removed successfully")
-	return nil
-}
-
-// ListImages lists all Docker images on the host with retry mechanism.
-func ListImages(ctx context.Context, retries int) ([]string, error) {
-	log.Info().Msg("Listing all Docker images")
-	output, err := executeDockerCommand(ctx, retries, "docker", "images", "--format", "{{.Repository}}:{{.Tag}}")
-	if err != nil {
-		log.Error().Err(err).Str("output", string(output)).Msg("Failed to list Docker images")
-		return nil, fmt.Errorf("failed to list Docker images: %w", err)
-	}
-
-	images := strings.Split(strings.TrimSpace(string(output)), "\n")
-	log.Info().Int("image_count", len(images)).Msg("Docker images listed successfully")
-	return images, nil
-}
-
 // GetImageIDByTag retrieves the Image ID for a given image tag.
 func GetImageIDByTag(ctx context.Context, retries int, imageTag string) (string, error) {
 	// Step 1: Inspect the Docker image to retrieve its ID
@@ -84,7 +58,7 @@ func ExportImageToTar(ctx context.Context, retries int, imageName, outputFile st
 	}
 
 	// Save the Docker image to a tar stream
-	imageReader, err := cli.ImageSave(context.Background(), []string{imageName})
+	imageReader, err := cli.ImageSave(ctx, []string{imageName})
 	if err != nil {
 		log.Error().Err(err).Str("image_name", imageName).Msg("Failed to save Docker image")
 		return "", fmt.Errorf("could not save Docker image: %w", err)
@@ -129,9 +103,71 @@ func ExportImageToTar(ctx context.Context, retries int, imageName, outputFile st
 	return outputFile, nil
 }
 
-// BuildDockerImage builds a Docker image from a Dockerfile with retry mechanism.
+// Builder selects the image builder used by docker build.
+type Builder string
+
+const (
+	// BuilderBuildKit builds with BuildKit, which Dockerfile features such as RUN --mount=type=cache need.
+	BuilderBuildKit Builder = "buildkit"
+	// BuilderClassic builds with the legacy builder, which ignores BuildKit-only Dockerfile features.
+	BuilderClassic Builder = "classic"
+)
+
+// BuildProgress is the progress output format of BuildKit builds.
+type BuildProgress string
+
+// Supported values of BuildSettings.Progress.
+const (
+	BuildProgressAuto  BuildProgress = "auto"
+	BuildProgressPlain BuildProgress = "plain"
+	BuildProgressTTY   BuildProgress = "tty"
+)
+
+// BuildSettings selects the builder and progress output of BuildDockerImageWithSettings.
+type BuildSettings struct {
+	// Builder defaults to BuilderBuildKit.
+	Builder Builder
+	// Progress defaults to BuildProgressPlain, which keeps the captured build output readable in logs.
+	// It is not supported by BuilderClassic.
+	Progress BuildProgress
+}
+
+// buildArgs validates the settings and returns the docker build flags and environment selecting the builder.
+func (s BuildSettings) buildArgs() ([]string, []string, error) {
+	switch s.Builder {
+	case "", BuilderBuildKit:
+		progress := s.Progress
+		switch progress {
+		case "":
+			progress = BuildProgressPlain
+		case BuildProgressAuto, BuildProgressPlain, BuildProgressTTY:
+		default:
+			return nil, nil, fmt.Errorf("unsupported build progress %q, expected auto, plain or tty", s.Progress)
+		}
+		return []string{"--progress", string(progress)}, []string{"DOCKER_BUILDKIT=1"}, nil
+	case BuilderClassic:
+		if s.Progress != "" {
+			return nil, nil, fmt.Errorf("build progress %q requires the buildkit builder", s.Progress)
+		}
+		return nil, []string{"DOCKER_BUILDKIT=0"}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported builder %q, expected buildkit or classic", s.Builder)
+	}
+}
+
+// BuildDockerImage builds a Docker image from a Dockerfile with retry mechanism, using BuildKit with plain progress.
 func BuildDockerImage(ctx context.Context, retries int, dockerfilePath, imageName string) error {
-	log.Info().Str("dockerfile_path", dockerfilePath).Str("image_name", imageName).Msg("Building Docker image")
+	return BuildDockerImageWithSettings(ctx, retries, dockerfilePath, imageName, BuildSettings{})
+}
+
+// BuildDockerImageWithSettings builds a Docker image from a Dockerfile with retry mechanism and the builder
+// and progress format of settings.
+func BuildDockerImageWithSettings(ctx context.Context, retries int, dockerfilePath, imageName string, settings BuildSettings) error {
+	builderArgs, builderEnv, err := settings.buildArgs()
+	if err != nil {
+		return err
+	}
+	log.Info().Str("dockerfile_path", dockerfilePath).Str("image_name", imageName).Strs("builder_env", builderEnv).Msg("Building Docker image")
 
 	// Ensure the Dockerfile exists
 	if _, err := os.Stat(dockerfilePath); errors.Is(err, os.ErrNotExist) {
@@ -143,7 +179,9 @@ func BuildDockerImage(ctx context.Context, retries int, dockerfilePath, imageNam
 	buildContext := filepath.Dir(dockerfilePath)
 
 	// Execute the Docker build command with retries
-	output, err := executeDockerCommand(ctx, retries, "docker", "build", "-t", imageName, "-f", dockerfilePath, buildContext)
+	args := append([]string{"build"}, builderArgs...)
+	args = append(args, "-t", imageName, "-f", dockerfilePath, buildContext)
+	output, err := executeDockerCommandWithEnv(ctx, retries, builderEnv, "docker", args...)
 	if err != nil {
 		log.Error().Err(err).Str("output", string(output)).Str("image_name", imageName).Msg("Failed to build Docker image")
 		return fmt.Errorf("failed to build Docker image %s: %w", imageName, err)
@@ -0,0 +1,252 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NetworkOptions describes a Docker network to create.
+type NetworkOptions struct {
+	Name       string
+	Driver     string            // Network driver, defaults to "bridge" when empty.
+	Subnet     string            // Subnet in CIDR notation, e.g. "172.28.0.0/16".
+	Gateway    string            // IPv4 or IPv6 gateway inside Subnet.
+	IPRange    string            // Range in CIDR notation inside Subnet from which container IPs are allocated.
+	Internal   bool              // Restrict external access to the network.
+	Attachable bool              // Allow standalone containers to attach to the network.
+	Labels     map[string]string // Metadata labels for the network.
+}
+
+// Validate checks the network name and the CIDR/IP formats of the IPAM settings.
+func (o NetworkOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("network name cannot be empty")
+	}
+
+	var subnet *net.IPNet
+	if o.Subnet != "" {
+		_, parsed, err := net.ParseCIDR(o.Subnet)
+		if err != nil {
+			return fmt.Errorf("invalid subnet %q: %w", o.Subnet, err)
+		}
+		subnet = parsed
+	}
+
+	if o.Gateway != "" {
+		gateway := net.ParseIP(o.Gateway)
+		if gateway == nil {
+			return fmt.Errorf("invalid gateway %q: not an IP address", o.Gateway)
+		}
+		if subnet == nil {
+			return fmt.Errorf("gateway %q requires a subnet", o.Gateway)
+		}
+		if !subnet.Contains(gateway) {
+			return fmt.Errorf("gateway %q is not inside subnet %q", o.Gateway, o.Subnet)
+		}
+	}
+
+	if o.IPRange != "" {
+		rangeIP, ipRange, err := net.ParseCIDR(o.IPRange)
+		if err != nil {
+			return fmt.Errorf("invalid ip range %q: %w", o.IPRange, err)
+		}
+		if subnet == nil {
+			return fmt.Errorf("ip range %q requires a subnet", o.IPRange)
+		}
+		rangeOnes, _ := ipRange.Mask.Size()
+		subnetOnes, _ := subnet.Mask.Size()
+		if !subnet.Contains(rangeIP) || rangeOnes < subnetOnes {
+			return fmt.Errorf("ip range %q is not inside subnet %q", o.IPRange, o.Subnet)
+		}
+	}
+
+	return nil
+}
+
+// args returns the docker CLI arguments for `docker network create`.
+func (o NetworkOptions) args() []string {
+	driver := o.Driver
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	args := []string{"network", "create", "--driver", driver}
+	if o.Subnet != "" {
+		args = append(args, "--subnet", o.Subnet)
+	}
+	if o.Gateway != "" {
+		args = append(args, "--gateway", o.Gateway)
+	}
+	if o.IPRange != "" {
+		args = append(args, "--ip-range", o.IPRange)
+	}
+	if o.Internal {
+		args = append(args, "--internal")
+	}
+	if o.Attachable {
+		args = append(args, "--attachable")
+	}
+	args = append(args, labelArgs(o.Labels)...)
+	return append(args, o.Name)
+}
+
+// labelArgs converts labels to sorted --label arguments so generated commands are deterministic.
+func labelArgs(labels map[string]string) []string {
+	args := make([]string, 0, len(labels)*2)
+	for _, key := range sortedKeys(labels) {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+	return args
+}
+
+// CreateDockerNetwork creates a Docker network with the given options.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - opts: Network name, driver and IPAM settings.
+// Returns:
+// - The ID of the created network.
+// - An error if the options are invalid or the network cannot be created.
+func (dc *DockerClient) CreateDockerNetwork(ctx context.Context, opts NetworkOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("network", opts.Name).
+		Str("driver", opts.Driver).
+		Str("subnet", opts.Subnet).
+		Str("gateway", opts.Gateway).
+		Msg("Creating Docker network")
+
+	output, err := dc.runDocker(ctx, opts.args()...)
+	if err != nil {
+		log.Error().Err(err).Str("network", opts.Name).Str("output", output).Msg("Failed to create Docker network")
+		return "", fmt.Errorf("failed to create network %s: %w", opts.Name, err)
+	}
+
+	networkID := lastLine(output)
+	log.Info().Str("network", opts.Name).Str("network_id", networkID).Msg("Docker network created successfully")
+	return networkID, nil
+}
+
+// NetworkExists reports whether a network with exactly the given name exists on the Docker host.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - name: Name of the network.
+// Returns:
+// - True if the network exists.
+// - An error if the networks cannot be listed.
+func (dc *DockerClient) NetworkExists(ctx context.Context, name string) (bool, error) {
+	if name == "" {
+		return false, fmt.Errorf("network name cannot be empty")
+	}
+
+	// The name filter matches substrings, so the listed names are compared exactly.
+	output, err := dc.runDocker(ctx, "network", "ls", "--filter", "name="+name, "--format", "{{.Name}}")
+	if err != nil {
+		log.Error().Err(err).Str("network", name).Str("output", output).Msg("Failed to list Docker networks")
+		return false, fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureNetwork creates a network unless a network with the same name already exists.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - opts: The network to create; only the name is compared with existing networks.
+// Returns:
+// - True if the network was created, false if it already existed.
+// - An error if the networks cannot be listed or the network cannot be created.
+func (dc *DockerClient) EnsureNetwork(ctx context.Context, opts NetworkOptions) (bool, error) {
+	exists, err := dc.NetworkExists(ctx, opts.Name)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		log.Info().Str("network", opts.Name).Msg("Docker network already exists")
+		return false, nil
+	}
+	if _, err := dc.CreateDockerNetwork(ctx, opts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ConnectContainerToNetwork attaches a running container to a network.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - containerID: ID or name of the container.
+// - networkName: Name or ID of the network to join.
+// - aliases: Optional DNS aliases for the container on that network.
+// - ipAddress: Optional static IPv4 address; requires a network created with a subnet.
+// Returns:
+// - An error if the address is invalid or the container cannot be connected.
+func (dc *DockerClient) ConnectContainerToNetwork(ctx context.Context, containerID, networkName string, aliases []string, ipAddress string) error {
+	if containerID == "" || networkName == "" {
+		return fmt.Errorf("container ID and network name cannot be empty")
+	}
+
+	args := []string{"network", "connect"}
+	for _, alias := range aliases {
+		args = append(args, "--alias", alias)
+	}
+	if ipAddress != "" {
+		ip := net.ParseIP(ipAddress)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address %q", ipAddress)
+		}
+		if ip.To4() != nil {
+			args = append(args, "--ip", ipAddress)
+		} else {
+			args = append(args, "--ip6", ipAddress)
+		}
+	}
+	args = append(args, networkName, containerID)
+
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Str("network", networkName).Str("output", output).Msg("Failed to connect container to network")
+		return fmt.Errorf("failed to connect container %s to network %s: %w", containerID, networkName, err)
+	}
+
+	log.Info().Str("container_id", containerID).Str("network", networkName).Strs("aliases", aliases).Str("ip", ipAddress).Msg("Container connected to network")
+	return nil
+}
+
+// DisconnectContainerFromNetwork detaches a container from a network.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - containerID: ID or name of the container.
+// - networkName: Name or ID of the network to leave.
+// - force: Force the disconnect, e.g. for stopped containers.
+// Returns:
+// - An error if the container cannot be disconnected.
+func (dc *DockerClient) DisconnectContainerFromNetwork(ctx context.Context, containerID, networkName string, force bool) error {
+	if containerID == "" || networkName == "" {
+		return fmt.Errorf("container ID and network name cannot be empty")
+	}
+
+	args := []string{"network", "disconnect"}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, networkName, containerID)
+
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Str("network", networkName).Str("output", output).Msg("Failed to disconnect container from network")
+		return fmt.Errorf("failed to disconnect container %s from network %s: %w", containerID, networkName, err)
+	}
+
+	log.Info().Str("container_id", containerID).Str("network", networkName).Msg("Container disconnected from network")
+	return nil
+}
@@ -0,0 +1,152 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/rs/zerolog/log"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/webApi"
+)
+
+// RegistryAuth holds the credentials for a private registry.
+type RegistryAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// RegistryAuthFromTargetImage returns the registry credentials of a Kasm workspace image definition.
+// Returns false if the image carries no DockerUser.
+func RegistryAuthFromTargetImage(target webApi.TargetImage) (RegistryAuth, bool) {
+	if target.DockerUser == "" {
+		return RegistryAuth{}, false
+	}
+	return RegistryAuth{Username: target.DockerUser, Password: target.DockerToken}, true
+}
+
+// RegistryLogin authenticates the Docker host against a registry.
+// With the Docker SDK the credentials are only verified, as the daemon does not store them; SDK pushes and pulls
+// pass them with each request instead. With the docker CLI (local or remote) the password is written to the stdin
+// of `docker login --password-stdin`, so it never appears in a command line, the process list or the logs, and
+// the login is stored by the CLI. Remote logins need an executor implementing StdinCommandExecutor.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - registryHost: The registry host, e.g. "nexus.internal:8443". Empty means Docker Hub.
+// - user: The registry user.
+// - password: The registry password or access token.
+// Returns:
+// - An error if the login fails.
+func (dc *DockerClient) RegistryLogin(ctx context.Context, registryHost, user, password string) error {
+	if user == "" {
+		return fmt.Errorf("registry user cannot be empty")
+	}
+	if registryHost == "" {
+		registryHost = DefaultRegistry
+	}
+
+	if !dc.isRemote() && dc.cli != nil {
+		if _, err := dc.cli.RegistryLogin(ctx, registry.AuthConfig{
+			Username:      user,
+			Password:      password,
+			ServerAddress: registryHost,
+		}); err != nil {
+			return fmt.Errorf("failed to log in to registry %s: %w", registryHost, err)
+		}
+	} else {
+		args := []string{"login", "--username", user, "--password-stdin", registryHost}
+
+		var (
+			output []byte
+			err    error
+		)
+		if dc.isRemote() {
+			executor, ok := dc.executor.(StdinCommandExecutor)
+			if !ok {
+				return fmt.Errorf("failed to log in to registry %s: the executor cannot pass the password on stdin", registryHost)
+			}
+			var remoteOutput string
+			remoteOutput, err = executor.ExecuteCommandWithStdin(ctx, "docker "+shadowssh.ShellJoin(args...), strings.NewReader(password))
+			output = []byte(remoteOutput)
+		} else {
+			cmd := exec.CommandContext(ctx, "docker", args...)
+			cmd.Stdin = strings.NewReader(password)
+			output, err = cmd.CombinedOutput()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to log in to registry %s: %w, output: %s", registryHost, err, output)
+		}
+	}
+
+	log.Info().Str("registry", registryHost).Str("username", user).Msg("Logged in to registry")
+	return nil
+}
+
+// PushImage pushes ref to its registry, authenticating first if credentials are given.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - ref: The image reference to push, including the registry host for private registries.
+// - auth: Optional registry credentials; nil relies on an existing login.
+// Returns:
+// - An error if logging in or pushing fails.
+func (dc *DockerClient) PushImage(ctx context.Context, ref string, auth *RegistryAuth) error {
+	log.Info().Str("image", ref).Msg("Pushing Docker image")
+
+	if !dc.isRemote() && dc.cli != nil {
+		options := image.PushOptions{}
+		if auth != nil {
+			encoded, err := encodeRegistryAuth(ref, *auth)
+			if err != nil {
+				return err
+			}
+			options.RegistryAuth = encoded
+		}
+
+		reader, err := dc.cli.ImagePush(ctx, ref, options)
+		if err != nil {
+			return fmt.Errorf("failed to push image %s: %w", ref, err)
+		}
+		defer reader.Close()
+		if err := drainJSONMessages(reader); err != nil {
+			return fmt.Errorf("failed to push image %s: %w", ref, err)
+		}
+	} else {
+		if auth != nil {
+			host, _ := SplitImageReference(ref)
+			if err := dc.RegistryLogin(ctx, host, auth.Username, auth.Password); err != nil {
+				return err
+			}
+		}
+		if output, err := dc.runDocker(ctx, "push", ref); err != nil {
+			return fmt.Errorf("failed to push image %s: %w, output: %s", ref, err, output)
+		}
+	}
+
+	log.Info().Str("image", ref).Msg("Docker image pushed successfully")
+	return nil
+}
+
+// encodeRegistryAuth encodes credentials for the registry hosting ref as an X-Registry-Auth header value.
+func encodeRegistryAuth(ref string, auth RegistryAuth) (string, error) {
+	host, _ := SplitImageReference(ref)
+	encoded, err := registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		ServerAddress: host,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credentials for %s: %w", host, err)
+	}
+	return encoded, nil
+}
+
+// drainJSONMessages consumes a pull or push progress stream and returns the first error reported in it.
+// The daemon reports failures such as denied pushes inside the stream rather than as an HTTP error.
+func drainJSONMessages(stream io.Reader) error {
+	return jsonmessage.DisplayJSONMessagesStream(stream, io.Discard, 0, false, nil)
+}
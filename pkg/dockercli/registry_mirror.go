@@ -0,0 +1,241 @@
+package dockercli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRegistry is the registry used for image references without a registry host.
+const DefaultRegistry = "docker.io"
+
+// PullConfig routes image pulls through registry mirrors and supplies registry credentials.
+//
+// Example file:
+//
+//	mirrors:
+//	  docker.io: nexus.internal:8443/dockerhub
+//	  ghcr.io: nexus.internal:8443/ghcr
+//	auths:
+//	  nexus.internal:8443:
+//	    username: deploy
+//	    password: secret
+type PullConfig struct {
+	// Mirrors maps a source registry host to the prefix that replaces it, e.g.
+	// "docker.io" -> "mirror.internal" rewrites docker.io/library/alpine to mirror.internal/library/alpine.
+	Mirrors map[string]string `yaml:"mirrors"`
+	// Auths maps a registry host to its credentials. Lookups use the host of the rewritten reference.
+	Auths map[string]RegistryAuth `yaml:"auths"`
+}
+
+// LoadPullConfig reads a PullConfig from a YAML file.
+// Parameters:
+// - path: Path to the YAML file.
+// Returns:
+// - The parsed configuration.
+// - An error if the file cannot be read or parsed.
+func LoadPullConfig(path string) (*PullConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pull configuration %s: %w", path, err)
+	}
+
+	var config PullConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse pull configuration %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// SplitImageReference splits an image reference into its registry host and repository path,
+// following the Docker rules: the first path component is a host only if it contains a "." or ":"
+// or is "localhost"; otherwise the image lives on Docker Hub, where official images are under "library/".
+func SplitImageReference(ref string) (string, string) {
+	host, remainder, found := strings.Cut(ref, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		if host == "index.docker.io" || host == "registry-1.docker.io" {
+			host = DefaultRegistry
+		}
+		return host, remainder
+	}
+	if !found {
+		return DefaultRegistry, "library/" + ref
+	}
+	return DefaultRegistry, ref
+}
+
+// Rewrite returns the reference to pull instead of ref, or ref itself if no mirror applies.
+func (c *PullConfig) Rewrite(ref string) string {
+	if c == nil || len(c.Mirrors) == 0 {
+		return ref
+	}
+
+	host, path := SplitImageReference(ref)
+	mirror, ok := c.Mirrors[host]
+	if !ok || mirror == "" {
+		return ref
+	}
+	return strings.TrimSuffix(mirror, "/") + "/" + path
+}
+
+// AuthFor returns the credentials for the registry hosting ref.
+func (c *PullConfig) AuthFor(ref string) (RegistryAuth, bool) {
+	if c == nil {
+		return RegistryAuth{}, false
+	}
+	host, _ := SplitImageReference(ref)
+	auth, ok := c.Auths[host]
+	return auth, ok
+}
+
+// PullImage pulls ref on the Docker host, routing the pull through the configured mirror and logging in
+// to the registry if credentials are configured. When a mirror is used, the pulled image is tagged with the
+// original reference so compose files and workspaces can keep referring to it. A nil config pulls directly.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - ref: The image reference to pull, e.g. "kasmweb/core-ubuntu-focal:1.16.0".
+// - config: Optional mirror and credential configuration.
+// Returns:
+// - The reference that was actually pulled.
+// - An error if logging in, pulling or tagging fails.
+func (dc *DockerClient) PullImage(ctx context.Context, ref string, config *PullConfig) (string, error) {
+	return dc.PullImageWithProgress(ctx, ref, config, nil)
+}
+
+// PullImageWithProgress pulls ref like PullImage and reports the pull as BuildEvents: started, a progress
+// event per layer download and extract update, a log-line event per status message and succeeded or failed.
+// Remote pulls only report the output lines of docker pull once it finished.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - ref: The image reference to pull; it is the ImageTag of the events.
+// - config: Optional mirror and credential configuration.
+// - progress: Receives the events on the pulling goroutine; nil discards them.
+// Returns:
+// - The reference that was actually pulled.
+// - An error if logging in, pulling or tagging fails.
+func (dc *DockerClient) PullImageWithProgress(ctx context.Context, ref string, config *PullConfig, progress func(BuildEvent)) (string, error) {
+	emit := func(event BuildEvent) {
+		if progress != nil {
+			event.ImageTag = ref
+			event.Time = time.Now()
+			progress(event)
+		}
+	}
+
+	start := time.Now()
+	emit(BuildEvent{Type: BuildEventStarted})
+	pullRef, err := dc.pullImage(ctx, ref, config, emit)
+	if err != nil {
+		emit(BuildEvent{Type: BuildEventFailed, Err: err, Duration: time.Since(start)})
+		return "", err
+	}
+	emit(BuildEvent{Type: BuildEventSucceeded, Duration: time.Since(start)})
+	return pullRef, nil
+}
+
+// pullImage performs the pull of PullImageWithProgress, passing log-line and progress events to emit.
+func (dc *DockerClient) pullImage(ctx context.Context, ref string, config *PullConfig, emit func(BuildEvent)) (string, error) {
+	pullRef := config.Rewrite(ref)
+	auth, hasAuth := config.AuthFor(pullRef)
+
+	log.Info().Str("image", ref).Str("pull_reference", pullRef).Msg("Pulling Docker image")
+
+	if !dc.isRemote() && dc.cli != nil {
+		options := image.PullOptions{}
+		if hasAuth {
+			encoded, err := encodeRegistryAuth(pullRef, auth)
+			if err != nil {
+				return "", err
+			}
+			options.RegistryAuth = encoded
+		}
+
+		reader, err := dc.cli.ImagePull(ctx, pullRef, options)
+		if err != nil {
+			return "", fmt.Errorf("failed to pull image %s: %w", pullRef, err)
+		}
+		err = decodePullStream(reader, emit)
+		reader.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to pull image %s: %w", pullRef, err)
+		}
+	} else {
+		if hasAuth {
+			host, _ := SplitImageReference(pullRef)
+			if err := dc.RegistryLogin(ctx, host, auth.Username, auth.Password); err != nil {
+				return "", err
+			}
+		}
+
+		output, err := dc.runDocker(ctx, "pull", pullRef)
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				emit(BuildEvent{Type: BuildEventLogLine, Line: line})
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to pull image %s: %w, output: %s", pullRef, err, output)
+		}
+	}
+
+	if pullRef != ref {
+		if err := dc.TagImage(ctx, pullRef, ref); err != nil {
+			return "", err
+		}
+	}
+
+	log.Info().Str("image", ref).Str("pull_reference", pullRef).Msg("Docker image pulled successfully")
+	return pullRef, nil
+}
+
+// decodePullStream reads the JSON messages of an image pull, passing layer progress as progress events and
+// other status messages as log-line events to emit.
+// Returns:
+// - An error if the stream cannot be decoded or reports an error, e.g. an unknown manifest.
+func decodePullStream(stream io.Reader, emit func(BuildEvent)) error {
+	decoder := json.NewDecoder(stream)
+	for {
+		var message jsonmessage.JSONMessage
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode pull output: %w", err)
+		}
+		if message.Error != nil {
+			return message.Error
+		}
+		if message.ErrorMessage != "" {
+			return errors.New(message.ErrorMessage)
+		}
+
+		if message.Progress != nil && message.Progress.Total > 0 {
+			emit(BuildEvent{
+				Type:    BuildEventProgress,
+				Line:    message.Status,
+				Layer:   message.ID,
+				Current: message.Progress.Current,
+				Total:   message.Progress.Total,
+			})
+			continue
+		}
+		if message.Status == "" {
+			continue
+		}
+		line := message.Status
+		if message.ID != "" {
+			line = message.ID + ": " + message.Status
+		}
+		emit(BuildEvent{Type: BuildEventLogLine, Layer: message.ID, Line: line})
+	}
+}
@@ -0,0 +1,316 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/webApi"
+)
+
+// RunContainer creates and starts a detached container from a DockerRunConfig and returns its ID.
+// Memory/CPU limits, restart policy, volumes, mounts, ports and environment are honored; options that only
+// apply to attached runs (Stream, Stdout, Stderr, Detach) are ignored because the container always runs detached.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - config: The run configuration describing the container.
+// Returns:
+// - The ID of the started container.
+// - An error if the configuration is invalid or the container cannot be started.
+func (dc *DockerClient) RunContainer(ctx context.Context, config webApi.DockerRunConfig) (string, error) {
+	args, err := runContainerArgs(config)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("image", config.Image).
+		Str("name", config.Name).
+		Msg("Running Docker container")
+
+	output, err := dc.runDocker(ctx, args...)
+	if err != nil {
+		log.Error().Err(err).Str("image", config.Image).Str("output", output).Msg("Failed to run Docker container")
+		return "", fmt.Errorf("failed to run container from image %s: %w", config.Image, err)
+	}
+
+	containerID := lastLine(output)
+	log.Info().Str("image", config.Image).Str("container_id", containerID).Msg("Docker container started successfully")
+	return containerID, nil
+}
+
+// runContainerArgs maps a DockerRunConfig onto `docker run -d` arguments.
+func runContainerArgs(config webApi.DockerRunConfig) ([]string, error) {
+	if config.Image == "" {
+		return nil, fmt.Errorf("image cannot be empty")
+	}
+	if config.Network != "" && config.NetworkMode != "" {
+		return nil, fmt.Errorf("network and network_mode are mutually exclusive")
+	}
+
+	args := []string{"run", "--detach"}
+	addString := func(flag, value string) {
+		if value != "" {
+			args = append(args, flag, value)
+		}
+	}
+	addInt := func(flag string, value int) {
+		if value != 0 {
+			args = append(args, flag, strconv.Itoa(value))
+		}
+	}
+	addBool := func(flag string, value bool) {
+		if value {
+			args = append(args, flag)
+		}
+	}
+	addEach := func(flag string, values []string) {
+		for _, value := range values {
+			args = append(args, flag, value)
+		}
+	}
+
+	// Basic config
+	addString("--name", config.Name)
+	addString("--workdir", config.WorkingDir)
+	addString("--user", config.User)
+	addString("--hostname", config.Hostname)
+	addString("--domainname", config.Domainname)
+	addString("--platform", config.Platform)
+	args = append(args, labelArgs(config.Labels)...)
+
+	// The docker CLI only accepts the executable as --entrypoint; remaining entries are prepended to the command.
+	command := config.Command
+	if len(config.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", config.Entrypoint[0])
+		command = append(append([]string{}, config.Entrypoint[1:]...), command...)
+	}
+
+	// Networking
+	addString("--network", config.Network)
+	addString("--network", config.NetworkMode)
+	if config.NetworkDisabled {
+		args = append(args, "--network", "none")
+	}
+	addEach("--dns", config.DNS)
+	addEach("--dns-option", config.DNSOpt)
+	addEach("--dns-search", config.DNSSearch)
+	for _, host := range sortedKeys(config.ExtraHosts) {
+		args = append(args, "--add-host", host+":"+config.ExtraHosts[host])
+	}
+	addBool("--publish-all", config.PublishAllPorts)
+	ports, err := portArgs(config.Ports)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, ports...)
+
+	// Resources & Limits
+	addInt("--cpu-shares", config.CPUShares)
+	addInt("--cpu-period", config.CPUPeriod)
+	addInt("--cpu-quota", config.CPUQuota)
+	addInt("--cpu-rt-period", config.CPURtPeriod)
+	addInt("--cpu-rt-runtime", config.CPURtRuntime)
+	addString("--cpuset-cpus", config.CPUSetCpus)
+	addString("--cpuset-mems", config.CPUSetMems)
+	addInt("--cpu-count", config.CPUCount)
+	addInt("--cpu-percent", config.CPUPercent)
+	addString("--memory", config.MemLimit)
+	addString("--memory-reservation", config.MemReservation)
+	addString("--memory-swap", config.MemswapLimit)
+	addInt("--memory-swappiness", config.MemSwappiness)
+	addInt("--pids-limit", config.PidsLimit)
+	for _, ulimit := range config.Ulimits {
+		args = append(args, "--ulimit", fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+
+	// Mounts & Volumes
+	for _, hostPath := range sortedKeys(config.Volumes) {
+		mapping := config.Volumes[hostPath]
+		if mapping.Bind == "" {
+			return nil, fmt.Errorf("volume %s has no bind path", hostPath)
+		}
+		volume := hostPath + ":" + mapping.Bind
+		if mapping.Mode != "" {
+			volume += ":" + mapping.Mode
+		}
+		args = append(args, "--volume", volume)
+	}
+	addEach("--volumes-from", config.VolumesFrom)
+	addString("--volume-driver", config.VolumeDriver)
+	for _, mount := range config.Mounts {
+		args = append(args, "--mount", mountArg(mount))
+	}
+	for _, path := range sortedKeys(config.Tmpfs) {
+		tmpfs := path
+		if opts := config.Tmpfs[path]; opts != "" {
+			tmpfs += ":" + opts
+		}
+		args = append(args, "--tmpfs", tmpfs)
+	}
+
+	// Devices & Caps
+	addEach("--device", config.Devices)
+	addEach("--device-cgroup-rule", config.DeviceCgroupRules)
+	for _, request := range config.DeviceRequests {
+		args = append(args, "--gpus", gpuArg(request))
+	}
+	addEach("--cap-add", config.CapAdd)
+	addEach("--cap-drop", config.CapDrop)
+
+	// Security
+	addEach("--security-opt", config.SecurityOpt)
+	addBool("--privileged", config.Privileged)
+	addString("--userns", config.UsernsMode)
+	addString("--ipc", config.IpcMode)
+	addString("--pid", config.PidMode)
+	addString("--uts", config.UtsMode)
+	addString("--isolation", config.Isolation)
+	addString("--shm-size", config.ShmSize)
+	for _, key := range sortedKeys(config.Sysctls) {
+		args = append(args, "--sysctl", key+"="+config.Sysctls[key])
+	}
+	addEach("--group-add", config.GroupAdd)
+
+	// Environment
+	for _, key := range sortedKeys(config.Environment) {
+		args = append(args, "--env", key+"="+config.Environment[key])
+	}
+
+	// Healthcheck
+	if hc := config.Healthcheck; hc != nil {
+		if len(hc.Test) > 0 {
+			test := hc.Test
+			if test[0] == "CMD" || test[0] == "CMD-SHELL" {
+				test = test[1:]
+			}
+			args = append(args, "--health-cmd", strings.Join(test, " "))
+		}
+		if hc.Interval > 0 {
+			args = append(args, "--health-interval", time.Duration(hc.Interval).String())
+		}
+		if hc.Timeout > 0 {
+			args = append(args, "--health-timeout", time.Duration(hc.Timeout).String())
+		}
+		if hc.StartPeriod > 0 {
+			args = append(args, "--health-start-period", time.Duration(hc.StartPeriod).String())
+		}
+		addInt("--health-retries", hc.Retries)
+	}
+
+	// Other runtime configs
+	addString("--cgroup-parent", config.CgroupParent)
+	addString("--cgroupns", config.Cgroupns)
+	addBool("--rm", config.AutoRemove || config.Remove)
+	addBool("--interactive", config.StdinOpen)
+	addBool("--tty", config.Tty)
+	addString("--runtime", config.Runtime)
+	for _, key := range sortedKeys(config.StorageOpt) {
+		args = append(args, "--storage-opt", key+"="+config.StorageOpt[key])
+	}
+	addBool("--init", config.Init)
+	if policy := config.RestartPolicy; policy != nil {
+		restart, err := restartPolicyArg(*policy)
+		if err != nil {
+			return nil, err
+		}
+		addString("--restart", restart)
+	}
+
+	args = append(args, config.Image)
+	return append(args, command...), nil
+}
+
+// restartPolicyArg converts a RestartPolicy into the value of the docker run --restart flag.
+func restartPolicyArg(policy webApi.RestartPolicy) (string, error) {
+	switch policy.Condition {
+	case "", "none", "no":
+		return "no", nil
+	case "any", "always":
+		return "always", nil
+	case "unless-stopped":
+		return "unless-stopped", nil
+	case "on-failure":
+		if policy.MaximumRetryCount > 0 {
+			return fmt.Sprintf("on-failure:%d", policy.MaximumRetryCount), nil
+		}
+		return "on-failure", nil
+	default:
+		return "", fmt.Errorf("unsupported restart policy condition %q", policy.Condition)
+	}
+}
+
+// portArgs converts the ports mapping into --publish arguments.
+// Values may be nil (random host port), a host port number, a "host_ip:host_port" string or a list of such values.
+func portArgs(ports map[string]interface{}) ([]string, error) {
+	var args []string
+	for _, containerPort := range sortedKeys(ports) {
+		values, ok := ports[containerPort].([]interface{})
+		if !ok {
+			values = []interface{}{ports[containerPort]}
+		}
+		for _, value := range values {
+			switch v := value.(type) {
+			case nil:
+				args = append(args, "--publish", containerPort)
+			case int:
+				args = append(args, "--publish", fmt.Sprintf("%d:%s", v, containerPort))
+			case float64:
+				args = append(args, "--publish", fmt.Sprintf("%d:%s", int(v), containerPort))
+			case string:
+				args = append(args, "--publish", v+":"+containerPort)
+			default:
+				return nil, fmt.Errorf("unsupported host port %v for container port %s", value, containerPort)
+			}
+		}
+	}
+	return args, nil
+}
+
+// mountArg converts a MountConfig into the value of the docker run --mount flag.
+func mountArg(mount webApi.MountConfig) string {
+	mountType := mount.Type
+	if mountType == "" {
+		mountType = "volume"
+	}
+	parts := []string{"type=" + mountType}
+	if mount.Source != "" {
+		parts = append(parts, "source="+mount.Source)
+	}
+	parts = append(parts, "target="+mount.Target)
+	if mount.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	if mount.Propagation != "" {
+		parts = append(parts, "bind-propagation="+mount.Propagation)
+	}
+	if mount.NoCopy {
+		parts = append(parts, "volume-nocopy")
+	}
+	return strings.Join(parts, ",")
+}
+
+// gpuArg converts a DeviceRequest into the value of the docker run --gpus flag.
+func gpuArg(request webApi.DeviceRequest) string {
+	if len(request.DeviceIDs) > 0 {
+		return `"device=` + strings.Join(request.DeviceIDs, ",") + `"`
+	}
+	if request.Count > 0 {
+		return strconv.Itoa(request.Count)
+	}
+	return "all"
+}
+
+// sortedKeys returns the keys of a string-keyed map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
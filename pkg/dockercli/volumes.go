@@ -0,0 +1,64 @@
+package dockercli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// VolumeOptions describes a Docker volume to create.
+type VolumeOptions struct {
+	Name       string
+	Driver     string            // Volume driver, defaults to "local" when empty.
+	DriverOpts map[string]string // Driver options, e.g. {"type": "nfs", "o": "addr=10.0.0.5,rw", "device": ":/exports/data"}.
+	Labels     map[string]string // Metadata labels for the volume.
+}
+
+// args returns the docker CLI arguments for `docker volume create`.
+func (o VolumeOptions) args() []string {
+	args := []string{"volume", "create"}
+	if o.Driver != "" {
+		args = append(args, "--driver", o.Driver)
+	}
+
+	for _, key := range sortedKeys(o.DriverOpts) {
+		args = append(args, "--opt", key+"="+o.DriverOpts[key])
+	}
+
+	args = append(args, labelArgs(o.Labels)...)
+	return append(args, o.Name)
+}
+
+// CreateVolumeWithOptions creates a Docker volume with a driver, driver options and labels.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - opts: Volume name, driver, driver options and labels.
+// Returns:
+// - The name of the created volume.
+// - An error if the volume cannot be created.
+func (dc *DockerClient) CreateVolumeWithOptions(ctx context.Context, opts VolumeOptions) (string, error) {
+	if opts.Name == "" {
+		return "", fmt.Errorf("volume name cannot be empty")
+	}
+
+	log.Info().
+		Str("volume", opts.Name).
+		Str("driver", opts.Driver).
+		Msg("Creating Docker volume")
+
+	output, err := dc.runDocker(ctx, opts.args()...)
+	if err != nil {
+		log.Error().Err(err).Str("volume", opts.Name).Str("output", output).Msg("Failed to create Docker volume")
+		return "", fmt.Errorf("failed to create volume %s: %w", opts.Name, err)
+	}
+
+	volumeName := lastLine(output)
+	log.Info().Str("volume", volumeName).Msg("Docker volume created successfully")
+	return volumeName, nil
+}
+
+// CreateVolume creates a Docker volume with the default driver and no options.
+func (dc *DockerClient) CreateVolume(ctx context.Context, name string) (string, error) {
+	return dc.CreateVolumeWithOptions(ctx, VolumeOptions{Name: name})
+}
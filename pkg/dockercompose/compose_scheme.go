@@ -1,5 +1,7 @@
 package dockercompose
 
+import "gopkg.in/yaml.v3"
+
 // ComposeFile represents the structure of a Docker Compose file.
 type ComposeFile struct {
 	Version  string             `yaml:"version,omitempty"`  // Optional: specifies the version of the Compose file format
@@ -16,6 +18,7 @@ type Service struct {
 	Image           string            `yaml:"image,omitempty"`             // Optional: Docker image to use
 	Build           *BuildConfig      `yaml:"build,omitempty"`             // Optional: build context or options
 	Environment     interface{}       `yaml:"environment,omitempty"`       // Accepts both []string and map[string]string
+	EnvFile         StringList        `yaml:"env_file,omitempty"`          // Optional: env files, a single path or a list
 	Ports           []string          `yaml:"ports,omitempty"`             // Optional: port mappings
 	Volumes         []string          `yaml:"volumes,omitempty"`           // Optional: volume mounts
 	Secrets         []string          `yaml:"secrets,omitempty"`           // Optional: secret references
@@ -29,6 +32,7 @@ type Service struct {
 	RestartPolicy   string            `yaml:"restart,omitempty"`           // Optional: restart policy
 	StopGracePeriod string            `yaml:"stop_grace_period,omitempty"` // Optional: stop grace period
 	DependsOn       []string          `yaml:"depends_on,omitempty"`        // Optional: service dependencies
+	Profiles        []string          `yaml:"profiles,omitempty"`          // Optional: profiles enabling the service
 	Healthcheck     *Healthcheck      `yaml:"healthcheck,omitempty"`       // Optional: health check configuration
 	Logging         *Logging          `yaml:"logging,omitempty"`           // Optional: logging configuration
 	ExtraHosts      []string          `yaml:"extra_hosts,omitempty"`       // Optional: additional hostnames
@@ -58,6 +62,33 @@ type BuildConfig struct {
 	Labels     map[string]string `yaml:"labels,omitempty"`     // Build labels
 }
 
+// UnmarshalYAML accepts both the short syntax "build: ./dir" and the long syntax with a context mapping.
+func (b *BuildConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*b = BuildConfig{Context: value.Value}
+		return nil
+	}
+	type plain BuildConfig
+	return value.Decode((*plain)(b))
+}
+
+// StringList is a list of strings that may also be written as a single string, e.g. "env_file: .env".
+type StringList []string
+
+// UnmarshalYAML accepts both a single scalar and a sequence of scalars.
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = StringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
 // CPUConfig holds CPU-related settings for a service.
 type CPUConfig struct {
 	Count     string `yaml:"cpu_count,omitempty"`      // Optional: number of CPUs
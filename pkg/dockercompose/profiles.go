@@ -0,0 +1,62 @@
+package dockercompose
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Profiles returns the profiles used by the services of the compose file, sorted by name.
+func (c *ComposeFile) Profiles() []string {
+	var profiles []string
+	for _, service := range c.Services {
+		for _, profile := range service.Profiles {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// ValidateProfiles checks that every requested profile is used by a service of the compose file, so a typo
+// does not silently start only the default services.
+// Parameters:
+// - profiles: The profiles passed to docker compose via --profile.
+// Returns:
+// - An error listing the unknown profiles and the available ones.
+func (c *ComposeFile) ValidateProfiles(profiles []string) error {
+	available := c.Profiles()
+	var unknown []string
+	for _, profile := range profiles {
+		if !slices.Contains(available, profile) {
+			unknown = append(unknown, profile)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("profile(s) %s not defined by any service, available profiles: [%s]", strings.Join(unknown, ", "), strings.Join(available, ", "))
+	}
+	return nil
+}
+
+// ActiveServices returns the names of the services started by docker compose up with the given profiles:
+// services without profiles and services in at least one of the profiles, sorted by name.
+func (c *ComposeFile) ActiveServices(profiles []string) []string {
+	var names []string
+	for name, service := range c.Services {
+		active := len(service.Profiles) == 0
+		for _, profile := range service.Profiles {
+			if slices.Contains(profiles, profile) {
+				active = true
+				break
+			}
+		}
+		if active {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+package logRedact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces the values of sensitive fields.
+const Placeholder = "[REDACTED]"
+
+// sensitiveKeys lists field names (lower case) whose values are always redacted.
+var sensitiveKeys = map[string]bool{
+	"api_key":          true,
+	"api_key_secret":   true,
+	"apikey":           true,
+	"apikeysecret":     true,
+	"password":         true,
+	"postgrespassword": true,
+	"secret":           true,
+	"token":            true,
+	"docker_token":     true,
+	"dockertoken":      true,
+	"session_token":    true,
+	"sessiontoken":     true,
+	"authorization":    true,
+	"private_key":      true,
+	"privatekey":       true,
+}
+
+// inlinePattern matches "key=value", "key: value" and `"key":"value"` pairs of sensitive keys embedded in strings,
+// e.g. in fmt.Sprintf("%+v") output, command lines or nested JSON documents.
+var inlinePattern = regexp.MustCompile(`(?i)("?\b(?:api_key_secret|api_key|apikeysecret|apikey|password|docker_token|dockertoken|session_token|sessiontoken|private_key|secret|token|authorization)"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|\S+)`)
+
+// bearerPattern matches bearer credentials, e.g. in logged request headers.
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)\S+`)
+
+// Writer scrubs secrets from JSON log events before passing them to the wrapped writer.
+// It is meant to wrap the output of a zerolog logger, which writes one JSON event per Write call.
+type Writer struct {
+	out io.Writer
+}
+
+// NewWriter returns a Writer that redacts sensitive fields in every event written to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write redacts the event and writes it to the wrapped writer.
+// The full length of p is reported on success, so callers are unaware of the rewriting.
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(Redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Redact returns a copy of a JSON document with the values of sensitive keys replaced by Placeholder.
+// Input that is not a JSON document is redacted as plain text.
+func Redact(p []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		return []byte(RedactString(string(p)))
+	}
+
+	redacted, err := json.Marshal(redactValue(document))
+	if err != nil {
+		return []byte(RedactString(string(p)))
+	}
+	if bytes.HasSuffix(p, []byte("\n")) {
+		redacted = append(redacted, '\n')
+	}
+	return redacted
+}
+
+// RedactString replaces sensitive key/value pairs and bearer credentials embedded in free text.
+func RedactString(s string) string {
+	s = inlinePattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := inlinePattern.FindStringSubmatch(match)
+		if strings.HasPrefix(parts[2], `"`) {
+			return parts[1] + `"` + Placeholder + `"`
+		}
+		return parts[1] + Placeholder
+	})
+	return bearerPattern.ReplaceAllString(s, "${1}"+Placeholder)
+}
+
+// redactValue walks a decoded JSON value and redacts sensitive keys and embedded secrets.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if isSensitiveKey(key) {
+				if nested != nil && nested != "" {
+					v[key] = Placeholder
+				}
+				continue
+			}
+			v[key] = redactValue(nested)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactValue(nested)
+		}
+		return v
+	case string:
+		return RedactString(v)
+	default:
+		return v
+	}
+}
+
+// isSensitiveKey reports whether a field name denotes a secret.
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(strings.ReplaceAll(key, "-", "_"))]
+}
@@ -206,7 +206,7 @@ func DeployBackendServices(ctx context.Context, backendComposePath string, sshCo
 				Str("command", loadCmd).
 				Msg("Loading Docker image on remote node")
 
-			output, err := client.ExecuteCommandWithOutput(ctx, loadCmd, 1*time.Minute)
+			output, err := client.ExecuteCommandWithOutputSudo(ctx, loadCmd, 1*time.Minute, imageLoadTimeout)
 			if err != nil {
 				log.Error().
 					Err(err).
@@ -227,7 +227,7 @@ func DeployBackendServices(ctx context.Context, backendComposePath string, sshCo
 				Str("command", removeCmd).
 				Msg("Removing tar file from remote node")
 
-			output, err = client.ExecuteCommandWithOutput(ctx, removeCmd, 30*time.Second)
+			output, err = client.ExecuteCommandWithOutput(ctx, removeCmd, 30*time.Second, cleanupCommandTimeout)
 			if err != nil {
 				log.Warn().
 					Err(err).
@@ -268,7 +268,7 @@ func DeployBackendServices(ctx context.Context, backendComposePath string, sshCo
 		Str("command", composeUpCmd).
 		Msg("Executing 'docker compose up' on remote node")
 
-	output, err := client.ExecuteCommandWithOutput(ctx, composeUpCmd, 2*time.Minute)
+	output, err := client.ExecuteCommandWithOutputSudo(ctx, composeUpCmd, 2*time.Minute, 0)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -10,11 +10,32 @@ import (
 
 // findDockerfileForService searches for a Dockerfile in the ./dockerfiles/ directory that contains the serviceName.
 func findDockerfileForService(serviceName string) (string, error) {
+	return findDockerfileInDir("./dockerfiles", serviceName)
+}
+
+// findDockerfileInDir returns the Dockerfile of serviceName in dockerfilesDir. The conventional names
+// Dockerfile.<service>, <service>/Dockerfile and dockerfile-<service> are checked first, in this order, so
+// overlapping service names such as "db" and "dbadmin" resolve deterministically. Only when none exists is
+// the directory searched for the single file whose name contains serviceName.
+func findDockerfileInDir(dockerfilesDir, serviceName string) (string, error) {
 	log.Debug().
 		Str("service_name", serviceName).
+		Str("directory", dockerfilesDir).
 		Msg("Searching for Dockerfile matching service name")
 
-	dockerfilesDir := "./dockerfiles"
+	for _, candidate := range []string{
+		filepath.Join(dockerfilesDir, "Dockerfile."+serviceName),
+		filepath.Join(dockerfilesDir, serviceName, "Dockerfile"),
+		filepath.Join(dockerfilesDir, "dockerfile-"+serviceName),
+	} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			log.Debug().
+				Str("dockerfile", candidate).
+				Msg("Found Dockerfile by naming convention")
+			return candidate, nil
+		}
+	}
+
 	pattern := fmt.Sprintf("*%s*", serviceName)
 
 	matchedFiles, err := filepath.Glob(filepath.Join(dockerfilesDir, pattern))
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	embedfiles "kasmlink/embedded"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"kasmlink/pkg/dockercli"
+	"kasmlink/pkg/dockercompose"
 	shadowscp "kasmlink/pkg/scp"
 	shadowssh "kasmlink/pkg/sshmanager"
 
@@ -21,16 +24,82 @@ import (
 const (
 	DefaultBaseImage       = "opensuse/leap:15.5"
 	DefaultBuildContextDir = "workspace-core-image"
+	// DefaultSpaceSafetyFactor is the multiple of the tar size that must be free on the remote node,
+	// covering the uploaded tar plus the image layers extracted by docker load.
+	DefaultSpaceSafetyFactor = 2.0
 )
 
+// DefaultStatusCheckDelay is how long compose services may settle before DeployComposeFile checks them.
+const DefaultStatusCheckDelay = 5 * time.Second
+
+// failedServiceLogLines is the number of log lines reported for a service that crashed after deployment.
+const failedServiceLogLines = 20
+
+// Lifetimes of remote commands, after which they are interrupted so a hanging docker load cannot block a
+// deployment forever.
+const (
+	imageLoadTimeout      = 30 * time.Minute
+	cleanupCommandTimeout = 2 * time.Minute
+)
+
+// ImageDeployOptions holds optional settings for DeployKasmDockerImage.
+type ImageDeployOptions struct {
+	// SpaceSafetyFactor is multiplied with the tar size to determine the free space required on the
+	// remote node before the upload starts. Values <= 0 fall back to DefaultSpaceSafetyFactor.
+	SpaceSafetyFactor float64
+	// Force transfers the image even if the remote node already has an image with the same ID.
+	Force bool
+	// TarCacheDir is the local directory caching exported image tars; DefaultTarCacheDir when empty.
+	TarCacheDir string
+	// Build selects the builder and progress format of the image build.
+	Build dockercli.BuildSettings
+	// SSH is the configuration of the target node; nil loads it from the SSH_* environment variables.
+	SSH *shadowssh.SSHConfig
+	// SSHClient is an established connection to the target node that is reused instead of dialing SSH, e.g.
+	// by orchestrators running several procedures on one node. It is left open; SSH is ignored when it is set.
+	SSHClient *shadowssh.SSHClient
+}
+
+// ComposeDeployOptions holds optional settings for DeployComposeFile and TeardownComposeFile.
+type ComposeDeployOptions struct {
+	// ProjectName is passed to docker compose via -p so several stacks can run on one node. Empty uses the
+	// compose default derived from the target directory.
+	ProjectName string
+	// EnvFilePath is an optional local env file copied next to the compose file and passed via --env-file.
+	EnvFilePath string
+	// ServiceEnv maps service names to environment variables written to a .env.<service> file per service,
+	// which is uploaded next to the compose file, referenced via env_file and removed on teardown.
+	ServiceEnv map[string]map[string]string
+	// Profiles are passed to docker compose up via --profile. Services assigned to profiles only start when one
+	// of their profiles is requested; every profile must be used by a service of the compose file.
+	Profiles []string
+	// SkipStatusCheck skips the check after docker compose up that no service crashed. docker compose up -d
+	// exits successfully even when a container crash-loops right after starting.
+	SkipStatusCheck bool
+	// StatusCheckDelay is how long the services may settle before the status check; DefaultStatusCheckDelay
+	// when zero.
+	StatusCheckDelay time.Duration
+	// Rollback keeps the deployed compose file as <file>.bak before uploading the new one. If docker compose up
+	// or the status check fails, the backup is restored and started again and the error wraps ErrRolledBack.
+	// Env files are not restored.
+	Rollback bool
+	// SSH is the configuration of the target node; nil loads it from the SSH_* environment variables.
+	SSH *shadowssh.SSHConfig
+	// SSHClient is an established connection to the target node that is reused instead of dialing SSH, e.g.
+	// by orchestrators running several procedures on one node. It is left open; SSH is ignored when it is set.
+	SSHClient *shadowssh.SSHClient
+}
+
 // BuildCoreImageKasm orchestrates the Docker image build using the embedded Dockerfile and base image.
 // It utilizes the dockercli package to create the build context and build the Docker image.
 // Parameters:
+// - ctx: Context for managing cancellation of the build.
 // - imageTag: The tag to assign to the built Docker image (e.g., "kasm/core:latest").
 // - baseImage: The base image to use for building. If empty, DefaultBaseImage is used.
+// - settings: The builder and progress format; BuildKit is used by default.
 // Returns:
 // - An error if the build process fails.
-func BuildCoreImageKasm(imageTag, baseImage string) error {
+func BuildCoreImageKasm(ctx context.Context, imageTag, baseImage string, settings dockercli.BuildSettings) error {
 	if imageTag == "" {
 		return fmt.Errorf("imageTag cannot be empty")
 	}
@@ -74,7 +143,7 @@ func BuildCoreImageKasm(imageTag, baseImage string) error {
 	retries := 3
 
 	// Corrected function call
-	err = dockercli.BuildDockerImage(context.Background(), retries, "dockerfile-kasm-core-suse", imageTag)
+	err = dockercli.BuildDockerImageWithSettings(ctx, retries, "dockerfile-kasm-core-suse", imageTag, settings)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -91,18 +160,31 @@ func BuildCoreImageKasm(imageTag, baseImage string) error {
 
 // DeployKasmDockerImage builds, exports, and loads a Docker image on a remote node.
 // If a localTarFilePath is provided, it will use that file instead of building a new image.
+// A freshly built image is not transferred when the remote node already has an image with the same ID,
+// unless options.Force is set. Its tar is exported to the tar cache of options.TarCacheDir once per image ID
+// and reused by later deploys to other nodes.
 // Parameters:
+// - ctx: Context for managing cancellation of the build, transfer and remote commands.
 // - imageTag: The Docker image tag to deploy.
 // - baseImage: The base image to use for building (if building).
 // - targetNodePath: The destination path on the remote node where the image will be loaded.
 // - localTarFilePath: Optional local tar file path. If provided and exists, it will be used instead of building.
+// - options: Optional deployment settings such as the free-space safety factor and Force.
 // Returns:
 // - An error if any step in the deployment process fails.
-func DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath string) error {
-	var tarFilePath string
-	var err error
+func DeployKasmDockerImage(ctx context.Context, imageTag, baseImage, targetNodePath, localTarFilePath string, options ImageDeployOptions) error {
+	// Step 1: Establish SSH connection to target node.
+	sshClient, _, release, err := connectSSH(ctx, options.SSHClient, options.SSH)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to connect to remote node")
+		return err
+	}
+	defer release()
 
-	// Step 1: Determine the tar file to use.
+	// Step 2: Determine the tar file to use.
+	var tarFilePath string
 	if localTarFilePath != "" {
 		if _, err = os.Stat(localTarFilePath); err == nil {
 			// Local tar file exists, use it.
@@ -116,42 +198,65 @@ func DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath
 			return fmt.Errorf("local tar file specified but not found: %w", err)
 		}
 	} else {
-		// Step 2: Build the Docker image if no local tar file is provided.
-		if err = BuildCoreImageKasm(imageTag, baseImage); err != nil {
+		// Step 3: Build the Docker image if no local tar file is provided. Deployments of the same image to
+		// other nodes running at the same time share one build.
+		_, _, err = imageBuildFlights.Do(ctx, imageTag, func() (string, error) {
+			return "", BuildCoreImageKasm(ctx, imageTag, baseImage, options.Build)
+		})
+		if err != nil {
 			log.Error().
 				Err(err).
 				Msg("Failed to build Docker image")
 			return fmt.Errorf("failed to build Docker image: %w", err)
 		}
 
-		// Step 3: Export image to tar file.
-		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+		// Skip the export, upload and load if the remote node already has this exact image.
+		if !options.Force {
+			present, err := remoteImagePresent(ctx, sshClient, imageTag)
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("imageTag", imageTag).
+					Msg("Could not compare image with remote node, transferring it")
+			} else if present {
+				log.Info().
+					Str("imageTag", imageTag).
+					Msg("Remote node already has the image, skipping transfer")
+				return nil
+			}
+		}
+
+		// Step 4: Export image to tar file, reusing the cached tar of this image ID.
+		exportCtx, cancel := context.WithTimeout(ctx, 120*time.Second)
 		defer cancel()
 
 		// Define the number of retries, e.g., 3
 		retries := 3
 
-		// Define the output file path using a temporary file
-		tempFile, err := os.CreateTemp("", "docker-image-*.tar")
+		imageID, err := dockercli.GetImageIDByTag(exportCtx, retries, imageTag)
 		if err != nil {
 			log.Error().
 				Err(err).
-				Msg("Could not create temporary tar file")
-			return fmt.Errorf("could not create temporary tar file: %w", err)
+				Str("imageTag", imageTag).
+				Msg("Failed to get Docker image ID")
+			return fmt.Errorf("failed to get Docker image ID: %w", err)
 		}
-		defer func() {
-			if cerr := tempFile.Close(); cerr != nil {
-				log.Error().
-					Err(cerr).
-					Str("tarFilePath", tempFile.Name()).
-					Msg("Failed to close tar file")
-			}
-		}()
 
-		outputFile := tempFile.Name()
-
-		// Corrected function call
-		tarFilePath, err = dockercli.ExportImageToTar(ctx, retries, imageTag, outputFile)
+		// Concurrent deployments of the image share one export instead of racing on the cached tar.
+		cache := ImageTarCache{Dir: options.TarCacheDir}
+		tarFilePath, _, err = imageExportFlights.Do(ctx, cache.Path(imageTag, imageID), func() (string, error) {
+			if cachedTar, ok := cache.Get(imageTag, imageID); ok {
+				log.Info().
+					Str("imageTag", imageTag).
+					Str("tarFilePath", cachedTar).
+					Msg("Reusing cached image tar")
+				return cachedTar, nil
+			}
+			return cache.Put(imageTag, imageID, func(path string) error {
+				_, err := dockercli.ExportImageToTar(exportCtx, retries, imageTag, path)
+				return err
+			})
+		})
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -159,39 +264,43 @@ func DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath
 				Msg("Failed to export Docker image to tar")
 			return fmt.Errorf("failed to export Docker image to tar: %w", err)
 		}
-		defer func() {
-			if cerr := os.Remove(tarFilePath); cerr != nil {
-				log.Error().
-					Err(cerr).
-					Str("tarFilePath", tarFilePath).
-					Msg("Failed to remove temporary tar file")
-			}
-		}()
 	}
 
-	// Step 4: Establish SSH connection to target node.
-	sshConfig, err := configureSSH()
+	// Report the Docker disk usage of the node so operators can see how much space could be reclaimed.
+	if usage, err := dockercli.GetDiskUsage(ctx, sshClient); err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Could not determine Docker disk usage on remote node")
+	} else {
+		log.Info().
+			Int64("docker_size_bytes", usage.TotalSize()).
+			Int64("reclaimable_bytes", usage.TotalReclaimable()).
+			Int64("images_reclaimable_bytes", usage.Images.Reclaimable).
+			Int64("containers_reclaimable_bytes", usage.Containers.Reclaimable).
+			Int64("volumes_reclaimable_bytes", usage.Volumes.Reclaimable).
+			Int64("build_cache_reclaimable_bytes", usage.BuildCache.Reclaimable).
+			Msg("Docker disk usage on remote node")
+	}
+
+	// Ensure the target directory can hold the tar and the loaded image before uploading anything.
+	tarInfo, err := os.Stat(tarFilePath)
 	if err != nil {
 		log.Error().
 			Err(err).
-			Msg("Failed to configure SSH settings")
-		return fmt.Errorf("failed to configure SSH settings: %w", err)
+			Str("tarFilePath", tarFilePath).
+			Msg("Failed to stat tar file")
+		return fmt.Errorf("failed to stat tar file %s: %w", tarFilePath, err)
 	}
 
-	sshClient, err := shadowssh.NewSSHClient(context.Background(), sshConfig)
-	if err != nil {
+	requiredBytes := RequiredFreeSpace(tarInfo.Size(), options.SpaceSafetyFactor)
+	if err := checkRemoteFreeSpace(ctx, sshClient, targetNodePath, requiredBytes); err != nil {
 		log.Error().
 			Err(err).
-			Msg("Failed to establish SSH connection to remote node")
-		return fmt.Errorf("failed to establish SSH connection: %w", err)
+			Str("remoteDir", targetNodePath).
+			Int64("requiredBytes", requiredBytes).
+			Msg("Insufficient free space on remote node")
+		return err
 	}
-	defer func() {
-		if cerr := sshClient.Close(); cerr != nil {
-			log.Error().
-				Err(cerr).
-				Msg("Failed to close SSH client")
-		}
-	}()
 
 	// Step 5: Copy the tar file to the remote node.
 	log.Info().
@@ -199,7 +308,7 @@ func DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath
 		Str("remoteDir", targetNodePath).
 		Msg("Starting file copy to remote node via SCP")
 
-	err = shadowscp.ShadowCopyFile(context.Background(), tarFilePath, targetNodePath, sshConfig)
+	err = shadowscp.ShadowCopyFileWithClient(ctx, tarFilePath, targetNodePath, sshClient)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -212,72 +321,162 @@ func DeployKasmDockerImage(imageTag, baseImage, targetNodePath, localTarFilePath
 	log.Info().Msg("Tar file copied to remote node successfully")
 
 	// Step 6: Import the Docker image on the remote node.
-	importCommand := fmt.Sprintf("docker load -i %s/%s", targetNodePath, filepath.Base(tarFilePath))
+	remoteTarPath := path.Join(filepath.ToSlash(targetNodePath), filepath.Base(tarFilePath))
+	importCommand := "docker load -i " + shadowssh.ShellQuote(remoteTarPath)
 	log.Info().
 		Str("command", importCommand).
 		Msg("Importing Docker image on remote node")
 
-	output, err := sshClient.ExecuteCommandWithOutput(context.Background(), importCommand, 1*time.Minute)
+	output, err := sshClient.ExecuteCommandWithOutputSudo(ctx, importCommand, 1*time.Minute, imageLoadTimeout)
 	if err != nil {
+		// The tar is kept on the node so the failed load can be investigated.
 		log.Error().
 			Err(err).
 			Str("command", importCommand).
 			Str("output", output).
-			Msg("Failed to import Docker image on remote node")
-		return fmt.Errorf("failed to import Docker image on remote node: %w", err)
+			Str("remoteTarPath", remoteTarPath).
+			Msg("Failed to import Docker image on remote node, keeping uploaded tar file")
+		return fmt.Errorf("failed to import Docker image on remote node (tar kept at %s): %w", remoteTarPath, err)
 	}
 
 	log.Info().Msg("Docker image imported successfully on remote node")
+
+	// Step 7: Delete uploaded tar file. It was uploaded as the SSH user, so no sudo is needed to remove it.
+	removeCommand := "rm -f " + shadowssh.ShellQuote(remoteTarPath)
+	if output, err := sshClient.ExecuteCommandWithOutput(context.Background(), removeCommand, 30*time.Second, cleanupCommandTimeout); err != nil {
+		log.Warn().
+			Err(err).
+			Str("command", removeCommand).
+			Str("output", output).
+			Msg("Failed to delete uploaded tar file from remote node")
+	} else {
+		log.Info().
+			Str("remoteTarPath", remoteTarPath).
+			Msg("Deleted uploaded tar file from remote node")
+	}
 	return nil
 }
 
+// remoteImagePresent reports whether the remote node has an image with the same ID as the local image imageTag.
+func remoteImagePresent(ctx context.Context, sshClient *shadowssh.SSHClient, imageTag string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	localID, err := dockercli.GetImageIDByTag(ctx, 1, imageTag)
+	if err != nil {
+		return false, fmt.Errorf("failed to get local image ID: %w", err)
+	}
+
+	remote := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	return remote.ImageMatchesDigest(ctx, imageTag, localID)
+}
+
 // DeployComposeFile uploads a specified Docker Compose file and deploys the services on the target node.
+// Build contexts and Dockerfiles referenced by build sections are uploaded next to the compose file,
+// the uploaded compose file points at them and the services are built on the node. Unless
+// options.SkipStatusCheck is set, the services are checked with docker compose ps after starting; with
+// options.Rollback, a failed start restores the previously deployed compose file.
 // Parameters:
+// - ctx: Context for managing cancellation of the upload and docker compose.
 // - composeFilePath: The local path to the Docker Compose YAML file.
 // - targetNodePath: The destination directory on the remote node where the Compose file will be placed.
+// - options: Optional compose project name, env file and per-service environment.
 // Returns:
-// - An error if any step in the deployment process fails.
-func DeployComposeFile(composeFilePath, targetNodePath string) error {
-	// Validate compose file existence.
-	if _, err := os.Stat(composeFilePath); os.IsNotExist(err) {
-		log.Error().
-			Err(err).
-			Str("composeFilePath", composeFilePath).
-			Msg("Compose file does not exist")
-		return fmt.Errorf("compose file does not exist at path %s: %w", composeFilePath, err)
+// - An error if any step in the deployment process fails or a service crashed after starting.
+func DeployComposeFile(ctx context.Context, composeFilePath, targetNodePath string, options ComposeDeployOptions) error {
+	if err := validateComposeInputs(composeFilePath, options); err != nil {
+		return err
 	}
 
 	// Step 1: Establish SSH connection to target node.
-	sshConfig, err := configureSSH()
+	sshClient, sshConfig, release, err := connectSSH(ctx, options.SSHClient, options.SSH)
 	if err != nil {
 		log.Error().
 			Err(err).
-			Msg("Failed to configure SSH settings")
-		return fmt.Errorf("failed to configure SSH settings: %w", err)
+			Msg("Failed to connect to remote node")
+		return err
 	}
+	defer release()
 
-	sshClient, err := shadowssh.NewSSHClient(context.Background(), sshConfig)
-	if err != nil {
+	return deployComposeFile(ctx, sshClient, sshConfig, composeFilePath, targetNodePath, options)
+}
+
+// validateComposeInputs checks that the local compose file and env file of a deployment exist and that the
+// requested profiles are defined by the compose file.
+func validateComposeInputs(composeFilePath string, options ComposeDeployOptions) error {
+	if _, err := os.Stat(composeFilePath); os.IsNotExist(err) {
 		log.Error().
 			Err(err).
-			Msg("Failed to establish SSH connection to remote node")
-		return fmt.Errorf("failed to establish SSH connection: %w", err)
+			Str("composeFilePath", composeFilePath).
+			Msg("Compose file does not exist")
+		return fmt.Errorf("compose file does not exist at path %s: %w", composeFilePath, err)
 	}
-	defer func() {
-		if cerr := sshClient.Close(); cerr != nil {
+	if options.EnvFilePath != "" {
+		if _, err := os.Stat(options.EnvFilePath); err != nil {
 			log.Error().
-				Err(cerr).
-				Msg("Failed to close SSH client")
+				Err(err).
+				Str("envFilePath", options.EnvFilePath).
+				Msg("Env file does not exist")
+			return fmt.Errorf("env file does not exist at path %s: %w", options.EnvFilePath, err)
 		}
-	}()
+	}
+	if len(options.Profiles) > 0 {
+		composeFile, err := dockercompose.LoadComposeFile(composeFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to load compose file %s to check profiles: %w", composeFilePath, err)
+		}
+		if err := composeFile.ValidateProfiles(options.Profiles); err != nil {
+			log.Error().
+				Err(err).
+				Strs("profiles", options.Profiles).
+				Msg("Invalid compose profiles")
+			return fmt.Errorf("compose file %s: %w", composeFilePath, err)
+		}
+	}
+	return nil
+}
+
+// deployComposeFile uploads the compose file, its build contexts and env file over an established SSH
+// connection and starts the services, as described for DeployComposeFile.
+func deployComposeFile(ctx context.Context, sshClient *shadowssh.SSHClient, sshConfig *shadowssh.SSHConfig, composeFilePath, targetNodePath string, options ComposeDeployOptions) error {
+	// Step 1: Upload build contexts and rewrite the compose file to use their remote paths.
+	uploadComposeFilePath, build, cleanup, err := uploadBuildContexts(ctx, sshClient, composeFilePath, targetNodePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Step 2: Generate the env files of the services and reference them from the compose file.
+	var envFiles []string
+	if len(options.ServiceEnv) > 0 {
+		var envCleanup func()
+		uploadComposeFilePath, envFiles, envCleanup, err = prepareServiceEnvFiles(uploadComposeFilePath, options.ServiceEnv)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("composeFilePath", composeFilePath).
+				Msg("Failed to generate service env files")
+			return err
+		}
+		defer envCleanup()
+	}
+
+	// Step 3: Copy compose file onto node, keeping the deployed one if a rollback is requested.
+	remoteComposeFile := filepath.Join(targetNodePath, filepath.Base(composeFilePath))
+	backedUp := false
+	if options.Rollback {
+		backedUp, err = backupRemoteComposeFile(ctx, sshClient, remoteComposeFile)
+		if err != nil {
+			return err
+		}
+	}
 
-	// Step 2: Copy compose file onto node.
 	log.Info().
-		Str("source", composeFilePath).
+		Str("source", uploadComposeFilePath).
 		Str("destination", targetNodePath).
 		Msg("Starting to copy compose file onto remote node")
 
-	err = shadowscp.ShadowCopyFile(context.Background(), composeFilePath, targetNodePath, sshConfig)
+	err = shadowscp.ShadowCopyFileWithClient(ctx, uploadComposeFilePath, targetNodePath, sshClient)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -289,27 +488,71 @@ func DeployComposeFile(composeFilePath, targetNodePath string) error {
 
 	log.Info().
 		Str("nodeAddress", sshConfig.Host).
-		Str("composeFile", filepath.Join(targetNodePath, filepath.Base(composeFilePath))).
+		Str("composeFile", remoteComposeFile).
 		Msg("Compose file copied successfully")
 
-	// Step 3: Start Docker Compose on the remote node.
-	targetNodeComposeFilePath := filepath.Join(targetNodePath, filepath.Base(composeFilePath))
-	dockerComposeUpCommand := fmt.Sprintf("docker compose -f %s up -d", targetNodeComposeFilePath)
+	// Step 4: Copy the env files next to the compose file.
+	if options.EnvFilePath != "" {
+		log.Info().
+			Str("source", options.EnvFilePath).
+			Str("destination", targetNodePath).
+			Msg("Copying env file onto remote node")
+		if err := shadowscp.ShadowCopyFileWithClient(ctx, options.EnvFilePath, targetNodePath, sshClient); err != nil {
+			log.Error().
+				Err(err).
+				Str("nodeAddress", sshConfig.Host).
+				Str("targetPath", targetNodePath).
+				Msg("Failed to copy env file onto remote node")
+			return fmt.Errorf("failed to copy env file onto remote node: %w", err)
+		}
+	}
+	if err := uploadServiceEnvFiles(ctx, sshClient, envFiles, targetNodePath); err != nil {
+		log.Error().
+			Err(err).
+			Str("nodeAddress", sshConfig.Host).
+			Str("targetPath", targetNodePath).
+			Msg("Failed to copy service env files onto remote node")
+		return err
+	}
 
+	// Step 5: Start Docker Compose on the remote node.
+	project := remoteComposeProject(composeFilePath, targetNodePath, options)
 	log.Info().
-		Str("command", dockerComposeUpCommand).
+		Str("composeFile", project.File).
+		Str("project", project.Name).
+		Str("envFile", project.EnvFile).
+		Strs("profiles", options.Profiles).
+		Bool("build", build).
 		Str("nodeAddress", sshConfig.Host).
 		Msg("Starting Docker Compose on the remote node")
 
-	output, err := sshClient.ExecuteCommandWithOutput(context.Background(), dockerComposeUpCommand, 1*time.Minute)
-	if err != nil {
+	remote := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	// A failed start restores the previous deployment if it was backed up.
+	fail := func(err error) error {
+		if !backedUp {
+			return err
+		}
+		return rollbackComposeDeployment(ctx, sshClient, remote, project, options.Profiles, err)
+	}
+
+	// No deadline of its own: pulling and building images can take far longer than starting the services.
+	if _, err := remote.ComposeUp(ctx, project, build, options.Profiles, composeServiceNames(composeFilePath, options.Profiles)); err != nil {
 		log.Error().
 			Err(err).
 			Str("host", sshConfig.Host).
-			Str("command", dockerComposeUpCommand).
-			Str("output", output).
 			Msg("Failed to start Docker Compose on remote node")
-		return fmt.Errorf("failed to start Docker Compose on remote node: %w", err)
+		return fail(fmt.Errorf("failed to start Docker Compose on remote node: %w", err))
+	}
+
+	// Step 6: Check that no service crashed right after starting.
+	if !options.SkipStatusCheck {
+		if err := verifyComposeServices(ctx, remote, project, options.StatusCheckDelay); err != nil {
+			log.Error().
+				Err(err).
+				Str("host", sshConfig.Host).
+				Msg("Docker Compose services failed after starting")
+			return fail(err)
+		}
 	}
 
 	log.Info().
@@ -318,59 +561,195 @@ func DeployComposeFile(composeFilePath, targetNodePath string) error {
 	return nil
 }
 
-// configureSSH sets up the SSH configuration based on environment variables or other sources.
-// It returns an SSHConfig instance or an error if configuration fails.
-func configureSSH() (*shadowssh.SSHConfig, error) {
-	// Example: Fetch SSH configurations from environment variables.
-	// Replace these with your actual configuration retrieval logic.
-	username := os.Getenv("SSH_USERNAME")
-	password := os.Getenv("SSH_PASSWORD")
-	host := os.Getenv("SSH_HOST")
-	portStr := os.Getenv("SSH_PORT")
-	knownHostsFile := os.Getenv("SSH_KNOWN_HOSTS")
-	connectionTimeoutStr := os.Getenv("SSH_CONNECTION_TIMEOUT")
-
-	// Parse port.
-	port := 22 // Default SSH port.
-	if portStr != "" {
-		_, err := fmt.Sscanf(portStr, "%d", &port)
-		if err != nil {
-			log.Warn().
-				Err(err).
-				Str("SSH_PORT", portStr).
-				Msg("Invalid SSH port format; using default port 22")
-			port = 22
+// verifyComposeServices waits for delay and checks the containers of project with docker compose ps.
+// Returns:
+// - An error naming every crashed service with its last log lines, or describing why the check failed.
+func verifyComposeServices(ctx context.Context, remote *dockercli.DockerClient, project dockercli.ComposeProject, delay time.Duration) error {
+	if delay <= 0 {
+		delay = DefaultStatusCheckDelay
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	statusCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	statuses, err := remote.ComposeStatus(statusCtx, project)
+	if err != nil {
+		return fmt.Errorf("failed to check Docker Compose services after starting: %w", err)
+	}
+
+	var failures []string
+	for _, status := range statuses {
+		if !status.Failed() {
+			if status.Health == "starting" {
+				log.Warn().Str("service", status.Service).Msg("Service is still starting, its health is not verified")
+			}
+			continue
+		}
+		failure := fmt.Sprintf("service %s is %s (%s)", status.Service, status.State, status.Status)
+		if status.Health == "unhealthy" {
+			failure = fmt.Sprintf("service %s is unhealthy (%s)", status.Service, status.Status)
+		}
+		if logs, err := remote.ComposeLogs(statusCtx, project, failedServiceLogLines, status.Service); err != nil {
+			log.Warn().Err(err).Str("service", status.Service).Msg("Failed to fetch logs of crashed service")
+		} else if logs = strings.TrimSpace(logs); logs != "" {
+			failure += ", last log lines:\n" + logs
 		}
+		failures = append(failures, failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("docker compose up succeeded but services failed:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// TeardownComposeFile stops and removes the services deployed by DeployComposeFile on the target node.
+// Parameters:
+// - ctx: Context for managing cancellation of docker compose down.
+// - composeFilePath: The local path of the deployed Docker Compose YAML file.
+// - targetNodePath: The directory on the remote node the compose file was deployed to.
+// - options: The project name, env file and service env files used for the deployment.
+// - removeVolumes: Whether to also remove the named volumes of the project.
+// Returns:
+// - An error if the connection or docker compose down fails.
+func TeardownComposeFile(ctx context.Context, composeFilePath, targetNodePath string, options ComposeDeployOptions, removeVolumes bool) error {
+	sshClient, sshConfig, release, err := connectSSH(ctx, options.SSHClient, options.SSH)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to connect to remote node")
+		return err
 	}
+	defer release()
+
+	project := remoteComposeProject(composeFilePath, targetNodePath, options)
+	log.Info().
+		Str("composeFile", project.File).
+		Str("project", project.Name).
+		Bool("removeVolumes", removeVolumes).
+		Str("nodeAddress", sshConfig.Host).
+		Msg("Stopping Docker Compose on the remote node")
 
-	// Parse connection timeout.
-	connectionTimeout := 10 * time.Second // Default timeout.
-	if connectionTimeoutStr != "" {
-		duration, err := time.ParseDuration(connectionTimeoutStr)
+	remote := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	if _, err := remote.ComposeDown(ctx, project, removeVolumes); err != nil {
+		log.Error().
+			Err(err).
+			Str("host", sshConfig.Host).
+			Msg("Failed to stop Docker Compose on remote node")
+		return fmt.Errorf("failed to stop Docker Compose on remote node: %w", err)
+	}
+	if err := removeServiceEnvFiles(ctx, sshClient, options.ServiceEnv, targetNodePath); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("nodeAddress", sshConfig.Host).
+		Msg("Docker Compose removed successfully from target node")
+	return nil
+}
+
+// remoteComposeProject returns the compose project of a file deployed to targetNodePath, so deploy and
+// teardown address the same project.
+func remoteComposeProject(composeFilePath, targetNodePath string, options ComposeDeployOptions) dockercli.ComposeProject {
+	project := dockercli.ComposeProject{
+		File: filepath.Join(targetNodePath, filepath.Base(composeFilePath)),
+		Name: options.ProjectName,
+	}
+	if options.EnvFilePath != "" {
+		project.EnvFile = filepath.Join(targetNodePath, filepath.Base(options.EnvFilePath))
+	}
+	return project
+}
+
+// uploadBuildContexts copies the build contexts referenced by a compose file to the remote node.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - sshClient: Connected client used to create the remote directories and upload the build contexts.
+// - composeFilePath: The local compose file.
+// - targetNodePath: The remote directory the compose file is deployed to.
+// Returns:
+// - The compose file to upload: the original or a rewritten copy in a temporary directory with the same name.
+// - Whether the compose file has build sections and must be started with --build.
+// - A cleanup function removing the temporary copy, always safe to call.
+// - An error if the compose file cannot be parsed or a build context cannot be resolved or uploaded.
+func uploadBuildContexts(ctx context.Context, sshClient *shadowssh.SSHClient, composeFilePath, targetNodePath string) (string, bool, func(), error) {
+	noCleanup := func() {}
+
+	document, err := loadComposeDocument(composeFilePath)
+	if err != nil {
+		log.Error().Err(err).Str("composeFilePath", composeFilePath).Msg("Failed to inspect build sections")
+		return "", false, noCleanup, err
+	}
+
+	composeDir, err := filepath.Abs(filepath.Dir(composeFilePath))
+	if err != nil {
+		return "", false, noCleanup, fmt.Errorf("failed to resolve directory of compose file %s: %w", composeFilePath, err)
+	}
+
+	uploads, err := RewriteBuildContexts(document, composeDir, filepath.ToSlash(targetNodePath))
+	if err != nil {
+		log.Error().Err(err).Str("composeFilePath", composeFilePath).Msg("Failed to resolve build contexts")
+		return "", false, noCleanup, fmt.Errorf("failed to resolve build contexts: %w", err)
+	}
+	if len(uploads) == 0 {
+		build, err := hasBuildSections(document)
 		if err != nil {
-			log.Warn().
-				Err(err).
-				Str("SSH_CONNECTION_TIMEOUT", connectionTimeoutStr).
-				Msg("Invalid connection timeout format; using default timeout of 10s")
-			connectionTimeout = 10 * time.Second
-		} else {
-			connectionTimeout = duration
+			return "", false, noCleanup, err
 		}
+		return composeFilePath, build, noCleanup, nil
 	}
 
-	// Validate required fields.
-	if username == "" || host == "" {
-		return nil, fmt.Errorf("SSH_USERNAME and SSH_HOST must be set")
+	for _, upload := range uploads {
+		log.Info().
+			Str("service", upload.ServiceRef).
+			Str("source", upload.LocalPath).
+			Str("destination", upload.RemoteDir).
+			Msg("Uploading build context to remote node")
+
+		if output, err := sshClient.ExecuteCommand(ctx, shadowssh.ShellJoin("mkdir", "-p", upload.RemoteDir)); err != nil {
+			log.Error().Err(err).Str("output", output).Str("remoteDir", upload.RemoteDir).Msg("Failed to create remote build context directory")
+			return "", false, noCleanup, fmt.Errorf("failed to create remote directory %s: %w", upload.RemoteDir, err)
+		}
+
+		if upload.IsDir {
+			err = shadowscp.ShadowCopyDirWithClient(ctx, upload.LocalPath, upload.RemoteDir, sshClient)
+		} else {
+			err = shadowscp.ShadowCopyFileWithClient(ctx, upload.LocalPath, upload.RemoteDir, sshClient)
+		}
+		if err != nil {
+			return "", false, noCleanup, fmt.Errorf("failed to upload build context %s of service %s: %w", upload.LocalPath, upload.ServiceRef, err)
+		}
 	}
 
-	// Initialize SSHConfig.
-	sshConfig, err := shadowssh.NewSSHConfig(username, password, host, port, knownHostsFile, connectionTimeout)
+	tempDir, err := os.MkdirTemp("", "kasmlink-compose-")
 	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Failed to initialize SSH configuration")
-		return nil, fmt.Errorf("failed to initialize SSH configuration: %w", err)
+		return "", false, noCleanup, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Warn().Err(err).Str("tempDir", tempDir).Msg("Failed to remove temporary compose directory")
+		}
 	}
 
-	return sshConfig, nil
+	rewrittenPath := filepath.Join(tempDir, filepath.Base(composeFilePath))
+	if err := writeComposeDocument(document, rewrittenPath); err != nil {
+		cleanup()
+		return "", false, noCleanup, err
+	}
+	return rewrittenPath, true, cleanup, nil
+}
+
+// composeServiceNames returns the names of the services of a compose file started with the given profiles,
+// or nil if it cannot be loaded.
+func composeServiceNames(composeFilePath string, profiles []string) []string {
+	composeFile, err := dockercompose.LoadComposeFile(composeFilePath)
+	if err != nil {
+		return nil
+	}
+	return composeFile.ActiveServices(profiles)
 }
@@ -0,0 +1,169 @@
+package procedures
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// remoteBuildContextsDir is the directory below the remote compose directory that receives the build contexts.
+const remoteBuildContextsDir = "build-contexts"
+
+// BuildContextUpload describes a local build context directory or Dockerfile that has to be copied to the node.
+type BuildContextUpload struct {
+	LocalPath  string // Absolute local path of the directory or file.
+	RemoteDir  string // Remote directory receiving the directory contents or the file.
+	IsDir      bool   // LocalPath is a build context directory rather than a single Dockerfile.
+	ServiceRef string // Name of the first service referencing the upload, used for logging.
+}
+
+// RewriteBuildContexts points the build sections of a compose file at the locations they will have on the remote node.
+// Relative contexts are resolved against composeDir, the directory of the local compose file; absolute contexts are used as is.
+// Contexts that are URLs (git repositories, tarballs) are left untouched. A Dockerfile inside its context keeps its
+// relative path; a Dockerfile outside the context is uploaded separately and referenced by its absolute remote path.
+// Only the context and dockerfile of the build sections are changed, every other node of the document is kept as is.
+// Parameters:
+// - document: The parsed compose file to rewrite in place.
+// - composeDir: Local directory relative contexts are resolved against.
+// - remoteDir: Remote directory the compose file is deployed to.
+// Returns:
+// - The directories and files to upload, deduplicated and sorted by local path.
+// - An error if the document is malformed or a context or Dockerfile does not exist locally.
+func RewriteBuildContexts(document *yaml.Node, composeDir, remoteDir string) ([]BuildContextUpload, error) {
+	uploads := make(map[string]BuildContextUpload)
+
+	// Iterate the services in a stable order so remote paths do not depend on map ordering.
+	serviceNames, services, err := composeServices(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range serviceNames {
+		service := services[name]
+		buildNode := mappingValue(service, "build")
+		if buildNode == nil {
+			continue
+		}
+
+		var contextDir, dockerfileRef string
+		switch buildNode.Kind {
+		case yaml.ScalarNode:
+			contextDir = buildNode.Value
+		case yaml.MappingNode:
+			if node := mappingValue(buildNode, "context"); node != nil {
+				contextDir = node.Value
+			}
+			if node := mappingValue(buildNode, "dockerfile"); node != nil {
+				dockerfileRef = node.Value
+			}
+		default:
+			return nil, fmt.Errorf("build section of service %s is neither a path nor a mapping", name)
+		}
+		if isRemoteBuildContext(contextDir) {
+			continue
+		}
+
+		if contextDir == "" {
+			contextDir = "."
+		}
+		if !filepath.IsAbs(contextDir) {
+			contextDir = filepath.Join(composeDir, contextDir)
+		}
+		contextDir = filepath.Clean(contextDir)
+
+		info, err := os.Stat(contextDir)
+		if err != nil {
+			return nil, fmt.Errorf("build context %s of service %s: %w", contextDir, name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("build context %s of service %s is not a directory", contextDir, name)
+		}
+
+		upload, ok := uploads[contextDir]
+		if !ok {
+			upload = BuildContextUpload{
+				LocalPath:  contextDir,
+				RemoteDir:  path.Join(remoteDir, remoteBuildContextsDir, name),
+				IsDir:      true,
+				ServiceRef: name,
+			}
+			uploads[contextDir] = upload
+		}
+
+		if buildNode.Kind == yaml.ScalarNode {
+			// Keep the short syntax; a new node leaves an anchored build section shared with other services intact.
+			setMappingValue(service, "build", stringNode(upload.RemoteDir))
+			continue
+		}
+
+		build := *buildNode
+		build.Anchor = ""
+		build.Content = append([]*yaml.Node(nil), buildNode.Content...)
+		setMappingValue(&build, "context", stringNode(upload.RemoteDir))
+
+		if dockerfileRef != "" {
+			dockerfile := dockerfileRef
+			if !filepath.IsAbs(dockerfile) {
+				dockerfile = filepath.Join(contextDir, dockerfile)
+			}
+			dockerfile = filepath.Clean(dockerfile)
+
+			if _, err := os.Stat(dockerfile); err != nil {
+				return nil, fmt.Errorf("dockerfile %s of service %s: %w", dockerfile, name, err)
+			}
+
+			if relative, err := filepath.Rel(contextDir, dockerfile); err == nil && !strings.HasPrefix(relative, "..") {
+				setMappingValue(&build, "dockerfile", stringNode(filepath.ToSlash(relative)))
+			} else {
+				dockerfileUpload, ok := uploads[dockerfile]
+				if !ok {
+					dockerfileUpload = BuildContextUpload{
+						LocalPath:  dockerfile,
+						RemoteDir:  path.Join(remoteDir, remoteBuildContextsDir, name+"-dockerfile"),
+						ServiceRef: name,
+					}
+					uploads[dockerfile] = dockerfileUpload
+				}
+				setMappingValue(&build, "dockerfile", stringNode(path.Join(dockerfileUpload.RemoteDir, filepath.Base(dockerfile))))
+			}
+		}
+
+		setMappingValue(service, "build", &build)
+	}
+
+	result := make([]BuildContextUpload, 0, len(uploads))
+	for _, upload := range uploads {
+		result = append(result, upload)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LocalPath < result[j].LocalPath })
+	return result, nil
+}
+
+// hasBuildSections reports whether any service of the compose document is built instead of pulled.
+func hasBuildSections(document *yaml.Node) (bool, error) {
+	serviceNames, services, err := composeServices(document)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range serviceNames {
+		if mappingValue(services[name], "build") != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isRemoteBuildContext reports whether a build context is fetched by Docker itself, e.g. a git repository URL.
+func isRemoteBuildContext(context string) bool {
+	for _, prefix := range []string{"http://", "https://", "git://", "git@", "github.com/", "ssh://"} {
+		if strings.HasPrefix(context, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+	shadowscp "kasmlink/pkg/scp"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// ServiceEnvFileName returns the name of the env file generated for a service, e.g. ".env.db".
+func ServiceEnvFileName(service string) string {
+	return ".env." + service
+}
+
+// WriteServiceEnvFiles writes an env file per service of serviceEnv into dir and adds it to the env_file list of
+// the service, so the variables are kept out of the compose YAML and out of process arguments.
+// The files are created with 0600 permissions and referenced relative to the compose file, so they have to be
+// placed next to it.
+// Parameters:
+// - document: The parsed compose file to rewrite in place; nodes other than the env_file lists are kept as is.
+// - serviceEnv: The environment variables per service name.
+// - dir: Local directory receiving the env files.
+// Returns:
+// - The paths of the written env files, sorted by service name.
+// - An error if a service is not part of the compose file or a variable cannot be written to an env file.
+func WriteServiceEnvFiles(document *yaml.Node, serviceEnv map[string]map[string]string, dir string) ([]string, error) {
+	_, serviceNodes, err := composeServices(document)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0, len(serviceEnv))
+	for name := range serviceEnv {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	paths := make([]string, 0, len(services))
+	for _, name := range services {
+		service, ok := serviceNodes[name]
+		if !ok {
+			return nil, fmt.Errorf("environment is configured for service %s, which is not part of the compose file", name)
+		}
+
+		content, err := formatEnvFile(serviceEnv[name])
+		if err != nil {
+			return nil, fmt.Errorf("environment of service %s: %w", name, err)
+		}
+		envFilePath := filepath.Join(dir, ServiceEnvFileName(name))
+		if err := os.WriteFile(envFilePath, []byte(content), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write env file of service %s: %w", name, err)
+		}
+		// WriteFile keeps the mode of an existing file.
+		if err := os.Chmod(envFilePath, 0600); err != nil {
+			return nil, fmt.Errorf("failed to restrict permissions of env file %s: %w", envFilePath, err)
+		}
+
+		if err := addEnvFileReference(service, ServiceEnvFileName(name)); err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		paths = append(paths, envFilePath)
+	}
+	return paths, nil
+}
+
+// addEnvFileReference appends envFileName to the env_file entry of a service mapping unless it is already listed.
+// The short syntax "env_file: common.env" is turned into a list; entries of the long syntax are matched by path.
+// A new list node is set, so an env_file list shared through an anchor is not changed for other services.
+func addEnvFileReference(service *yaml.Node, envFileName string) error {
+	var entries []*yaml.Node
+	switch current := mappingValue(service, "env_file"); {
+	case current == nil || current.Tag == "!!null":
+	case current.Kind == yaml.ScalarNode:
+		entries = []*yaml.Node{current}
+	case current.Kind == yaml.SequenceNode:
+		entries = current.Content
+	default:
+		return fmt.Errorf("env_file is neither a path nor a list")
+	}
+
+	for _, entry := range entries {
+		if entry.Kind == yaml.MappingNode {
+			entry = mappingValue(entry, "path")
+		}
+		if entry != nil && entry.Value == envFileName {
+			return nil
+		}
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	list.Content = append(append(list.Content, entries...), stringNode(envFileName))
+	setMappingValue(service, "env_file", list)
+	return nil
+}
+
+// formatEnvFile renders variables as KEY='value' lines sorted by key. Single quotes keep values literal, so
+// compose neither interpolates them nor strips comments.
+func formatEnvFile(env map[string]string) (string, error) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		value := env[key]
+		if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+			return "", fmt.Errorf("invalid variable name %q", key)
+		}
+		if strings.ContainsAny(value, "'\r\n") {
+			return "", fmt.Errorf("value of %s contains a quote or line break, which env files cannot hold", key)
+		}
+		fmt.Fprintf(&builder, "%s='%s'\n", key, value)
+	}
+	return builder.String(), nil
+}
+
+// prepareServiceEnvFiles writes the env files of serviceEnv and a copy of the compose file referencing
+// them into a temporary directory.
+// Returns:
+// - The compose file to upload, with the same name as composeFilePath.
+// - The env files to upload next to it.
+// - A cleanup function removing the temporary directory, always safe to call.
+// - An error if the compose file cannot be parsed or an env file cannot be written.
+func prepareServiceEnvFiles(composeFilePath string, serviceEnv map[string]map[string]string) (string, []string, func(), error) {
+	noCleanup := func() {}
+
+	document, err := loadComposeDocument(composeFilePath)
+	if err != nil {
+		return "", nil, noCleanup, fmt.Errorf("failed to add env files: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "kasmlink-env-")
+	if err != nil {
+		return "", nil, noCleanup, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Warn().Err(err).Str("tempDir", tempDir).Msg("Failed to remove temporary env file directory")
+		}
+	}
+
+	envFiles, err := WriteServiceEnvFiles(document, serviceEnv, tempDir)
+	if err != nil {
+		cleanup()
+		return "", nil, noCleanup, err
+	}
+	rewrittenPath := filepath.Join(tempDir, filepath.Base(composeFilePath))
+	if err := writeComposeDocument(document, rewrittenPath); err != nil {
+		cleanup()
+		return "", nil, noCleanup, err
+	}
+	return rewrittenPath, envFiles, cleanup, nil
+}
+
+// uploadServiceEnvFiles copies the env files into targetNodePath and restricts them to the SSH user.
+func uploadServiceEnvFiles(ctx context.Context, sshClient *shadowssh.SSHClient, envFiles []string, targetNodePath string) error {
+	remotePaths := make([]string, 0, len(envFiles))
+	for _, envFile := range envFiles {
+		log.Info().
+			Str("envFile", filepath.Base(envFile)).
+			Str("destination", targetNodePath).
+			Msg("Copying service env file onto remote node")
+		if err := shadowscp.ShadowCopyFileWithClient(ctx, envFile, targetNodePath, sshClient); err != nil {
+			return fmt.Errorf("failed to copy env file %s onto remote node: %w", filepath.Base(envFile), err)
+		}
+		remotePaths = append(remotePaths, path.Join(filepath.ToSlash(targetNodePath), filepath.Base(envFile)))
+	}
+	if len(remotePaths) == 0 {
+		return nil
+	}
+
+	command := "chmod 600 " + shadowssh.ShellJoin(remotePaths...)
+	if output, err := sshClient.ExecuteCommand(ctx, command); err != nil {
+		log.Error().Err(err).Str("output", output).Msg("Failed to restrict permissions of service env files")
+		return fmt.Errorf("failed to restrict permissions of env files on remote node: %w", err)
+	}
+	return nil
+}
+
+// removeServiceEnvFiles deletes the env files generated for the services of serviceEnv from targetNodePath.
+func removeServiceEnvFiles(ctx context.Context, sshClient *shadowssh.SSHClient, serviceEnv map[string]map[string]string, targetNodePath string) error {
+	if len(serviceEnv) == 0 {
+		return nil
+	}
+	remotePaths := make([]string, 0, len(serviceEnv))
+	for name := range serviceEnv {
+		remotePaths = append(remotePaths, path.Join(filepath.ToSlash(targetNodePath), ServiceEnvFileName(name)))
+	}
+	sort.Strings(remotePaths)
+
+	command := "rm -f " + shadowssh.ShellJoin(remotePaths...)
+	if output, err := sshClient.ExecuteCommand(ctx, command); err != nil {
+		log.Error().Err(err).Str("output", output).Msg("Failed to remove service env files")
+		return fmt.Errorf("failed to remove env files from remote node: %w", err)
+	}
+	return nil
+}
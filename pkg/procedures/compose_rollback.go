@@ -0,0 +1,75 @@
+package procedures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// ErrRolledBack is wrapped by the error of DeployComposeFile when a failed deployment was rolled back to the
+// previous compose file.
+var ErrRolledBack = errors.New("deployment rolled back to the previous compose file")
+
+// composeBackupMarker is printed by the backup command when a deployed compose file was copied.
+const composeBackupMarker = "kasmlink-backup-created"
+
+// composeBackupPath returns the path the deployed compose file is kept at during a deployment.
+func composeBackupPath(remoteComposeFile string) string {
+	return remoteComposeFile + ".bak"
+}
+
+// backupRemoteComposeFile copies the deployed compose file to <file>.bak on the remote node.
+// Returns:
+// - Whether a compose file was deployed and backed up; there is nothing to roll back to on a first deployment.
+// - An error if the backup cannot be created.
+func backupRemoteComposeFile(ctx context.Context, sshClient *shadowssh.SSHClient, remoteComposeFile string) (bool, error) {
+	backup := composeBackupPath(remoteComposeFile)
+	file := shadowssh.ShellQuote(remoteComposeFile)
+	command := fmt.Sprintf("if [ -f %s ]; then cp -p %s %s && echo %s; fi", file, file, shadowssh.ShellQuote(backup), composeBackupMarker)
+	output, err := sshClient.ExecuteCommand(ctx, command)
+	if err != nil {
+		log.Error().Err(err).Str("output", output).Str("composeFile", remoteComposeFile).Msg("Failed to back up deployed compose file")
+		return false, fmt.Errorf("failed to back up compose file %s on remote node: %w", remoteComposeFile, err)
+	}
+	if !strings.Contains(output, composeBackupMarker) {
+		log.Warn().Str("composeFile", remoteComposeFile).Msg("No deployed compose file to back up, a failed deployment cannot be rolled back")
+		return false, nil
+	}
+
+	log.Info().Str("composeFile", remoteComposeFile).Str("backup", backup).Msg("Backed up deployed compose file")
+	return true, nil
+}
+
+// rollbackComposeDeployment restores the backup of the compose file of project and starts it again after the
+// deployment failed with cause.
+// Returns:
+// - cause wrapped with ErrRolledBack if the previous deployment was restored, or an error describing both
+// cause and the failed rollback.
+func rollbackComposeDeployment(ctx context.Context, sshClient *shadowssh.SSHClient, remote *dockercli.DockerClient, project dockercli.ComposeProject, profiles []string, cause error) error {
+	backup := composeBackupPath(project.File)
+	log.Warn().
+		Err(cause).
+		Str("composeFile", project.File).
+		Str("backup", backup).
+		Msg("Deployment failed, rolling back to the previous compose file")
+
+	command := shadowssh.ShellJoin("cp", "-p", backup, project.File)
+	if output, err := sshClient.ExecuteCommand(ctx, command); err != nil {
+		log.Error().Err(err).Str("output", output).Str("backup", backup).Msg("Failed to restore compose file backup")
+		return fmt.Errorf("%w; rollback failed to restore %s: %v", cause, backup, err)
+	}
+
+	// The images of the previous deployment are still on the node, so nothing is built.
+	if _, err := remote.ComposeUp(ctx, project, false, profiles, nil); err != nil {
+		log.Error().Err(err).Str("composeFile", project.File).Msg("Failed to start the restored compose file")
+		return fmt.Errorf("%w; rollback failed to start the previous compose file: %v", cause, err)
+	}
+
+	log.Warn().Str("composeFile", project.File).Msg("Restored and started the previous compose file")
+	return fmt.Errorf("%w: %w", ErrRolledBack, cause)
+}
@@ -0,0 +1,150 @@
+package procedures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+	"kasmlink/pkg/dockercompose"
+)
+
+// DefaultDockerfilesDir is the folder next to a services spec holding the Dockerfiles of its services.
+const DefaultDockerfilesDir = "container"
+
+// ComposeSpec lists the services of a custom compose file. Every service is built from its Dockerfile in the
+// Dockerfiles folder, e.g. container/Dockerfile.db for service "db" (see findDockerfileInDir for the naming
+// conventions), unless the spec names the Dockerfile explicitly.
+//
+//	dockerfiles_dir: container
+//	dockerfiles:
+//	  admin: tools/admin.Dockerfile
+//	network: kasm_custom
+//	services:
+//	  - name: db
+//	    image: kasm/db:1.0
+//	    environment:
+//	      POSTGRES_DB: kasm
+type ComposeSpec struct {
+	// DockerfilesDir is the Dockerfiles folder relative to the spec file; DefaultDockerfilesDir when empty.
+	DockerfilesDir string `yaml:"dockerfiles_dir,omitempty"`
+	// Dockerfiles maps service names to their Dockerfile relative to the spec file, bypassing the lookup.
+	Dockerfiles map[string]string `yaml:"dockerfiles,omitempty"`
+	// Network is an existing network every service joins; the services use the default network when empty.
+	Network  string               `yaml:"network,omitempty"`
+	Services []ComposeServiceSpec `yaml:"services"`
+}
+
+// ComposeServiceSpec describes a single service of a ComposeSpec.
+type ComposeServiceSpec struct {
+	Name        string            `yaml:"name"`
+	Image       string            `yaml:"image,omitempty"` // Tag of the built image; compose names it when empty.
+	Ports       []string          `yaml:"ports,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	DependsOn   []string          `yaml:"depends_on,omitempty"`
+}
+
+// GenerateComposeFromSpec creates a compose file for the services listed in a spec YAML, with a build section
+// per service pointing at its Dockerfile.
+// Build contexts are relative to the directory of the spec file, so the compose file belongs next to it.
+// Parameters:
+// - specPath: Path of the services spec YAML.
+// - dockerfilesDir: Dockerfiles folder overriding the one of the spec; relative paths are resolved against
+// the spec directory. The spec's folder, or DefaultDockerfilesDir, is used when empty.
+// Returns:
+// - The generated compose file.
+// - An error if the spec is invalid or a service has no unique Dockerfile.
+func GenerateComposeFromSpec(specPath, dockerfilesDir string) (dockercompose.ComposeFile, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return dockercompose.ComposeFile{}, fmt.Errorf("failed to read services spec %s: %w", specPath, err)
+	}
+	var spec ComposeSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return dockercompose.ComposeFile{}, fmt.Errorf("failed to parse services spec %s: %w", specPath, err)
+	}
+	if len(spec.Services) == 0 {
+		return dockercompose.ComposeFile{}, fmt.Errorf("services spec %s lists no services", specPath)
+	}
+
+	if dockerfilesDir == "" {
+		dockerfilesDir = spec.DockerfilesDir
+	}
+	if dockerfilesDir == "" {
+		dockerfilesDir = DefaultDockerfilesDir
+	}
+	specDir := filepath.Dir(specPath)
+	if !filepath.IsAbs(dockerfilesDir) {
+		dockerfilesDir = filepath.Join(specDir, dockerfilesDir)
+	}
+
+	composeFile := dockercompose.ComposeFile{Services: make(map[string]dockercompose.Service, len(spec.Services))}
+	if spec.Network != "" {
+		composeFile.Networks = map[string]dockercompose.Network{spec.Network: {External: true}}
+	}
+
+	for _, serviceSpec := range spec.Services {
+		if serviceSpec.Name == "" {
+			return dockercompose.ComposeFile{}, fmt.Errorf("services spec %s contains a service without name", specPath)
+		}
+		if _, exists := composeFile.Services[serviceSpec.Name]; exists {
+			return dockercompose.ComposeFile{}, fmt.Errorf("services spec %s lists service %s twice", specPath, serviceSpec.Name)
+		}
+
+		dockerfilePath, err := specDockerfile(spec, specDir, dockerfilesDir, serviceSpec.Name)
+		if err != nil {
+			return dockercompose.ComposeFile{}, fmt.Errorf("failed to find Dockerfile for service %s: %w", serviceSpec.Name, err)
+		}
+		buildContext, err := filepath.Rel(specDir, filepath.Dir(dockerfilePath))
+		if err != nil {
+			buildContext = filepath.Dir(dockerfilePath)
+		}
+
+		service := dockercompose.Service{
+			Image: serviceSpec.Image,
+			Build: &dockercompose.BuildConfig{
+				Context:    filepath.ToSlash(buildContext),
+				Dockerfile: filepath.Base(dockerfilePath),
+			},
+			Ports:     serviceSpec.Ports,
+			Volumes:   serviceSpec.Volumes,
+			DependsOn: serviceSpec.DependsOn,
+		}
+		if len(serviceSpec.Environment) > 0 {
+			service.Environment = serviceSpec.Environment
+		}
+		if spec.Network != "" {
+			service.NetworkConfig.Networks = []string{spec.Network}
+		}
+		composeFile.Services[serviceSpec.Name] = service
+
+		log.Debug().
+			Str("service", serviceSpec.Name).
+			Str("dockerfile", dockerfilePath).
+			Msg("Added service from spec")
+	}
+
+	log.Info().
+		Str("specPath", specPath).
+		Int("services", len(composeFile.Services)).
+		Msg("Generated compose file from services spec")
+	return composeFile, nil
+}
+
+// specDockerfile returns the Dockerfile of a service: the override of the spec if there is one, otherwise the
+// Dockerfile found in dockerfilesDir.
+func specDockerfile(spec ComposeSpec, specDir, dockerfilesDir, serviceName string) (string, error) {
+	override, ok := spec.Dockerfiles[serviceName]
+	if !ok {
+		return findDockerfileInDir(dockerfilesDir, serviceName)
+	}
+	if !filepath.IsAbs(override) {
+		override = filepath.Join(specDir, override)
+	}
+	if _, err := os.Stat(override); err != nil {
+		return "", fmt.Errorf("dockerfile override %s: %w", override, err)
+	}
+	return override, nil
+}
@@ -0,0 +1,162 @@
+package procedures
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadComposeDocument parses a compose file into a YAML node tree. Unlike dockercompose.LoadComposeFile it keeps
+// the keys the typed schema does not model, comments and the original value styles, so the file can be
+// rewritten without losing anything compose would have honoured.
+// Parameters:
+// - filePath: The compose file to parse.
+// Returns:
+// - The document node of the compose file.
+// - An error if the file cannot be read or is not valid YAML.
+func loadComposeDocument(filePath string) (*yaml.Node, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file %s: %w", filePath, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file %s: %w", filePath, err)
+	}
+	return &document, nil
+}
+
+// writeComposeDocument writes a node tree loaded by loadComposeDocument to filePath.
+func writeComposeDocument(document *yaml.Node, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+	}
+	encoder := yaml.NewEncoder(file)
+	if err := encoder.Encode(document); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write compose file to %s: %w", filePath, err)
+	}
+	if err := encoder.Close(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write compose file to %s: %w", filePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close compose file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// composeServices returns the service mappings of a compose document by name, together with the names in sorted order.
+// A service defined as an alias is replaced by a copy of the anchored mapping, so changes to one service do not
+// leak into the others sharing the anchor.
+// Parameters:
+// - document: The document node returned by loadComposeDocument.
+// Returns:
+// - The service names, sorted.
+// - The mapping node of each service.
+// - An error if the document or a service is not a mapping.
+func composeServices(document *yaml.Node) ([]string, map[string]*yaml.Node, error) {
+	services := make(map[string]*yaml.Node)
+	if document.Kind == 0 {
+		return nil, services, nil
+	}
+
+	root := document
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, services, nil
+		}
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("compose file is not a mapping")
+	}
+
+	servicesNode := mappingValue(root, "services")
+	if servicesNode == nil || servicesNode.Tag == "!!null" {
+		return nil, services, nil
+	}
+	if servicesNode.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("services of the compose file are not a mapping")
+	}
+
+	names := make([]string, 0, len(servicesNode.Content)/2)
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		name := servicesNode.Content[i].Value
+		service := servicesNode.Content[i+1]
+		if service.Kind == yaml.AliasNode && service.Alias != nil {
+			copied := *service.Alias
+			copied.Anchor = ""
+			copied.Content = append([]*yaml.Node(nil), service.Alias.Content...)
+			service = &copied
+			servicesNode.Content[i+1] = service
+		}
+		if service.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("service %s is not a mapping", name)
+		}
+		names = append(names, name)
+		services[name] = service
+	}
+	sort.Strings(names)
+	return names, services, nil
+}
+
+// mappingValue returns the value of key in a mapping node, following aliases and merge keys ("<<: *base").
+// Returns nil if the key is not set.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil {
+		return nil
+	}
+	if mapping.Kind == yaml.AliasNode {
+		mapping = mapping.Alias
+	}
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keyNode, valueNode := mapping.Content[i], mapping.Content[i+1]
+		if keyNode.Tag == "!!merge" {
+			if valueNode.Kind == yaml.SequenceNode {
+				merges = append(merges, valueNode.Content...)
+			} else {
+				merges = append(merges, valueNode)
+			}
+			continue
+		}
+		if keyNode.Value == key {
+			if valueNode.Kind == yaml.AliasNode {
+				return valueNode.Alias
+			}
+			return valueNode
+		}
+	}
+	// Keys set directly take precedence over merged ones, and earlier merges over later ones.
+	for _, merged := range merges {
+		if value := mappingValue(merged, key); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key of a mapping node to value. A key set directly on the mapping is replaced; a key
+// inherited through a merge key is overridden by adding it to the mapping, leaving the anchored node untouched.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Tag != "!!merge" && mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, stringNode(key), value)
+}
+
+// stringNode returns a scalar node holding value as a string.
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
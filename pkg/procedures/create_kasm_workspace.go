@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
 	"kasmlink/pkg/userParser"
 	"kasmlink/pkg/webApi"
 )
@@ -12,7 +14,7 @@ import (
 // CreateKasmWorkspace creates a workspace based on user-provided YAML file
 func CreateKasmWorkspace(ctx context.Context, imageDetail webApi.ImageDetail, details userParser.UserDetails, kasmApi *webApi.KasmAPI) error {
 	// Parse volume mounts
-	volumeMappings, err := parseVolumeMounts(details)
+	volumeMappings, err := UserVolumeMappings(details)
 	if err != nil {
 		return fmt.Errorf("failed to parse volume mounts: %w", err)
 	}
@@ -28,28 +30,27 @@ func CreateKasmWorkspace(ctx context.Context, imageDetail webApi.ImageDetail, de
 		return fmt.Errorf("failed to marshal run configuration: %w", err)
 	}
 
-	volumeMappingsJSON, err := json.Marshal(volumeMappings)
-	if err != nil {
-		return fmt.Errorf("failed to marshal volume mappings: %w", err)
-	}
-
 	targetImage := webApi.TargetImage{
 		Name:                  imageDetail.Name,
 		Cores:                 imageDetail.Cores,
 		Memory:                imageDetail.Memory * 1000000,
 		FriendlyName:          imageDetail.FriendlyName,
 		Description:           imageDetail.Description,
-		RestrictNetworkNames:  []string{details.Network},  // Restrict to specified network
-		VolumeMappings:        string(volumeMappingsJSON), // Pass as serialized JSON
-		RunConfig:             string(runConfigJSON),      // Serialized run configuration
-		AllowNetworkSelection: false,                      // Allows network selection
+		RestrictNetworkNames:  []string{details.Network}, // Restrict to specified network
+		VolumeMappings:        volumeMappings,            // Pass as serialized JSON
+		RunConfig:             string(runConfigJSON),     // Serialized run configuration
+		AllowNetworkSelection: false,                     // Allows network selection
+		RequireGPU:            imageDetail.RequireGPU,
+		GPUCount:              imageDetail.GPUCount,
+	}
+
+	if err := webApi.ValidateResources(targetImage.Cores, targetImage.Memory); err != nil {
+		return fmt.Errorf("invalid workspace %s: %w", imageDetail.FriendlyName, err)
 	}
 
 	// Create the request payload
 	req := webApi.CreateImageRequest{
-		APIKey:       kasmApi.APIKey,
-		APIKeySecret: kasmApi.APIKeySecret,
-		TargetImage:  targetImage,
+		TargetImage: targetImage,
 	}
 
 	// Call the API to create the image
@@ -63,3 +64,48 @@ func CreateKasmWorkspace(ctx context.Context, imageDetail webApi.ImageDetail, de
 		Msg("Workspace created successfully")
 	return nil
 }
+
+// VerifyGPUSupport checks that the node can run a GPU workspace before it is created.
+// Images that neither require a GPU nor request GPUs pass without connecting to the node.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - sshConfig: SSH configuration of the agent node the workspace will run on.
+// - imageDetail: The workspace image with its GPU requirements.
+// Returns:
+// - An error if the node has no nvidia runtime or GPUs, or fewer GPUs than requested.
+func VerifyGPUSupport(ctx context.Context, sshConfig *shadowssh.SSHConfig, imageDetail webApi.ImageDetail) error {
+	if !imageDetail.RequireGPU && imageDetail.GPUCount <= 0 {
+		return nil
+	}
+
+	client, err := shadowssh.NewSSHClient(ctx, sshConfig)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH connection: %w", err)
+	}
+	defer client.Close()
+
+	gpuInfo, err := dockercli.NewRemoteDockerClient(client.Sudo(), 1).DetectGPUs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to detect GPUs on %s: %w", sshConfig.Host, err)
+	}
+
+	if !gpuInfo.Supported() {
+		log.Error().
+			Str("host", sshConfig.Host).
+			Str("image", imageDetail.Name).
+			Bool("nvidia_runtime", gpuInfo.NvidiaRuntime).
+			Int("gpu_count", len(gpuInfo.GPUs)).
+			Msg("Node cannot run GPU workspaces")
+		return fmt.Errorf("workspace %s requires a GPU but node %s has no nvidia runtime or GPUs", imageDetail.FriendlyName, sshConfig.Host)
+	}
+	if int(imageDetail.GPUCount) > len(gpuInfo.GPUs) {
+		return fmt.Errorf("workspace %s requests %v GPUs but node %s only has %d", imageDetail.FriendlyName, imageDetail.GPUCount, sshConfig.Host, len(gpuInfo.GPUs))
+	}
+
+	log.Info().
+		Str("host", sshConfig.Host).
+		Str("image", imageDetail.Name).
+		Int("gpu_count", len(gpuInfo.GPUs)).
+		Msg("Node supports GPU workspace")
+	return nil
+}
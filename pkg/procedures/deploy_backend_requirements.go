@@ -0,0 +1,119 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// BackendSpec describes the Kasm backend requirements of a custom run: the compose file starting the backend
+// containers (e.g. DB, NFS and core) and the network the workspaces of the run use.
+type BackendSpec struct {
+	// ComposeFilePath is the local compose file of the backend containers.
+	ComposeFilePath string
+	// TargetNodePath is the directory on the node the compose file is uploaded to.
+	TargetNodePath string
+	// ComposeOptions holds the compose project name and env file of the backend.
+	ComposeOptions ComposeDeployOptions
+	// Services are the services that must be running; all services of the compose file when empty.
+	Services []string
+	// Network is created unless a network with its name exists; skipped when the name is empty.
+	Network dockercli.NetworkOptions
+}
+
+// DeployBackendRequirements makes sure the backend containers of a custom run are running and its network
+// exists on the node. It is idempotent: an existing network is kept, and the compose file is only uploaded and
+// started when a required service is not running.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - sshConfig: SSH configuration of the node; nil loads it from the SSH_* environment variables. Ignored when
+// spec.ComposeOptions.SSHClient is set.
+// - spec: The backend to deploy.
+// Returns:
+// - An error if the network cannot be ensured or the backend cannot be deployed.
+func DeployBackendRequirements(ctx context.Context, sshConfig *shadowssh.SSHConfig, spec BackendSpec) error {
+	if spec.ComposeFilePath == "" || spec.TargetNodePath == "" {
+		return fmt.Errorf("backend requires a compose file and a target node path")
+	}
+	if err := validateComposeInputs(spec.ComposeFilePath, spec.ComposeOptions); err != nil {
+		return err
+	}
+
+	sshClient, sshConfig, release, err := connectSSH(ctx, spec.ComposeOptions.SSHClient, sshConfig)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to connect to remote node")
+		return err
+	}
+	defer release()
+	remote := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+
+	// Step 1: Create the network of the run unless it exists.
+	if spec.Network.Name != "" {
+		networkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		created, err := remote.EnsureNetwork(networkCtx, spec.Network)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to ensure network %s: %w", spec.Network.Name, err)
+		}
+		log.Info().
+			Str("network", spec.Network.Name).
+			Bool("created", created).
+			Msg("Backend network is available")
+	}
+
+	// Step 2: Deploy the backend unless every required service is running.
+	required := spec.Services
+	if len(required) == 0 {
+		required = composeServiceNames(spec.ComposeFilePath, spec.ComposeOptions.Profiles)
+	}
+	project := remoteComposeProject(spec.ComposeFilePath, spec.TargetNodePath, spec.ComposeOptions)
+	missing := missingBackendServices(ctx, remote, project, required)
+	if len(required) > 0 && len(missing) == 0 {
+		log.Info().
+			Str("composeFile", project.File).
+			Strs("services", required).
+			Msg("Backend services are already running")
+		return nil
+	}
+
+	log.Info().
+		Str("composeFile", filepath.Base(spec.ComposeFilePath)).
+		Strs("missingServices", missing).
+		Msg("Deploying backend services")
+	if err := deployComposeFile(ctx, sshClient, sshConfig, spec.ComposeFilePath, spec.TargetNodePath, spec.ComposeOptions); err != nil {
+		return fmt.Errorf("failed to deploy backend: %w", err)
+	}
+	return nil
+}
+
+// missingBackendServices returns the required services without a running container. All services are
+// reported missing when the project cannot be inspected, e.g. before its first deployment.
+func missingBackendServices(ctx context.Context, remote *dockercli.DockerClient, project dockercli.ComposeProject, required []string) []string {
+	psCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	running, err := remote.ComposeRunningServices(psCtx, project)
+	if err != nil {
+		log.Debug().Err(err).Str("composeFile", project.File).Msg("Cannot inspect backend services, treating them as stopped")
+		return required
+	}
+
+	runningSet := make(map[string]bool, len(running))
+	for _, service := range running {
+		runningSet[service] = true
+	}
+	var missing []string
+	for _, service := range required {
+		if !runningSet[service] {
+			missing = append(missing, service)
+		}
+	}
+	return missing
+}
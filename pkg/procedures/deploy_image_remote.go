@@ -154,7 +154,7 @@ func DeployImages(ctx context.Context, dockerFilePath string, imageName string,
 
 	// Execute the docker load command on the remote node
 	loadCmd := fmt.Sprintf("docker load -i %s", remoteTarPath)
-	output, err := client.ExecuteCommandWithOutput(ctx, loadCmd, 1*time.Minute)
+	output, err := client.ExecuteCommandWithOutputSudo(ctx, loadCmd, 1*time.Minute, imageLoadTimeout)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -174,7 +174,7 @@ func DeployImages(ctx context.Context, dockerFilePath string, imageName string,
 		Msg("Removing tar file from remote node")
 
 	removeCmd := fmt.Sprintf("rm %s", remoteTarPath)
-	output, err = client.ExecuteCommandWithOutput(ctx, removeCmd, 30*time.Second)
+	output, err = client.ExecuteCommandWithOutput(ctx, removeCmd, 30*time.Second, cleanupCommandTimeout)
 	if err != nil {
 		log.Warn().
 			Err(err).
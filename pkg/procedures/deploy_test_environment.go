@@ -2,22 +2,42 @@ package procedures
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
 	shadowssh "kasmlink/pkg/sshmanager"
 	"kasmlink/pkg/userParser"
 	"kasmlink/pkg/webApi"
 	"path/filepath"
+	"sync"
 )
 
+// DefaultProvisioningWorkers is the number of users provisioned concurrently when no worker count is configured.
+const DefaultProvisioningWorkers = 4
+
+// TestEnvironmentOptions controls how CreateTestEnvironment provisions users.
+type TestEnvironmentOptions struct {
+	// Workers is the maximum number of users provisioned concurrently. Values below 1 fall back to DefaultProvisioningWorkers.
+	Workers int
+}
+
 // CreateTestEnvironment creates a test environment based on the user configuration file.
+// Docker images are ensured on the remote node once per distinct tag before any user is processed,
+// afterwards users are provisioned concurrently by a bounded pool of workers.
+// The role of a user names the Kasm group it is added to. Groups are not created: the public Kasm API offers no
+// endpoint for it, so every group must exist beforehand, and a user whose group is missing fails on its own.
+// Every provisioned user is written back to the configuration file as soon as it completes, so a
+// cancelled or failed run can be restarted and resumes with the users that are not yet provisioned.
 // Parameters:
 // - ctx: Context for managing cancellation and timeouts.
 // - userConfigurationFilePath: Path to the user configuration YAML file.
 // - sshConfig: SSH configuration for connecting to the remote node.
+// - kasmApi: KasmAPI instance used to create users and sessions.
+// - options: Provisioning options such as the worker count.
 // Returns:
-// - An error if any step in the environment creation process fails.
-func CreateTestEnvironment(ctx context.Context, userConfigurationFilePath string, sshConfig *shadowssh.SSHConfig, kasmApi *webApi.KasmAPI) error {
+// - An error aggregating every user that could not be provisioned.
+func CreateTestEnvironment(ctx context.Context, userConfigurationFilePath string, sshConfig *shadowssh.SSHConfig, kasmApi *webApi.KasmAPI, options TestEnvironmentOptions) error {
 	// Initialize UserParser
 	userParserInstance := userParser.NewUserParser()
 
@@ -64,14 +84,111 @@ func CreateTestEnvironment(ctx context.Context, userConfigurationFilePath string
 		}
 	}()
 
-	// Step 3: Iterate over each user in the configuration
+	// Users recorded by a previous run are checkpoints and are not provisioned again.
+	pending := make([]userParser.UserDetails, 0, len(usersConfig.UserDetails))
 	for _, user := range usersConfig.UserDetails {
-		log.Info().
-			Str("username", user.TargetUser.Username).
-			Str("docker_image_tag", user.AssignedContainerTag).
-			Msg("Processing user")
+		if isUserProvisioned(user) {
+			log.Info().
+				Str("username", user.TargetUser.Username).
+				Str("user_id", user.TargetUser.UserID).
+				Str("kasm_session_of_container", user.KasmSessionOfContainer).
+				Msg("User already provisioned in a previous run. Skipping.")
+			continue
+		}
+		pending = append(pending, user)
+	}
+	skipped := len(usersConfig.UserDetails) - len(pending)
+
+	// Step 3: Ensure every assigned Docker image exists on the remote node.
+	// This phase touches shared remote state and therefore runs serially, once per distinct tag.
+	if err := ensureRemoteImages(ctx, client, sshConfig, pending); err != nil {
+		return err
+	}
+
+	// Step 4: Provision users concurrently
+	workers := options.Workers
+	if workers < 1 {
+		workers = DefaultProvisioningWorkers
+	}
+
+	log.Info().
+		Int("workers", workers).
+		Int("user_count", len(pending)).
+		Int("skipped_users", skipped).
+		Msg("Provisioning users")
+
+	var (
+		wg       sync.WaitGroup
+		errMutex sync.Mutex
+		errs     []error
+	)
+	semaphore := make(chan struct{}, workers)
+	groups := webApi.NewGroupCache(kasmApi)
+
+	for _, user := range pending {
+		wg.Add(1)
+		go func(user userParser.UserDetails) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				errMutex.Lock()
+				errs = append(errs, fmt.Errorf("user %s not provisioned: %w", user.TargetUser.Username, ctx.Err()))
+				errMutex.Unlock()
+				return
+			}
+
+			// Do not start new work once the run has been cancelled; completed users are already checkpointed.
+			if err := ctx.Err(); err != nil {
+				errMutex.Lock()
+				errs = append(errs, fmt.Errorf("user %s not provisioned: %w", user.TargetUser.Username, err))
+				errMutex.Unlock()
+				return
+			}
+
+			if err := provisionUser(ctx, kasmApi, client.Sudo(), groups, userParserInstance, userConfigurationFilePath, user); err != nil {
+				errMutex.Lock()
+				errs = append(errs, err)
+				errMutex.Unlock()
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		log.Error().
+			Int("failed_users", len(errs)).
+			Int("user_count", len(pending)).
+			Msg("Test environment creation completed with errors")
+		return fmt.Errorf("failed to provision %d of %d users: %w", len(errs), len(pending), errors.Join(errs...))
+	}
+
+	log.Info().
+		Int("skipped_users", skipped).
+		Msg("Test environment creation completed successfully")
+
+	return nil
+}
 
-		// Step 3.1: Ensure that DockerImageTag exists on the remote node
+// ensureRemoteImages makes sure every distinct image tag assigned to users is present on the remote node.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - client: SSHClient connected to the remote node.
+// - sshConfig: SSH configuration used when images have to be deployed.
+// - users: Users whose assigned container tags should be present.
+// Returns:
+// - An error if an image cannot be checked or deployed.
+func ensureRemoteImages(ctx context.Context, client *shadowssh.SSHClient, sshConfig *shadowssh.SSHConfig, users []userParser.UserDetails) error {
+	seen := make(map[string]struct{})
+	for _, user := range users {
+		if _, ok := seen[user.AssignedContainerTag]; ok {
+			continue
+		}
+		seen[user.AssignedContainerTag] = struct{}{}
+
+		// Ensure that DockerImageTag exists on the remote node
 		missingImages, err := checkRemoteImages(ctx, client, []string{user.AssignedContainerTag})
 		if err != nil {
 			log.Error().
@@ -86,7 +203,7 @@ func CreateTestEnvironment(ctx context.Context, userConfigurationFilePath string
 				Str("image_tag", user.AssignedContainerTag).
 				Msg("Required Docker image tag does not exist on remote node. Deploying image.")
 
-			// Step 3.2: Deploy the missing Docker image
+			// Deploy the missing Docker image
 			// Assume DockerfilePath is known or derived based on image tag
 			//TODO: Implement this function as needed
 			//dockerfilePath := getDockerfilePath(user.AssignedContainerTag)
@@ -104,86 +221,152 @@ func CreateTestEnvironment(ctx context.Context, userConfigurationFilePath string
 				Str("image_tag", user.AssignedContainerTag).
 				Msg("Docker image tag already exists on remote node. Skipping deployment.")
 		}
+	}
 
-		// Step 3.3: Create or retrieve the user via KASM API
-		log.Info().
-			Str("username", user.TargetUser.Username).
-			Msg("Creating or retrieving user via KASM API")
-
-		userID, err := createOrGetUser(ctx, kasmApi, user)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("username", user.TargetUser.Username).
-				Msg("Failed to create or retrieve user via KASM API")
-			return fmt.Errorf("failed to create or retrieve user %s: %w", user.TargetUser.Username, err)
-		}
-		user.TargetUser.UserID = userID
-
-		/*	// Step 3.4: Add the user to the specified group via KASM API
-			log.Info().
-				Str("username", user.TargetUser.Username).
-				Str("role", user.Role).
-				Msg("Adding user to the specified group via KASM API")
+	return nil
+}
 
-			groupID, err := getGroupIDByName(ctx, kasmApi, user.Role)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("role", user.Role).
-					Msg("Failed to retrieve group ID from KASM API")
-				return fmt.Errorf("failed to retrieve group ID for role %s: %w", user.Role, err)
-			}
+// ensureGroupMembership adds the user to the group named by its role unless it is already a member.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - kasmApi: KasmAPI instance used for the membership requests.
+// - groups: Group cache used to resolve the role name.
+// - user: The user with UserID and Role set.
+// Returns:
+// - An error if the group cannot be resolved or the membership cannot be added.
+func ensureGroupMembership(ctx context.Context, kasmApi *webApi.KasmAPI, groups *webApi.GroupCache, user userParser.UserDetails) error {
+	log.Info().
+		Str("username", user.TargetUser.Username).
+		Str("role", user.Role).
+		Msg("Adding user to the specified group via KASM API")
 
-			if err := addUserToGroup(ctx, user.TargetUser.UserID, groupID); err != nil {
-				log.Error().
-					Err(err).
-					Str("user_id", user.TargetUser.UserID).
-					Str("group_id", groupID).
-					Msg("Failed to add user to group via KASM API")
-				return fmt.Errorf("failed to add user %s to group %s: %w", user.TargetUser.Username, groupID, err)
-			}
+	groupID, err := groups.GroupIDByName(ctx, user.Role)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("role", user.Role).
+			Msg("Failed to retrieve group ID from KASM API")
+		return fmt.Errorf("failed to retrieve group ID for role %s of user %s: %w", user.Role, user.TargetUser.Username, err)
+	}
 
+	// Users that existed before or were provisioned partially may already be members.
+	existing, err := kasmApi.GetUser(ctx, user.TargetUser.UserID, "")
+	if err != nil {
+		return fmt.Errorf("failed to fetch groups of user %s: %w", user.TargetUser.Username, err)
+	}
+	for _, group := range existing.Groups {
+		if group.GroupID == groupID {
 			log.Info().
 				Str("user_id", user.TargetUser.UserID).
 				Str("group_id", groupID).
-				Msg("Successfully added user to group via KASM API")
-		*/
-
-		// Step 3.5: Update the YAML file with UserID and KasmSessionOfContainer
-		// TODO: Implement logic to obtain the actual KasmSessionOfContainer
-		iamgeID, _ := getImageIDbyTag(ctx, kasmApi, user.AssignedContainerTag)
-		kasmRequestResponse, err := kasmApi.RequestKasmSession(ctx, user.TargetUser.UserID, iamgeID, user.EnvironmentArgs)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("username", user.TargetUser.Username).
-				Msg("Failed to generate KasmSessionOfContainer")
-			return fmt.Errorf("failed to generate KasmSessionOfContainer for user %s: %w", user.TargetUser.Username, err)
+				Msg("User is already a member of the group")
+			return nil
 		}
+	}
 
-		log.Info().
-			Str("username", user.TargetUser.Username).
+	if err := kasmApi.AddUserToGroup(ctx, user.TargetUser.UserID, groupID); err != nil {
+		log.Error().
+			Err(err).
 			Str("user_id", user.TargetUser.UserID).
-			Str("kasm_session_of_container", kasmRequestResponse.KasmID).
-			Str("url: ", kasmRequestResponse.KasmURL).
-			Msg("Updating user configuration in YAML file")
+			Str("group_id", groupID).
+			Msg("Failed to add user to group via KASM API")
+		return fmt.Errorf("failed to add user %s to group %s: %w", user.TargetUser.Username, groupID, err)
+	}
 
-		if err := userParserInstance.UpdateUserConfig(userConfigurationFilePath, user.TargetUser.Username, user.TargetUser.UserID, kasmRequestResponse.KasmID, user.AssignedContainerId); err != nil {
-			log.Error().
-				Err(err).
-				Str("username", user.TargetUser.Username).
-				Msg("Failed to update user configuration in YAML file")
-			return fmt.Errorf("failed to update user %s configuration: %w", user.TargetUser.Username, err)
+	log.Info().
+		Str("user_id", user.TargetUser.UserID).
+		Str("group_id", groupID).
+		Msg("Successfully added user to group via KASM API")
+	return nil
+}
+
+// isUserProvisioned reports whether a previous run already recorded a user ID and Kasm session for the user.
+// Both are written by a single checkpoint after the session was requested. A run interrupted between creating
+// the user and that checkpoint leaves neither recorded; the next run finds the user by username in
+// createOrGetUser and reuses its running session in RequestOrReuseSession, so nothing is created twice.
+func isUserProvisioned(user userParser.UserDetails) bool {
+	return user.TargetUser.UserID != "" && user.KasmSessionOfContainer != ""
+}
+
+// provisionUser creates or retrieves a single user, requests a Kasm session for it and records the result.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - kasmApi: KasmAPI instance used for user and session requests.
+// - executor: Runs commands on the agent node to create the volume directories of the user.
+// - groups: Group cache shared by all users of the run to resolve role names.
+// - userParserInstance: Parser guarding concurrent writes to the configuration file.
+// - userConfigurationFilePath: Path to the user configuration YAML file.
+// - user: The user to provision.
+// Returns:
+// - An error if any provisioning step fails.
+func provisionUser(ctx context.Context, kasmApi *webApi.KasmAPI, executor dockercli.CommandExecutor, groups *webApi.GroupCache, userParserInstance *userParser.UserParser, userConfigurationFilePath string, user userParser.UserDetails) error {
+	log.Info().
+		Str("username", user.TargetUser.Username).
+		Str("docker_image_tag", user.AssignedContainerTag).
+		Msg("Processing user")
+
+	// Step 1: Create or retrieve the user via KASM API. The user ID is not checkpointed here; looking the user up
+	// by username covers a run that was interrupted after the user was created.
+	log.Info().
+		Str("username", user.TargetUser.Username).
+		Msg("Creating or retrieving user via KASM API")
+
+	userID, err := createOrGetUser(ctx, kasmApi, user)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("username", user.TargetUser.Username).
+			Msg("Failed to create or retrieve user via KASM API")
+		return fmt.Errorf("failed to create or retrieve user %s: %w", user.TargetUser.Username, err)
+	}
+	user.TargetUser.UserID = userID
+
+	// Step 2: Add the user to the group named by its role via KASM API
+	if user.Role != "" {
+		if err := ensureGroupMembership(ctx, kasmApi, groups, user); err != nil {
+			return err
+		}
+	}
+
+	// Step 3: Create the host directories of the user's volume mounts before the session mounts them
+	if len(user.VolumeMounts) > 0 {
+		if err := ProvisionUserVolumes(ctx, executor, user); err != nil {
+			return err
 		}
+	}
 
-		log.Info().
+	// Step 4: Request a session and checkpoint the user in the YAML file with UserID and KasmSessionOfContainer
+	// TODO: Implement logic to obtain the actual KasmSessionOfContainer
+	iamgeID, _ := getImageIDbyTag(ctx, kasmApi, user.AssignedContainerTag)
+	// Reuse a session left running by an interrupted run instead of launching a duplicate.
+	kasmRequestResponse, reused, err := kasmApi.RequestOrReuseSession(ctx, user.TargetUser.UserID, iamgeID, user.EnvironmentArgs)
+	if err != nil {
+		log.Error().
+			Err(err).
 			Str("username", user.TargetUser.Username).
-			Msg("Successfully updated user configuration in YAML file")
+			Msg("Failed to generate KasmSessionOfContainer")
+		return fmt.Errorf("failed to generate KasmSessionOfContainer for user %s: %w", user.TargetUser.Username, err)
 	}
 
 	log.Info().
-		Msg("Test environment creation completed successfully")
+		Str("username", user.TargetUser.Username).
+		Str("user_id", user.TargetUser.UserID).
+		Str("kasm_session_of_container", kasmRequestResponse.KasmID).
+		Bool("reused", reused).
+		Str("url: ", kasmRequestResponse.KasmURL).
+		Msg("Updating user configuration in YAML file")
+
+	if err := userParserInstance.UpdateUserConfig(userConfigurationFilePath, user.TargetUser.Username, user.TargetUser.UserID, kasmRequestResponse.KasmID, user.AssignedContainerId); err != nil {
+		log.Error().
+			Err(err).
+			Str("username", user.TargetUser.Username).
+			Msg("Failed to update user configuration in YAML file")
+		return fmt.Errorf("failed to update user %s configuration: %w", user.TargetUser.Username, err)
+	}
+
+	log.Info().
+		Str("username", user.TargetUser.Username).
+		Msg("Successfully updated user configuration in YAML file")
 
 	return nil
 }
@@ -0,0 +1,374 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+)
+
+// Actions of a PlanChange.
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+)
+
+// Resource kinds of a PlanChange.
+const (
+	PlanKindGroup = "group"
+	PlanKindImage = "image"
+	PlanKindUser  = "user"
+)
+
+// PlanChange is a single change needed to bring a live Kasm deployment to the desired configuration.
+type PlanChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// Name is the username, group name or image name (docker tag).
+	Name string `json:"name"`
+	// ID identifies the live resource of updates and deletes.
+	ID string `json:"id,omitempty"`
+	// Fields lists the changed fields of updates.
+	Fields []webApi.FieldChange `json:"fields,omitempty"`
+
+	user         userParser.UserDetails
+	image        webApi.TargetImage
+	currentImage webApi.ImageDetail
+}
+
+// String renders the change as "+ user alice", "~ image kasmweb/core:1.0 (cores: 1 -> 2)" or "- user bob".
+func (c PlanChange) String() string {
+	symbol := map[string]string{PlanCreate: "+", PlanUpdate: "~", PlanDelete: "-"}[c.Action]
+	text := fmt.Sprintf("%s %s %s", symbol, c.Kind, c.Name)
+	if len(c.Fields) > 0 {
+		fields := make([]string, len(c.Fields))
+		for i, field := range c.Fields {
+			fields[i] = field.String()
+		}
+		text += " (" + strings.Join(fields, ", ") + ")"
+	}
+	return text
+}
+
+// Plan lists the changes computed by PlanDeployment in the order ApplyPlan executes them:
+// groups, images and users are created or updated first, deletes come last.
+type Plan struct {
+	Changes []PlanChange `json:"changes"`
+}
+
+// Empty reports whether the live deployment already matches the desired configuration.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// PlanOptions controls which changes PlanDeployment may produce.
+type PlanOptions struct {
+	// Delete plans the removal of users and images that are missing from the desired configuration.
+	// Without it, resources that only exist live are left alone.
+	Delete bool
+}
+
+// PlanDeployment compares a desired deployment configuration with the live Kasm deployment and returns the
+// creates, updates and deletes needed to reconcile them. Users are matched by case-insensitive username,
+// images by name and groups by name. Nothing is changed; pass the plan to ApplyPlan to execute it.
+// Only fields set in the desired configuration are compared, so values left empty, such as passwords or the
+// manual fields of an export, never cause updates. Missing groups are planned as creates; groups are never
+// updated or deleted.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - kasmApi: KasmAPI instance of the live deployment.
+// - desired: The desired configuration.
+// - options: Options such as whether deletes are planned.
+// Returns:
+// - The plan.
+// - An error if the live state cannot be read.
+func PlanDeployment(ctx context.Context, kasmApi *webApi.KasmAPI, desired *userParser.DeploymentConfig, options PlanOptions) (*Plan, error) {
+	groups, err := kasmApi.GetGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read live groups: %w", err)
+	}
+	images, err := kasmApi.ListImageDetails(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read live images: %w", err)
+	}
+	users, err := kasmApi.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read live users: %w", err)
+	}
+
+	plan := &Plan{}
+	var deletes []PlanChange
+
+	// Groups
+	liveGroups := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		liveGroups[group.Name] = true
+	}
+	for _, group := range desired.Groups {
+		if !liveGroups[group.Name] {
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanCreate, Kind: PlanKindGroup, Name: group.Name})
+		}
+	}
+
+	// Images
+	liveImages := make(map[string]webApi.ImageDetail, len(images))
+	for _, image := range images {
+		liveImages[image.Name] = image
+	}
+	desiredImages := make(map[string]bool, len(desired.Images))
+	for _, image := range desired.Images {
+		desiredImages[image.Name] = true
+		current, exists := liveImages[image.Name]
+		if !exists {
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanCreate, Kind: PlanKindImage, Name: image.Name, image: image})
+			continue
+		}
+		if fields := webApi.DiffTargetImage(current, image); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, PlanChange{
+				Action:       PlanUpdate,
+				Kind:         PlanKindImage,
+				Name:         image.Name,
+				ID:           current.ImageID,
+				Fields:       fields,
+				image:        image,
+				currentImage: current,
+			})
+		}
+	}
+	if options.Delete {
+		for _, image := range images {
+			if !desiredImages[image.Name] {
+				deletes = append(deletes, PlanChange{Action: PlanDelete, Kind: PlanKindImage, Name: image.Name, ID: image.ImageID})
+			}
+		}
+	}
+
+	// Users
+	liveUsers := make(map[string]webApi.UserResponse, len(users))
+	for _, user := range users {
+		liveUsers[strings.ToLower(user.Username)] = user
+	}
+	desiredUsers := make(map[string]bool, len(desired.UserDetails))
+	for _, user := range desired.UserDetails {
+		desiredUsers[strings.ToLower(user.TargetUser.Username)] = true
+		current, exists := liveUsers[strings.ToLower(user.TargetUser.Username)]
+		if !exists {
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanCreate, Kind: PlanKindUser, Name: user.TargetUser.Username, user: user})
+			continue
+		}
+		if fields := diffUser(current, user); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, PlanChange{
+				Action: PlanUpdate,
+				Kind:   PlanKindUser,
+				Name:   user.TargetUser.Username,
+				ID:     current.UserID,
+				Fields: fields,
+				user:   mergeUser(current, user),
+			})
+		}
+	}
+	if options.Delete {
+		for _, user := range users {
+			if !desiredUsers[strings.ToLower(user.Username)] {
+				deletes = append(deletes, PlanChange{Action: PlanDelete, Kind: PlanKindUser, Name: user.Username, ID: user.UserID})
+			}
+		}
+	}
+
+	// Users are deleted before images so no session of a deleted user still references a deleted image.
+	sort.SliceStable(deletes, func(i, j int) bool {
+		if deletes[i].Kind != deletes[j].Kind {
+			return deletes[i].Kind == PlanKindUser
+		}
+		return deletes[i].Name < deletes[j].Name
+	})
+	plan.Changes = append(plan.Changes, deletes...)
+
+	log.Info().
+		Int("changes", len(plan.Changes)).
+		Bool("delete", options.Delete).
+		Msg("Planned deployment changes")
+	return plan, nil
+}
+
+// diffUser returns the fields of a live user that differ from the desired one.
+// Empty desired strings are not compared; locked and disabled always are. The role is compared by membership.
+func diffUser(current webApi.UserResponse, desired userParser.UserDetails) []webApi.FieldChange {
+	var fields []webApi.FieldChange
+	for _, field := range []struct {
+		name     string
+		old, new string
+	}{
+		{"first_name", current.FirstName, desired.TargetUser.FirstName},
+		{"last_name", current.LastName, desired.TargetUser.LastName},
+		{"organization", current.Organization, desired.TargetUser.Organization},
+		{"phone", current.Phone, desired.TargetUser.Phone},
+	} {
+		if field.new != "" && field.new != field.old {
+			fields = append(fields, webApi.FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	if current.Locked != desired.TargetUser.Locked {
+		fields = append(fields, webApi.FieldChange{Field: "locked", Old: current.Locked, New: desired.TargetUser.Locked})
+	}
+	if current.Disabled != desired.TargetUser.Disabled {
+		fields = append(fields, webApi.FieldChange{Field: "disabled", Old: current.Disabled, New: desired.TargetUser.Disabled})
+	}
+
+	if desired.Role != "" {
+		member := false
+		for _, group := range current.Groups {
+			if group.Name == desired.Role {
+				member = true
+				break
+			}
+		}
+		if !member {
+			fields = append(fields, webApi.FieldChange{Field: "role", New: desired.Role})
+		}
+	}
+	return fields
+}
+
+// mergeUser fills the fields left empty in the desired user with the live values, so an update does not
+// clear them.
+func mergeUser(current webApi.UserResponse, desired userParser.UserDetails) userParser.UserDetails {
+	for _, field := range []struct {
+		value *string
+		live  string
+	}{
+		{&desired.TargetUser.FirstName, current.FirstName},
+		{&desired.TargetUser.LastName, current.LastName},
+		{&desired.TargetUser.Organization, current.Organization},
+		{&desired.TargetUser.Phone, current.Phone},
+	} {
+		if *field.value == "" {
+			*field.value = field.live
+		}
+	}
+	desired.TargetUser.Username = current.Username
+	return desired
+}
+
+// ApplyPlan executes the changes of a plan in order and stops at the first failure, so running PlanDeployment
+// again afterwards shows what is left. Users created without a password get a generated one, which is
+// returned to the caller and never logged.
+// Groups cannot be created through the Kasm public API; if the plan creates groups, nothing is applied and
+// an error names the groups to create in the Kasm admin UI first.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - kasmApi: KasmAPI instance of the live deployment.
+// - plan: The plan returned by PlanDeployment.
+// Returns:
+// - The credentials of users created with a generated password.
+// - An error naming the change that failed.
+func ApplyPlan(ctx context.Context, kasmApi *webApi.KasmAPI, plan *Plan) ([]webApi.RosterCredential, error) {
+	var missingGroups []string
+	for _, change := range plan.Changes {
+		if change.Kind == PlanKindGroup && change.Action == PlanCreate {
+			missingGroups = append(missingGroups, change.Name)
+		}
+	}
+	if len(missingGroups) > 0 {
+		return nil, fmt.Errorf("groups %s do not exist and cannot be created through the Kasm API, create them in the admin UI first", strings.Join(missingGroups, ", "))
+	}
+
+	groups := webApi.NewGroupCache(kasmApi)
+	var credentials []webApi.RosterCredential
+	for _, change := range plan.Changes {
+		if err := ctx.Err(); err != nil {
+			return credentials, err
+		}
+
+		log.Info().Str("change", change.String()).Msg("Applying deployment change")
+		var err error
+		switch {
+		case change.Kind == PlanKindImage && change.Action == PlanCreate:
+			_, err = kasmApi.CreateImage(ctx, webApi.CreateImageRequest{TargetImage: change.image})
+		case change.Kind == PlanKindImage && change.Action == PlanUpdate:
+			_, err = kasmApi.ReconcileImage(ctx, change.currentImage, change.image, false)
+		case change.Kind == PlanKindImage && change.Action == PlanDelete:
+			err = kasmApi.DeleteImage(ctx, change.ID)
+		case change.Kind == PlanKindUser && change.Action == PlanCreate:
+			var credential *webApi.RosterCredential
+			credential, err = applyUserCreate(ctx, kasmApi, groups, change.user)
+			if credential != nil {
+				credentials = append(credentials, *credential)
+			}
+		case change.Kind == PlanKindUser && change.Action == PlanUpdate:
+			err = applyUserUpdate(ctx, kasmApi, groups, change)
+		case change.Kind == PlanKindUser && change.Action == PlanDelete:
+			// Deletes are opt-in, so running sessions of the user are ended as well.
+			err = kasmApi.DeleteUser(ctx, change.ID, true)
+		default:
+			err = fmt.Errorf("unsupported change")
+		}
+		if err != nil {
+			return credentials, fmt.Errorf("failed to %s %s %s: %w", change.Action, change.Kind, change.Name, err)
+		}
+	}
+
+	log.Info().Int("changes", len(plan.Changes)).Msg("Applied deployment plan")
+	return credentials, nil
+}
+
+// applyUserCreate creates a user and adds it to the group named by its role.
+// Returns the generated credential if the desired user has no password.
+func applyUserCreate(ctx context.Context, kasmApi *webApi.KasmAPI, groups *webApi.GroupCache, user userParser.UserDetails) (*webApi.RosterCredential, error) {
+	target := user.TargetUser
+	generated := target.Password == ""
+	if generated {
+		target.Password = webApi.GeneratePassword(webApi.DefaultPasswordPolicy())
+	}
+
+	created, err := kasmApi.CreateUser(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	user.TargetUser.UserID = created.UserID
+
+	var credential *webApi.RosterCredential
+	if generated {
+		credential = &webApi.RosterCredential{Username: created.Username, UserID: created.UserID, Password: target.Password}
+	}
+	if user.Role != "" {
+		if err := ensureGroupMembership(ctx, kasmApi, groups, user); err != nil {
+			return credential, err
+		}
+	}
+	return credential, nil
+}
+
+// applyUserUpdate updates the changed fields of a user and its group membership.
+func applyUserUpdate(ctx context.Context, kasmApi *webApi.KasmAPI, groups *webApi.GroupCache, change PlanChange) error {
+	user := change.user
+	user.TargetUser.UserID = change.ID
+
+	roleChanged := false
+	attributesChanged := false
+	for _, field := range change.Fields {
+		if field.Field == "role" {
+			roleChanged = true
+		} else {
+			attributesChanged = true
+		}
+	}
+
+	if attributesChanged {
+		// The password is not part of the plan and must not be reset by an update.
+		target := user.TargetUser
+		target.Password = ""
+		if _, err := kasmApi.UpdateUser(ctx, target); err != nil {
+			return err
+		}
+	}
+	if roleChanged {
+		return ensureGroupMembership(ctx, kasmApi, groups, user)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	embedfiles "kasmlink/embedded"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/webApi"
+)
+
+// DefaultDoctorCheckTimeout limits every check run by RunDoctorChecks.
+const DefaultDoctorCheckTimeout = 15 * time.Second
+
+// DoctorCheck is a single prerequisite verified by RunDoctorChecks.
+type DoctorCheck struct {
+	Name string
+	// Critical checks fail the doctor run; other failures are reported as warnings.
+	Critical bool
+	// Hint tells the user how to fix a failure of the check.
+	Hint string
+	// Run performs the check and returns a short detail shown on success, e.g. a version.
+	Run func(ctx context.Context) (string, error)
+}
+
+// DoctorResult is the outcome of a DoctorCheck.
+type DoctorResult struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// RunDoctorChecks runs the checks one after another, each limited to DefaultDoctorCheckTimeout.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - checks: The checks to run, in the order they are reported.
+// Returns:
+// - The result of every check. Failed checks carry the error as detail and their hint.
+// - false if a critical check failed.
+func RunDoctorChecks(ctx context.Context, checks []DoctorCheck) ([]DoctorResult, bool) {
+	results := make([]DoctorResult, 0, len(checks))
+	healthy := true
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, DefaultDoctorCheckTimeout)
+		detail, err := check.Run(checkCtx)
+		cancel()
+
+		result := DoctorResult{Name: check.Name, Critical: check.Critical, Passed: err == nil, Detail: detail}
+		if err != nil {
+			result.Detail = err.Error()
+			result.Hint = check.Hint
+			if check.Critical {
+				healthy = false
+			}
+		}
+		log.Debug().
+			Str("check", check.Name).
+			Bool("passed", result.Passed).
+			Str("detail", result.Detail).
+			Msg("Doctor check finished")
+		results = append(results, result)
+	}
+	return results, healthy
+}
+
+// DockerDoctorCheck verifies that the docker CLI is installed and its daemon is reachable.
+func DockerDoctorCheck(dc *dockercli.DockerClient) DoctorCheck {
+	return DoctorCheck{
+		Name:     "Docker daemon",
+		Critical: true,
+		Hint:     "install the docker CLI, start the daemon and make sure the current user may access it (DOCKER_HOST, docker group)",
+		Run: func(ctx context.Context) (string, error) {
+			version, err := dc.ServerVersion(ctx)
+			if err != nil {
+				return "", err
+			}
+			return "version " + version, nil
+		},
+	}
+}
+
+// SSHDoctorCheck verifies that the node can be reached over SSH and runs commands.
+// A nil sshConfig is read from the SSH_* environment variables used by the deploy procedures.
+func SSHDoctorCheck(sshConfig *shadowssh.SSHConfig) DoctorCheck {
+	return DoctorCheck{
+		Name:     "SSH connection",
+		Critical: true,
+		Hint:     "check SSH_HOST, SSH_PORT, SSH_USERNAME and SSH_PASSWORD, and that the host key is in SSH_KNOWN_HOSTS",
+		Run: func(ctx context.Context) (string, error) {
+			if sshConfig == nil {
+				var err error
+				if sshConfig, err = LoadSSHConfig(SSHConfigOptions{}); err != nil {
+					return "", fmt.Errorf("failed to configure SSH settings: %w", err)
+				}
+			}
+			sshClient, err := shadowssh.NewSSHClient(ctx, sshConfig)
+			if err != nil {
+				return "", fmt.Errorf("failed to establish SSH connection: %w", err)
+			}
+			defer func() {
+				if cerr := sshClient.Close(); cerr != nil {
+					log.Error().Err(cerr).Msg("Failed to close SSH client")
+				}
+			}()
+			if _, err := sshClient.ExecuteCommand(ctx, "true"); err != nil {
+				return "", fmt.Errorf("failed to run a command on %s: %w", sshConfig.Host, err)
+			}
+			return fmt.Sprintf("%s@%s:%d", sshConfig.Username, sshConfig.Host, sshConfig.Port), nil
+		},
+	}
+}
+
+// KasmDoctorCheck verifies that the Kasm API is reachable and accepts the credentials.
+func KasmDoctorCheck(kasmApi *webApi.KasmAPI) DoctorCheck {
+	return DoctorCheck{
+		Name:     "Kasm API",
+		Critical: true,
+		Hint: "check --kasm-url (or KASM_URL) is reachable from this machine, and that the API key and secret " +
+			"(KASM_API_KEY, KASM_API_SECRET) are valid and have the \"Users View\" permission",
+		Run: func(ctx context.Context) (string, error) {
+			if err := kasmApi.Ping(ctx); err != nil {
+				return "", err
+			}
+			return kasmApi.EndpointURL(""), nil
+		},
+	}
+}
+
+// embeddedDoctorFiles are the embedded files the build and init commands rely on.
+var embeddedDoctorFiles = []struct {
+	fs   fs.FS
+	path string
+}{
+	{embedfiles.EmbeddedServicesFS, "services/docker-compose-template.yaml"},
+	{embedfiles.EmbeddedDockerImagesDirectory, "dockerfiles/dockerfile-postgres"},
+	{embedfiles.EmbeddedDockerImagesDirectory, "dockerfiles/dockerfile-nfs-server"},
+	{embedfiles.EmbeddedKasmDirectory, DefaultBuildContextDir + "/dockerfile-kasm-core-suse"},
+}
+
+// EmbeddedTemplatesDoctorCheck verifies that the templates and Dockerfiles embedded in the binary are present.
+func EmbeddedTemplatesDoctorCheck() DoctorCheck {
+	return DoctorCheck{
+		Name:     "Embedded templates",
+		Critical: true,
+		Hint:     "the binary was built without its embedded files; rebuild it from a complete checkout",
+		Run: func(ctx context.Context) (string, error) {
+			var missing []string
+			for _, file := range embeddedDoctorFiles {
+				if _, err := fs.Stat(file.fs, file.path); err != nil {
+					missing = append(missing, file.path)
+				}
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("missing embedded files: %s", strings.Join(missing, ", "))
+			}
+			return fmt.Sprintf("%d files", len(embeddedDoctorFiles)), nil
+		},
+	}
+}
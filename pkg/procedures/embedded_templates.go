@@ -0,0 +1,102 @@
+package procedures
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	embedfiles "kasmlink/embedded"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EmbeddedTemplate describes a template or Dockerfile shipped with KasmLink.
+type EmbeddedTemplate struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+}
+
+// Kinds of embedded templates.
+const (
+	TemplateKindService    = "service"
+	TemplateKindDockerfile = "dockerfile"
+)
+
+// ListEmbeddedTemplates returns the names of the embedded service templates.
+func ListEmbeddedTemplates() []string {
+	return listEmbeddedFiles(embedfiles.EmbeddedServicesFS, "services")
+}
+
+// ListEmbeddedDockerfiles returns the names of the embedded Dockerfiles.
+func ListEmbeddedDockerfiles() []string {
+	return listEmbeddedFiles(embedfiles.EmbeddedDockerImagesDirectory, "dockerfiles")
+}
+
+// DescribeEmbeddedTemplates returns all embedded service templates and Dockerfiles with the description
+// taken from the leading comment of each file, if present.
+func DescribeEmbeddedTemplates() []EmbeddedTemplate {
+	var templates []EmbeddedTemplate
+	for _, name := range ListEmbeddedTemplates() {
+		templates = append(templates, EmbeddedTemplate{
+			Name:        name,
+			Kind:        TemplateKindService,
+			Description: embeddedFileDescription(embedfiles.EmbeddedServicesFS, path.Join("services", name)),
+		})
+	}
+	for _, name := range ListEmbeddedDockerfiles() {
+		templates = append(templates, EmbeddedTemplate{
+			Name:        name,
+			Kind:        TemplateKindDockerfile,
+			Description: embeddedFileDescription(embedfiles.EmbeddedDockerImagesDirectory, path.Join("dockerfiles", name)),
+		})
+	}
+	return templates
+}
+
+// listEmbeddedFiles returns the sorted names of the regular files below dir.
+func listEmbeddedFiles(embeddedFS fs.FS, dir string) []string {
+	var names []string
+	err := fs.WalkDir(embeddedFS, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			names = append(names, strings.TrimPrefix(filePath, dir+"/"))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("dir", dir).Msg("Failed to list embedded files")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// embeddedFileDescription returns the first line of the leading "#" comment block of a file.
+// Comment lines that only repeat the file name are skipped.
+func embeddedFileDescription(embeddedFS fs.FS, filePath string) string {
+	file, err := embeddedFS.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			return ""
+		}
+		comment := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if comment != "" && comment != path.Base(filePath) {
+			return comment
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+)
+
+// ExportDeploymentConfig reads the users, groups and workspace images of a live Kasm deployment and returns
+// them as a DeploymentConfig that CreateTestEnvironment can recreate elsewhere.
+// Users, groups and images are sorted by name so repeated exports produce the same file.
+// Values the API cannot return are left empty and listed in ManualFields: user passwords, docker tokens of
+// images pulled with credentials and the image of users without a running session. A user's role is its
+// first non-system group; further memberships are listed in ManualFields as well.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - kasmApi: KasmAPI instance of the deployment to export.
+// Returns:
+// - The exported configuration.
+// - An error if users, groups, images or sessions cannot be read.
+func ExportDeploymentConfig(ctx context.Context, kasmApi *webApi.KasmAPI) (*userParser.DeploymentConfig, error) {
+	users, err := kasmApi.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export users: %w", err)
+	}
+	groups, err := kasmApi.GetGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export groups: %w", err)
+	}
+	images, err := kasmApi.ListImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export images: %w", err)
+	}
+	kasms, err := kasmApi.GetKasms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export sessions: %w", err)
+	}
+
+	config := &userParser.DeploymentConfig{}
+
+	systemGroups := make(map[string]bool)
+	for _, group := range groups {
+		if group.IsSystem {
+			systemGroups[group.GroupID] = true
+			continue
+		}
+		config.Groups = append(config.Groups, userParser.GroupDetails{
+			Name:        group.Name,
+			Description: group.Description,
+			Priority:    group.Priority,
+		})
+	}
+	sort.Slice(config.Groups, func(i, j int) bool { return config.Groups[i].Name < config.Groups[j].Name })
+
+	imageTags := make(map[string]string, len(images))
+	for _, image := range images {
+		imageTags[image.ImageID] = image.ImageTag
+		target := webApi.TargetImageFromImage(image)
+		config.Images = append(config.Images, target)
+		if target.DockerUser != "" {
+			config.ManualFields = append(config.ManualFields, fmt.Sprintf("images[%s].docker_token", target.Name))
+		}
+	}
+	sort.SliceStable(config.Images, func(i, j int) bool {
+		if config.Images[i].Name != config.Images[j].Name {
+			return config.Images[i].Name < config.Images[j].Name
+		}
+		return config.Images[i].FriendlyName < config.Images[j].FriendlyName
+	})
+
+	// Running sessions are the only place the API links users to images.
+	sessionTags := make(map[string]string)
+	for _, kasm := range kasms {
+		if tag, ok := imageTags[kasm.ImageID]; ok && sessionTags[kasm.UserID] == "" {
+			sessionTags[kasm.UserID] = tag
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	for _, user := range users {
+		details := userParser.UserDetails{
+			TargetUser: webApi.TargetUser{
+				Username:     user.Username,
+				FirstName:    user.FirstName,
+				LastName:     user.LastName,
+				Organization: user.Organization,
+				Phone:        user.Phone,
+				Locked:       user.Locked,
+				Disabled:     user.Disabled,
+			},
+			AssignedContainerTag: sessionTags[user.UserID],
+		}
+		config.ManualFields = append(config.ManualFields, fmt.Sprintf("user_details[%s].target_user.password", user.Username))
+		if details.AssignedContainerTag == "" {
+			config.ManualFields = append(config.ManualFields, fmt.Sprintf("user_details[%s].assigned_container_tag", user.Username))
+		}
+
+		var memberships []string
+		for _, group := range user.Groups {
+			if !systemGroups[group.GroupID] {
+				memberships = append(memberships, group.Name)
+			}
+		}
+		sort.Strings(memberships)
+		if len(memberships) > 0 {
+			details.Role = memberships[0]
+		}
+		if len(memberships) > 1 {
+			config.ManualFields = append(config.ManualFields, fmt.Sprintf("user_details[%s].role: also member of %s", user.Username, strings.Join(memberships[1:], ", ")))
+		}
+
+		config.UserDetails = append(config.UserDetails, details)
+	}
+
+	log.Info().
+		Int("users", len(config.UserDetails)).
+		Int("groups", len(config.Groups)).
+		Int("images", len(config.Images)).
+		Int("manual_fields", len(config.ManualFields)).
+		Msg("Exported deployment configuration")
+	return config, nil
+}
@@ -2,6 +2,7 @@
 package procedures
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	embedfiles "kasmlink/embedded"
@@ -11,6 +12,8 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
@@ -20,6 +23,8 @@ import (
 const (
 	DefaultFilePermission   = 0644
 	DefaultFolderPermission = 0755
+	// TemplateFileSuffix marks embedded files that are rendered with text/template during initialization.
+	TemplateFileSuffix = ".tmpl"
 )
 
 // Precompiled regular expressions for performance
@@ -31,15 +36,63 @@ var (
 // Mutex to ensure thread-safe operations if needed in future
 var mu sync.RWMutex
 
+// OverwritePolicy controls what folder initialization does with files that already exist in the target folder.
+type OverwritePolicy string
+
+// Supported overwrite policies. The zero value behaves like OverwriteSkipExisting.
+const (
+	// OverwriteSkipExisting keeps existing files, preserving user edits.
+	OverwriteSkipExisting OverwritePolicy = "skip"
+	// OverwriteReplace replaces existing files with the embedded version.
+	OverwriteReplace OverwritePolicy = "overwrite"
+	// OverwriteBackup renames existing files to "<name>.bak-<timestamp>" before writing the embedded version.
+	OverwriteBackup OverwritePolicy = "backup"
+)
+
+// ParseOverwritePolicy validates an overwrite policy name. An empty name selects OverwriteSkipExisting.
+func ParseOverwritePolicy(name string) (OverwritePolicy, error) {
+	switch policy := OverwritePolicy(strings.ToLower(name)); policy {
+	case "":
+		return OverwriteSkipExisting, nil
+	case OverwriteSkipExisting, OverwriteReplace, OverwriteBackup:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overwrite policy %q, expected %s, %s or %s", name, OverwriteSkipExisting, OverwriteReplace, OverwriteBackup)
+	}
+}
+
 // InitFolder initializes a specified folder by copying embedded templates or Dockerfiles.
 // Parameters:
 // - folderPath: The base directory where the subfolder will be created.
 // - subfolder: The name of the subfolder to initialize.
 // - sourcePath: The path within the embedded filesystem to copy files from.
 // - embeddedFS: The embedded filesystem containing the source files.
+// - policy: What to do with files that already exist in the target folder.
 // Returns:
 // - An error if the initialization fails.
-func InitFolder(folderPath, subfolder, sourcePath string, embeddedFS fs.FS) error {
+func InitFolder(folderPath, subfolder, sourcePath string, embeddedFS fs.FS, policy OverwritePolicy) error {
+	return InitFolderWithData(folderPath, subfolder, sourcePath, embeddedFS, nil, policy)
+}
+
+// InitFolderWithData initializes a folder like InitFolder and renders template files with data.
+// Files ending in TemplateFileSuffix are executed as Go text/templates (e.g. "FROM {{ .BaseImage }}") and
+// written without the suffix; all other files are copied verbatim. With nil data, template files are copied
+// verbatim as well, keeping their suffix.
+// Parameters:
+// - folderPath: The base directory where the subfolder will be created.
+// - subfolder: The name of the subfolder to initialize.
+// - sourcePath: The path within the embedded filesystem to copy files from.
+// - embeddedFS: The embedded filesystem containing the source files.
+// - data: Values for the template files, or nil to skip rendering.
+// - policy: What to do with files that already exist in the target folder.
+// Returns:
+// - An error if the initialization or rendering fails.
+func InitFolderWithData(folderPath, subfolder, sourcePath string, embeddedFS fs.FS, data map[string]interface{}, policy OverwritePolicy) error {
+	policy, err := ParseOverwritePolicy(string(policy))
+	if err != nil {
+		return err
+	}
+
 	targetFolder := filepath.Join(folderPath, subfolder)
 	log.Info().
 		Str("folderPath", folderPath).
@@ -56,7 +109,8 @@ func InitFolder(folderPath, subfolder, sourcePath string, embeddedFS fs.FS) erro
 	}
 
 	// Copy files from embedded FS to target folder
-	if err := copyEmbeddedFiles(embeddedFS, sourcePath, targetFolder); err != nil {
+	skipped, err := copyEmbeddedFiles(embeddedFS, sourcePath, targetFolder, data, policy)
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str("subfolder", subfolder).
@@ -64,6 +118,13 @@ func InitFolder(folderPath, subfolder, sourcePath string, embeddedFS fs.FS) erro
 		return fmt.Errorf("error initializing folder %s: %w", subfolder, err)
 	}
 
+	if len(skipped) > 0 {
+		log.Warn().
+			Strs("files", skipped).
+			Int("count", len(skipped)).
+			Msg("Skipped existing files; use the overwrite or backup policy to replace them")
+	}
+
 	log.Info().
 		Str("folderPath", targetFolder).
 		Msg("Folder initialization completed successfully")
@@ -75,10 +136,14 @@ func InitFolder(folderPath, subfolder, sourcePath string, embeddedFS fs.FS) erro
 // - embeddedFS: The embedded filesystem to copy files from.
 // - sourcePath: The source directory within the embedded filesystem.
 // - targetFolder: The target directory on the local filesystem.
+// - data: Values for template files, or nil to copy them verbatim.
+// - policy: What to do with files that already exist in the target folder.
 // Returns:
+// - The target paths that were skipped because they already exist.
 // - An error if the copying process fails.
-func copyEmbeddedFiles(embeddedFS fs.FS, sourcePath, targetFolder string) error {
-	return fs.WalkDir(embeddedFS, sourcePath, func(path string, d fs.DirEntry, err error) error {
+func copyEmbeddedFiles(embeddedFS fs.FS, sourcePath, targetFolder string, data map[string]interface{}, policy OverwritePolicy) ([]string, error) {
+	var skipped []string
+	err := fs.WalkDir(embeddedFS, sourcePath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -110,6 +175,34 @@ func copyEmbeddedFiles(embeddedFS fs.FS, sourcePath, targetFolder string) error
 				Msg("Failed to read embedded file")
 			return fmt.Errorf("failed to read embedded file %s: %w", path, err)
 		}
+		if data != nil && strings.HasSuffix(path, TemplateFileSuffix) {
+			content, err = renderTemplate(path, content, data)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("path", path).
+					Msg("Failed to render template file")
+				return err
+			}
+			targetPath = strings.TrimSuffix(targetPath, TemplateFileSuffix)
+		}
+
+		if _, err := os.Stat(targetPath); err == nil {
+			switch policy {
+			case OverwriteReplace:
+				log.Debug().Str("file", targetPath).Msg("Overwriting existing file")
+			case OverwriteBackup:
+				backupPath := fmt.Sprintf("%s.bak-%s", targetPath, time.Now().Format("20060102150405"))
+				if err := os.Rename(targetPath, backupPath); err != nil {
+					return fmt.Errorf("failed to back up %s: %w", targetPath, err)
+				}
+				log.Info().Str("file", targetPath).Str("backup", backupPath).Msg("Backed up existing file")
+			default:
+				skipped = append(skipped, targetPath)
+				return nil
+			}
+		}
+
 		if err := os.WriteFile(targetPath, content, DefaultFilePermission); err != nil {
 			log.Error().
 				Err(err).
@@ -122,24 +215,53 @@ func copyEmbeddedFiles(embeddedFS fs.FS, sourcePath, targetFolder string) error
 			Msg("File initialized successfully")
 		return nil
 	})
+	return skipped, err
 }
 
 // InitTemplatesFolder initializes the templates folder with embedded templates.
 // Parameters:
 // - folderPath: The base directory where the templates folder will be created.
+// - policy: What to do with files that already exist in the target folder.
 // Returns:
 // - An error if the initialization fails.
-func InitTemplatesFolder(folderPath string) error {
-	return InitFolder(folderPath, "templates", "templates", embedfiles.EmbeddedServicesFS)
+func InitTemplatesFolder(folderPath string, policy OverwritePolicy) error {
+	return InitFolder(folderPath, "templates", "templates", embedfiles.EmbeddedServicesFS, policy)
 }
 
 // InitDockerfilesFolder initializes the Dockerfiles folder with embedded Dockerfile templates.
 // Parameters:
 // - folderPath: The base directory where the Dockerfiles folder will be created.
+// - policy: What to do with files that already exist in the target folder.
 // Returns:
 // - An error if the initialization fails.
-func InitDockerfilesFolder(folderPath string) error {
-	return InitFolder(folderPath, "dockerfiles", "dockerfiles", embedfiles.EmbeddedDockerImagesDirectory)
+func InitDockerfilesFolder(folderPath string, policy OverwritePolicy) error {
+	return InitFolder(folderPath, "dockerfiles", "dockerfiles", embedfiles.EmbeddedDockerImagesDirectory, policy)
+}
+
+// InitDockerfilesFolderWithData initializes the Dockerfiles folder and renders template files with data,
+// e.g. {"BaseImage": "opensuse/leap:15.6"}.
+// Parameters:
+// - folderPath: The base directory where the Dockerfiles folder will be created.
+// - data: Values for the template files.
+// - policy: What to do with files that already exist in the target folder.
+// Returns:
+// - An error if the initialization or rendering fails.
+func InitDockerfilesFolderWithData(folderPath string, data map[string]interface{}, policy OverwritePolicy) error {
+	return InitFolderWithData(folderPath, "dockerfiles", "dockerfiles", embedfiles.EmbeddedDockerImagesDirectory, data, policy)
+}
+
+// renderTemplate executes content as a text/template. Missing keys are reported instead of rendered as "<no value>".
+func renderTemplate(name string, content []byte, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return rendered.Bytes(), nil
 }
 
 // MergeComposeFiles merges two Docker Compose files into one.
@@ -4,59 +4,126 @@ import (
 	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
 	shadowssh "kasmlink/pkg/sshmanager"
 	"kasmlink/pkg/userParser"
 	"kasmlink/pkg/webApi"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // checkRemoteImages checks which Docker images are missing on the remote node.
+// Every image is inspected by reference, so "kasm/app" finds "kasm/app:latest" and image IDs work as well.
 // Parameters:
 // - ctx: Context for managing cancellation and timeouts.
 // - client: SSHClient for executing commands on the remote node.
 // - images: List of Docker image names to check.
 // Returns:
 // - List of missing Docker image names.
-// - An error if the check fails.
+// - An error if an image cannot be inspected; a missing image is not an error.
 func checkRemoteImages(ctx context.Context, client *shadowssh.SSHClient, images []string) ([]string, error) {
-	log.Debug().
-		Msg("Executing remote Docker images command to list available images")
+	dc := dockercli.NewRemoteDockerClient(client, 1)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
 
-	cmd := "docker images --format '{{.Repository}}:{{.Tag}}'"
-	// Execute the command with a timeout for logging
-	output, err := client.ExecuteCommandWithOutput(ctx, cmd, 30*time.Second)
+	missing := []string{}
+	for _, img := range images {
+		exists, err := dc.ImageExists(ctx, img)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("image", img).
+				Msg("Failed to inspect image on remote node")
+			return nil, fmt.Errorf("failed to check image %s on remote node: %w", img, err)
+		}
+		if !exists {
+			missing = append(missing, img)
+			log.Debug().
+				Str("image", img).
+				Msg("Image is missing on remote node")
+		}
+	}
+	return missing, nil
+}
+
+// checkRemoteFreeSpace verifies that the filesystem holding remoteDir has at least requiredBytes available.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - client: SSHClient for executing commands on the remote node.
+// - remoteDir: Directory on the remote node whose filesystem is checked.
+// - requiredBytes: Minimum number of bytes that must be available.
+// Returns:
+// - An error if the free space cannot be determined or is insufficient.
+func checkRemoteFreeSpace(ctx context.Context, client *shadowssh.SSHClient, remoteDir string, requiredBytes int64) error {
+	cmd := "df -P -B1 " + shadowssh.ShellQuote(remoteDir)
+	output, err := client.ExecuteCommand(ctx, cmd)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("command", cmd).
-			Msg("Failed to execute remote Docker images command")
-		return nil, fmt.Errorf("failed to execute remote Docker images command: %w", err)
+			Str("output", output).
+			Msg("Failed to determine free space on remote node")
+		return fmt.Errorf("failed to determine free space in %s on remote node: %w", remoteDir, err)
 	}
 
-	remoteImages := strings.Split(output, "\n")
-	missing := []string{}
+	availableBytes, err := CheckFreeSpace(output, requiredBytes)
+	log.Debug().
+		Str("remoteDir", remoteDir).
+		Int64("availableBytes", availableBytes).
+		Int64("requiredBytes", requiredBytes).
+		Msg("Checked free space on remote node")
+	if err != nil {
+		return fmt.Errorf("free space in %s on remote node: %w", remoteDir, err)
+	}
+	return nil
+}
 
-	// Create a set of remote images for efficient lookup
-	imageSet := make(map[string]struct{})
-	for _, img := range remoteImages {
-		trimmedImg := strings.TrimSpace(img)
-		if trimmedImg != "" {
-			imageSet[trimmedImg] = struct{}{}
-		}
+// RequiredFreeSpace returns the free space needed to upload and load a tar of tarSize bytes.
+// Parameters:
+// - tarSize: Size of the image tar in bytes.
+// - safetyFactor: Multiple of the tar size that must be free; values <= 0 select DefaultSpaceSafetyFactor.
+// Returns:
+// - The required number of bytes.
+func RequiredFreeSpace(tarSize int64, safetyFactor float64) int64 {
+	if safetyFactor <= 0 {
+		safetyFactor = DefaultSpaceSafetyFactor
 	}
+	return int64(float64(tarSize) * safetyFactor)
+}
 
-	// Identify missing images
-	for _, img := range images {
-		if _, exists := imageSet[img]; !exists {
-			missing = append(missing, img)
-			log.Debug().
-				Str("image", img).
-				Msg("Image is missing on remote node")
-		}
+// CheckFreeSpace compares the available space reported by POSIX `df -P -B1` output with requiredBytes.
+// Returns:
+// - The available bytes, 0 if the output cannot be parsed.
+// - An error if the output cannot be parsed or less than requiredBytes are available.
+func CheckFreeSpace(dfOutput string, requiredBytes int64) (int64, error) {
+	availableBytes, err := parseDfAvailable(dfOutput)
+	if err != nil {
+		return 0, err
+	}
+	if availableBytes < requiredBytes {
+		return availableBytes, fmt.Errorf("insufficient free space: %d bytes available, %d bytes required", availableBytes, requiredBytes)
 	}
+	return availableBytes, nil
+}
 
-	return missing, nil
+// parseDfAvailable extracts the available bytes from POSIX `df -P -B1` output.
+func parseDfAvailable(output string) (int64, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %q", output)
+	}
+
+	// Filesystem 1-blocks Used Available Capacity Mounted-on
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("unexpected df output: %q", output)
+	}
+	available, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil || available < 0 {
+		return 0, fmt.Errorf("unexpected available space %q in df output", fields[3])
+	}
+	return available, nil
 }
 
 // createOrGetUser creates a new user via KASM API or retrieves the existing user's ID.
@@ -74,83 +141,54 @@ func createOrGetUser(ctx context.Context, api *webApi.KasmAPI, user userParser.U
 		Msg("Attempting to retrieve or create user via KASM API")
 
 	// Step 1: Try to retrieve the user by username
-	userExisting, err := api.GetUser(ctx, user.TargetUser.UserID, user.TargetUser.Username)
+	exists, userExisting, err := api.UserExists(ctx, user.TargetUser.Username)
 	if err != nil {
-		// Assuming that an error containing "not found" indicates the user does not exist
-		if userExisting != nil {
-			log.Info().
-				Str("username", userExisting.Username).
-				Str("user_id", userExisting.UserID).
-				Msg("User already exists in KASM API")
-
-			return userExisting.UserID, nil
-		}
-
-		// User does not exist; proceed to create
-		log.Info().
+		log.Error().
+			Err(err).
 			Str("username", user.TargetUser.Username).
-			Msg("User not found. Proceeding to create a new user.")
-
-		// Define the target user details
-		targetUser := webApi.TargetUser{
-			Username:     user.TargetUser.Username,
-			FirstName:    user.TargetUser.FirstName,
-			LastName:     user.TargetUser.LastName,
-			Locked:       user.TargetUser.Locked,
-			Disabled:     user.TargetUser.Disabled,
-			Organization: user.TargetUser.Organization,
-			Phone:        user.TargetUser.Phone,
-			Password:     user.TargetUser.Password,
-		}
-
-		// Step 2: Create the user via the API
-		createdUser, err := api.CreateUser(ctx, targetUser)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("username", user.TargetUser.Username).
-				Msg("Failed to create user via KASM API")
-			return "", fmt.Errorf("failed to create user %s: %w", user.TargetUser.Username, err)
-		}
-
+			Msg("Failed to look up user via KASM API")
+		return "", fmt.Errorf("failed to look up user %s: %w", user.TargetUser.Username, err)
+	}
+	if exists {
 		log.Info().
-			Str("username", createdUser.Username).
-			Str("user_id", createdUser.UserID).
-			Msg("User created successfully via KASM API")
-		return createdUser.UserID, nil
+			Str("username", userExisting.Username).
+			Str("user_id", userExisting.UserID).
+			Msg("User already exists in KASM API")
+		return userExisting.UserID, nil
 	}
 
-	// User exists; return the existing user ID
+	// User does not exist; proceed to create
 	log.Info().
-		Str("username", userExisting.Username).
-		Str("user_id", userExisting.UserID).
-		Msg("User already exists in KASM API")
-	return userExisting.UserID, nil
-}
-
-func parseVolumeMounts(details userParser.UserDetails) (map[string]webApi.VolumeMapping, error) {
-	volumeMappings := make(map[string]webApi.VolumeMapping)
-
-	for hostPath, containerPathAndMode := range details.VolumeMounts {
-		parts := strings.Split(containerPathAndMode, ":")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid volume mount format: %s, expected 'containerPath:mode'", containerPathAndMode)
-		}
-
-		containerPath, mode := parts[0], parts[1]
-		if mode != "rw" && mode != "ro" {
-			return nil, fmt.Errorf("invalid volume mount mode: %s, expected 'rw' or 'ro'", mode)
-		}
+		Str("username", user.TargetUser.Username).
+		Msg("User not found. Proceeding to create a new user.")
+
+	// Define the target user details
+	targetUser := webApi.TargetUser{
+		Username:     user.TargetUser.Username,
+		FirstName:    user.TargetUser.FirstName,
+		LastName:     user.TargetUser.LastName,
+		Locked:       user.TargetUser.Locked,
+		Disabled:     user.TargetUser.Disabled,
+		Organization: user.TargetUser.Organization,
+		Phone:        user.TargetUser.Phone,
+		Password:     user.TargetUser.Password,
+	}
 
-		volumeMappings[containerPath] = webApi.VolumeMapping{
-			Bind: hostPath,
-			Mode: mode,
-			Gid:  1000,
-			Uid:  1000,
-		}
+	// Step 2: Create the user via the API
+	createdUser, err := api.CreateUser(ctx, targetUser)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("username", user.TargetUser.Username).
+			Msg("Failed to create user via KASM API")
+		return "", fmt.Errorf("failed to create user %s: %w", user.TargetUser.Username, err)
 	}
 
-	return volumeMappings, nil
+	log.Info().
+		Str("username", createdUser.Username).
+		Str("user_id", createdUser.UserID).
+		Msg("User created successfully via KASM API")
+	return createdUser.UserID, nil
 }
 
 func getImageIDbyTag(ctx context.Context, api *webApi.KasmAPI, imageTag string) (string, error) {
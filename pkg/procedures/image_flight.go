@@ -0,0 +1,70 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ImageFlightGroup de-duplicates concurrent work on the same image, e.g. when several nodes are provisioned in
+// parallel: the first caller for a key runs the work and callers arriving while it is in flight wait for it and
+// share its result instead of building or exporting the image again. Finished work is not cached.
+// The zero value is ready to use.
+type ImageFlightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*imageFlight
+}
+
+// imageFlight is a call of an ImageFlightGroup that is in flight or finished.
+type imageFlight struct {
+	done   chan struct{}
+	result string
+	err    error
+}
+
+// Shared flight groups of DeployKasmDockerImage, keyed by image tag and by cached tar path.
+var (
+	imageBuildFlights  ImageFlightGroup
+	imageExportFlights ImageFlightGroup
+)
+
+// Do runs fn for key unless a call for key is in flight, in which case it waits for that call.
+// Parameters:
+// - ctx: Context of this caller; a waiting caller stops waiting when it is done, without cancelling the call.
+// - key: Identifies the work, e.g. the image tag.
+// - fn: The work, run on the calling goroutine of the first caller.
+// Returns:
+// - The result and error of the call, shared by all callers that waited for it.
+// - Whether the result was shared from a call of another caller.
+func (g *ImageFlightGroup) Do(ctx context.Context, key string, fn func() (string, error)) (string, bool, error) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*imageFlight)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		log.Info().Str("key", key).Msg("Waiting for the same image work of another deployment")
+		select {
+		case <-call.done:
+			return call.result, true, call.err
+		case <-ctx.Done():
+			return "", true, ctx.Err()
+		}
+	}
+	call := &imageFlight{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	// The call is finished even if fn panics, so waiting callers neither hang nor see a success.
+	call.err = fmt.Errorf("image work for %s did not complete", key)
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+	}()
+	call.result, call.err = fn()
+	return call.result, false, call.err
+}
@@ -0,0 +1,123 @@
+package procedures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTarCacheDir is the local directory caching exported image tars.
+const DefaultTarCacheDir = "./tarfiles"
+
+// imageIDCacheLength is the number of image ID hex digits in the name of a cached tar.
+const imageIDCacheLength = 12
+
+// ImageTarCache stores exported image tars keyed by image tag and image ID, so repeated deploys of the same
+// image to several nodes export it only once. A tar is stale once the tag points at another image ID.
+type ImageTarCache struct {
+	// Dir is the cache directory; DefaultTarCacheDir when empty.
+	Dir string
+}
+
+// Path returns the cache path of the tar of imageTag at imageID, e.g. tarfiles/kasm_core_1.0-0123456789ab.tar.
+func (c ImageTarCache) Path(imageTag, imageID string) string {
+	digest := strings.TrimPrefix(imageID, "sha256:")
+	if len(digest) > imageIDCacheLength {
+		digest = digest[:imageIDCacheLength]
+	}
+	return filepath.Join(c.dir(), fmt.Sprintf("%s-%s.tar", sanitizeImageName(imageTag), digest))
+}
+
+// Get returns the cached tar of imageTag at imageID.
+// Returns:
+// - The path of the cached tar.
+// - Whether the tar is cached.
+func (c ImageTarCache) Get(imageTag, imageID string) (string, bool) {
+	path := c.Path(imageTag, imageID)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return path, false
+	}
+	return path, true
+}
+
+// Put stores the tar of imageTag at imageID and removes the tars of other image IDs of imageTag.
+// The tar is written to a temporary file first, so an interrupted export never leaves a truncated cache entry.
+// Parameters:
+// - imageTag: Tag of the exported image.
+// - imageID: ID of the image the tag points at.
+// - write: Writes the tar to the given path.
+// Returns:
+// - The path of the cached tar.
+// - An error if the tar cannot be written.
+func (c ImageTarCache) Put(imageTag, imageID string, write func(path string) error) (string, error) {
+	if err := os.MkdirAll(c.dir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create tar cache directory %s: %w", c.dir(), err)
+	}
+
+	path := c.Path(imageTag, imageID)
+	partialPath := path + ".partial"
+	if err := write(partialPath); err != nil {
+		_ = os.Remove(partialPath)
+		return "", err
+	}
+	if err := os.Rename(partialPath, path); err != nil {
+		_ = os.Remove(partialPath)
+		return "", fmt.Errorf("failed to store cached tar %s: %w", path, err)
+	}
+
+	c.removeStale(imageTag, path)
+	return path, nil
+}
+
+// removeStale deletes the cached tars of imageTag except keep.
+func (c ImageTarCache) removeStale(imageTag, keep string) {
+	prefix := sanitizeImageName(imageTag) + "-"
+	entries, err := os.ReadDir(c.dir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".tar") {
+			continue
+		}
+		digest, found := strings.CutPrefix(strings.TrimSuffix(name, ".tar"), prefix)
+		if !found || !isCachedDigest(digest) {
+			continue
+		}
+		path := filepath.Join(c.dir(), name)
+		if path == keep {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Warn().Err(err).Str("tarFilePath", path).Msg("Failed to remove stale cached tar")
+			continue
+		}
+		log.Debug().Str("tarFilePath", path).Msg("Removed stale cached tar")
+	}
+}
+
+func (c ImageTarCache) dir() string {
+	if c.Dir == "" {
+		return DefaultTarCacheDir
+	}
+	return c.Dir
+}
+
+// isCachedDigest reports whether s is the image ID part of a cached tar name, so tars of other tags sharing the
+// prefix (e.g. "app_1.0-rc") are never removed.
+func isCachedDigest(s string) bool {
+	if len(s) == 0 || len(s) > imageIDCacheLength {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/webApi"
+)
+
+// ReleaseSpec describes an application release: the workspace image to ship, the backend stack and network
+// to deploy on the node, and the group of users who get a session of the new image.
+// Docker steps with empty inputs are skipped, so a release can also just start sessions of a deployed image.
+type ReleaseSpec struct {
+	// ImageTag is the Docker image of the application, e.g. "kasm/app:1.2". Without a release network the
+	// Kasm workspace image launched for the users is the one using this Docker image.
+	ImageTag string
+	// BaseImage is the base image used to build ImageTag; DefaultBaseImage when empty.
+	BaseImage string
+	// TargetNodePath is the directory on the node receiving the image tar and the compose file.
+	// The image is not built and transferred when it is empty.
+	TargetNodePath string
+	// LocalTarFilePath is an optional prebuilt image tar to transfer instead of building the image.
+	LocalTarFilePath string
+	// SSH is the configuration of the node used by all Docker steps; nil loads it from the SSH_* environment
+	// variables.
+	SSH *shadowssh.SSHConfig
+	// ImageOptions configures the image transfer.
+	ImageOptions ImageDeployOptions
+
+	// Network is created on the node before the compose file is deployed, unless its name is empty. The
+	// workspace image launched for the users is then the one Kasm returns for this network.
+	Network dockercli.NetworkOptions
+
+	// ComposeFilePath is the local compose file of the Kasm backend; not deployed when empty.
+	ComposeFilePath string
+	// ComposeOptions holds the compose project name and env file of the backend.
+	ComposeOptions ComposeDeployOptions
+
+	// GroupName is the Kasm group whose members get a session of the released image.
+	GroupName string
+	// Usernames are added to GroupName before the sessions are started.
+	Usernames []string
+	// EnvArgs are passed to every newly requested session.
+	EnvArgs map[string]string
+}
+
+// ReleaseSession is a session started or reused for a group member by ReleaseApplication.
+type ReleaseSession struct {
+	UserID   string
+	Username string
+	KasmID   string
+	Reused   bool
+}
+
+// ReleaseResult summarizes a completed release.
+type ReleaseResult struct {
+	ImageID  string
+	GroupID  string
+	Sessions []ReleaseSession
+}
+
+// ReleaseApplication runs the release workflow: build and transfer the application image, create the network
+// and deploy the backend compose file on the node, then start a session of the image for every member of the
+// release group. Members with a running session of the image keep it.
+// The Docker steps share one connection to the node of spec.SSH, which is loaded from the SSH_* environment
+// variables if nil.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts of the Docker steps and Kasm API calls.
+// - kasmApi: KasmAPI instance of the deployment.
+// - spec: The release to perform.
+// Returns:
+// - The resolved image and group and the sessions of the group members.
+// - An error naming the step that failed; later steps are not run.
+func ReleaseApplication(ctx context.Context, kasmApi *webApi.KasmAPI, spec ReleaseSpec) (*ReleaseResult, error) {
+	if spec.ImageTag == "" {
+		return nil, fmt.Errorf("release requires an image tag")
+	}
+	if spec.GroupName == "" {
+		return nil, fmt.Errorf("release requires a group name")
+	}
+
+	// The Docker steps share one connection to the node.
+	var sshClient *shadowssh.SSHClient
+	if spec.TargetNodePath != "" || spec.Network.Name != "" || spec.ComposeFilePath != "" {
+		var release func()
+		var err error
+		sshClient, _, release, err = connectSSH(ctx, nil, spec.SSH)
+		if err != nil {
+			return nil, fmt.Errorf("release failed to connect to the node: %w", err)
+		}
+		defer release()
+		spec.ImageOptions.SSHClient, spec.ComposeOptions.SSHClient = sshClient, sshClient
+	}
+
+	// Step 1: Build the application image and load it on the node.
+	if spec.TargetNodePath != "" {
+		log.Info().Str("imageTag", spec.ImageTag).Str("targetNodePath", spec.TargetNodePath).Msg("Release: deploying application image")
+		if err := DeployKasmDockerImage(ctx, spec.ImageTag, spec.BaseImage, spec.TargetNodePath, spec.LocalTarFilePath, spec.ImageOptions); err != nil {
+			return nil, fmt.Errorf("release failed to deploy image %s: %w", spec.ImageTag, err)
+		}
+	}
+
+	// Step 2: Create the network of the custom run on the node.
+	if spec.Network.Name != "" {
+		log.Info().Str("network", spec.Network.Name).Msg("Release: creating network")
+		if err := createReleaseNetwork(ctx, sshClient, spec.Network); err != nil {
+			return nil, fmt.Errorf("release failed to create network %s: %w", spec.Network.Name, err)
+		}
+	}
+
+	// Step 3: Upload the compose file and start the Kasm backend.
+	if spec.ComposeFilePath != "" {
+		if spec.TargetNodePath == "" {
+			return nil, fmt.Errorf("release requires a target node path to deploy %s", spec.ComposeFilePath)
+		}
+		log.Info().Str("composeFile", spec.ComposeFilePath).Msg("Release: deploying backend compose file")
+		if err := DeployComposeFile(ctx, spec.ComposeFilePath, spec.TargetNodePath, spec.ComposeOptions); err != nil {
+			return nil, fmt.Errorf("release failed to deploy compose file %s: %w", spec.ComposeFilePath, err)
+		}
+	}
+
+	// Step 4: Start a session of the image for every group member.
+	return startReleaseSessions(ctx, kasmApi, spec)
+}
+
+// createReleaseNetwork creates the release network on the node; an existing network is left as it is.
+func createReleaseNetwork(ctx context.Context, sshClient *shadowssh.SSHClient, network dockercli.NetworkOptions) error {
+	remote := dockercli.NewRemoteDockerClient(sshClient.Sudo(), 1)
+	_, err := remote.EnsureNetwork(ctx, network)
+	return err
+}
+
+// startReleaseSessions resolves the workspace image and group of the release, adds the release users to the
+// group and starts or reuses a session for every member.
+func startReleaseSessions(ctx context.Context, kasmApi *webApi.KasmAPI, spec ReleaseSpec) (*ReleaseResult, error) {
+	imageID, err := releaseImageID(ctx, kasmApi, spec)
+	if err != nil {
+		return nil, err
+	}
+	groupID, err := kasmApi.GetGroupIDByName(ctx, spec.GroupName)
+	if err != nil {
+		return nil, fmt.Errorf("release failed to resolve group %s: %w", spec.GroupName, err)
+	}
+	result := &ReleaseResult{ImageID: imageID, GroupID: groupID}
+
+	for _, username := range spec.Usernames {
+		user, err := kasmApi.GetUser(ctx, "", username)
+		if err != nil {
+			return result, fmt.Errorf("release failed to look up user %s: %w", username, err)
+		}
+		if user.InGroup(groupID) {
+			continue
+		}
+		if err := kasmApi.AddUserToGroup(ctx, user.UserID, groupID); err != nil {
+			return result, fmt.Errorf("release failed to add user %s to group %s: %w", username, spec.GroupName, err)
+		}
+	}
+
+	sessions, err := kasmApi.StartSessionsForGroup(ctx, groupID, imageID, spec.EnvArgs)
+	if sessions != nil {
+		for _, started := range sessions.Started {
+			result.Sessions = append(result.Sessions, ReleaseSession{
+				UserID:   started.UserID,
+				Username: started.Username,
+				KasmID:   started.Session.KasmID,
+			})
+		}
+		for _, skipped := range sessions.Skipped {
+			session := ReleaseSession{UserID: skipped.UserID, KasmID: skipped.KasmID, Reused: true}
+			if skipped.User != nil {
+				session.Username = skipped.User.Username
+			}
+			result.Sessions = append(result.Sessions, session)
+		}
+	}
+	if err != nil {
+		return result, fmt.Errorf("release failed to start sessions for group %s: %w", spec.GroupName, err)
+	}
+
+	log.Info().
+		Str("imageID", imageID).
+		Str("group", spec.GroupName).
+		Int("sessions", len(result.Sessions)).
+		Msg("Release completed")
+	return result, nil
+}
+
+// releaseImageID returns the ID of the Kasm workspace image to launch. With a release network the image is
+// resolved through get_image for that network, otherwise it is the image using the Docker image ImageTag.
+func releaseImageID(ctx context.Context, kasmApi *webApi.KasmAPI, spec ReleaseSpec) (string, error) {
+	if spec.Network.Name != "" {
+		image, err := kasmApi.GetImageForNetwork(ctx, spec.Network.Name)
+		if err != nil {
+			return "", fmt.Errorf("release failed to resolve image: %w", err)
+		}
+		return image.ImageID, nil
+	}
+
+	var imageID string
+	err := kasmApi.ForEachImage(ctx, func(image webApi.ImageDetail) error {
+		if image.Name != spec.ImageTag {
+			return nil
+		}
+		imageID = image.ImageID
+		return webApi.ErrStopIteration
+	})
+	if err != nil {
+		return "", fmt.Errorf("release failed to list images: %w", err)
+	}
+	if imageID == "" {
+		return "", fmt.Errorf("release found no Kasm workspace image using %s", spec.ImageTag)
+	}
+	return imageID, nil
+}
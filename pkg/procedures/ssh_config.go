@@ -0,0 +1,207 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// DefaultSSHPort and DefaultSSHConnectionTimeout apply when no source sets the port or connection timeout.
+const (
+	DefaultSSHPort              = 22
+	DefaultSSHConnectionTimeout = 10 * time.Second
+)
+
+// SSHConfigOptions holds explicit SSH settings for LoadSSHConfig. Non-zero fields take precedence over the
+// config file, which takes precedence over the SSH_* environment variables.
+type SSHConfigOptions struct {
+	Username             string
+	Password             string
+	Host                 string
+	Port                 int
+	KnownHostsFile       string
+	ConnectionTimeout    time.Duration
+	UseSudo              bool
+	SudoPassword         string
+	FileTransferProtocol string
+	// KeepaliveInterval overrides the keepalive interval; see shadowssh.SSHConfig.KeepaliveInterval.
+	KeepaliveInterval time.Duration
+	// ConfigFile is an optional YAML file with the keys of sshConfigFile, e.g. "host: node1" and "port: 2222".
+	ConfigFile string
+}
+
+// sshConfigFile is the layout of the YAML file read by LoadSSHConfig.
+type sshConfigFile struct {
+	Username             string `yaml:"username"`
+	Password             string `yaml:"password"`
+	Host                 string `yaml:"host"`
+	Port                 string `yaml:"port"`
+	KnownHostsFile       string `yaml:"known_hosts"`
+	ConnectionTimeout    string `yaml:"connection_timeout"`
+	UseSudo              bool   `yaml:"use_sudo"`
+	SudoPassword         string `yaml:"sudo_password"`
+	FileTransferProtocol string `yaml:"file_transfer"`
+	KeepaliveInterval    string `yaml:"keepalive_interval"`
+}
+
+// LoadSSHConfig resolves the SSH configuration of the target node from explicit values, a config file and the
+// SSH_USERNAME, SSH_PASSWORD, SSH_HOST, SSH_PORT, SSH_KNOWN_HOSTS, SSH_CONNECTION_TIMEOUT, SSH_USE_SUDO,
+// SSH_SUDO_PASSWORD, SSH_FILE_TRANSFER and SSH_KEEPALIVE_INTERVAL environment variables, in that order of priority.
+// Parameters:
+// - opts: Explicit settings and the optional config file.
+// Returns:
+// - The SSH configuration.
+// - An error if the config file cannot be read, the port, a duration or the file transfer protocol is invalid,
+// or the username or host is missing.
+func LoadSSHConfig(opts SSHConfigOptions) (*shadowssh.SSHConfig, error) {
+	var file sshConfigFile
+	if opts.ConfigFile != "" {
+		data, err := os.ReadFile(opts.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH config file %s: %w", opts.ConfigFile, err)
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse SSH config file %s: %w", opts.ConfigFile, err)
+		}
+	}
+
+	port := opts.Port
+	if port == 0 {
+		value, source := firstSetting(file.Port, "port in "+opts.ConfigFile, "SSH_PORT")
+		if value == "" {
+			port = DefaultSSHPort
+		} else {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 || parsed > 65535 {
+				return nil, fmt.Errorf("invalid SSH port %q from %s: must be a number between 1 and 65535", value, source)
+			}
+			port = parsed
+		}
+	} else if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid SSH port %d: must be between 1 and 65535", port)
+	}
+
+	connectionTimeout := opts.ConnectionTimeout
+	if connectionTimeout == 0 {
+		value, source := firstSetting(file.ConnectionTimeout, "connection_timeout in "+opts.ConfigFile, "SSH_CONNECTION_TIMEOUT")
+		if value == "" {
+			connectionTimeout = DefaultSSHConnectionTimeout
+		} else {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid SSH connection timeout %q from %s: must be a positive duration such as 10s", value, source)
+			}
+			connectionTimeout = parsed
+		}
+	} else if connectionTimeout < 0 {
+		return nil, fmt.Errorf("invalid SSH connection timeout %s: must be positive", connectionTimeout)
+	}
+
+	keepaliveInterval := opts.KeepaliveInterval
+	if keepaliveInterval == 0 {
+		value, source := firstSetting(file.KeepaliveInterval, "keepalive_interval in "+opts.ConfigFile, "SSH_KEEPALIVE_INTERVAL")
+		if value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SSH keepalive interval %q from %s: must be a duration such as 30s, or negative to disable keepalives", value, source)
+			}
+			keepaliveInterval = parsed
+		}
+	}
+
+	username := firstNonEmpty(opts.Username, file.Username, os.Getenv("SSH_USERNAME"))
+	host := firstNonEmpty(opts.Host, file.Host, os.Getenv("SSH_HOST"))
+	if username == "" || host == "" {
+		return nil, fmt.Errorf("SSH username and host must be set (SSH_USERNAME and SSH_HOST, or username and host in the SSH config file)")
+	}
+
+	fileTransferProtocol := firstNonEmpty(opts.FileTransferProtocol, file.FileTransferProtocol, os.Getenv("SSH_FILE_TRANSFER"))
+	switch fileTransferProtocol {
+	case "", shadowssh.TransferAuto, shadowssh.TransferSFTP, shadowssh.TransferSCP:
+	default:
+		return nil, fmt.Errorf("invalid SSH file transfer protocol %q: must be %s, %s or %s", fileTransferProtocol, shadowssh.TransferAuto, shadowssh.TransferSFTP, shadowssh.TransferSCP)
+	}
+
+	sshConfig, err := shadowssh.NewSSHConfig(
+		username,
+		firstNonEmpty(opts.Password, file.Password, os.Getenv("SSH_PASSWORD")),
+		host,
+		port,
+		firstNonEmpty(opts.KnownHostsFile, file.KnownHostsFile, os.Getenv("SSH_KNOWN_HOSTS")),
+		connectionTimeout,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize SSH configuration: %w", err)
+	}
+
+	// Docker commands run through sudo when the SSH user cannot access the Docker socket.
+	sshConfig.UseSudo = opts.UseSudo || file.UseSudo || os.Getenv("SSH_USE_SUDO") == "true"
+	sshConfig.SudoPassword = firstNonEmpty(opts.SudoPassword, file.SudoPassword, os.Getenv("SSH_SUDO_PASSWORD"))
+	// Uploads prefer SFTP and fall back to SCP unless the protocol is "sftp" or "scp".
+	sshConfig.FileTransferProtocol = fileTransferProtocol
+	sshConfig.KeepaliveInterval = keepaliveInterval
+
+	return sshConfig, nil
+}
+
+// sshConfigOrLoad returns sshConfig, or the configuration loaded from the SSH_* environment variables if it is nil.
+func sshConfigOrLoad(sshConfig *shadowssh.SSHConfig) (*shadowssh.SSHConfig, error) {
+	if sshConfig != nil {
+		return sshConfig, nil
+	}
+	return LoadSSHConfig(SSHConfigOptions{})
+}
+
+// connectSSH returns sshClient if it is set, so procedures can share one connection, and otherwise opens a
+// connection to the node of sshConfig, which is loaded from the SSH_* environment variables if nil.
+// Returns:
+// - The connection and its configuration.
+// - A release function closing the connection if it was opened here; a supplied connection is left open.
+// - An error if the configuration cannot be loaded or the connection cannot be established.
+func connectSSH(ctx context.Context, sshClient *shadowssh.SSHClient, sshConfig *shadowssh.SSHConfig) (*shadowssh.SSHClient, *shadowssh.SSHConfig, func(), error) {
+	if sshClient != nil {
+		return sshClient, sshClient.Config(), func() {}, nil
+	}
+
+	sshConfig, err := sshConfigOrLoad(sshConfig)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to configure SSH settings: %w", err)
+	}
+	sshClient, err = shadowssh.NewSSHClient(ctx, sshConfig)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to establish SSH connection: %w", err)
+	}
+	release := func() {
+		if cerr := sshClient.Close(); cerr != nil {
+			log.Error().
+				Err(cerr).
+				Msg("Failed to close SSH client")
+		}
+	}
+	return sshClient, sshConfig, release, nil
+}
+
+// firstSetting returns the config file value if set, otherwise the environment variable env, together with
+// the name of the source for error messages.
+func firstSetting(fileValue, fileSource, env string) (string, string) {
+	if fileValue != "" {
+		return fileValue, fileSource
+	}
+	return os.Getenv(env), env
+}
+
+// firstNonEmpty returns the first non-empty value.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
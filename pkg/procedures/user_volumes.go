@@ -0,0 +1,83 @@
+package procedures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/dockercli"
+	shadowssh "kasmlink/pkg/sshmanager"
+	"kasmlink/pkg/userParser"
+	"kasmlink/pkg/webApi"
+)
+
+// Owner of the host directories of user volume mounts: the kasm-user of the Kasm workspace images.
+const (
+	DefaultVolumeUID = 1000
+	DefaultVolumeGID = 1000
+)
+
+// UserVolumeMappings translates the volume mounts of a user, given as hostPath: "containerPath:mode", into the
+// volume_mappings JSON of a Kasm workspace image.
+// Parameters:
+// - details: The user whose VolumeMounts are translated.
+// Returns:
+// - The serialized volume mappings, or an empty string if the user has no volume mounts.
+// - An error if a mount is not in the "containerPath:mode" format or uses relative paths or another mode.
+func UserVolumeMappings(details userParser.UserDetails) (string, error) {
+	mappings := webApi.NewVolumeMappings()
+	for _, hostPath := range sortedVolumeHostPaths(details) {
+		containerPath, mode, found := strings.Cut(details.VolumeMounts[hostPath], ":")
+		if !found {
+			return "", fmt.Errorf("invalid volume mount format: %s, expected 'containerPath:mode'", details.VolumeMounts[hostPath])
+		}
+		mappings.Add(containerPath, hostPath, mode, DefaultVolumeUID, DefaultVolumeGID)
+	}
+	return mappings.Build()
+}
+
+// ProvisionUserVolumes creates the host directories of the volume mounts of a user on the agent node and hands
+// them to the kasm-user, so the personal storage of the user can be mounted when their session starts.
+// Existing directories and their content are kept.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - executor: Runs commands on the agent node, e.g. the Sudo executor of an SSHClient.
+// - details: The user whose volume mount directories are created.
+// Returns:
+// - An error if a directory cannot be created or its owner cannot be set.
+func ProvisionUserVolumes(ctx context.Context, executor dockercli.CommandExecutor, details userParser.UserDetails) error {
+	if _, err := UserVolumeMappings(details); err != nil {
+		return fmt.Errorf("invalid volume mounts of user %s: %w", details.TargetUser.Username, err)
+	}
+
+	for _, hostPath := range sortedVolumeHostPaths(details) {
+		quoted := shadowssh.ShellQuote(hostPath)
+		command := fmt.Sprintf("mkdir -p %s && chown %d:%d %s", quoted, DefaultVolumeUID, DefaultVolumeGID, quoted)
+		if output, err := executor.ExecuteCommand(ctx, command); err != nil {
+			log.Error().
+				Err(err).
+				Str("username", details.TargetUser.Username).
+				Str("host_path", hostPath).
+				Str("output", output).
+				Msg("Failed to create volume directory on remote node")
+			return fmt.Errorf("failed to create volume directory %s for user %s: %w", hostPath, details.TargetUser.Username, err)
+		}
+		log.Debug().
+			Str("username", details.TargetUser.Username).
+			Str("host_path", hostPath).
+			Msg("Volume directory is ready on remote node")
+	}
+	return nil
+}
+
+// sortedVolumeHostPaths returns the host paths of the volume mounts of a user in sorted order.
+func sortedVolumeHostPaths(details userParser.UserDetails) []string {
+	hostPaths := make([]string, 0, len(details.VolumeMounts))
+	for hostPath := range details.VolumeMounts {
+		hostPaths = append(hostPaths, hostPath)
+	}
+	sort.Strings(hostPaths)
+	return hostPaths
+}
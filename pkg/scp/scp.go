@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -12,7 +14,9 @@ import (
 	sshmanager "kasmlink/pkg/sshmanager"
 )
 
-// ShadowCopyFile copies a local file to a remote node via SFTP over SSH.
+// ShadowCopyFile copies a local file into remoteDir on a remote node over SSH.
+// The transfer protocol follows sshConfig.FileTransferProtocol: by default SFTP is used and SCP is the fallback
+// for nodes without an SFTP subsystem.
 func ShadowCopyFile(ctx context.Context, localFilePath, remoteDir string, sshConfig *sshmanager.SSHConfig) error {
 	log.Info().
 		Str("username", sshConfig.Username).
@@ -20,24 +24,94 @@ func ShadowCopyFile(ctx context.Context, localFilePath, remoteDir string, sshCon
 		Int("port", sshConfig.Port).
 		Str("local_file", localFilePath).
 		Str("remote_dir", remoteDir).
-		Msg("Starting file copy to remote node via SSH using SFTP")
+		Msg("Starting file copy to remote node via SSH")
 
+	if err := copyWithRetries(ctx, func() error {
+		return performCopy(ctx, localFilePath, remoteDir, sshConfig, false)
+	}); err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", localFilePath, err)
+	}
+
+	log.Info().Msg("File copy completed successfully")
+	return nil
+}
+
+// ShadowCopyDir recursively copies a local directory to remoteDir on a remote node over SSH.
+// The contents of localDir end up directly in remoteDir, which is created if necessary. File modes are preserved.
+// The transfer protocol is selected like for ShadowCopyFile.
+func ShadowCopyDir(ctx context.Context, localDir, remoteDir string, sshConfig *sshmanager.SSHConfig) error {
+	log.Info().
+		Str("host", sshConfig.Host).
+		Str("local_dir", localDir).
+		Str("remote_dir", remoteDir).
+		Msg("Starting directory copy to remote node via SSH")
+
+	if err := copyWithRetries(ctx, func() error {
+		return performCopy(ctx, localDir, remoteDir, sshConfig, true)
+	}); err != nil {
+		return fmt.Errorf("failed to copy directory %s: %w", localDir, err)
+	}
+
+	log.Info().Str("remote_dir", remoteDir).Msg("Directory copy completed successfully")
+	return nil
+}
+
+// ShadowCopyFileWithClient copies a local file into remoteDir like ShadowCopyFile, but over an established
+// connection, which is left open. The transfer protocol follows the configuration of sshClient.
+func ShadowCopyFileWithClient(ctx context.Context, localFilePath, remoteDir string, sshClient *sshmanager.SSHClient) error {
+	log.Info().
+		Str("host", sshClient.Config().Host).
+		Str("local_file", localFilePath).
+		Str("remote_dir", remoteDir).
+		Msg("Starting file copy to remote node over the existing SSH connection")
+
+	if err := copyWithRetries(ctx, func() error {
+		return copyOverClient(ctx, sshClient, localFilePath, remoteDir, false)
+	}); err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", localFilePath, err)
+	}
+
+	log.Info().Msg("File copy completed successfully")
+	return nil
+}
+
+// ShadowCopyDirWithClient recursively copies a local directory to remoteDir like ShadowCopyDir, but over an
+// established connection, which is left open.
+func ShadowCopyDirWithClient(ctx context.Context, localDir, remoteDir string, sshClient *sshmanager.SSHClient) error {
+	log.Info().
+		Str("host", sshClient.Config().Host).
+		Str("local_dir", localDir).
+		Str("remote_dir", remoteDir).
+		Msg("Starting directory copy to remote node over the existing SSH connection")
+
+	if err := copyWithRetries(ctx, func() error {
+		return copyOverClient(ctx, sshClient, localDir, remoteDir, true)
+	}); err != nil {
+		return fmt.Errorf("failed to copy directory %s: %w", localDir, err)
+	}
+
+	log.Info().Str("remote_dir", remoteDir).Msg("Directory copy completed successfully")
+	return nil
+}
+
+// copyWithRetries runs a copy up to three times, waiting between attempts unless the context is done.
+func copyWithRetries(ctx context.Context, copyFn func() error) error {
 	retries := 3
 	delay := 2 * time.Second
 
+	var lastErr error
 	for attempt := 1; attempt <= retries; attempt++ {
-		err := performSFTPCopy(ctx, localFilePath, remoteDir, sshConfig)
-		if err == nil {
-			log.Info().Msg("File copy completed successfully")
+		lastErr = copyFn()
+		if lastErr == nil {
 			return nil
 		}
 
 		log.Warn().
-			Err(err).
+			Err(lastErr).
 			Int("attempt", attempt).
 			Int("max_retries", retries).
 			Dur("delay", delay).
-			Msg("Failed to copy file, retrying")
+			Msg("Failed to copy, retrying")
 
 		if attempt < retries {
 			select {
@@ -46,16 +120,21 @@ func ShadowCopyFile(ctx context.Context, localFilePath, remoteDir string, sshCon
 			case <-ctx.Done():
 				log.Error().
 					Err(ctx.Err()).
-					Msg("File copy canceled due to context cancellation")
-				return fmt.Errorf("file copy canceled: %w", ctx.Err())
+					Msg("Copy canceled due to context cancellation")
+				return fmt.Errorf("copy canceled: %w", ctx.Err())
 			}
 		}
 	}
 
-	return fmt.Errorf("failed to copy file after %d retries", retries)
+	return fmt.Errorf("failed after %d retries: %w", retries, lastErr)
 }
 
-func performSFTPCopy(ctx context.Context, localFilePath, remoteDir string, sshConfig *sshmanager.SSHConfig) error {
+// performCopy opens an SSH connection and copies a file or directory with the configured protocol.
+func performCopy(ctx context.Context, localPath, remoteDir string, sshConfig *sshmanager.SSHConfig, isDir bool) error {
+	if err := validateProtocol(sshConfig.FileTransferProtocol); err != nil {
+		return err
+	}
+
 	log.Debug().Msg("Establishing SSH connection")
 	sshClient, err := sshmanager.NewSSHClient(ctx, sshConfig)
 	if err != nil {
@@ -66,26 +145,63 @@ func performSFTPCopy(ctx context.Context, localFilePath, remoteDir string, sshCo
 			log.Error().Err(cerr).Msg("Failed to close SSH client")
 		}
 	}()
-	log.Debug().Msg("SSH connection established")
+
+	return copyOverClient(ctx, sshClient, localPath, remoteDir, isDir)
+}
+
+// validateProtocol checks that protocol is a known file transfer protocol.
+func validateProtocol(protocol string) error {
+	switch protocol {
+	case "", sshmanager.TransferAuto, sshmanager.TransferSFTP, sshmanager.TransferSCP:
+		return nil
+	default:
+		return fmt.Errorf("unknown file transfer protocol %q, expected %s, %s or %s", protocol, sshmanager.TransferAuto, sshmanager.TransferSFTP, sshmanager.TransferSCP)
+	}
+}
+
+// copyOverClient copies a file or directory over an established connection with the protocol configured for it.
+func copyOverClient(ctx context.Context, sshClient *sshmanager.SSHClient, localPath, remoteDir string, isDir bool) error {
+	sshConfig := sshClient.Config()
+	protocol := sshConfig.FileTransferProtocol
+	if err := validateProtocol(protocol); err != nil {
+		return err
+	}
 
 	client := sshClient.GetClient()
 	if client == nil {
 		return fmt.Errorf("SSH client is nil")
 	}
 
-	// Create SFTP client
-	log.Debug().Msg("Creating SFTP client")
-	sftpClient, err := sftp.NewClient(client)
-	if err != nil {
-		return fmt.Errorf("failed to create SFTP client: %w", err)
-	}
-	defer func() {
-		if cerr := sftpClient.Close(); cerr != nil {
-			log.Error().Err(cerr).Msg("Failed to close SFTP client")
+	if protocol != sshmanager.TransferSCP {
+		sftpClient, err := sftp.NewClient(client)
+		if err == nil {
+			defer func() {
+				if cerr := sftpClient.Close(); cerr != nil {
+					log.Error().Err(cerr).Msg("Failed to close SFTP client")
+				}
+			}()
+			if isDir {
+				return sftpCopyDir(ctx, sftpClient, localPath, remoteDir)
+			}
+			return sftpCopyFile(sftpClient, localPath, remoteDir)
 		}
-	}()
-	log.Debug().Msg("SFTP client created successfully")
+		if protocol == sshmanager.TransferSFTP {
+			return fmt.Errorf("failed to create SFTP client: %w", err)
+		}
+		log.Warn().
+			Err(err).
+			Str("host", sshConfig.Host).
+			Msg("SFTP is not available on the remote node, falling back to SCP")
+	}
 
+	if isDir {
+		return scpCopyDir(ctx, client, localPath, remoteDir)
+	}
+	return scpCopyFile(ctx, client, localPath, remoteDir)
+}
+
+// sftpCopyFile copies a local file into remoteDir via SFTP.
+func sftpCopyFile(sftpClient *sftp.Client, localFilePath, remoteDir string) error {
 	// Construct remote file path
 	remoteFilePath := remoteDir + "/" + fileNameFromPath(localFilePath)
 
@@ -122,6 +238,57 @@ func performSFTPCopy(ctx context.Context, localFilePath, remoteDir string, sshCo
 	return nil
 }
 
+// sftpCopyDir recursively copies the contents of localDir into remoteDir via SFTP.
+func sftpCopyDir(ctx context.Context, sftpClient *sftp.Client, localDir, remoteDir string) error {
+	return filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relativePath, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return err
+		}
+		remotePath := path.Join(remoteDir, filepath.ToSlash(relativePath))
+
+		if info.IsDir() {
+			if err := sftpClient.MkdirAll(remotePath); err != nil {
+				return fmt.Errorf("failed to create remote directory %s: %w", remotePath, err)
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			log.Debug().Str("file", localPath).Msg("Skipping non-regular file")
+			return nil
+		}
+
+		localFile, err := os.Open(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to open local file: %w", err)
+		}
+		defer localFile.Close()
+
+		remoteFile, err := sftpClient.Create(remotePath)
+		if err != nil {
+			return fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
+		}
+		defer remoteFile.Close()
+
+		if _, err := io.Copy(remoteFile, localFile); err != nil {
+			return fmt.Errorf("failed to copy file %s: %w", localPath, err)
+		}
+		if err := sftpClient.Chmod(remotePath, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to set mode of remote file %s: %w", remotePath, err)
+		}
+
+		log.Debug().Str("local_file", localPath).Str("remote_file", remotePath).Msg("File transferred via SFTP")
+		return nil
+	})
+}
+
 func fileNameFromPath(path string) string {
 	// Simple helper to extract filename from a path
 	// without adding extra dependencies.
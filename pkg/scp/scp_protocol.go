@@ -0,0 +1,198 @@
+package shadowscp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	shadowssh "kasmlink/pkg/sshmanager"
+)
+
+// scpCopyFile copies a local file into remoteDir using the SCP protocol ("scp -t" on the remote node).
+func scpCopyFile(ctx context.Context, client *ssh.Client, localFilePath, remoteDir string) error {
+	info, err := os.Stat(localFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	err = runSCPSink(ctx, client, "scp -t "+shadowssh.ShellQuote(remoteDir), func(w *scpWriter) error {
+		return w.sendFile(localFilePath, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("local_file", localFilePath).
+		Str("remote_dir", remoteDir).
+		Msg("File transferred successfully via SCP")
+	return nil
+}
+
+// scpCopyDir recursively copies the contents of localDir into remoteDir using the SCP protocol.
+func scpCopyDir(ctx context.Context, client *ssh.Client, localDir, remoteDir string) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	output, err := session.CombinedOutput("mkdir -p " + shadowssh.ShellQuote(remoteDir))
+	session.Close()
+	if err != nil {
+		return fmt.Errorf("failed to create remote directory %s: %w: %s", remoteDir, err, strings.TrimSpace(string(output)))
+	}
+
+	err = runSCPSink(ctx, client, "scp -r -t "+shadowssh.ShellQuote(remoteDir), func(w *scpWriter) error {
+		return w.sendDirContents(localDir)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("local_dir", localDir).
+		Str("remote_dir", remoteDir).
+		Msg("Directory transferred successfully via SCP")
+	return nil
+}
+
+// runSCPSink starts an SCP sink on the remote node and lets send stream files to it.
+// The session is closed when the context is done, which aborts a running transfer.
+func runSCPSink(ctx context.Context, client *ssh.Client, command string, send func(w *scpWriter) error) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("failed to start %q: %w", command, err)
+	}
+
+	writer := &scpWriter{in: stdin, out: bufio.NewReader(stdout)}
+	sendErr := writer.readAck() // The sink acknowledges once it is ready.
+	if sendErr == nil {
+		sendErr = send(writer)
+	}
+	stdin.Close()
+	waitErr := session.Wait()
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("SCP transfer canceled: %w", ctx.Err())
+	}
+	if sendErr != nil {
+		return fmt.Errorf("SCP transfer failed: %w, stderr: %s", sendErr, strings.TrimSpace(stderr.String()))
+	}
+	if waitErr != nil {
+		return fmt.Errorf("SCP sink failed: %w, stderr: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// scpWriter speaks the source side of the SCP protocol.
+type scpWriter struct {
+	in  io.Writer
+	out *bufio.Reader
+}
+
+// readAck reads the sink's response: a zero byte on success, or 1/2 followed by an error message.
+func (w *scpWriter) readAck() error {
+	code, err := w.out.ReadByte()
+	if err != nil {
+		return fmt.Errorf("failed to read SCP acknowledgement: %w", err)
+	}
+	if code == 0 {
+		return nil
+	}
+	message, _ := w.out.ReadString('\n')
+	return fmt.Errorf("remote SCP error: %s", strings.TrimSpace(message))
+}
+
+// sendFile sends a single regular file.
+func (w *scpWriter) sendFile(localPath string, info os.FileInfo) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(w.in, "C%04o %d %s\n", info.Mode().Perm(), info.Size(), info.Name()); err != nil {
+		return err
+	}
+	if err := w.readAck(); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(w.in, file, info.Size()); err != nil {
+		return fmt.Errorf("failed to send file %s: %w", localPath, err)
+	}
+	if _, err := w.in.Write([]byte{0}); err != nil {
+		return err
+	}
+	if err := w.readAck(); err != nil {
+		return err
+	}
+
+	log.Debug().Str("local_file", localPath).Msg("File transferred via SCP")
+	return nil
+}
+
+// sendDirContents sends the entries of a directory, descending into subdirectories.
+func (w *scpWriter) sendDirContents(localDir string) error {
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", localDir, err)
+	}
+
+	for _, entry := range entries {
+		localPath := filepath.Join(localDir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			if _, err := fmt.Fprintf(w.in, "D%04o 0 %s\n", info.Mode().Perm(), info.Name()); err != nil {
+				return err
+			}
+			if err := w.readAck(); err != nil {
+				return err
+			}
+			if err := w.sendDirContents(localPath); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w.in, "E\n"); err != nil {
+				return err
+			}
+			if err := w.readAck(); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := w.sendFile(localPath, info); err != nil {
+				return err
+			}
+		default:
+			log.Debug().Str("file", localPath).Msg("Skipping non-regular file")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package shadowssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultClusterParallelism is the number of nodes a ClusterExecutor works on at once when no limit is given.
+const DefaultClusterParallelism = 8
+
+// CommandResult is the outcome of a command on a single node.
+type CommandResult struct {
+	Host     string        // Host of the node, as configured.
+	Output   string        // Combined output of the command.
+	Err      error         // Connection or command error, nil on success.
+	Duration time.Duration // Time spent connecting and running the command.
+}
+
+// ClusterExecutor runs the same command on a set of nodes concurrently.
+type ClusterExecutor struct {
+	configs     []*SSHConfig
+	parallelism int
+}
+
+// NewClusterExecutor creates a ClusterExecutor for the given nodes.
+// Parameters:
+// - configs: SSH configurations of the nodes.
+// - parallelism: Maximum number of nodes worked on at once; DefaultClusterParallelism when zero or negative.
+func NewClusterExecutor(configs []*SSHConfig, parallelism int) *ClusterExecutor {
+	if parallelism <= 0 {
+		parallelism = DefaultClusterParallelism
+	}
+	return &ClusterExecutor{configs: configs, parallelism: parallelism}
+}
+
+// Run executes a command on every node. A failing node does not stop the others.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts; nodes not started yet are skipped once it is done.
+// - command: The command to run.
+// Returns:
+// - One result per node, in the order of the configurations.
+func (e *ClusterExecutor) Run(ctx context.Context, command string) []CommandResult {
+	return e.run(ctx, command, false)
+}
+
+// RunSudo behaves like Run but runs the command through sudo on nodes configured with UseSudo.
+func (e *ClusterExecutor) RunSudo(ctx context.Context, command string) []CommandResult {
+	return e.run(ctx, command, true)
+}
+
+func (e *ClusterExecutor) run(ctx context.Context, command string, sudo bool) []CommandResult {
+	results := make([]CommandResult, len(e.configs))
+	semaphore := make(chan struct{}, e.parallelism)
+	var wg sync.WaitGroup
+
+	log.Info().
+		Str("command", command).
+		Int("nodes", len(e.configs)).
+		Int("parallelism", e.parallelism).
+		Msg("Running command on cluster")
+
+	for i, config := range e.configs {
+		wg.Add(1)
+		go func(i int, config *SSHConfig) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i] = CommandResult{Host: config.Host, Err: ctx.Err()}
+				return
+			}
+
+			results[i] = runOnNode(ctx, config, command, sudo)
+		}(i, config)
+	}
+	wg.Wait()
+
+	failed := len(FailedResults(results))
+	log.Info().
+		Str("command", command).
+		Int("succeeded", len(results)-failed).
+		Int("failed", failed).
+		Msg("Cluster command finished")
+	return results
+}
+
+// runOnNode connects to a single node and runs the command.
+func runOnNode(ctx context.Context, config *SSHConfig, command string, sudo bool) CommandResult {
+	start := time.Now()
+	result := CommandResult{Host: config.Host}
+
+	client, err := NewSSHClient(ctx, config)
+	if err != nil {
+		result.Err = err
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer client.Close()
+
+	if sudo {
+		result.Output, result.Err = client.ExecuteCommandSudo(ctx, command)
+	} else {
+		result.Output, result.Err = client.ExecuteCommand(ctx, command)
+	}
+	result.Duration = time.Since(start)
+
+	if result.Err != nil {
+		log.Error().Err(result.Err).Str("host", config.Host).Str("command", command).Msg("Command failed on node")
+	}
+	return result
+}
+
+// FailedResults returns the results with an error.
+func FailedResults(results []CommandResult) []CommandResult {
+	var failed []CommandResult
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// ResultsError aggregates the errors of failed nodes into a single error naming each host, or returns nil.
+func ResultsError(results []CommandResult) error {
+	var errs []error
+	for _, result := range FailedResults(results) {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Host, result.Err))
+	}
+	return errors.Join(errs...)
+}
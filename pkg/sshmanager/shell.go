@@ -0,0 +1,25 @@
+package shadowssh
+
+import "strings"
+
+// ShellQuote quotes value as a single argument for a POSIX shell, so paths and arguments of remote commands
+// are never interpreted. Values made only of characters without special meaning are returned unchanged to
+// keep commands readable; everything else is wrapped in single quotes, inside which the shell expands nothing.
+// Use it instead of %q, whose double quotes still expand $, backticks and backslashes.
+func ShellQuote(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) < 0 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// ShellJoin quotes each argument with ShellQuote and joins them with spaces.
+func ShellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
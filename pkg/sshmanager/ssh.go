@@ -10,6 +10,8 @@ import (
 	"net"
 	"os/user"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -17,20 +19,40 @@ import (
 	"golang.org/x/crypto/ssh/knownhosts"
 )
 
+// File transfer protocols for SSHConfig.FileTransferProtocol.
+const (
+	TransferAuto = "auto" // Prefer SFTP and fall back to SCP when the node has no SFTP subsystem. Used when empty.
+	TransferSFTP = "sftp"
+	TransferSCP  = "scp"
+)
+
 // SSHConfig holds SSH connection parameters.
 type SSHConfig struct {
-	Username          string
-	Password          string
-	Host              string
-	Port              int
-	KnownHostsFile    string
-	ConnectionTimeout time.Duration
+	Username             string
+	Password             string
+	Host                 string
+	Port                 int
+	KnownHostsFile       string
+	ConnectionTimeout    time.Duration
+	UseSudo              bool   // Run privileged commands (docker) through sudo.
+	SudoPassword         string // Password for sudo; defaults to Password. Sent via stdin, never on the command line.
+	FileTransferProtocol string // TransferAuto, TransferSFTP or TransferSCP; uploads use TransferAuto when empty.
+	// KeepaliveInterval is the interval of keepalive requests sent while the connection is open, so idle
+	// timeouts of the server or of firewalls in between do not drop long commands such as docker load.
+	// Zero uses DefaultKeepaliveInterval; a negative value disables keepalives.
+	KeepaliveInterval time.Duration
 }
 
+// DefaultKeepaliveInterval is the keepalive interval used when SSHConfig.KeepaliveInterval is zero.
+const DefaultKeepaliveInterval = 30 * time.Second
+
 // SSHClient manages the SSH client connection.
 type SSHClient struct {
 	client *ssh.Client
 	config SSHConfig
+
+	stopKeepalive chan struct{}
+	closeOnce     sync.Once
 }
 
 // NewSSHConfig initializes and validates an SSHConfig struct.
@@ -123,10 +145,56 @@ func NewSSHClient(ctx context.Context, config *SSHConfig) (*SSHClient, error) {
 		Str("address", address).
 		Msg("SSH connection established")
 
-	return &SSHClient{
-		client: client,
-		config: *config,
-	}, nil
+	sshClient := &SSHClient{
+		client:        client,
+		config:        *config,
+		stopKeepalive: make(chan struct{}),
+	}
+	interval := config.KeepaliveInterval
+	if interval == 0 {
+		interval = DefaultKeepaliveInterval
+	}
+	if interval > 0 {
+		go sshClient.keepalive(interval)
+	}
+	return sshClient, nil
+}
+
+// keepalive sends a keepalive@openssh.com request every interval until the client is closed. A request that
+// fails or is not answered within the interval means the connection is gone, so the client is closed and
+// running commands fail instead of hanging.
+func (c *SSHClient) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopKeepalive:
+			return
+		case <-ticker.C:
+			// Servers answer unknown global requests with a failure reply, which still proves the connection works.
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+			var err error
+			select {
+			case <-c.stopKeepalive:
+				return
+			case err = <-reply:
+			case <-time.After(interval):
+				err = fmt.Errorf("no keepalive reply within %s", interval)
+			}
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("address", fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)).
+					Msg("SSH keepalive failed, closing connection")
+				c.client.Close()
+				return
+			}
+		}
+	}
 }
 
 // GetClient returns the underlying ssh.Client.
@@ -135,9 +203,18 @@ func (c *SSHClient) GetClient() *ssh.Client {
 	return c.client
 }
 
+// Config returns a copy of the configuration the connection was established with.
+func (c *SSHClient) Config() *SSHConfig {
+	config := c.config
+	return &config
+}
+
 // Close gracefully closes the SSH client connection.
 // It logs any errors encountered during closure.
 func (c *SSHClient) Close() error {
+	if c.stopKeepalive != nil {
+		c.closeOnce.Do(func() { close(c.stopKeepalive) })
+	}
 	if c.client != nil {
 		err := c.client.Close()
 		if err != nil {
@@ -154,9 +231,36 @@ func (c *SSHClient) Close() error {
 	return nil
 }
 
-// ExecuteCommandWithOutput executes a command over SSH and logs the output in real-time for a specified duration.
-// It returns the combined output from stdout and stderr.
-func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string, logDuration time.Duration) (string, error) {
+// ErrCommandTimeout is returned by ExecuteCommandWithOutput when a command exceeds its command timeout.
+var ErrCommandTimeout = errors.New("command timed out")
+
+// commandKillGrace is how long a command interrupted after its command timeout may take to exit before it is
+// killed. It is capped at the command timeout itself.
+const commandKillGrace = 10 * time.Second
+
+// ExecuteCommandWithOutput executes a command over SSH and returns the combined output from stdout and stderr.
+// The two durations are independent:
+// - logDuration only limits how long the output is logged line by line; afterwards the output is still
+// captured and returned, and the command keeps running until it exits.
+// - commandTimeout limits the lifetime of the command. When it is exceeded, the command is sent SIGINT and,
+// if it has not exited after a grace period, SIGKILL, and an error wrapping ErrCommandTimeout is returned.
+// Zero disables the limit, leaving ctx as the only bound.
+// Note that signals are only delivered by SSH servers supporting them (OpenSSH 7.9 and later); the session is
+// closed in any case.
+func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string, logDuration, commandTimeout time.Duration) (string, error) {
+	return c.executeCommandWithOutput(ctx, command, command, nil, logDuration, commandTimeout)
+}
+
+// ExecuteCommandWithOutputSudo behaves like ExecuteCommandWithOutput but runs the command through sudo
+// when UseSudo is set in the SSH configuration.
+func (c *SSHClient) ExecuteCommandWithOutputSudo(ctx context.Context, command string, logDuration, commandTimeout time.Duration) (string, error) {
+	remoteCommand, stdin := c.sudoCommand(command)
+	return c.executeCommandWithOutput(ctx, command, remoteCommand, stdin, logDuration, commandTimeout)
+}
+
+// executeCommandWithOutput runs remoteCommand with the given stdin and logs it as command,
+// so secrets passed to sudo never end up in the logs.
+func (c *SSHClient) executeCommandWithOutput(ctx context.Context, command, remoteCommand string, stdin io.Reader, logDuration, commandTimeout time.Duration) (string, error) {
 	// Create a new session for the command.
 	session, err := c.client.NewSession()
 	if err != nil {
@@ -195,7 +299,8 @@ func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string
 	}
 
 	// Start the command.
-	if err := session.Start(command); err != nil {
+	session.Stdin = stdin
+	if err := session.Start(remoteCommand); err != nil {
 		log.Error().
 			Err(err).
 			Str("command", command).
@@ -205,14 +310,22 @@ func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string
 
 	// Channels for real-time logging and capturing output.
 	outputChan := make(chan string)
-	errChan := make(chan error)
+	errChan := make(chan error, 1)
+
+	// stopReading releases the reader when the command is abandoned before its output ends.
+	stopReading := make(chan struct{})
+	defer close(stopReading)
 
 	go func() {
 		defer close(outputChan)
 		combinedReader := io.MultiReader(stdoutPipe, stderrPipe)
 		scanner := bufio.NewScanner(combinedReader)
 		for scanner.Scan() {
-			outputChan <- scanner.Text()
+			select {
+			case outputChan <- scanner.Text():
+			case <-stopReading:
+				return
+			}
 		}
 		if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
 			errChan <- fmt.Errorf("error reading output: %w", err)
@@ -220,14 +333,23 @@ func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string
 		close(errChan)
 	}()
 
-	// Log command output in real-time for the specified duration.
+	// Log command output in real-time for the specified duration, then keep capturing it silently.
 	var outputBuffer string
+	logging := true
 	logTimer := time.NewTimer(logDuration)
 	defer logTimer.Stop()
 
+	var timeout <-chan time.Time
+	if commandTimeout > 0 {
+		timeoutTimer := time.NewTimer(commandTimeout)
+		defer timeoutTimer.Stop()
+		timeout = timeoutTimer.C
+	}
+
 	log.Info().
 		Str("command", command).
 		Dur("log_duration", logDuration).
+		Dur("command_timeout", commandTimeout).
 		Msg("Logging command output")
 
 	for {
@@ -244,31 +366,32 @@ func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string
 				}
 				return outputBuffer, nil
 			}
-			log.Info().
-				Str("output", output).
-				Msg("Command output")
+			if logging {
+				log.Info().
+					Str("output", output).
+					Msg("Command output")
+			}
 			outputBuffer += output + "\n"
-		case err := <-errChan:
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("command", command).
-					Msg("Error reading command output")
-				return outputBuffer, err
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
 			}
+			log.Error().
+				Err(err).
+				Str("command", command).
+				Msg("Error reading command output")
+			return outputBuffer, err
 		case <-logTimer.C:
-			log.Info().Msg("Logging duration expired; capturing remaining output")
-			for output := range outputChan {
-				outputBuffer += output + "\n"
-			}
-			if err := session.Wait(); err != nil {
-				log.Error().
-					Err(err).
-					Str("command", command).
-					Msg("Command execution failed after log duration")
-				return outputBuffer, fmt.Errorf("command execution failed: %w", err)
-			}
-			return outputBuffer, nil
+			log.Info().Msg("Logging duration expired; capturing remaining output until the command exits")
+			logging = false
+		case <-timeout:
+			log.Error().
+				Str("command", command).
+				Dur("command_timeout", commandTimeout).
+				Msg("Command timed out; terminating it")
+			outputBuffer += terminateCommand(session, command, outputChan, min(commandKillGrace, commandTimeout))
+			return outputBuffer, fmt.Errorf("%w after %s", ErrCommandTimeout, commandTimeout)
 		case <-ctx.Done():
 			log.Warn().
 				Err(ctx.Err()).
@@ -285,9 +408,83 @@ func (c *SSHClient) ExecuteCommandWithOutput(ctx context.Context, command string
 	}
 }
 
+// terminateCommand sends SIGINT to the command of session and SIGKILL if its output does not end within grace.
+// Returns the output captured in the meantime.
+func terminateCommand(session *ssh.Session, command string, outputChan <-chan string, grace time.Duration) string {
+	if err := session.Signal(ssh.SIGINT); err != nil {
+		log.Error().
+			Err(err).
+			Str("command", command).
+			Msg("Failed to send interrupt signal to SSH session")
+	}
+
+	var output string
+	graceTimer := time.NewTimer(grace)
+	defer graceTimer.Stop()
+	for {
+		select {
+		case line, ok := <-outputChan:
+			if !ok {
+				return output
+			}
+			output += line + "\n"
+		case <-graceTimer.C:
+			log.Warn().
+				Str("command", command).
+				Dur("grace", grace).
+				Msg("Command did not exit after interrupt; killing it")
+			if err := session.Signal(ssh.SIGKILL); err != nil {
+				log.Error().
+					Err(err).
+					Str("command", command).
+					Msg("Failed to send kill signal to SSH session")
+			}
+			return output
+		}
+	}
+}
+
 // ExecuteCommand connects to a remote node via SSH, executes a command, and returns the combined stdout and stderr output.
 // It respects the provided context for cancellation and timeout.
 func (c *SSHClient) ExecuteCommand(ctx context.Context, command string) (string, error) {
+	return c.executeCommand(ctx, command, command, nil)
+}
+
+// ExecuteCommandSudo behaves like ExecuteCommand but runs the command through sudo when UseSudo is set
+// in the SSH configuration. Without a sudo password, sudo runs non-interactively and fails instead of prompting.
+func (c *SSHClient) ExecuteCommandSudo(ctx context.Context, command string) (string, error) {
+	remoteCommand, stdin := c.sudoCommand(command)
+	return c.executeCommand(ctx, command, remoteCommand, stdin)
+}
+
+// ExecuteCommandWithStdin behaves like ExecuteCommand but feeds stdin to the command, so secrets such as
+// registry passwords reach the command without appearing in its command line, the process list or the logs.
+func (c *SSHClient) ExecuteCommandWithStdin(ctx context.Context, command string, stdin io.Reader) (string, error) {
+	return c.executeCommand(ctx, command, command, stdin)
+}
+
+// ExecuteCommandWithStdinSudo behaves like ExecuteCommandWithStdin but runs the command through sudo when
+// UseSudo is set. sudo only reads a password when it prompts, so a password sent ahead of stdin would reach
+// the command whenever NOPASSWD or a cached timestamp skips the prompt. sudo is therefore authenticated
+// on its own with `sudo -S -v` first, and the command then runs with `sudo -n` and only the caller's stdin.
+func (c *SSHClient) ExecuteCommandWithStdinSudo(ctx context.Context, command string, stdin io.Reader) (string, error) {
+	remoteCommand, sudoStdin := c.sudoCommand(command)
+	if sudoStdin == nil {
+		return c.executeCommand(ctx, command, remoteCommand, stdin)
+	}
+
+	if output, err := c.executeCommand(ctx, sudoValidateCommand, sudoValidateCommand, sudoStdin); err != nil {
+		return output, fmt.Errorf("failed to authenticate sudo: %w", err)
+	}
+	return c.executeCommand(ctx, command, "sudo -n sh -c "+ShellQuote(command), stdin)
+}
+
+// sudoValidateCommand authenticates sudo with the password on stdin and refreshes its timestamp, without
+// running a command.
+const sudoValidateCommand = "sudo -S -p '' -v"
+
+// executeCommand runs remoteCommand with the given stdin and logs it as command.
+func (c *SSHClient) executeCommand(ctx context.Context, command, remoteCommand string, stdin io.Reader) (string, error) {
 	// Create a new session for the command.
 	session, err := c.client.NewSession()
 	if err != nil {
@@ -312,7 +509,8 @@ func (c *SSHClient) ExecuteCommand(ctx context.Context, command string) (string,
 	session.Stderr = &stderrBuf
 
 	// Start the command.
-	if err := session.Start(command); err != nil {
+	session.Stdin = stdin
+	if err := session.Start(remoteCommand); err != nil {
 		log.Error().
 			Err(err).
 			Str("command", command).
@@ -357,6 +555,45 @@ func (c *SSHClient) ExecuteCommand(ctx context.Context, command string) (string,
 	}
 }
 
+// sudoCommand wraps a command in sudo if the configuration asks for it.
+// Returns the command to run and the stdin feeding sudo its password, or the unchanged command and nil stdin.
+func (c *SSHClient) sudoCommand(command string) (string, io.Reader) {
+	if !c.config.UseSudo {
+		return command, nil
+	}
+
+	password := c.config.SudoPassword
+	if password == "" {
+		password = c.config.Password
+	}
+	if password == "" {
+		return "sudo -n sh -c " + ShellQuote(command), nil
+	}
+	// -S reads the password from stdin, -p '' suppresses the prompt in the command output.
+	return "sudo -S -p '' sh -c " + ShellQuote(command), strings.NewReader(password + "\n")
+}
+
+// SudoExecutor runs commands through ExecuteCommandSudo. It satisfies executor interfaces such as
+// dockercli.CommandExecutor, so remote docker commands honor the UseSudo setting.
+type SudoExecutor struct {
+	client *SSHClient
+}
+
+// Sudo returns an executor running every command through ExecuteCommandSudo.
+func (c *SSHClient) Sudo() SudoExecutor {
+	return SudoExecutor{client: c}
+}
+
+// ExecuteCommand runs the command through sudo when UseSudo is set.
+func (e SudoExecutor) ExecuteCommand(ctx context.Context, command string) (string, error) {
+	return e.client.ExecuteCommandSudo(ctx, command)
+}
+
+// ExecuteCommandWithStdin runs the command through sudo when UseSudo is set, feeding it stdin.
+func (e SudoExecutor) ExecuteCommandWithStdin(ctx context.Context, command string, stdin io.Reader) (string, error) {
+	return e.client.ExecuteCommandWithStdinSudo(ctx, command, stdin)
+}
+
 // netDialer is a custom dialer that respects the context for SSH connections.
 type netDialer struct {
 	ctx     context.Context
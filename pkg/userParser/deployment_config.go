@@ -0,0 +1,52 @@
+package userParser
+
+import (
+	"fmt"
+	"kasmlink/pkg/webApi"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeploymentConfig describes a complete Kasm deployment: the users in the format read by CreateTestEnvironment
+// together with the groups and workspace images they rely on.
+type DeploymentConfig struct {
+	UsersConfig `yaml:",inline"`
+	Groups      []GroupDetails       `yaml:"groups,omitempty"`
+	Images      []webApi.TargetImage `yaml:"images,omitempty"`
+	// ServiceEnv maps compose service names to the environment variables of their generated env files.
+	ServiceEnv map[string]map[string]string `yaml:"service_env,omitempty"`
+	// ManualFields lists values that could not be exported and have to be filled in by hand, e.g. passwords.
+	ManualFields []string `yaml:"manual_fields,omitempty"`
+}
+
+// GroupDetails describes a user group of a deployment.
+type GroupDetails struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Priority    int    `yaml:"priority"`
+}
+
+// SaveDeploymentConfig writes a deployment configuration to path atomically.
+// The file can be used as the user configuration of CreateTestEnvironment, which reads the users and keeps
+// the other sections when it records its progress.
+func SaveDeploymentConfig(path string, config *DeploymentConfig) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment configuration: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// LoadDeploymentConfig reads a deployment configuration, e.g. one written by SaveDeploymentConfig.
+func LoadDeploymentConfig(path string) (*DeploymentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deployment configuration %s: %w", path, err)
+	}
+	var config DeploymentConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment configuration %s: %w", path, err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,147 @@
+package userParser
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overlayListKeys names the field identifying an entry of the top-level lists merged by
+// LoadDeploymentConfigWithOverlay, e.g. users are matched by target_user.username.
+var overlayListKeys = map[string][]string{
+	"user_details": {"target_user", "username"},
+	"groups":       {"name"},
+	"images":       {"name"},
+}
+
+// LoadDeploymentConfigWithOverlay reads a base deployment configuration and deep-merges an environment overlay
+// onto it, so one roster can be shared by dev, staging and prod with only the differences kept per environment.
+// Merge semantics:
+// - Mappings are merged key by key; keys missing from the overlay keep their base value.
+// - Scalars and all other lists set in the overlay replace the base value.
+// - The users, groups and images lists are merged by username and name respectively: an overlay entry matching
+// a base entry is merged into it, other overlay entries are appended.
+// Parameters:
+// - basePath: Path of the base deployment YAML.
+// - overlayPath: Path of the overlay YAML; the base is loaded as it is when empty.
+// Returns:
+// - The merged deployment configuration.
+// - An error if a file cannot be read or parsed.
+func LoadDeploymentConfigWithOverlay(basePath, overlayPath string) (*DeploymentConfig, error) {
+	if overlayPath == "" {
+		return LoadDeploymentConfig(basePath)
+	}
+
+	base, err := readYAMLDocument(basePath)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readYAMLDocument(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if base != nil && overlay != nil && (base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode) {
+		return nil, fmt.Errorf("deployment configuration %s and overlay %s must both be YAML mappings", basePath, overlayPath)
+	}
+	merged := base
+	if overlay != nil {
+		merged = mergeYAMLNodes(base, overlay, nil, overlayListKeys)
+	}
+
+	var config DeploymentConfig
+	if merged != nil {
+		if err := merged.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to apply overlay %s to deployment configuration %s: %w", overlayPath, basePath, err)
+		}
+	}
+	return &config, nil
+}
+
+// readYAMLDocument parses path and returns its root node, or nil for an empty file.
+func readYAMLDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deployment configuration %s: %w", path, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment configuration %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	return document.Content[0], nil
+}
+
+// mergeYAMLNodes returns overlay merged onto base. Mappings are merged recursively, sequences are merged by
+// listKey when it is set, and overlay wins for everything else. childListKeys holds the list keys of the values
+// of a mapping.
+func mergeYAMLNodes(base, overlay *yaml.Node, listKey []string, childListKeys map[string][]string) *yaml.Node {
+	if base == nil {
+		return overlay
+	}
+
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key := overlay.Content[i]
+			setMappingValue(base, key, mergeYAMLNodes(mappingValue(base, key.Value), overlay.Content[i+1], childListKeys[key.Value], nil))
+		}
+		return base
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && listKey != nil:
+		for _, entry := range overlay.Content {
+			id := nestedScalar(entry, listKey)
+			matched := false
+			for i, existing := range base.Content {
+				if id != "" && nestedScalar(existing, listKey) == id {
+					base.Content[i] = mergeYAMLNodes(existing, entry, nil, nil)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				base.Content = append(base.Content, entry)
+			}
+		}
+		return base
+	default:
+		return overlay
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value of key in a mapping node or appends the pair.
+func setMappingValue(mapping, key, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key.Value {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+// nestedScalar follows path through nested mappings and returns the scalar at its end, or "".
+func nestedScalar(node *yaml.Node, path []string) string {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return ""
+		}
+		node = mappingValue(node, key)
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"kasmlink/pkg/webApi"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -28,13 +30,26 @@ type UserDetails struct {
 
 type UserParser struct {
 	mutex sync.Mutex
+
+	// cacheMutex guards cache. It is separate from mutex because LoadConfig is called with and without mutex held.
+	cacheMutex sync.Mutex
+	cache      map[string]cachedConfig
+}
+
+// cachedConfig is a parsed configuration together with the file state it was parsed from.
+type cachedConfig struct {
+	config  *UsersConfig
+	modTime time.Time
+	size    int64
 }
 
 func NewUserParser() *UserParser {
-	return &UserParser{}
+	return &UserParser{cache: make(map[string]cachedConfig)}
 }
 
 // LoadConfig loads the configuration from the YAML file.
+// Parsed configurations are cached per path and reloaded when the modification time or size of the file
+// changes. Each call returns its own copy, so callers may modify it freely.
 // Assumes that the caller holds the mutex.
 func (u *UserParser) LoadConfig(path string) (*UsersConfig, error) {
 	file, err := os.Open(path)
@@ -43,15 +58,62 @@ func (u *UserParser) LoadConfig(path string) (*UsersConfig, error) {
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	u.cacheMutex.Lock()
+	defer u.cacheMutex.Unlock()
+
+	if cached, ok := u.cache[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		log.Debug().Str("path", path).Msg("Using cached user configuration")
+		return cloneUsersConfig(cached.config), nil
+	}
+
 	var config UsersConfig
 	decoder := yaml.NewDecoder(file)
 	if err := decoder.Decode(&config); err != nil {
 		return nil, err
 	}
 
+	if u.cache == nil {
+		u.cache = make(map[string]cachedConfig)
+	}
+	u.cache[path] = cachedConfig{config: cloneUsersConfig(&config), modTime: info.ModTime(), size: info.Size()}
+
 	return &config, nil
 }
 
+// InvalidateCache drops the cached configuration of path, forcing the next LoadConfig to read the file.
+func (u *UserParser) InvalidateCache(path string) {
+	u.cacheMutex.Lock()
+	defer u.cacheMutex.Unlock()
+	delete(u.cache, path)
+}
+
+// cloneUsersConfig returns a deep copy of config.
+func cloneUsersConfig(config *UsersConfig) *UsersConfig {
+	clone := &UsersConfig{}
+	for _, user := range config.UserDetails {
+		user.VolumeMounts = cloneStringMap(user.VolumeMounts)
+		user.EnvironmentArgs = cloneStringMap(user.EnvironmentArgs)
+		clone.UserDetails = append(clone.UserDetails, user)
+	}
+	return clone
+}
+
+func cloneStringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(values))
+	for key, value := range values {
+		clone[key] = value
+	}
+	return clone
+}
+
 // UpdateUserConfig updates the user configuration.
 func (u *UserParser) UpdateUserConfig(path, username, newUserID, newKasmSessionID, containerId string) error {
 	u.mutex.Lock()
@@ -82,18 +144,116 @@ func (u *UserParser) UpdateUserConfig(path, username, newUserID, newKasmSessionI
 		return errors.New("user " + username + " not found in configuration")
 	}
 
-	// Marshal and write the updated configuration
-	data, err := yaml.Marshal(config)
+	if err := SaveConfig(path, config); err != nil {
+		log.Printf("Failed to write updated configuration to YAML file: %v\n", err)
+		return err
+	}
+	u.InvalidateCache(path)
+
+	log.Printf("Successfully updated user %s\n", username)
+	return nil
+}
+
+// SaveConfig marshals the configuration and writes it to path atomically.
+// The data is written to a temporary file in the same directory, flushed to disk
+// and then renamed over the original, so an interrupted write never leaves a
+// truncated configuration behind.
+// Top-level sections of an existing file that UsersConfig does not know, such as the groups and images of
+// a DeploymentConfig, are kept as written; groups are neither created in Kasm nor resolved to IDs here.
+func SaveConfig(path string, config *UsersConfig) error {
+	var document yaml.Node
+	if err := document.Encode(config); err != nil {
+		return fmt.Errorf("failed to marshal updated configuration: %w", err)
+	}
+	if existing, err := os.ReadFile(path); err == nil {
+		document = mergeTopLevel(existing, &document)
+	}
+
+	data, err := yaml.Marshal(&document)
 	if err != nil {
-		log.Printf("Failed to marshal updated configuration: %v\n", err)
 		return fmt.Errorf("failed to marshal updated configuration: %w", err)
 	}
-	err = os.WriteFile(path, data, 0644)
+	return writeFileAtomic(path, data, 0644)
+}
+
+// mergeTopLevel returns the mapping of existing with every key of updated replaced or added.
+// If existing is not a YAML mapping, updated is returned unchanged.
+func mergeTopLevel(existing []byte, updated *yaml.Node) yaml.Node {
+	var document yaml.Node
+	if err := yaml.Unmarshal(existing, &document); err != nil || len(document.Content) == 0 {
+		return *updated
+	}
+	merged := document.Content[0]
+	if merged.Kind != yaml.MappingNode {
+		return *updated
+	}
+
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key, value := updated.Content[i], updated.Content[i+1]
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return *merged
+}
+
+// writeFileAtomic writes data to a temporary file next to path, fsyncs it and renames it into place.
+// The permissions of an existing file at path are preserved.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		log.Printf("Failed to write updated configuration to YAML file: %v\n", err)
-		return fmt.Errorf("failed to write updated configuration to YAML file: %w", err)
+		return fmt.Errorf("failed to create temporary file in %s: %w", dir, err)
+	}
+	tempPath := tempFile.Name()
+
+	// Remove the temporary file on any failure; after a successful rename it no longer exists.
+	success := false
+	defer func() {
+		if !success {
+			tempFile.Close()
+			if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+				log.Warn().Err(err).Str("tempFile", tempPath).Msg("Failed to remove temporary file")
+			}
+		}
+	}()
+
+	if _, err := tempFile.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file %s: %w", tempPath, err)
+	}
+	if err := tempFile.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set permissions on temporary file %s: %w", tempPath, err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file %s: %w", tempPath, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to rename temporary file %s to %s: %w", tempPath, path, err)
+	}
+	success = true
+
+	// Sync the directory so the rename itself survives a crash.
+	if dirHandle, err := os.Open(dir); err == nil {
+		if err := dirHandle.Sync(); err != nil {
+			log.Debug().Err(err).Str("dir", dir).Msg("Failed to sync config directory")
+		}
+		dirHandle.Close()
 	}
 
-	log.Printf("Successfully updated user %s\n", username)
 	return nil
 }
@@ -0,0 +1,99 @@
+package userParser
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultWatchInterval is the polling interval of WatchConfig.
+const DefaultWatchInterval = time.Second
+
+// fileState identifies a version of a file on disk.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+func (s fileState) equal(other fileState) bool {
+	return s.exists == other.exists && s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+// WatchConfig watches the user configuration at path and calls onChange with the reloaded configuration
+// after every change, until ctx is cancelled.
+// The file is polled by path, so editors that save by writing a new file and renaming it over the old one
+// are handled like in-place writes. Changes are debounced: the configuration is reloaded once the file has
+// stayed unchanged for one polling interval, and a file that is briefly missing or unparsable mid-save is
+// skipped until it settles.
+// Parameters:
+// - ctx: Context whose cancellation stops the watcher.
+// - path: Path to the user configuration YAML.
+// - interval: Polling interval; values <= 0 fall back to DefaultWatchInterval.
+// - onChange: Callback invoked with each reloaded configuration.
+// Returns:
+// - nil once ctx is cancelled.
+func (u *UserParser) WatchConfig(ctx context.Context, path string, interval time.Duration, onChange func(*UsersConfig)) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info().Str("path", path).Dur("interval", interval).Msg("Watching user configuration for changes")
+
+	current := statFile(path)
+	pending := false
+	var previous fileState
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Str("path", path).Msg("Stopped watching user configuration")
+			return nil
+		case <-ticker.C:
+		}
+
+		state := statFile(path)
+		if !pending {
+			if state.equal(current) {
+				continue
+			}
+			// Wait for the next poll so a save in progress can finish.
+			pending = true
+			previous = state
+			continue
+		}
+		if !state.equal(previous) {
+			previous = state
+			continue
+		}
+
+		pending = false
+		current = state
+		if !state.exists {
+			log.Warn().Str("path", path).Msg("User configuration was removed")
+			continue
+		}
+
+		u.InvalidateCache(path)
+		config, err := u.LoadConfig(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to reload changed user configuration")
+			continue
+		}
+
+		log.Info().Str("path", path).Int("users", len(config.UserDetails)).Msg("User configuration changed")
+		onChange(config)
+	}
+}
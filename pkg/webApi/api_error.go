@@ -0,0 +1,107 @@
+package webApi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// KasmAPIError is returned when the Kasm API answers a request with an unexpected status code.
+// Use errors.As to inspect it, or IsNotFound to detect a missing user, image or session.
+type KasmAPIError struct {
+	// StatusCode is the HTTP status code of the response, e.g. 400.
+	StatusCode int
+	// Status is the HTTP status line of the response, e.g. "400 Bad Request".
+	Status string
+	// Message is the error_message reported by Kasm, empty if the body carries none.
+	Message string
+	// Body is the trimmed response body.
+	Body string
+}
+
+// newKasmAPIError builds the error of a response with an unexpected status code.
+func newKasmAPIError(resp *http.Response, body []byte) *KasmAPIError {
+	apiErr := &KasmAPIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(body)),
+	}
+	var errorResponse struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	if json.Unmarshal(body, &errorResponse) == nil {
+		apiErr.Message = errorResponse.ErrorMessage
+	}
+	return apiErr
+}
+
+func (e *KasmAPIError) Error() string {
+	return fmt.Sprintf("unexpected response status: %s, body: %s", e.Status, e.Body)
+}
+
+// Is makes errors.Is(err, ErrUnauthorized) hold for 401 and 403 responses.
+func (e *KasmAPIError) Is(target error) bool {
+	return target == ErrUnauthorized && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
+
+// NotFound reports whether Kasm rejected the request because the requested object does not exist.
+// Kasm answers such requests with 400 and a message like "User not found", so the message is checked here,
+// in one place, instead of by every caller.
+func (e *KasmAPIError) NotFound() bool {
+	if e.StatusCode < 400 || e.StatusCode >= 500 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(e.Message), "not found")
+}
+
+// SessionNotReady reports whether Kasm rejected the request because the session or its agent cannot accept
+// commands yet, which happens for a while after a session was created even if it is reported as running.
+// Server errors are never reported as not ready; the request retries already cover them.
+func (e *KasmAPIError) SessionNotReady() bool {
+	return e.StatusCode < 500 && isSessionNotReadyMessage(e.Message)
+}
+
+// sessionNotReadyMessages are lower case fragments of the messages Kasm reports while a session or its agent
+// is starting. They name the session or the agent so that errors like "Image not available" stay final.
+var sessionNotReadyMessages = []string{
+	"session not ready",
+	"session is not ready",
+	"session is starting",
+	"session is still starting",
+	"agent not ready",
+	"agent is not ready",
+	"agent is not available",
+	"agent is unavailable",
+	"agent is starting",
+}
+
+// isSessionNotReadyMessage reports whether an error_message of Kasm describes a session that is not ready yet.
+func isSessionNotReadyMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, fragment := range sessionNotReadyMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports whether repeating the request may succeed. Client errors other than timeouts and rate
+// limiting are final.
+func (e *KasmAPIError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsNotFound reports whether err is a KasmAPIError for a missing object.
+func IsNotFound(err error) bool {
+	var apiErr *KasmAPIError
+	return errors.As(err, &apiErr) && apiErr.NotFound()
+}
+
+// isFinal reports whether err is a KasmAPIError that is not worth retrying.
+func isFinal(err error) bool {
+	var apiErr *KasmAPIError
+	return errors.As(err, &apiErr) && !apiErr.Retryable()
+}
@@ -0,0 +1,116 @@
+package webApi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Sections of an exec_config: first_launch runs once when a session is created, go runs every time the
+// workspace is launched or resumed, e.g. to open a URL passed via the launch form.
+const (
+	ExecSectionFirstLaunch = "first_launch"
+	ExecSectionGo          = "go"
+)
+
+// ExecAction is a command of an exec_config section.
+type ExecAction struct {
+	Cmd         string            `json:"cmd"`
+	Environment map[string]string `json:"environment,omitempty"`
+	User        string            `json:"user,omitempty"`
+	Privileged  bool              `json:"privileged,omitempty"`
+	Workdir     string            `json:"workdir,omitempty"`
+}
+
+// ExecConfigBuilder builds the exec_config JSON string of a TargetImage in the shape of ExecConfig, so the
+// commands end up under the section keys Kasm looks for instead of hand-written nested maps.
+// Calls can be chained; the first validation error is reported by Build.
+type ExecConfigBuilder struct {
+	sections map[string]ExecAction
+	err      error
+}
+
+// NewExecConfig returns an empty ExecConfigBuilder.
+func NewExecConfig() *ExecConfigBuilder {
+	return &ExecConfigBuilder{sections: make(map[string]ExecAction)}
+}
+
+// FirstLaunch sets the command run once when a session is created.
+// Parameters:
+// - env: Environment variables of the command, may be nil.
+// - cmd: The shell command, e.g. "bash -c '/dockerstartup/setup.sh'".
+// Returns:
+// - The builder, for chaining.
+func (e *ExecConfigBuilder) FirstLaunch(env map[string]string, cmd string) *ExecConfigBuilder {
+	return e.Action(ExecSectionFirstLaunch, ExecAction{Cmd: cmd, Environment: env})
+}
+
+// Go sets the command run every time the workspace is launched or resumed.
+// Parameters:
+// - cmd: The shell command.
+// Returns:
+// - The builder, for chaining.
+func (e *ExecConfigBuilder) Go(cmd string) *ExecConfigBuilder {
+	return e.Action(ExecSectionGo, ExecAction{Cmd: cmd})
+}
+
+// Action sets the command of a section, for settings FirstLaunch and Go do not cover, e.g. running as root.
+// Parameters:
+// - section: ExecSectionFirstLaunch or ExecSectionGo; each section can only be set once.
+// - action: The command and its settings.
+// Returns:
+// - The builder, for chaining.
+func (e *ExecConfigBuilder) Action(section string, action ExecAction) *ExecConfigBuilder {
+	if e.err != nil {
+		return e
+	}
+
+	switch {
+	case section != ExecSectionFirstLaunch && section != ExecSectionGo:
+		e.err = fmt.Errorf("invalid exec_config section %q: must be %s or %s", section, ExecSectionFirstLaunch, ExecSectionGo)
+	case strings.TrimSpace(action.Cmd) == "":
+		e.err = fmt.Errorf("command of exec_config section %s cannot be empty", section)
+	default:
+		if _, exists := e.sections[section]; exists {
+			e.err = fmt.Errorf("exec_config section %s is set more than once", section)
+			return e
+		}
+		for key := range action.Environment {
+			if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+				e.err = fmt.Errorf("invalid environment variable name %q in exec_config section %s", key, section)
+				return e
+			}
+		}
+		e.sections[section] = action
+	}
+	return e
+}
+
+// Build returns the exec_config JSON string.
+// Returns:
+// - The serialized configuration, or an empty string if no section was set.
+// - The first validation error of the chained calls.
+func (e *ExecConfigBuilder) Build() (string, error) {
+	if e.err != nil {
+		return "", e.err
+	}
+	if len(e.sections) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(e.sections)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize exec config: %w", err)
+	}
+	return string(data), nil
+}
+
+// ApplyTo stores the built configuration in target.ExecConfig.
+func (e *ExecConfigBuilder) ApplyTo(target *TargetImage) error {
+	execConfig, err := e.Build()
+	if err != nil {
+		return err
+	}
+	target.ExecConfig = execConfig
+	return nil
+}
@@ -0,0 +1,134 @@
+package webApi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+//NOTE: get_groups is not part of the documented public API and mirrors the admin endpoint. It might require changes for new versions of Kasm.
+
+// Group represents a Kasm user group.
+type Group struct {
+	GroupID     string `json:"group_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Priority    int    `json:"priority"`
+	IsSystem    bool   `json:"is_system"`
+}
+
+// GetGroupsRequest represents the payload for fetching all groups.
+type GetGroupsRequest struct {
+	APIKey       string `json:"api_key"`
+	APIKeySecret string `json:"api_key_secret"`
+}
+
+// GetGroupsResponse represents the response containing a list of groups.
+type GetGroupsResponse struct {
+	Groups []Group `json:"groups"`
+}
+
+// GetGroups retrieves all user groups.
+// Note: Requires api key permission "Groups View"
+func (api *KasmAPI) GetGroups(ctx context.Context) ([]Group, error) {
+	endpoint := "/api/public/get_groups"
+	log.Info().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Msg("Fetching all groups")
+
+	requestPayload := GetGroupsRequest{}
+
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, requestPayload)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Msg("Failed to fetch groups")
+		return nil, fmt.Errorf("failed to fetch groups: %w", err)
+	}
+
+	var parsedResponse GetGroupsResponse
+	if err := json.Unmarshal(responseBytes, &parsedResponse); err != nil {
+		log.Error().
+			Err(err).
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Msg("Failed to decode get groups response")
+		return nil, fmt.Errorf("failed to decode get groups response: %v", err)
+	}
+
+	log.Info().
+		Int("group_count", len(parsedResponse.Groups)).
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Msg("Groups retrieved successfully")
+	return parsedResponse.Groups, nil
+}
+
+// GetGroupIDByName resolves the ID of the group whose name matches name case-insensitively.
+// Use a GroupCache instead when resolving many names, e.g. for every user of a deployment.
+// Returns an error if the groups cannot be fetched or no or several groups match.
+func (api *KasmAPI) GetGroupIDByName(ctx context.Context, name string) (string, error) {
+	groups, err := api.GetGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	return findGroupID(groups, name)
+}
+
+// findGroupID returns the ID of the only group named name, ignoring case.
+func findGroupID(groups []Group, name string) (string, error) {
+	var matches []string
+	for _, group := range groups {
+		if strings.EqualFold(strings.TrimSpace(group.Name), strings.TrimSpace(name)) {
+			matches = append(matches, group.GroupID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no group found with name %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("group name %q is ambiguous, matching groups: %s", name, strings.Join(matches, ", "))
+	}
+}
+
+// GroupCache resolves group names to IDs, fetching the group list only once.
+// It is safe for concurrent use and is meant to live for a single provisioning run.
+type GroupCache struct {
+	api    *KasmAPI
+	mutex  sync.Mutex
+	groups []Group
+}
+
+// NewGroupCache creates an empty GroupCache backed by the given API.
+func NewGroupCache(api *KasmAPI) *GroupCache {
+	return &GroupCache{api: api}
+}
+
+// GroupIDByName resolves a group name like KasmAPI.GetGroupIDByName, fetching the groups on first use.
+// A failed fetch is not cached, so the next call retries.
+func (c *GroupCache) GroupIDByName(ctx context.Context, name string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.groups == nil {
+		groups, err := c.api.GetGroups(ctx)
+		if err != nil {
+			return "", err
+		}
+		if groups == nil {
+			groups = []Group{}
+		}
+		c.groups = groups
+	}
+	return findGroupID(c.groups, name)
+}
@@ -9,13 +9,17 @@ import (
 	"math"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"kasmlink/pkg/logRedact"
 )
 
-// HandleResponse reads the response body and checks for errors or unexpected status codes
+// HandleResponse reads the response body and checks for errors or unexpected status codes.
+// An unexpected status code is reported as a *KasmAPIError.
 func HandleResponse(resp *http.Response, expectedStatusCode int) ([]byte, error) {
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -40,11 +44,10 @@ func HandleResponse(resp *http.Response, expectedStatusCode int) ([]byte, error)
 		log.Warn().
 			Str("url", resp.Request.URL.String()).
 			Int("status_code", resp.StatusCode).
-			Str("response_body", trimmedBody).
+			Str("response_body", logRedact.RedactString(trimmedBody)).
 			Msg("Unexpected response status")
 
-		return nil, fmt.Errorf("unexpected response status: %s, body: %s",
-			resp.Status, trimmedBody)
+		return nil, newKasmAPIError(resp, body)
 	}
 
 	if len(body) == 0 {
@@ -59,161 +62,275 @@ func HandleResponse(resp *http.Response, expectedStatusCode int) ([]byte, error)
 		Int("status_code", resp.StatusCode).
 		Msg("Request succeeded")
 
-	// If the response is JSON, you can log it as raw JSON:
+	// Responses carry session tokens and user credentials, so the body is redacted even if the logger is not.
 	log.Debug().
 		Str("url", resp.Request.URL.String()).
 		Int("status_code", resp.StatusCode).
-		RawJSON("response_body", body).
+		RawJSON("response_body", logRedact.Redact(body)).
 		Msg("Response details")
 
 	return body, nil
 }
 
-// MakeGetRequest handles making GET requests to the KASM API.
-// It now accepts a context for better request management.
-func (api *KasmAPI) MakeGetRequest(ctx context.Context, endpoint string, queryParams map[string]string) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", api.BaseURL, endpoint)
-	if len(queryParams) > 0 {
-		query := "?"
-		for key, value := range queryParams {
-			query += fmt.Sprintf("%s=%s&", key, value)
-		}
-		url += strings.TrimSuffix(query, "&")
+// MakeGetRequest handles making GET requests to the KASM API, e.g. for read-only endpoints that take their
+// arguments as query parameters. Authentication, retries and error handling match MakePostRequest; the
+// credentials are sent in the request headers only, so they never end up in the URL.
+// Parameters:
+// - ctx: Context for request cancellation.
+// - endpoint: The endpoint path, e.g. "/api/public/get_users".
+// - query: Query parameters appended to the URL; may be nil.
+// Returns:
+// - The response body if the request is successful.
+// - An error if the request fails after all attempts or the API rejects it.
+func (api *KasmAPI) MakeGetRequest(ctx context.Context, endpoint string, query url.Values) ([]byte, error) {
+	requestURL := api.EndpointURL(endpoint)
+	if encoded := query.Encode(); encoded != "" {
+		requestURL += "?" + encoded
 	}
 
-	log.Info().
-		Str("method", "GET").
-		Str("url", url).
-		Msg("Initiating GET request")
+	log.Debug().
+		Str("method", http.MethodGet).
+		Str("url", api.EndpointURL(endpoint)).
+		Strs("query_keys", queryKeys(query)).
+		Msg("Sending GET request")
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Str("method", "GET").
-			Str("url", url).
-			Msg("Failed to create GET request")
-		return nil, fmt.Errorf("failed to create GET request: %w", err)
-	}
+	return api.doRequest(ctx, http.MethodGet, endpoint, requestURL, nil)
+}
 
-	// Set Authorization header if required
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s:%s", api.APIKey, api.APIKeySecret))
+// queryKeys returns the sorted parameter names of query for logging without their values.
+func queryKeys(query url.Values) []string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
+// doRequest sends a request to requestURL and retries it with exponential backoff until it succeeds, the API
+// rejects it with a non-retryable error, the attempts are used up or ctx is done. A non-nil body is sent as JSON.
+// Returns:
+// - The response body of the successful attempt.
+// - An error describing the last failure.
+func (api *KasmAPI) doRequest(ctx context.Context, method, endpoint, requestURL string, body []byte) ([]byte, error) {
 	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
-		resp, err := api.Client.Do(req)
+	for attempt := 1; attempt <= api.attempts(); attempt++ {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
 		if err != nil {
-			backoff := time.Duration(attempt) * time.Second
-			log.Error().
-				Err(err).
-				Int("attempt", attempt).
-				Str("method", "GET").
-				Str("url", url).
-				Dur("backoff", backoff).
-				Msg("GET request failed, will retry")
-
-			lastErr = err
-			time.Sleep(backoff)
-			continue
+			log.Error().Err(err).Str("method", method).Str("url", requestURL).Msg("Failed to create request")
+			return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
 		}
+		api.authorize(req)
 
-		body, err := HandleResponse(resp, http.StatusOK)
-		if err != nil {
-			backoff := time.Duration(attempt) * time.Second
-			log.Error().
-				Err(err).
-				Int("attempt", attempt).
-				Str("method", "GET").
-				Str("url", url).
-				Dur("backoff", backoff).
-				Msg("GET request returned unexpected status, will retry")
-
-			lastErr = err
-			time.Sleep(backoff)
-			continue
+		attemptCtx, cancel, client := api.endpointRequest(ctx, endpoint)
+		resp, err := client.Do(req.WithContext(attemptCtx))
+		if err == nil {
+			var responseBody []byte
+			responseBody, err = HandleResponse(resp, http.StatusOK)
+			cancel()
+			if err == nil {
+				return responseBody, nil
+			}
+			if isFinal(err) {
+				return nil, fmt.Errorf("%s request to %s failed: %w", method, requestURL, err)
+			}
+		} else {
+			cancel()
+		}
+		lastErr = err
+		if attempt == api.attempts() {
+			break
 		}
 
-		log.Debug().
-			Str("method", "GET").
-			Str("url", url).
-			RawJSON("response_body", body).
-			Msg("Received successful response")
+		backoff := api.retryBackoff(attempt)
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Str("method", method).
+			Str("url", requestURL).
+			Dur("backoff", backoff).
+			Msg("Request failed, retrying")
+		if err := sleepContext(ctx, backoff); err != nil {
+			return nil, fmt.Errorf("request to %s aborted while retrying: %w (last error: %v)", requestURL, err, lastErr)
+		}
+	}
+
+	return nil, fmt.Errorf("%s request to %s failed after retries: %w", method, requestURL, lastErr)
+}
 
+// authorize adds the API credentials to the headers of req.
+func (api *KasmAPI) authorize(req *http.Request) {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s:%s", api.APIKey, api.APIKeySecret))
+}
+
+// requestBody marshals payload and sets its api_key and api_key_secret fields according to AuthMode, so API
+// methods never copy the credentials into their request structs. With AuthInHeader the fields are removed.
+// Payloads that do not marshal to a JSON object are returned unchanged.
+func (api *KasmAPI) requestBody(payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
 		return body, nil
 	}
 
-	return nil, fmt.Errorf("GET request to %s failed after retries: %w", url, lastErr)
+	if api.AuthMode == AuthInHeader {
+		delete(fields, "api_key")
+		delete(fields, "api_key_secret")
+	} else {
+		fields["api_key"], _ = json.Marshal(api.APIKey)
+		fields["api_key_secret"], _ = json.Marshal(api.APIKeySecret)
+	}
+	return json.Marshal(fields)
+}
+
+// attempts returns the number of attempts made per request.
+func (api *KasmAPI) attempts() int {
+	if api.Retries <= 0 {
+		return 3
+	}
+	return api.Retries
+}
+
+// retryBackoff returns the delay before the attempt following attempt: RetryBackoff doubled per attempt plus
+// up to RetryBackoff of jitter.
+func (api *KasmAPI) retryBackoff(attempt int) time.Duration {
+	base := api.RetryBackoff
+	if base <= 0 {
+		base = time.Second
+	}
+	return base*time.Duration(math.Pow(2, float64(attempt))) + time.Duration(rand.Int63n(int64(base)))
+}
+
+// endpointRequest prepares a single attempt against an endpoint. When EndpointTimeouts holds an override for
+// the endpoint, the attempt runs under a child context with that timeout; the client-wide timeout of an
+// *http.Client is lifted for the attempt so overrides longer than RequestTimeout take effect.
+// Returns:
+// - The context of the attempt.
+// - A cancel function that must be called once the response body has been read.
+// - The client to send the request with.
+func (api *KasmAPI) endpointRequest(ctx context.Context, endpoint string) (context.Context, context.CancelFunc, Doer) {
+	var timeout time.Duration
+	for path, override := range api.EndpointTimeouts {
+		if strings.Trim(path, "/") == strings.Trim(endpoint, "/") {
+			timeout = override
+			break
+		}
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, api.Client
+	}
+
+	client := api.Client
+	if httpClient, isHTTPClient := api.Client.(*http.Client); isHTTPClient && httpClient.Timeout != 0 && httpClient.Timeout < timeout {
+		withoutTimeout := *httpClient
+		withoutTimeout.Timeout = 0
+		client = &withoutTimeout
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	return attemptCtx, cancel, client
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+// Returns ctx.Err() if the wait was cut short.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // MakePostRequest handles making POST requests to the KASM API.
 // It accepts a context for request cancellation, an endpoint path, and a payload.
 // Returns the response body as bytes if the request is successful.
 func (api *KasmAPI) MakePostRequest(ctx context.Context, endpoint string, payload interface{}) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", api.BaseURL, endpoint)
+	requestURL := api.EndpointURL(endpoint)
 
-	// Marshal payload to JSON
-	body, err := json.Marshal(payload)
+	// Marshal payload to JSON with the credentials of AuthMode
+	body, err := api.requestBody(payload)
 	if err != nil {
-		log.Error().Err(err).Str("url", url).Msg("Failed to marshal payload for POST request")
+		log.Error().Err(err).Str("url", requestURL).Msg("Failed to marshal payload for POST request")
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	// Log payload as structured data
 	log.Debug().
 		Str("method", "POST").
-		Str("url", url).
+		Str("url", requestURL).
 		RawJSON("payload", body).
 		Msg("Sending POST request")
 
+	return api.doRequest(ctx, http.MethodPost, endpoint, requestURL, body)
+}
+
+// makeStreamingPostRequest sends a POST request like MakePostRequest but hands the body of a successful
+// response to handle instead of reading it into memory. Failed attempts are retried like MakePostRequest;
+// once handle has been called the request is not retried, as handle may already have acted on the body.
+func (api *KasmAPI) makeStreamingPostRequest(ctx context.Context, endpoint string, payload interface{}, handle func(io.Reader) error) error {
+	url := api.EndpointURL(endpoint)
+
+	body, err := api.requestBody(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
 	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
+	for attempt := 1; attempt <= api.attempts(); attempt++ {
 		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 		if err != nil {
-			log.Error().Err(err).Str("url", url).Msg("Failed to create POST request")
-			return nil, fmt.Errorf("failed to create POST request: %w", err)
+			return fmt.Errorf("failed to create POST request: %w", err)
 		}
-
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s:%s", api.APIKey, api.APIKeySecret))
+		api.authorize(req)
 
-		resp, err := api.Client.Do(req)
-		if err != nil {
-			backoff := time.Second*time.Duration(math.Pow(2, float64(attempt))) + time.Millisecond*time.Duration(rand.Intn(1000))
-			log.Error().
-				Err(err).
-				Int("attempt", attempt).
-				Str("method", "POST").
-				Str("url", url).
-				Dur("backoff", backoff).
-				Msg("POST request failed, retrying")
-			lastErr = err
-			time.Sleep(backoff)
-			continue
+		attemptCtx, cancel, client := api.endpointRequest(ctx, endpoint)
+		resp, err := client.Do(req.WithContext(attemptCtx))
+		if err == nil && resp.StatusCode == http.StatusOK {
+			err = handle(resp.Body)
+			if cerr := resp.Body.Close(); cerr != nil {
+				log.Error().Err(cerr).Str("url", url).Msg("Failed to close response body")
+			}
+			cancel()
+			return err
 		}
-
-		responseBody, err := HandleResponse(resp, http.StatusOK)
-		if err != nil {
-			backoff := time.Second*time.Duration(math.Pow(2, float64(attempt))) + time.Millisecond*time.Duration(rand.Intn(1000))
-			log.Warn().
-				Err(err).
-				Int("attempt", attempt).
-				Str("method", "POST").
-				Str("url", url).
-				Dur("backoff", backoff).
-				Msg("POST request returned unexpected status, retrying")
-			lastErr = err
-			time.Sleep(backoff)
-			continue
+		if err == nil {
+			_, err = HandleResponse(resp, http.StatusOK)
+		}
+		cancel()
+		if isFinal(err) {
+			return fmt.Errorf("POST request to %s failed: %w", url, err)
+		}
+		lastErr = err
+		if attempt == api.attempts() {
+			break
 		}
 
-		log.Debug().
+		backoff := api.retryBackoff(attempt)
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
 			Str("method", "POST").
 			Str("url", url).
-			RawJSON("response_body", responseBody).
-			Msg("Received successful response")
-
-		return responseBody, nil
+			Dur("backoff", backoff).
+			Msg("Streaming POST request failed, retrying")
+		if err := sleepContext(ctx, backoff); err != nil {
+			return fmt.Errorf("request to %s aborted while retrying: %w (last error: %v)", url, err, lastErr)
+		}
 	}
 
-	return nil, fmt.Errorf("POST request to %s failed after retries: %w", url, lastErr)
+	return fmt.Errorf("POST request to %s failed after retries: %w", url, lastErr)
 }
@@ -0,0 +1,189 @@
+package webApi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FieldChange describes a workspace image field that differs between the live image and the desired definition.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// String renders the change as "field: old -> new".
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, formatFieldValue(c.Old), formatFieldValue(c.New))
+}
+
+// diffIgnoredFields are not compared: identifiers are not part of the definition and the docker token is
+// not returned in clear text, so comparing it would always report a change.
+var diffIgnoredFields = map[string]bool{
+	"image_id":     true,
+	"docker_token": true,
+}
+
+// stringifiedJSONFields are sent as JSON strings in TargetImage but returned as objects in ImageDetail.
+var stringifiedJSONFields = map[string]bool{
+	"run_config":      true,
+	"exec_config":     true,
+	"volume_mappings": true,
+	"launch_config":   true,
+}
+
+// DiffTargetImage compares a live workspace image with a desired definition and returns the changed fields,
+// sorted by their JSON name. Only fields that are set in desired are compared, so omitted optional fields and
+// empty strings, lists and objects do not show up as changes; numbers and booleans are always compared.
+// The type differences between the request and response structures are normalized: pointers are compared
+// by value, nil equals the empty value, JSON strings (run_config, exec_config, volume_mappings,
+// launch_config) are compared as decoded objects and newline-separated categories are compared as lists.
+// Parameters:
+// - current: The image as returned by the Kasm API.
+// - desired: The desired image definition.
+// Returns:
+// - The changed fields with their old and new values; empty if the image is up to date.
+func DiffTargetImage(current ImageDetail, desired TargetImage) []FieldChange {
+	currentFields, err := toFieldMap(current)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to convert current image for diff")
+		return nil
+	}
+	desiredFields, err := toFieldMap(desired)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to convert desired image for diff")
+		return nil
+	}
+
+	fields := make([]string, 0, len(desiredFields))
+	for field := range desiredFields {
+		if !diffIgnoredFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []FieldChange
+	for _, field := range fields {
+		newValue := normalizeFieldValue(field, desiredFields[field])
+		if newValue == nil {
+			continue
+		}
+		oldValue := normalizeFieldValue(field, currentFields[field])
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// ReconcileImage updates a workspace image so it matches desired, doing nothing if it already does.
+// In dry-run mode the changes are only computed and returned.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - current: The live image; its ImageID identifies the image to update.
+// - desired: The desired image definition.
+// - dryRun: When true, no update is sent.
+// Returns:
+// - The changes that were (or would be) applied.
+// - An error if the update fails.
+func (api *KasmAPI) ReconcileImage(ctx context.Context, current ImageDetail, desired TargetImage, dryRun bool) ([]FieldChange, error) {
+	changes := DiffTargetImage(current, desired)
+	if len(changes) == 0 {
+		log.Info().Str("image_id", current.ImageID).Msg("Workspace image is up to date")
+		return nil, nil
+	}
+
+	for _, change := range changes {
+		log.Info().
+			Str("image_id", current.ImageID).
+			Str("field", change.Field).
+			Str("old", formatFieldValue(change.Old)).
+			Str("new", formatFieldValue(change.New)).
+			Bool("dry_run", dryRun).
+			Msg("Workspace image field changes")
+	}
+	if dryRun {
+		return changes, nil
+	}
+
+	desired.ImageID = current.ImageID
+	if _, err := api.UpdateImage(ctx, CreateImageRequest{TargetImage: desired}); err != nil {
+		return nil, fmt.Errorf("failed to reconcile image %s: %w", current.ImageID, err)
+	}
+	return changes, nil
+}
+
+// toFieldMap converts a struct into a map keyed by its JSON field names.
+func toFieldMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// normalizeFieldValue maps equivalent representations of a field to a single one.
+func normalizeFieldValue(field string, value interface{}) interface{} {
+	if text, ok := value.(string); ok {
+		switch {
+		case stringifiedJSONFields[field] && strings.TrimSpace(text) != "":
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(text), &decoded); err == nil {
+				value = decoded
+			}
+		case field == "categories":
+			var categories []interface{}
+			for _, category := range strings.Split(text, "\n") {
+				if category = strings.TrimSpace(category); category != "" {
+					categories = append(categories, category)
+				}
+			}
+			value = categories
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// formatFieldValue renders a normalized field value for display.
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<empty>"
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
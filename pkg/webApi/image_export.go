@@ -0,0 +1,66 @@
+package webApi
+
+import (
+	"encoding/json"
+)
+
+// TargetImageFromImage converts an image returned by ListImages into a definition that can be passed to
+// CreateImage on another deployment. The image ID is dropped and the docker token is left empty because
+// the API does not return it in clear text.
+// Parameters:
+// - image: The image as returned by the Kasm API.
+// Returns:
+// - The image definition.
+func TargetImageFromImage(image Image) TargetImage {
+	target := TargetImage{
+		Name:                  image.ImageTag,
+		FriendlyName:          image.FriendlyName,
+		Description:           image.Description,
+		Memory:                int(image.Memory),
+		Cores:                 image.Cores,
+		Enabled:               image.Enabled,
+		ImageType:             DefaultImageType,
+		CPUAllocationMethod:   image.CPUAllocationMethod,
+		DockerRegistry:        image.DockerRegistry,
+		PersistentProfilePath: image.PersistentProfilePath,
+		RestrictToNetwork:     image.RestrictToNetwork,
+		RestrictToServer:      image.RestrictToServer,
+		RestrictToZone:        image.RestrictToZone,
+	}
+	if target.CPUAllocationMethod == "" {
+		target.CPUAllocationMethod = DefaultCPUAllocationMethod
+	}
+	if image.DockerUser != nil {
+		target.DockerUser = *image.DockerUser
+	}
+	if image.ServerID != nil {
+		target.ServerID = *image.ServerID
+	}
+	if image.ZoneID != nil {
+		target.ZoneID = *image.ZoneID
+	}
+	if image.ImageSrc != "" {
+		imageSrc := image.ImageSrc
+		target.ImageSrc = &imageSrc
+	}
+
+	if image.RunConfig.Hostname != "" {
+		target.RunConfig = marshalJSONString(image.RunConfig)
+	}
+	if exec := image.ExecConfig; exec.FirstLaunch.Cmd != "" || len(exec.FirstLaunch.Environment) > 0 || exec.Go.Cmd != "" {
+		target.ExecConfig = marshalJSONString(exec)
+	}
+	if len(image.VolumeMappings) > 0 {
+		target.VolumeMappings = marshalJSONString(image.VolumeMappings)
+	}
+	return target
+}
+
+// marshalJSONString encodes value as a JSON string, returning an empty string if it cannot be encoded.
+func marshalJSONString(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,156 @@
+package webApi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SetImageEnabled enables or disables a workspace image, e.g. during maintenance. Only the enabled field is
+// changed: the image is read from Kasm and sent back with every other field exactly as it was returned.
+// Note: requires api key with "Images View" and "Images Modify" permissions
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageID: ID of the workspace image.
+// - enabled: Whether users can launch the image.
+// Returns:
+// - An error if the image does not exist or cannot be updated.
+func (api *KasmAPI) SetImageEnabled(ctx context.Context, imageID string, enabled bool) error {
+	return api.setImageFlag(ctx, imageID, "enabled", enabled)
+}
+
+// SetImageHidden hides a workspace image from or shows it in the workspace dashboard. Like SetImageEnabled,
+// every other field is sent back exactly as Kasm returned it.
+// Note: requires api key with "Images View" and "Images Modify" permissions
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageID: ID of the workspace image.
+// - hidden: Whether the image is hidden from the dashboard.
+// Returns:
+// - An error if the image does not exist or cannot be updated.
+func (api *KasmAPI) SetImageHidden(ctx context.Context, imageID string, hidden bool) error {
+	return api.setImageFlag(ctx, imageID, "hidden", hidden)
+}
+
+// setImageFlag sets a boolean field of an image, skipping the update if it already has the value.
+func (api *KasmAPI) setImageFlag(ctx context.Context, imageID, field string, value bool) error {
+	if imageID == "" {
+		return fmt.Errorf("image ID cannot be empty")
+	}
+
+	fields, err := api.rawImageFields(ctx, imageID)
+	if err != nil {
+		return err
+	}
+	var current bool
+	if raw, ok := fields[field]; ok && json.Unmarshal(raw, &current) == nil && current == value {
+		log.Info().
+			Str("image_id", imageID).
+			Str("field", field).
+			Bool("value", value).
+			Msg("Workspace image already has the requested state")
+		return nil
+	}
+
+	target, err := targetImageFields(fields)
+	if err != nil {
+		return fmt.Errorf("failed to convert image %s: %w", imageID, err)
+	}
+	target[field], _ = json.Marshal(value)
+
+	endpoint := "/api/public/update_image"
+	payload := map[string]interface{}{
+		"target_image": target,
+	}
+	if _, err := api.MakePostRequest(ctx, endpoint, payload); err != nil {
+		return fmt.Errorf("failed to set %s of image %s: %w", field, imageID, err)
+	}
+
+	log.Info().
+		Str("image_id", imageID).
+		Str("field", field).
+		Bool("value", value).
+		Msg("Workspace image updated")
+	return nil
+}
+
+// rawImageFields returns the fields of an image as returned by get_images, without decoding their values.
+// Besides the "images" list, a single "image" object is accepted, which some Kasm versions return instead.
+func (api *KasmAPI) rawImageFields(ctx context.Context, imageID string) (map[string]json.RawMessage, error) {
+	responseBytes, err := api.MakePostRequest(ctx, "/api/public/get_images", GetImagesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch images: %w", err)
+	}
+
+	var imagesResponse struct {
+		Images []map[string]json.RawMessage `json:"images"`
+		Image  map[string]json.RawMessage   `json:"image"`
+	}
+	if err := json.Unmarshal(responseBytes, &imagesResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode images response: %w", err)
+	}
+	images := imagesResponse.Images
+	if len(imagesResponse.Image) > 0 {
+		images = append(images, imagesResponse.Image)
+	}
+	for _, fields := range images {
+		var id string
+		if json.Unmarshal(fields["image_id"], &id) == nil && id == imageID {
+			return fields, nil
+		}
+	}
+	return nil, fmt.Errorf("image %s not found", imageID)
+}
+
+// targetImageFields keeps the fields of a get_images image that TargetImage accepts, converted to the
+// representation update_image expects: run_config, exec_config, volume_mappings and launch_config as JSON
+// strings and categories as newline separated string. The docker token is dropped because Kasm does not return
+// it in clear text.
+func targetImageFields(fields map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	target := make(map[string]json.RawMessage, len(fields))
+	for _, name := range targetImageFieldNames() {
+		raw, ok := fields[name]
+		if !ok || name == "docker_token" {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(string(raw))
+		switch {
+		case stringifiedJSONFields[name] && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")):
+			encoded, err := json.Marshal(trimmed)
+			if err != nil {
+				return nil, err
+			}
+			raw = encoded
+		case name == "categories" && strings.HasPrefix(trimmed, "["):
+			var categories []string
+			if err := json.Unmarshal(raw, &categories); err != nil {
+				return nil, fmt.Errorf("invalid categories: %w", err)
+			}
+			encoded, err := json.Marshal(strings.Join(categories, "\n"))
+			if err != nil {
+				return nil, err
+			}
+			raw = encoded
+		}
+		target[name] = raw
+	}
+	return target, nil
+}
+
+// targetImageFieldNames returns the JSON names of the TargetImage fields.
+func targetImageFieldNames() []string {
+	targetType := reflect.TypeOf(TargetImage{})
+	names := make([]string, 0, targetType.NumField())
+	for i := 0; i < targetType.NumField(); i++ {
+		name, _, _ := strings.Cut(targetType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,133 @@
+package webApi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrStopIteration can be returned by the callback of ForEachImage to stop early without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// ForEachImage calls fn for every image of the catalog, decoding the get_images response one image at a time
+// instead of holding the whole catalog in memory like ListImageDetails. Kasm does not page get_images, so the
+// catalog is still transferred in a single response; only one decoded image is kept at a time.
+// Note: requires api key with "Images View" permission
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - fn: Called with every image in the order of the response. Returning ErrStopIteration stops without an
+// error, any other error stops and is returned.
+// Returns:
+// - An error if the request or decoding fails, or the error returned by fn.
+func (api *KasmAPI) ForEachImage(ctx context.Context, fn func(ImageDetail) error) error {
+	endpoint := "/api/public/get_images"
+	log.Debug().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Msg("Initiating request to stream images")
+
+	requestPayload := GetImagesRequest{}
+
+	count := 0
+	err := api.makeStreamingPostRequest(ctx, endpoint, requestPayload, func(body io.Reader) error {
+		return decodeImageStream(body, func(image ImageDetail) error {
+			count++
+			return fn(image)
+		})
+	})
+	if errors.Is(err, ErrStopIteration) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stream images: %w", err)
+	}
+
+	log.Debug().
+		Int("image_count", count).
+		Msg("Successfully streamed images from KASM API")
+	return nil
+}
+
+// decodeImageStream decodes the images array of a get_images response element by element. Other top-level
+// fields are skipped, except for an error_message which is reported after the images.
+func decodeImageStream(body io.Reader, fn func(ImageDetail) error) error {
+	decoder := json.NewDecoder(body)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	var errorMessage string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode images response: %w", err)
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "images":
+			if err := decodeImageArray(decoder, fn); err != nil {
+				return err
+			}
+		case "error_message":
+			var message *string
+			if err := decoder.Decode(&message); err != nil {
+				return fmt.Errorf("failed to decode images response: %w", err)
+			}
+			if message != nil {
+				errorMessage = *message
+			}
+		default:
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return fmt.Errorf("failed to decode images response: %w", err)
+			}
+		}
+	}
+
+	if errorMessage != "" {
+		return fmt.Errorf("kasm returned an error: %s", errorMessage)
+	}
+	return nil
+}
+
+// decodeImageArray decodes the value of the images field, calling fn per element. A null value holds no images.
+func decodeImageArray(decoder *json.Decoder, fn func(ImageDetail) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode images response: %w", err)
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to decode images response: images is not an array")
+	}
+
+	for decoder.More() {
+		var image ImageDetail
+		if err := decoder.Decode(&image); err != nil {
+			return fmt.Errorf("failed to decode image: %w", err)
+		}
+		if err := fn(image); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim reads the next token and fails unless it is the given delimiter.
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode images response: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("failed to decode images response: expected %q", want)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"strings"
 )
 
 // ListImages fetches the available images from the KASM API.
@@ -16,10 +17,7 @@ func (api *KasmAPI) ListImages(ctx context.Context) ([]Image, error) {
 		Str("endpoint", endpoint).
 		Msg("Initiating request to fetch list of images")
 
-	requestPayload := GetImagesRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-	}
+	requestPayload := GetImagesRequest{}
 
 	responseBytes, err := api.MakePostRequest(ctx, endpoint, requestPayload)
 	if err != nil {
@@ -49,3 +47,123 @@ func (api *KasmAPI) ListImages(ctx context.Context) ([]Image, error) {
 
 	return imagesResponse.Images, nil
 }
+
+// ListImageDetails fetches the available images like ListImages but decodes every field of the response,
+// so the images can be compared with a TargetImage using DiffTargetImage.
+// Note: requires api key with "Images View" permission
+func (api *KasmAPI) ListImageDetails(ctx context.Context) ([]ImageDetail, error) {
+	endpoint := "/api/public/get_images"
+	log.Debug().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Msg("Initiating request to fetch image details")
+
+	requestPayload := GetImagesRequest{}
+
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch images: %w", err)
+	}
+
+	var imagesResponse struct {
+		Images []ImageDetail `json:"images"`
+	}
+	if err := json.Unmarshal(responseBytes, &imagesResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode images response: %w", err)
+	}
+
+	log.Debug().
+		Int("image_count", len(imagesResponse.Images)).
+		Msg("Successfully fetched image details from KASM API")
+	return imagesResponse.Images, nil
+}
+
+// GetImageForNetwork resolves the image made available on a custom Docker network through get_image,
+// e.g. to learn which image_id to launch after deploying the network of a release. See GetImageRequest for
+// the payload of this undocumented endpoint.
+// Note: requires api key with "Images View" permission
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - networkName: Name of the Docker network the image is restricted to.
+// Returns:
+// - The resolved image.
+// - An error if the request fails or Kasm returns no image for the network.
+func (api *KasmAPI) GetImageForNetwork(ctx context.Context, networkName string) (*ImageDetail, error) {
+	endpoint := "/api/public/get_image"
+	if networkName == "" {
+		return nil, fmt.Errorf("network name cannot be empty")
+	}
+	log.Debug().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Str("network", networkName).
+		Msg("Resolving image for network")
+
+	requestPayload := GetImageRequest{
+		TargetImage: GetImageTarget{NetworkName: networkName},
+	}
+
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, requestPayload)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("endpoint", endpoint).
+			Str("network", networkName).
+			Msg("Failed to resolve image for network")
+		return nil, fmt.Errorf("failed to get image for network %s: %w", networkName, err)
+	}
+
+	var imageResponse struct {
+		Image        ImageDetail `json:"image"`
+		ErrorMessage string      `json:"error_message"`
+	}
+	if err := json.Unmarshal(responseBytes, &imageResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode image response: %w", err)
+	}
+	if imageResponse.ErrorMessage != "" {
+		return nil, fmt.Errorf("failed to get image for network %s: %s", networkName, imageResponse.ErrorMessage)
+	}
+	if imageResponse.Image.ImageID == "" {
+		return nil, fmt.Errorf("no image available on network %s", networkName)
+	}
+
+	log.Info().
+		Str("network", networkName).
+		Str("image_id", imageResponse.Image.ImageID).
+		Str("image", imageResponse.Image.Name).
+		Msg("Resolved image for network")
+	return &imageResponse.Image, nil
+}
+
+// GetImageIDByFriendlyName resolves the ID of the image whose friendly name matches name case-insensitively.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - name: Friendly name of the image as shown in the Kasm UI.
+// Returns:
+// - The ID of the matching image.
+// - An error if the images cannot be listed or no or several images match.
+func (api *KasmAPI) GetImageIDByFriendlyName(ctx context.Context, name string) (string, error) {
+	images, err := api.ListImages(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, img := range images {
+		if strings.EqualFold(strings.TrimSpace(img.FriendlyName), strings.TrimSpace(name)) {
+			matches = append(matches, img.ImageID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		log.Warn().Str("friendly_name", name).Msg("No image found by friendly name")
+		return "", fmt.Errorf("no image found with friendly name %q", name)
+	case 1:
+		log.Debug().Str("friendly_name", name).Str("image_id", matches[0]).Msg("Image found by friendly name")
+		return matches[0], nil
+	default:
+		log.Warn().Str("friendly_name", name).Strs("image_ids", matches).Msg("Multiple images found by friendly name")
+		return "", fmt.Errorf("friendly name %q is ambiguous, matching images: %s", name, strings.Join(matches, ", "))
+	}
+}
@@ -1,32 +1,171 @@
 package webApi
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/rs/zerolog/log"
+	"io"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
+// Doer sends HTTP requests. *http.Client implements it; tests can substitute a stub to inspect requests
+// without a Kasm server.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 // KasmAPI holds the base URL, credentials, and HTTP client for making requests to the KASM API.
 type KasmAPI struct {
 	BaseURL             string
+	BasePath            string // Optional prefix for every endpoint, e.g. "/kasm" when the API is served under a sub-path.
 	APIKey              string
 	APIKeySecret        string
 	SkipTLSVerification bool
 	RequestTimeout      time.Duration
-	Client              *http.Client
+	Retries             int           // Attempts per request; defaults to 3 when zero.
+	RetryBackoff        time.Duration // Base delay between attempts, doubled per attempt; defaults to one second.
+	Client              Doer          // Defaults to an *http.Client configured by the constructors.
+	// EndpointTimeouts overrides RequestTimeout for single endpoints, keyed by endpoint path such as
+	// "/api/public/request_kasm". Endpoints without an entry use RequestTimeout.
+	EndpointTimeouts map[string]time.Duration
+	// NormalizeUsernames lowercases usernames in CreateUser and the user lookups, so "User@x.com" and
+	// "user@x.com" address the same account. Leave it off for realms with case-sensitive usernames.
+	NormalizeUsernames bool
+	// AuthMode selects how the credentials are sent; defaults to AuthInBody.
+	AuthMode AuthMode
+	// SessionEnvLimits bounds the environment of RequestKasmSession; the zero value selects the defaults
+	// of ValidateSessionEnvironment.
+	SessionEnvLimits SessionEnvLimits
+}
+
+// AuthMode selects where requests carry the API key and secret.
+type AuthMode string
+
+const (
+	// AuthInBody adds api_key and api_key_secret to the JSON body of every request, which every Kasm version
+	// accepts. The Authorization header is sent as well.
+	AuthInBody AuthMode = "body"
+	// AuthInHeader sends the credentials in the Authorization header only, so they never appear in a logged
+	// payload. Requires a Kasm version that accepts header authentication.
+	AuthInHeader AuthMode = "header"
+)
+
+// ParseAuthMode parses "body" or "header"; an empty string selects AuthInBody.
+func ParseAuthMode(value string) (AuthMode, error) {
+	switch mode := AuthMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", AuthInBody:
+		return AuthInBody, nil
+	case AuthInHeader:
+		return AuthInHeader, nil
+	default:
+		return "", fmt.Errorf("unknown auth mode %q, expected %q or %q", value, AuthInBody, AuthInHeader)
+	}
+}
+
+// NormalizeUsername returns the username as it is sent to Kasm: trimmed and, with NormalizeUsernames,
+// lowercased.
+func (api *KasmAPI) NormalizeUsername(username string) string {
+	username = strings.TrimSpace(username)
+	if api.NormalizeUsernames {
+		return strings.ToLower(username)
+	}
+	return username
+}
+
+// EndpointURL joins the base URL, the base path and an endpoint with exactly one slash between each part.
+func (api *KasmAPI) EndpointURL(endpoint string) string {
+	url := strings.TrimRight(api.BaseURL, "/")
+	for _, part := range []string{api.BasePath, endpoint} {
+		if part = strings.Trim(part, "/"); part != "" {
+			url += "/" + part
+		}
+	}
+	return url
+}
+
+// TLSOptions configures the TLS connection to the Kasm manager.
+type TLSOptions struct {
+	SkipVerification bool   // Skip server certificate verification.
+	CAFile           string // PEM bundle of CAs trusted in addition to the system roots.
+	ClientCertFile   string // PEM client certificate for mTLS; requires ClientKeyFile.
+	ClientKeyFile    string // PEM private key of the client certificate.
+}
+
+// buildTLSConfig loads the CA bundle and client key pair referenced by the options.
+func (o TLSOptions) buildTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: o.SkipVerification, // Configures TLS verification
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", o.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no valid PEM certificates", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (o.ClientCertFile == "") != (o.ClientKeyFile == "") {
+		return nil, fmt.Errorf("client certificate and client key must be provided together")
+	}
+	if o.ClientCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s with key %s: %w", o.ClientCertFile, o.ClientKeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
 }
 
 // NewKasmAPI creates a new instance of KasmAPI with provided credentials.
 // It initializes the HTTP client with appropriate configurations.
 func NewKasmAPI(baseURL, apiKey, apiKeySecret string, skipTLSVerification bool, requestTimeout time.Duration) *KasmAPI {
-	if requestTimeout == 0 {
-		requestTimeout = 240 * time.Second
-	}
-
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: skipTLSVerification, // Configures TLS verification
 	}
+	return newKasmAPI(baseURL, apiKey, apiKeySecret, tlsConfig, requestTimeout)
+}
+
+// NewKasmAPIWithTLS creates a new instance of KasmAPI using a custom CA bundle and/or a client certificate,
+// e.g. for managers fronted by mutual TLS.
+// Parameters:
+// - baseURL, apiKey, apiKeySecret: Address and credentials of the Kasm API.
+// - tlsOptions: CA bundle, client certificate/key pair and verification settings.
+// - requestTimeout: Timeout of a single request; defaults to 240 seconds when zero.
+// Returns:
+// - The configured KasmAPI.
+// - An error if the CA bundle or the client key pair cannot be loaded.
+func NewKasmAPIWithTLS(baseURL, apiKey, apiKeySecret string, tlsOptions TLSOptions, requestTimeout time.Duration) (*KasmAPI, error) {
+	tlsConfig, err := tlsOptions.buildTLSConfig()
+	if err != nil {
+		log.Error().Err(err).Str("base_url", baseURL).Msg("Failed to configure TLS for KasmAPI")
+		return nil, err
+	}
+	return newKasmAPI(baseURL, apiKey, apiKeySecret, tlsConfig, requestTimeout), nil
+}
+
+// newKasmAPI creates the KasmAPI and its HTTP client from a prepared TLS configuration.
+func newKasmAPI(baseURL, apiKey, apiKeySecret string, tlsConfig *tls.Config, requestTimeout time.Duration) *KasmAPI {
+	if requestTimeout == 0 {
+		requestTimeout = 240 * time.Second
+	}
 
 	transport := &http.Transport{
 		TLSClientConfig:     tlsConfig,
@@ -44,17 +183,75 @@ func NewKasmAPI(baseURL, apiKey, apiKeySecret string, skipTLSVerification bool,
 
 	log.Info().
 		Str("base_url", baseURL).
-		Bool("skip_tls_verification", skipTLSVerification).
+		Bool("skip_tls_verification", tlsConfig.InsecureSkipVerify).
+		Bool("custom_ca", tlsConfig.RootCAs != nil).
+		Bool("client_certificate", len(tlsConfig.Certificates) > 0).
 		Dur("request_timeout", requestTimeout).
-		Interface("tls_config", tlsConfig).
 		Msg("Creating new KasmAPI instance with configured HTTP client")
 
 	return &KasmAPI{
 		BaseURL:             baseURL,
 		APIKey:              apiKey,
 		APIKeySecret:        apiKeySecret,
-		SkipTLSVerification: skipTLSVerification,
+		SkipTLSVerification: tlsConfig.InsecureSkipVerify,
 		RequestTimeout:      requestTimeout,
 		Client:              client,
 	}
 }
+
+// ErrUnauthorized is returned by Ping when the Kasm API rejects the API key or secret.
+var ErrUnauthorized = errors.New("kasm API rejected the credentials")
+
+// Ping verifies that the Kasm API is reachable and accepts the configured credentials.
+// It makes a single authenticated get_users call without retries, so misconfigurations surface immediately.
+// Note: Requires api key permission "Users View"
+// Returns:
+// - nil if the credentials are accepted.
+// - An error wrapping ErrUnauthorized on 401/403 responses, or describing the network or server failure.
+func (api *KasmAPI) Ping(ctx context.Context) error {
+	url := api.EndpointURL("/api/public/get_users")
+
+	body, err := api.requestBody(GetUsersRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ping payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	api.authorize(req)
+
+	resp, err := api.Client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("url", url).Msg("Kasm API is not reachable")
+		return fmt.Errorf("cannot reach Kasm API at %s: %w", api.EndpointURL(""), err)
+	}
+	defer resp.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		log.Error().Int("status_code", resp.StatusCode).Str("url", url).Msg("Kasm API rejected the credentials")
+		return fmt.Errorf("%w (%s): check the API key, secret and its permissions", ErrUnauthorized, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		log.Error().Int("status_code", resp.StatusCode).Str("url", url).Msg("Kasm API returned an unexpected status")
+		return fmt.Errorf("kasm API at %s returned %s: %s", api.EndpointURL(""), resp.Status, strings.TrimSpace(string(responseBody)))
+	}
+
+	// Kasm reports some authorization failures as 200 responses carrying an error message.
+	var errorResponse struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := json.Unmarshal(responseBody, &errorResponse); err != nil {
+		return fmt.Errorf("kasm API at %s returned an invalid response, is the base URL correct? %w", api.EndpointURL(""), err)
+	}
+	if errorResponse.ErrorMessage != "" {
+		log.Error().Str("error_message", errorResponse.ErrorMessage).Str("url", url).Msg("Kasm API rejected the credentials")
+		return fmt.Errorf("%w: %s", ErrUnauthorized, errorResponse.ErrorMessage)
+	}
+
+	log.Info().Str("base_url", api.EndpointURL("")).Msg("Kasm API credentials verified")
+	return nil
+}
@@ -3,11 +3,38 @@ package webApi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"time"
 )
 
-// RequestKasmSession requests a new Kasm session.
+// kasmTimeLayouts are the timestamp formats used by the Kasm API, in UTC.
+var kasmTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02T15:04:05.999999",
+	time.RFC3339Nano,
+}
+
+// ParseKasmTime parses a timestamp as returned by the Kasm API, e.g. "2024-05-01 13:37:00.123456".
+func ParseKasmTime(value string) (time.Time, error) {
+	for _, layout := range kasmTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized Kasm timestamp %q", value)
+}
+
+// IsExpired reports whether the session's expiration date lies before now.
+// Sessions without a parseable expiration date are not considered expired.
+func (k KasmInfo) IsExpired(now time.Time) bool {
+	expiration, err := ParseKasmTime(k.ExpirationDate)
+	return err == nil && expiration.Before(now)
+}
+
+// RequestKasmSession requests a new Kasm session. envArgs is checked with ValidateSessionEnvironment against
+// api.SessionEnvLimits before anything is sent.
 // Note: requires api key with "Users Auth Session" and "User" permissions
 func (api *KasmAPI) RequestKasmSession(ctx context.Context, userID string, imageID string, envArgs map[string]string) (*RequestKasmResponse, error) {
 	endpoint := "/api/public/request_kasm"
@@ -18,10 +45,17 @@ func (api *KasmAPI) RequestKasmSession(ctx context.Context, userID string, image
 		Str("image_id", imageID).
 		Msg("Requesting Kasm session")
 
+	if err := ValidateSessionEnvironment(envArgs, api.SessionEnvLimits); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", userID).
+			Str("image_id", imageID).
+			Msg("Rejected Kasm session environment")
+		return nil, err
+	}
+
 	// Create a new RequestKasmRequest struct
 	req := RequestKasmRequest{
-		APIKey:        api.APIKey,
-		APIKeySecret:  api.APIKeySecret,
 		UserID:        userID,
 		ImageID:       imageID,
 		EnableSharing: false, //TODO: Think about if this should be configurable, securtiy wise not a good idea
@@ -64,6 +98,102 @@ func (api *KasmAPI) RequestKasmSession(ctx context.Context, userID string, image
 	return &kasmResponse, nil
 }
 
+// GetKasms retrieves all Kasm sessions known to the deployment.
+// Note: requires api key with "Sessions View" permission
+func (api *KasmAPI) GetKasms(ctx context.Context) ([]KasmInfo, error) {
+	endpoint := "/api/public/get_kasms"
+	log.Info().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Msg("Fetching Kasm sessions")
+
+	req := GetKasmsRequest{}
+
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, req)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Msg("Error fetching Kasm sessions")
+		return nil, fmt.Errorf("error fetching Kasm sessions: %w", err)
+	}
+
+	var kasmsResponse GetKasmsResponse
+	if err := json.Unmarshal(responseBytes, &kasmsResponse); err != nil {
+		log.Error().
+			Err(err).
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Msg("Failed to decode Kasm sessions response")
+		return nil, fmt.Errorf("failed to decode Kasm sessions response: %v", err)
+	}
+
+	log.Info().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Int("session_count", len(kasmsResponse.Kasms)).
+		Msg("Successfully fetched Kasm sessions")
+
+	return kasmsResponse.Kasms, nil
+}
+
+// FindRunningSession returns the first running session of a user for an image, or nil if there is none.
+// Sessions that are starting, stopping, paused or failed are not considered running.
+func (api *KasmAPI) FindRunningSession(ctx context.Context, userID, imageID string) (*KasmInfo, error) {
+	kasms, err := api.GetKasms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range kasms {
+		kasm := &kasms[i]
+		if kasm.UserID == userID && kasm.ImageID == imageID && kasm.OperationalStatus == "running" {
+			return kasm, nil
+		}
+	}
+	return nil, nil
+}
+
+// RequestOrReuseSession returns an existing running session of the user for the image, or requests a new one.
+// Reusing sessions avoids orphaning duplicates and running into per-user session limits.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - userID: ID of the user owning the session.
+// - imageID: ID of the Kasm image.
+// - envArgs: Environment variables passed to a newly requested session; ignored when a session is reused.
+// Returns:
+// - The session; KasmURL and SessionToken are only set for newly requested sessions.
+// - True if an existing session was reused.
+// - An error if the sessions cannot be listed or a new session cannot be requested.
+func (api *KasmAPI) RequestOrReuseSession(ctx context.Context, userID string, imageID string, envArgs map[string]string) (*RequestKasmResponse, bool, error) {
+	existing, err := api.FindRunningSession(ctx, userID, imageID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up existing sessions: %w", err)
+	}
+
+	if existing != nil {
+		log.Info().
+			Str("user_id", userID).
+			Str("image_id", imageID).
+			Str("session_id", existing.KasmID).
+			Msg("Reusing running Kasm session")
+
+		return &RequestKasmResponse{
+			KasmID:  existing.KasmID,
+			Status:  existing.OperationalStatus,
+			ShareID: existing.ShareID,
+			UserID:  existing.UserID,
+		}, true, nil
+	}
+
+	response, err := api.RequestKasmSession(ctx, userID, imageID, envArgs)
+	if err != nil {
+		return nil, false, err
+	}
+	return response, false, nil
+}
+
 // GetKasmStatus retrieves the status of an existing Kasm session.
 func (api *KasmAPI) GetKasmStatus(ctx context.Context, userId, kasmId string, skipAgentCheck bool) (*GetKasmStatusResponse, error) {
 	endpoint := "/api/public/get_kasm_status"
@@ -74,8 +204,6 @@ func (api *KasmAPI) GetKasmStatus(ctx context.Context, userId, kasmId string, sk
 		Msg("Getting status for Kasm session")
 
 	req := GetKasmStatusRequest{
-		APIKey:         api.APIKey,
-		APIKeySecret:   api.APIKeySecret,
 		UserID:         userId,
 		KasmID:         kasmId,
 		SkipAgentCheck: skipAgentCheck,
@@ -127,10 +255,8 @@ func (api *KasmAPI) DestroyKasmSession(ctx context.Context, kasmId, userId strin
 		Msg("Destroying Kasm session")
 
 	req := DestroyKasmRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-		KasmID:       kasmId,
-		UserID:       userId,
+		KasmID: kasmId,
+		UserID: userId,
 	}
 
 	var destroyResponse DestroyKasmResponse
@@ -165,7 +291,7 @@ func (api *KasmAPI) DestroyKasmSession(ctx context.Context, kasmId, userId strin
 			Str("user_id", req.UserID).
 			Str("error_message", destroyResponse.ErrorMessage).
 			Msg("Error destroying Kasm session")
-		return fmt.Errorf("error destroying Kasm session: %w", err)
+		return fmt.Errorf("error destroying Kasm session: %s", destroyResponse.ErrorMessage)
 	}
 
 	log.Info().
@@ -177,7 +303,111 @@ func (api *KasmAPI) DestroyKasmSession(ctx context.Context, kasmId, userId strin
 	return nil
 }
 
+// KeepaliveSession resets the idle timer of a Kasm session so it is not reaped while automation uses it.
+// Note: Requires api permission "Users Auth Session"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - userID: ID of the user owning the session.
+// - kasmID: ID of the session to keep alive.
+// Returns:
+// - An error if the request fails or Kasm rejects it, e.g. because the session no longer exists.
+func (api *KasmAPI) KeepaliveSession(ctx context.Context, userID, kasmID string) error {
+	endpoint := "/api/public/keepalive"
+	log.Debug().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Str("kasm_id", kasmID).
+		Str("user_id", userID).
+		Msg("Sending Kasm session keepalive")
+
+	req := KeepaliveRequest{
+		KasmID: kasmID,
+		UserID: userID,
+	}
+
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, req)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Str("kasm_id", kasmID).
+			Msg("Failed to send Kasm session keepalive")
+		return fmt.Errorf("failed to keep Kasm session %s alive: %w", kasmID, err)
+	}
+
+	var keepaliveResponse KeepaliveResponse
+	if err := json.Unmarshal(responseBytes, &keepaliveResponse); err != nil {
+		return fmt.Errorf("failed to decode keepalive response: %w", err)
+	}
+	if keepaliveResponse.ErrorMessage != "" {
+		log.Error().
+			Str("method", "POST").
+			Str("endpoint", endpoint).
+			Str("kasm_id", kasmID).
+			Str("error_message", keepaliveResponse.ErrorMessage).
+			Msg("Kasm rejected session keepalive")
+		return fmt.Errorf("failed to keep Kasm session %s alive: %s", kasmID, keepaliveResponse.ErrorMessage)
+	}
+
+	log.Debug().
+		Str("kasm_id", kasmID).
+		Msg("Kasm session keepalive sent")
+	return nil
+}
+
+// KeepAliveLoop calls KeepaliveSession immediately and then every interval until ctx is cancelled.
+// Failed keepalives are logged and retried on the next tick, so a temporary API outage does not end the loop.
+// Parameters:
+// - ctx: Context whose cancellation stops the loop.
+// - userID: ID of the user owning the session.
+// - kasmID: ID of the session to keep alive.
+// - interval: Time between keepalives; it should be well below the idle timeout of the session.
+func (api *KasmAPI) KeepAliveLoop(ctx context.Context, userID, kasmID string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info().
+		Str("kasm_id", kasmID).
+		Dur("interval", interval).
+		Msg("Keeping Kasm session alive")
+	for {
+		if err := api.KeepaliveSession(ctx, userID, kasmID); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Str("kasm_id", kasmID).Msg("Kasm session keepalive failed, retrying on next interval")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Str("kasm_id", kasmID).Msg("Stopped keeping Kasm session alive")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ErrSessionNotReady is wrapped by ExecCommand errors when the session or its agent cannot accept commands yet.
+var ErrSessionNotReady = errors.New("kasm session not ready for commands")
+
+// DefaultExecReadyTimeout bounds how long ExecCommand waits for a session to accept commands when ctx has no
+// deadline.
+const DefaultExecReadyTimeout = 2 * time.Minute
+
+// Backoff between the attempts of ExecCommand while the session is not ready.
+const (
+	execReadyInitialBackoff = 250 * time.Millisecond
+	execReadyMaxBackoff     = 5 * time.Second
+)
+
 // ExecCommand executes a command in an existing Kasm session.
+// A session can be reported as running before its agent accepts commands, so commands sent right after
+// RequestKasmSession may be rejected as not ready. These rejections are retried with a growing backoff until
+// ctx is done, or DefaultExecReadyTimeout passed if ctx has no deadline; every other error is returned at once.
+// Note: requires api key with "Users Auth Session" permission
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - req: The session and the command to execute.
+// Returns:
+// - An error if the command is rejected; it wraps ErrSessionNotReady if the session never became ready.
 func (api *KasmAPI) ExecCommand(ctx context.Context, req ExecCommandRequest) error {
 	endpoint := "/api/public/exec_command_kasm"
 	log.Info().
@@ -187,17 +417,44 @@ func (api *KasmAPI) ExecCommand(ctx context.Context, req ExecCommandRequest) err
 		Str("command", req.ExecConfig.Cmd).
 		Msg("Executing command in Kasm session")
 
-	// Make POST request using the enhanced MakePostRequest method
-	_, err := api.MakePostRequest(ctx, endpoint, req)
-	if err != nil {
-		log.Error().
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultExecReadyTimeout)
+		defer cancel()
+	}
+
+	backoff := execReadyInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := api.execCommandOnce(ctx, endpoint, req)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrSessionNotReady) {
+			log.Error().
+				Err(err).
+				Str("method", "POST").
+				Str("endpoint", endpoint).
+				Str("kasm_id", req.KasmID).
+				Str("command", req.ExecConfig.Cmd).
+				Msg("Error executing command in Kasm session")
+			return fmt.Errorf("error executing command in Kasm session: %w", err)
+		}
+
+		log.Warn().
 			Err(err).
-			Str("method", "POST").
-			Str("endpoint", endpoint).
+			Int("attempt", attempt).
 			Str("kasm_id", req.KasmID).
-			Str("command", req.ExecConfig.Cmd).
-			Msg("Error executing command in Kasm session")
-		return fmt.Errorf("error executing command in Kasm session: %w", err)
+			Dur("backoff", backoff).
+			Msg("Kasm session not ready for commands, retrying")
+		if serr := sleepContext(ctx, backoff); serr != nil {
+			log.Error().
+				Err(err).
+				Str("kasm_id", req.KasmID).
+				Int("attempts", attempt).
+				Msg("Kasm session did not become ready for commands")
+			return fmt.Errorf("gave up waiting for Kasm session %s to accept commands: %w (%w)", req.KasmID, serr, err)
+		}
+		backoff = min(backoff*2, execReadyMaxBackoff)
 	}
 
 	log.Info().
@@ -208,3 +465,29 @@ func (api *KasmAPI) ExecCommand(ctx context.Context, req ExecCommandRequest) err
 		Msg("Successfully executed command in Kasm session")
 	return nil
 }
+
+// execCommandOnce sends a single exec_command_kasm request. Rejections because the session is not ready yet,
+// whether reported as error status or as error_message of a successful response, wrap ErrSessionNotReady.
+func (api *KasmAPI) execCommandOnce(ctx context.Context, endpoint string, req ExecCommandRequest) error {
+	responseBytes, err := api.MakePostRequest(ctx, endpoint, req)
+	if err != nil {
+		var apiErr *KasmAPIError
+		if errors.As(err, &apiErr) && apiErr.SessionNotReady() {
+			return fmt.Errorf("%w: %w", ErrSessionNotReady, err)
+		}
+		return err
+	}
+
+	var execResponse ExecCommandResponse
+	if err := json.Unmarshal(responseBytes, &execResponse); err != nil {
+		return fmt.Errorf("failed to decode exec command response: %w", err)
+	}
+	switch {
+	case execResponse.ErrorMessage == "":
+		return nil
+	case isSessionNotReadyMessage(execResponse.ErrorMessage):
+		return fmt.Errorf("%w: %s", ErrSessionNotReady, execResponse.ErrorMessage)
+	default:
+		return fmt.Errorf("kasm rejected the command: %s", execResponse.ErrorMessage)
+	}
+}
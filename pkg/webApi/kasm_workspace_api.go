@@ -281,10 +281,6 @@ type DeleteImageRequest struct {
 func (api *KasmAPI) CreateImage(ctx context.Context, req CreateImageRequest) (*Response, error) {
 	endpoint := "/api/public/create_image"
 
-	// Populate API credentials
-	req.APIKey = api.APIKey
-	req.APIKeySecret = api.APIKeySecret
-
 	respBody, err := api.MakePostRequest(ctx, endpoint, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create image at %s: %w", endpoint, err)
@@ -313,10 +309,6 @@ func (api *KasmAPI) CreateImage(ctx context.Context, req CreateImageRequest) (*R
 func (api *KasmAPI) UpdateImage(ctx context.Context, req CreateImageRequest) (*Response, error) {
 	endpoint := "/api/public/update_image"
 
-	// Populate API credentials
-	req.APIKey = api.APIKey
-	req.APIKeySecret = api.APIKeySecret
-
 	if req.TargetImage.ImageID == "" {
 		return nil, fmt.Errorf("image_id must be set in TargetImage before calling UpdateImage")
 	}
@@ -354,10 +346,7 @@ func (api *KasmAPI) DeleteImage(ctx context.Context, imageID string) error {
 		return fmt.Errorf("image_id must be provided")
 	}
 
-	reqPayload := DeleteImageRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-	}
+	reqPayload := DeleteImageRequest{}
 	reqPayload.TargetImage.ImageID = imageID
 
 	_, err := api.MakePostRequest(ctx, endpoint, reqPayload)
@@ -0,0 +1,122 @@
+package webApi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Input types of a launch form field.
+const (
+	LaunchInputText   = "text"
+	LaunchInputSelect = "select"
+)
+
+// LaunchFormField is a value the user is asked for when launching a workspace. The values are passed to the
+// session, e.g. so the go command of the exec_config can open a URL entered in the form.
+type LaunchFormField struct {
+	Key                       string   `json:"key"`
+	Label                     string   `json:"label"`
+	Value                     string   `json:"value"`
+	Placeholder               string   `json:"placeholder"`
+	InputType                 string   `json:"input_type"`
+	AllowSaving               bool     `json:"allow_saving"`
+	Required                  bool     `json:"required"`
+	Help                      string   `json:"help"`
+	Options                   []string `json:"options"`
+	ValidatorRegex            string   `json:"validator_regex"`
+	ValidatorRegexDescription string   `json:"validator_regex_description"`
+}
+
+// launchConfig is the launch_config object of a TargetImage.
+type launchConfig struct {
+	LaunchForm []LaunchFormField `json:"launch_form"`
+}
+
+// LaunchConfigBuilder builds the launch_config of a TargetImage.
+// Field calls can be chained; the first validation error is reported by Build.
+type LaunchConfigBuilder struct {
+	fields []LaunchFormField
+	keys   map[string]bool
+	err    error
+}
+
+// NewLaunchConfig returns a LaunchConfigBuilder holding the given launch form fields.
+func NewLaunchConfig(fields ...LaunchFormField) *LaunchConfigBuilder {
+	builder := &LaunchConfigBuilder{keys: make(map[string]bool)}
+	for _, field := range fields {
+		builder.Field(field)
+	}
+	return builder
+}
+
+// Field appends a launch form field.
+// Parameters:
+// - field: The field; Key and Label are required, keys must be unique and InputType defaults to LaunchInputText.
+// Select fields need Options and ValidatorRegex must be a valid regular expression.
+// Returns:
+// - The builder, for chaining.
+func (l *LaunchConfigBuilder) Field(field LaunchFormField) *LaunchConfigBuilder {
+	if l.err != nil {
+		return l
+	}
+	if field.InputType == "" {
+		field.InputType = LaunchInputText
+	}
+	if field.Options == nil {
+		field.Options = []string{}
+	}
+
+	switch {
+	case strings.TrimSpace(field.Key) == "":
+		l.err = fmt.Errorf("launch form field %q has no key", field.Label)
+	case l.keys[field.Key]:
+		l.err = fmt.Errorf("launch form field %s is defined more than once", field.Key)
+	case strings.TrimSpace(field.Label) == "":
+		l.err = fmt.Errorf("launch form field %s has no label", field.Key)
+	case field.InputType != LaunchInputText && field.InputType != LaunchInputSelect:
+		l.err = fmt.Errorf("invalid input type %q of launch form field %s: must be %s or %s", field.InputType, field.Key, LaunchInputText, LaunchInputSelect)
+	case field.InputType == LaunchInputSelect && len(field.Options) == 0:
+		l.err = fmt.Errorf("launch form field %s is a select without options", field.Key)
+	default:
+		if field.ValidatorRegex != "" {
+			if _, err := regexp.Compile(field.ValidatorRegex); err != nil {
+				l.err = fmt.Errorf("invalid validator_regex of launch form field %s: %w", field.Key, err)
+				return l
+			}
+		}
+		l.keys[field.Key] = true
+		l.fields = append(l.fields, field)
+	}
+	return l
+}
+
+// Build returns the launch_config object.
+// Returns:
+// - The serialized configuration, or nil if no field was added.
+// - The first validation error of the chained calls.
+func (l *LaunchConfigBuilder) Build() (json.RawMessage, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	if len(l.fields) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(launchConfig{LaunchForm: l.fields})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize launch config: %w", err)
+	}
+	return data, nil
+}
+
+// ApplyTo stores the built configuration in target.LaunchConfig.
+func (l *LaunchConfigBuilder) ApplyTo(target *TargetImage) error {
+	launch, err := l.Build()
+	if err != nil {
+		return err
+	}
+	target.LaunchConfig = launch
+	return nil
+}
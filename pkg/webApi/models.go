@@ -56,6 +56,16 @@ type UserResponse struct {
 	Notes        string        `json:"notes,omitempty"` // Added Notes field based on new API
 }
 
+// InGroup reports whether the user is a member of the group with the given ID.
+func (u UserResponse) InGroup(groupID string) bool {
+	for _, group := range u.Groups {
+		if group.GroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
 type GetUserResponse struct {
 	User UserResponse `json:"user"`
 }
@@ -129,6 +139,33 @@ type KasmInfo struct {
 	ShareID           string          `json:"share_id"`
 	ClientSettings    ClientSettings  `json:"client_settings"`
 	ContainerID       string          `json:"container_id"`
+	StartDate         string          `json:"start_date,omitempty"`
+	KeepaliveDate     string          `json:"keepalive_date,omitempty"`
+	User              *KasmInfoUser   `json:"user,omitempty"`  // Only returned by get_kasms.
+	Image             *KasmInfoImage  `json:"image,omitempty"` // Only returned by get_kasms.
+}
+
+// KasmInfoUser represents the owner of a Kasm session as returned by get_kasms.
+type KasmInfoUser struct {
+	Username string `json:"username"`
+}
+
+// KasmInfoImage represents the image of a Kasm session as returned by get_kasms.
+type KasmInfoImage struct {
+	FriendlyName string `json:"friendly_name"`
+	ImageSrc     string `json:"image_src,omitempty"`
+}
+
+// GetKasmsRequest represents the request to list all Kasm sessions.
+type GetKasmsRequest struct {
+	APIKey       string `json:"api_key"`
+	APIKeySecret string `json:"api_key_secret"`
+}
+
+// GetKasmsResponse represents the response listing all Kasm sessions.
+type GetKasmsResponse struct {
+	Kasms       []KasmInfo `json:"kasms"`
+	CurrentTime string     `json:"current_time,omitempty"`
 }
 
 // Port represents port mappings in a Kasm session.
@@ -157,6 +194,19 @@ type DestroyKasmResponse struct {
 	ErrorMessage string `json:"error_message"`
 }
 
+// KeepaliveRequest represents the request to extend the lifetime of a Kasm session.
+type KeepaliveRequest struct {
+	APIKey       string `json:"api_key"`
+	APIKeySecret string `json:"api_key_secret"`
+	KasmID       string `json:"kasm_id"`
+	UserID       string `json:"user_id,omitempty"`
+}
+
+// KeepaliveResponse represents the response to a keepalive request.
+type KeepaliveResponse struct {
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
 // ExecCommandRequest represents the request to execute a command inside a Kasm session.
 type ExecCommandRequest struct {
 	APIKey       string            `json:"api_key"`
@@ -166,6 +216,11 @@ type ExecCommandRequest struct {
 	ExecConfig   ExecConfigRequest `json:"exec_config"`
 }
 
+// ExecCommandResponse represents the response to an exec_command_kasm request.
+type ExecCommandResponse struct {
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
 // ExecConfigRequest contains the execution configuration for a command.
 type ExecConfigRequest struct {
 	Cmd         string            `json:"cmd"`
@@ -183,6 +238,25 @@ type GetImagesRequest struct {
 	APIKeySecret string `json:"api_key_secret"`
 }
 
+// GetImageRequest represents the request of the undocumented get_image endpoint, which resolves the image
+// made available on a custom Docker network:
+//
+//	POST /api/public/get_image
+//	{"api_key": "...", "api_key_secret": "...", "target_image": {"network_name": "kasm_custom"}}
+//
+// Kasm answers with {"image": {...}} carrying the same fields as the entries of get_images.
+type GetImageRequest struct {
+	APIKey       string         `json:"api_key"`
+	APIKeySecret string         `json:"api_key_secret"`
+	TargetImage  GetImageTarget `json:"target_image"`
+}
+
+// GetImageTarget selects the image returned by get_image.
+type GetImageTarget struct {
+	ImageID     string `json:"image_id,omitempty"`
+	NetworkName string `json:"network_name,omitempty"`
+}
+
 // ImageAttribute represents an attribute of a Kasm image.
 type ImageAttribute struct {
 	ImageID  string `json:"image_id"`
@@ -0,0 +1,130 @@
+package webApi
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Character classes used for generated passwords. Ambiguous characters (0/O, 1/l/I) are left out.
+const (
+	passwordUpper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordLower   = "abcdefghijkmnopqrstuvwxyz"
+	passwordDigits  = "23456789"
+	passwordSymbols = "!@#$%^&*-_=+"
+)
+
+// minPasswordLength is the shortest password GeneratePassword produces, matching Kasm's complexity requirements.
+const minPasswordLength = 8
+
+// PasswordPolicy describes the length and character classes of a generated password.
+type PasswordPolicy struct {
+	Length  int
+	Upper   bool
+	Lower   bool
+	Digits  bool
+	Symbols bool
+}
+
+// DefaultPasswordPolicy returns a policy producing 20 character passwords containing every character class.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{Length: 20, Upper: true, Lower: true, Digits: true, Symbols: true}
+}
+
+// classes returns the enabled character classes, falling back to all classes if none is enabled.
+func (p PasswordPolicy) classes() []string {
+	var classes []string
+	if p.Upper {
+		classes = append(classes, passwordUpper)
+	}
+	if p.Lower {
+		classes = append(classes, passwordLower)
+	}
+	if p.Digits {
+		classes = append(classes, passwordDigits)
+	}
+	if p.Symbols {
+		classes = append(classes, passwordSymbols)
+	}
+	if len(classes) == 0 {
+		classes = []string{passwordUpper, passwordLower, passwordDigits, passwordSymbols}
+	}
+	return classes
+}
+
+// GeneratePassword returns a cryptographically random password satisfying the policy.
+// Every enabled character class occurs at least once; lengths below 8 or below the number of classes are raised.
+// It panics if the system's secure random number generator fails.
+func GeneratePassword(policy PasswordPolicy) string {
+	classes := policy.classes()
+
+	length := policy.Length
+	if length < minPasswordLength {
+		length = minPasswordLength
+	}
+	if length < len(classes) {
+		length = len(classes)
+	}
+
+	alphabet := ""
+	for _, class := range classes {
+		alphabet += class
+	}
+
+	// Seed one character of each class, fill the rest from the full alphabet and shuffle.
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		password = append(password, class[randomIndex(len(class))])
+	}
+	for len(password) < length {
+		password = append(password, alphabet[randomIndex(len(alphabet))])
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j := randomIndex(i + 1)
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password)
+}
+
+// randomIndex returns a uniformly distributed random number in [0, n).
+func randomIndex(n int) int {
+	value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read secure random number: %v", err))
+	}
+	return int(value.Int64())
+}
+
+// RotateUserPassword replaces a user's password with a newly generated one.
+// The new password is returned to the caller and never logged.
+// Note: Requires api permissions "Users View" and "Users Modify"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - userID: ID of the user whose password is reset.
+// Returns:
+// - The new password.
+// - An error if the user cannot be fetched or updated.
+func (api *KasmAPI) RotateUserPassword(ctx context.Context, userID string) (string, error) {
+	user, err := api.GetUser(ctx, userID, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch user %s: %w", userID, err)
+	}
+
+	password := GeneratePassword(DefaultPasswordPolicy())
+	if _, err := api.UpdateUser(ctx, TargetUser{
+		UserID:   user.UserID,
+		Username: user.Username,
+		Password: password,
+	}); err != nil {
+		return "", fmt.Errorf("failed to update password of user %s: %w", userID, err)
+	}
+
+	log.Info().
+		Str("user_id", user.UserID).
+		Str("username", user.Username).
+		Msg("User password rotated")
+	return password, nil
+}
@@ -0,0 +1,209 @@
+package webApi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Bounds accepted by ValidateResources. Values outside are almost always unit mistakes,
+// e.g. "1" meant as gigabytes but sent as bytes.
+const (
+	MinWorkspaceMemory = 256 << 20 // 256 MiB
+	MaxWorkspaceMemory = 1 << 40   // 1 TiB
+	MaxWorkspaceCores  = 512
+)
+
+// memoryUnits maps the lower case unit suffixes accepted by ParseMemorySize to their size in bytes.
+// Like Docker's mem_limit, "k", "m", "g" and "t" are binary units.
+var memoryUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// ParseMemorySize converts a human readable memory size such as "2g", "512m" or "1.5GiB" into bytes,
+// the unit TargetImage.Memory is sent in. A plain number is taken as bytes.
+// Returns an error if the value is empty, negative or has an unknown unit.
+func ParseMemorySize(value string) (int, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	split := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split == -1 {
+		split = len(trimmed)
+	}
+
+	number, unit := trimmed[:split], strings.TrimSpace(trimmed[split:])
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: expected a number with an optional unit like 512m or 2g", value)
+	}
+	multiplier, ok := memoryUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory size %q: unknown unit %q, use b, k, m, g or t", value, unit)
+	}
+
+	bytes := amount * multiplier
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid memory size %q: value too large", value)
+	}
+	return int(bytes), nil
+}
+
+// ParseCPUCores converts a CPU value such as "2", "1.5" or "1500m" (millicores) into cores.
+func ParseCPUCores(value string) (float64, error) {
+	trimmed := strings.TrimSpace(value)
+	divisor := 1.0
+	if strings.HasSuffix(trimmed, "m") {
+		trimmed = strings.TrimSuffix(trimmed, "m")
+		divisor = 1000
+	}
+
+	cores, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || cores < 0 || math.IsInf(cores, 0) || math.IsNaN(cores) {
+		return 0, fmt.Errorf("invalid cores %q: expected a number of cores like 2 or 1.5, or millicores like 1500m", value)
+	}
+	return cores / divisor, nil
+}
+
+// ValidateResources rejects cores and memory values that cannot be intended for a workspace.
+// Parameters:
+// - cores: CPU cores of the workspace.
+// - memory: Memory of the workspace in bytes.
+// Returns:
+// - A FieldError naming the offending field and value, or nil.
+func ValidateResources(cores float64, memory int) error {
+	if cores <= 0 || cores > MaxWorkspaceCores {
+		return FieldError{Field: "cores", Message: fmt.Sprintf("invalid cores %g: must be greater than 0 and at most %d", cores, MaxWorkspaceCores)}
+	}
+	if memory < MinWorkspaceMemory || memory > MaxWorkspaceMemory {
+		return FieldError{Field: "memory", Message: fmt.Sprintf("invalid memory %d bytes: must be between 256MiB and 1TiB, use a unit like 2g if the value was meant in MB or GB", memory)}
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes a TargetImage from YAML using the same field names as the API, e.g. friendly_name.
+// Memory and cores may be given as human readable strings ("2g", "1500m"); run_config, exec_config and
+// volume_mappings may be written as objects and are stored as JSON strings.
+// Decoding does not enforce workspace limits, so exported Server and Link images without cores or memory can be
+// read back; LoadTargetImage and CreateKasmWorkspace validate the resources with ValidateResources.
+func (t *TargetImage) UnmarshalYAML(value *yaml.Node) error {
+	var fields map[string]interface{}
+	if err := value.Decode(&fields); err != nil {
+		return err
+	}
+	if err := normalizeResourceFields(fields); err != nil {
+		return err
+	}
+	if fieldErrors := normalizeJSONStringFields(fields); len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to convert target image: %w", err)
+	}
+	type plain TargetImage
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return fmt.Errorf("failed to decode target image: %w", err)
+	}
+	return nil
+}
+
+// MarshalYAML encodes a TargetImage with the API field names, so the output can be read back by UnmarshalYAML
+// and LoadTargetImage. run_config, exec_config and volume_mappings are written as objects.
+func (t TargetImage) MarshalYAML() (interface{}, error) {
+	type plain TargetImage
+	data, err := json.Marshal(plain(t))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert target image: %w", err)
+	}
+	fields, err := decodeJSONNumbers(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert target image: %w", err)
+	}
+
+	values := fields.(map[string]interface{})
+	for _, field := range []string{"run_config", "exec_config", "volume_mappings"} {
+		text, ok := values[field].(string)
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+		if decoded, err := decodeJSONNumbers([]byte(text)); err == nil {
+			values[field] = decoded
+		}
+	}
+	return values, nil
+}
+
+// decodeJSONNumbers decodes JSON keeping integers as int64, so large values such as memory are not written
+// in exponent notation.
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return convertJSONNumbers(value), nil
+}
+
+func convertJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+// normalizeResourceFields replaces human readable memory and cores values in decoded fields with numbers.
+func normalizeResourceFields(fields map[string]interface{}) error {
+	if memory, ok := fields["memory"].(string); ok {
+		bytes, err := ParseMemorySize(memory)
+		if err != nil {
+			return FieldError{Field: "memory", Message: err.Error()}
+		}
+		fields["memory"] = bytes
+	}
+	if cores, ok := fields["cores"].(string); ok {
+		parsed, err := ParseCPUCores(cores)
+		if err != nil {
+			return FieldError{Field: "cores", Message: err.Error()}
+		}
+		fields["cores"] = parsed
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package webApi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrScreenshotNotAvailable is returned by GetKasmScreenshot when the session has no frame yet, e.g. while
+// it is still starting.
+var ErrScreenshotNotAvailable = errors.New("kasm screenshot not available")
+
+// maxScreenshotSize limits the size of a screenshot response.
+const maxScreenshotSize = 32 << 20
+
+// GetKasmScreenshotRequest represents the request for a thumbnail of a running session.
+type GetKasmScreenshotRequest struct {
+	APIKey       string `json:"api_key"`
+	APIKeySecret string `json:"api_key_secret"`
+	KasmID       string `json:"kasm_id"`
+	UserID       string `json:"user_id"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// GetKasmScreenshot fetches the current frame of a running session as image bytes.
+// The request is made once without retries, since dashboards poll it regularly anyway.
+// Note: Requires api permission "Users Auth Session"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - userID: ID of the user owning the session.
+// - kasmID: ID of the session.
+// - width: Width of the thumbnail in pixels, 0 for the Kasm default.
+// - height: Height of the thumbnail in pixels, 0 for the Kasm default.
+// Returns:
+// - The encoded image as returned by Kasm (usually JPEG or PNG).
+// - An error wrapping ErrScreenshotNotAvailable if the session has no frame yet, or describing the failure.
+func (api *KasmAPI) GetKasmScreenshot(ctx context.Context, userID, kasmID string, width, height int) ([]byte, error) {
+	endpoint := "/api/public/get_kasm_screenshot"
+	url := api.EndpointURL(endpoint)
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("invalid screenshot size %dx%d", width, height)
+	}
+
+	body, err := api.requestBody(GetKasmScreenshotRequest{
+		KasmID: kasmID,
+		UserID: userID,
+		Width:  width,
+		Height: height,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal screenshot request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create screenshot request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	api.authorize(req)
+
+	log.Debug().
+		Str("method", "POST").
+		Str("endpoint", endpoint).
+		Str("kasm_id", kasmID).
+		Int("width", width).
+		Int("height", height).
+		Msg("Fetching Kasm session screenshot")
+
+	resp, err := api.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch screenshot of Kasm session %s: %w", kasmID, err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxScreenshotSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshot of Kasm session %s: %w", kasmID, err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	isImage := strings.HasPrefix(contentType, "image/") || (contentType == "" && !json.Valid(responseBody))
+	if resp.StatusCode == http.StatusOK && isImage && len(responseBody) > 0 {
+		log.Debug().
+			Str("kasm_id", kasmID).
+			Str("content_type", contentType).
+			Int("bytes", len(responseBody)).
+			Msg("Kasm session screenshot retrieved")
+		return responseBody, nil
+	}
+
+	// Kasm answers with a JSON error message while the session has no frame to capture.
+	var errorResponse struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	_ = json.Unmarshal(responseBody, &errorResponse)
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound:
+		message := errorResponse.ErrorMessage
+		if message == "" {
+			message = "no image in response"
+		}
+		log.Debug().Str("kasm_id", kasmID).Str("reason", message).Msg("Kasm session screenshot not available")
+		return nil, fmt.Errorf("%w for session %s: %s", ErrScreenshotNotAvailable, kasmID, message)
+	default:
+		return nil, fmt.Errorf("failed to fetch screenshot of Kasm session %s: unexpected response status: %s, body: %s",
+			kasmID, resp.Status, strings.TrimSpace(string(responseBody)))
+	}
+}
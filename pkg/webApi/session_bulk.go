@@ -0,0 +1,107 @@
+package webApi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// bulkDestroyWorkers is the number of sessions destroyed concurrently by the bulk destroy helpers.
+const bulkDestroyWorkers = 4
+
+// DestroyUserSessions destroys every running session of a user.
+// Note: Requires api permissions "Sessions View", "Users Auth Session" and "User"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - userID: ID of the user whose sessions are destroyed.
+// Returns:
+// - The number of sessions destroyed.
+// - An error joining the failure of every session that could not be destroyed.
+func (api *KasmAPI) DestroyUserSessions(ctx context.Context, userID string) (int, error) {
+	return api.destroySessions(ctx, "user_id", userID, func(kasm KasmInfo) bool {
+		return kasm.UserID == userID
+	})
+}
+
+// DestroySessionsByImage destroys every running session of a workspace image, e.g. at the end of a class.
+// Note: Requires api permissions "Sessions View", "Users Auth Session" and "User"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageID: ID of the image whose sessions are destroyed.
+// Returns:
+// - The number of sessions destroyed.
+// - An error joining the failure of every session that could not be destroyed.
+func (api *KasmAPI) DestroySessionsByImage(ctx context.Context, imageID string) (int, error) {
+	return api.destroySessions(ctx, "image_id", imageID, func(kasm KasmInfo) bool {
+		return kasm.ImageID == imageID
+	})
+}
+
+// destroySessions lists all sessions and destroys the ones matching match with bounded concurrency.
+func (api *KasmAPI) destroySessions(ctx context.Context, filterField, filterValue string, match func(KasmInfo) bool) (int, error) {
+	kasms, err := api.GetKasms(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var targets []KasmInfo
+	for _, kasm := range kasms {
+		if match(kasm) {
+			targets = append(targets, kasm)
+		}
+	}
+	log.Info().
+		Str(filterField, filterValue).
+		Int("sessions", len(targets)).
+		Msg("Destroying Kasm sessions")
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		destroyed int
+		errs      []error
+	)
+	semaphore := make(chan struct{}, bulkDestroyWorkers)
+	for _, kasm := range targets {
+		wg.Add(1)
+		go func(kasm KasmInfo) {
+			defer wg.Done()
+
+			var err error
+			select {
+			case semaphore <- struct{}{}:
+				err = api.DestroyKasmSession(ctx, kasm.KasmID, kasm.UserID)
+				<-semaphore
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("session %s: %w", kasm.KasmID, err))
+				return
+			}
+			destroyed++
+		}(kasm)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		log.Error().
+			Str(filterField, filterValue).
+			Int("destroyed", destroyed).
+			Int("failed", len(errs)).
+			Msg("Some Kasm sessions could not be destroyed")
+		return destroyed, fmt.Errorf("failed to destroy %d of %d sessions: %w", len(errs), len(targets), errors.Join(errs...))
+	}
+
+	log.Info().
+		Str(filterField, filterValue).
+		Int("destroyed", destroyed).
+		Msg("Destroyed Kasm sessions")
+	return destroyed, nil
+}
@@ -0,0 +1,89 @@
+package webApi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxSessionEnvSize is the total size of the session environment accepted by default. Kasm does not
+// document its limit and answers larger environments with an internal server error.
+const DefaultMaxSessionEnvSize = 32 << 10 // 32 KiB
+
+// DefaultReservedEnvPrefixes are the key prefixes Kasm sets itself inside sessions.
+var DefaultReservedEnvPrefixes = []string{"KASM_"}
+
+// ErrInvalidSessionEnv is wrapped by the errors of ValidateSessionEnvironment.
+var ErrInvalidSessionEnv = errors.New("invalid session environment")
+
+// SessionEnvLimits bounds the environment passed to request_kasm.
+type SessionEnvLimits struct {
+	// MaxSize is the maximum total size in bytes, counting every variable as KEY=VALUE plus a terminator.
+	// Zero selects DefaultMaxSessionEnvSize; a negative value disables the check.
+	MaxSize int
+	// ReservedPrefixes are rejected key prefixes, compared case-insensitively. Nil selects
+	// DefaultReservedEnvPrefixes; an empty slice allows every key.
+	ReservedPrefixes []string
+}
+
+// ValidateSessionEnvironment checks a session environment before it is sent to Kasm, so a bad variable
+// results in a clear local error instead of an opaque 500.
+// Parameters:
+// - env: The environment variables of the session.
+// - limits: The size limit and reserved prefixes; the zero value selects the defaults.
+// Returns:
+// - An error wrapping ErrInvalidSessionEnv and naming the offending key if a key is empty, reserved or
+// contains "=", whitespace or NUL, a key or value is not valid UTF-8, or the environment is too large.
+func ValidateSessionEnvironment(env map[string]string, limits SessionEnvLimits) error {
+	maxSize := limits.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSessionEnvSize
+	}
+	reservedPrefixes := limits.ReservedPrefixes
+	if reservedPrefixes == nil {
+		reservedPrefixes = DefaultReservedEnvPrefixes
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	totalSize := 0
+	largestKey, largestSize := "", 0
+	for _, key := range keys {
+		value := env[key]
+		switch {
+		case key == "":
+			return fmt.Errorf("%w: empty variable name", ErrInvalidSessionEnv)
+		case !utf8.ValidString(key):
+			return fmt.Errorf("%w: variable name %q is not valid UTF-8", ErrInvalidSessionEnv, key)
+		case strings.ContainsAny(key, "= \t\r\n\x00"):
+			return fmt.Errorf("%w: variable name %q contains \"=\", whitespace or NUL", ErrInvalidSessionEnv, key)
+		case !utf8.ValidString(value):
+			return fmt.Errorf("%w: value of %s is not valid UTF-8", ErrInvalidSessionEnv, key)
+		case strings.ContainsRune(value, 0):
+			return fmt.Errorf("%w: value of %s contains NUL", ErrInvalidSessionEnv, key)
+		}
+		for _, prefix := range reservedPrefixes {
+			if prefix != "" && strings.HasPrefix(strings.ToUpper(key), strings.ToUpper(prefix)) {
+				return fmt.Errorf("%w: variable %s uses the reserved prefix %s", ErrInvalidSessionEnv, key, prefix)
+			}
+		}
+
+		size := len(key) + len(value) + 2
+		totalSize += size
+		if size > largestSize {
+			largestKey, largestSize = key, size
+		}
+	}
+
+	if maxSize > 0 && totalSize > maxSize {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes, the largest variable is %s with %d bytes",
+			ErrInvalidSessionEnv, totalSize, maxSize, largestKey, largestSize)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package webApi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// groupSessionWorkers is the number of sessions requested concurrently by StartSessionsForGroup.
+const groupSessionWorkers = 4
+
+// GroupSession is a session requested for a group member by StartSessionsForGroup.
+type GroupSession struct {
+	UserID   string
+	Username string
+	Session  RequestKasmResponse
+}
+
+// GroupSessionError is the failure to request a session for a group member.
+type GroupSessionError struct {
+	UserID   string
+	Username string
+	Err      error
+}
+
+// GroupSessionsResult reports the outcome of StartSessionsForGroup per group member.
+type GroupSessionsResult struct {
+	// Started holds the newly requested sessions.
+	Started []GroupSession
+	// Skipped holds the running sessions of members who already had one for the image.
+	Skipped []KasmInfo
+	// Failed holds the members whose session could not be requested.
+	Failed []GroupSessionError
+}
+
+// StartSessionsForGroup requests a session of an image for every member of a group, e.g. as the last step of
+// a release. Members who already have a running session of the image are skipped.
+// Note: Requires api permissions "Users View", "Sessions View" and "Users Auth Session"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - groupID: ID of the group whose members get a session.
+// - imageID: ID of the Kasm image to launch.
+// - env: Environment variables passed to every requested session.
+// Returns:
+// - The started, skipped and failed members, sorted by username.
+// - An error if the members or sessions cannot be listed, or joining the failure of every member.
+func (api *KasmAPI) StartSessionsForGroup(ctx context.Context, groupID, imageID string, env map[string]string) (*GroupSessionsResult, error) {
+	users, err := api.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	kasms, err := api.GetKasms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	running := make(map[string]KasmInfo)
+	for _, kasm := range kasms {
+		if kasm.ImageID == imageID && kasm.OperationalStatus == "running" {
+			running[kasm.UserID] = kasm
+		}
+	}
+
+	result := &GroupSessionsResult{}
+	var members []UserResponse
+	for _, user := range users {
+		if !user.InGroup(groupID) {
+			continue
+		}
+		if kasm, ok := running[user.UserID]; ok {
+			result.Skipped = append(result.Skipped, kasm)
+			continue
+		}
+		members = append(members, user)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Username < members[j].Username })
+
+	log.Info().
+		Str("group_id", groupID).
+		Str("image_id", imageID).
+		Int("members", len(members)+len(result.Skipped)).
+		Int("skipped", len(result.Skipped)).
+		Msg("Starting Kasm sessions for group")
+
+	started := make([]*GroupSession, len(members))
+	failed := make([]*GroupSessionError, len(members))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, groupSessionWorkers)
+	for i, user := range members {
+		wg.Add(1)
+		go func(i int, user UserResponse) {
+			defer wg.Done()
+
+			var (
+				response *RequestKasmResponse
+				err      error
+			)
+			select {
+			case semaphore <- struct{}{}:
+				response, err = api.RequestKasmSession(ctx, user.UserID, imageID, env)
+				<-semaphore
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			if err != nil {
+				failed[i] = &GroupSessionError{UserID: user.UserID, Username: user.Username, Err: err}
+				return
+			}
+			started[i] = &GroupSession{UserID: user.UserID, Username: user.Username, Session: *response}
+		}(i, user)
+	}
+	wg.Wait()
+
+	var errs []error
+	for i := range members {
+		if started[i] != nil {
+			result.Started = append(result.Started, *started[i])
+		}
+		if failed[i] != nil {
+			result.Failed = append(result.Failed, *failed[i])
+			errs = append(errs, fmt.Errorf("user %s: %w", failed[i].Username, failed[i].Err))
+		}
+	}
+
+	if len(errs) > 0 {
+		log.Error().
+			Str("group_id", groupID).
+			Int("started", len(result.Started)).
+			Int("failed", len(errs)).
+			Msg("Some Kasm sessions could not be started for group")
+		return result, fmt.Errorf("failed to start %d of %d sessions: %w", len(errs), len(members), errors.Join(errs...))
+	}
+
+	log.Info().
+		Str("group_id", groupID).
+		Int("started", len(result.Started)).
+		Int("skipped", len(result.Skipped)).
+		Msg("Started Kasm sessions for group")
+	return result, nil
+}
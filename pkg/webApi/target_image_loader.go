@@ -0,0 +1,166 @@
+package webApi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults applied by LoadTargetImage to fields missing from a workspace definition file.
+const (
+	DefaultImageType           = "Container"
+	DefaultCPUAllocationMethod = "Inherit"
+)
+
+// validImageTypes are the workspace types known to Kasm.
+var validImageTypes = map[string]bool{
+	"Container":  true,
+	"Server":     true,
+	"ServerPool": true,
+	"Link":       true,
+}
+
+// FieldError describes an invalid field of a workspace definition.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects all field errors of a workspace definition.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "invalid target image: " + strings.Join(messages, "; ")
+}
+
+// LoadTargetImage reads a workspace definition from a YAML or JSON file, using the API field names
+// (e.g. friendly_name). Missing fields get defaults: enabled is true, image_type is DefaultImageType and
+// cpu_allocation_method is DefaultCPUAllocationMethod. run_config, exec_config and volume_mappings may be
+// written as objects and are converted to the JSON strings the API expects. Memory and cores accept human
+// readable values like "2g" and "1500m".
+// Parameters:
+// - path: Path to the YAML or JSON file.
+// Returns:
+// - The decoded TargetImage.
+// - ValidationErrors listing every invalid field, or an error if the file cannot be read or parsed.
+func LoadTargetImage(path string) (TargetImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TargetImage{}, fmt.Errorf("failed to read target image %s: %w", path, err)
+	}
+
+	// YAML is a superset of JSON, so one decoder handles both formats.
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return TargetImage{}, fmt.Errorf("failed to parse target image %s: %w", path, err)
+	}
+	if fields == nil {
+		return TargetImage{}, fmt.Errorf("target image %s is empty", path)
+	}
+
+	setDefault(fields, "enabled", true)
+	setDefault(fields, "image_type", DefaultImageType)
+	setDefault(fields, "cpu_allocation_method", DefaultCPUAllocationMethod)
+
+	fieldErrors := normalizeJSONStringFields(fields)
+	if err := normalizeResourceFields(fields); err != nil {
+		var fieldErr FieldError
+		if !errors.As(err, &fieldErr) {
+			return TargetImage{}, err
+		}
+		fieldErrors = append(fieldErrors, fieldErr)
+	}
+	if len(fieldErrors) > 0 {
+		return TargetImage{}, fieldErrors
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return TargetImage{}, fmt.Errorf("failed to convert target image %s: %w", path, err)
+	}
+	var target TargetImage
+	type plain TargetImage
+	if err := json.Unmarshal(encoded, (*plain)(&target)); err != nil {
+		return TargetImage{}, fmt.Errorf("failed to decode target image %s: %w", path, err)
+	}
+
+	if err := ValidateTargetImage(target); err != nil {
+		return TargetImage{}, err
+	}
+	return target, nil
+}
+
+// ValidateTargetImage checks the fields required to create a workspace.
+// Returns:
+// - ValidationErrors listing every invalid field, or nil if the definition is valid.
+func ValidateTargetImage(target TargetImage) error {
+	var fieldErrors ValidationErrors
+	if strings.TrimSpace(target.Name) == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "name", Message: "is required"})
+	}
+	if strings.TrimSpace(target.FriendlyName) == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "friendly_name", Message: "is required"})
+	}
+	if target.ImageType != "" && !validImageTypes[target.ImageType] {
+		fieldErrors = append(fieldErrors, FieldError{Field: "image_type", Message: fmt.Sprintf("unknown type %q, expected Container, Server, ServerPool or Link", target.ImageType)})
+	}
+	var resourceErr FieldError
+	if err := ValidateResources(target.Cores, target.Memory); errors.As(err, &resourceErr) {
+		fieldErrors = append(fieldErrors, resourceErr)
+	}
+	for field, value := range map[string]string{
+		"run_config":      target.RunConfig,
+		"exec_config":     target.ExecConfig,
+		"volume_mappings": target.VolumeMappings,
+	} {
+		if value != "" && !json.Valid([]byte(value)) {
+			fieldErrors = append(fieldErrors, FieldError{Field: field, Message: "is not valid JSON"})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		sort.Slice(fieldErrors, func(i, j int) bool { return fieldErrors[i].Field < fieldErrors[j].Field })
+		return fieldErrors
+	}
+	return nil
+}
+
+// normalizeJSONStringFields encodes object values of the JSON string fields as JSON strings.
+func normalizeJSONStringFields(fields map[string]interface{}) ValidationErrors {
+	var fieldErrors ValidationErrors
+	for _, field := range []string{"run_config", "exec_config", "volume_mappings"} {
+		value, ok := fields[field]
+		if !ok || value == nil {
+			continue
+		}
+		if _, isString := value.(string); isString {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		fields[field] = string(encoded)
+	}
+	return fieldErrors
+}
+
+func setDefault(fields map[string]interface{}, field string, value interface{}) {
+	if _, ok := fields[field]; !ok {
+		fields[field] = value
+	}
+}
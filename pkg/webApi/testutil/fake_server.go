@@ -0,0 +1,553 @@
+// Package testutil provides an in-memory fake of the Kasm API for tests that should not need a real Kasm deployment.
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kasmlink/pkg/webApi"
+)
+
+// Default credentials accepted by a FakeKasmServer.
+const (
+	DefaultAPIKey       = "fake-api-key"
+	DefaultAPIKeySecret = "fake-api-key-secret"
+)
+
+// kasmTimeLayout is the timestamp format used in Kasm API responses.
+const kasmTimeLayout = "2006-01-02 15:04:05.000000"
+
+// FakeKasmServer is an httptest.Server implementing the subset of the Kasm public API used by KasmLink:
+// create_user, get_user, get_users, update_user, delete_user, add_user_group, get_images, create_image,
+// update_image, delete_image, request_kasm, get_kasm_status, get_kasms, keepalive, destroy_kasm and get_groups. State is kept in memory and every call is recorded.
+//
+// Requests with credentials other than APIKey/APIKeySecret are answered with 403 like a real deployment.
+type FakeKasmServer struct {
+	*httptest.Server
+	APIKey       string
+	APIKeySecret string
+
+	mutex    sync.Mutex
+	nextID   int
+	users    map[string]webApi.UserResponse
+	images   map[string]webApi.ImageDetail
+	kasms    map[string]webApi.KasmInfo
+	groups   []webApi.Group
+	requests []string
+}
+
+// NewFakeKasmServer starts a fake Kasm API with the default credentials and the "All Users" system group.
+// Call Close when done.
+func NewFakeKasmServer() *FakeKasmServer {
+	f := &FakeKasmServer{
+		APIKey:       DefaultAPIKey,
+		APIKeySecret: DefaultAPIKeySecret,
+		users:        make(map[string]webApi.UserResponse),
+		images:       make(map[string]webApi.ImageDetail),
+		kasms:        make(map[string]webApi.KasmInfo),
+	}
+	f.groups = []webApi.Group{{GroupID: f.newID(), Name: "All Users", Priority: 1000, IsSystem: true}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// API returns a KasmAPI configured for the fake server.
+func (f *FakeKasmServer) API() *webApi.KasmAPI {
+	return webApi.NewKasmAPI(f.URL, f.APIKey, f.APIKeySecret, false, 5*time.Second)
+}
+
+// AddUser seeds a user and returns it with its generated ID.
+func (f *FakeKasmServer) AddUser(user webApi.TargetUser) webApi.UserResponse {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.createUser(user)
+}
+
+// AddImage seeds a workspace image and returns it with its generated ID.
+func (f *FakeKasmServer) AddImage(image webApi.TargetImage) webApi.ImageDetail {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.createImage(image)
+}
+
+// AddGroup seeds a group and returns it with its generated ID.
+func (f *FakeKasmServer) AddGroup(name string) webApi.Group {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	group := webApi.Group{GroupID: f.newID(), Name: name, Priority: 100}
+	f.groups = append(f.groups, group)
+	return group
+}
+
+// Image returns the stored image with the given ID.
+func (f *FakeKasmServer) Image(imageID string) (webApi.ImageDetail, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	image, ok := f.images[imageID]
+	return image, ok
+}
+
+// SetKasmStatus sets the operational status of a session, e.g. "starting" or "stopped".
+// Returns false if the session does not exist.
+func (f *FakeKasmServer) SetKasmStatus(kasmID, status string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	kasm, ok := f.kasms[kasmID]
+	if !ok {
+		return false
+	}
+	kasm.OperationalStatus = status
+	f.kasms[kasmID] = kasm
+	return true
+}
+
+// Requests returns the endpoint paths of all requests received so far, in order.
+func (f *FakeKasmServer) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// newID returns a new Kasm-style 32 character hex ID. The caller must hold the mutex or own f exclusively.
+func (f *FakeKasmServer) newID() string {
+	f.nextID++
+	return fmt.Sprintf("%032x", f.nextID)
+}
+
+// handle authenticates a request and dispatches it to the endpoint handler.
+func (f *FakeKasmServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	endpoint := r.URL.Path
+	f.requests = append(f.requests, endpoint)
+
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error_message": "Method not allowed"})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error_message": "Unreadable request body"})
+		return
+	}
+
+	var credentials struct {
+		APIKey       string `json:"api_key"`
+		APIKeySecret string `json:"api_key_secret"`
+	}
+	if err := json.Unmarshal(body, &credentials); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error_message": "Invalid JSON"})
+		return
+	}
+	if credentials.APIKey == "" && credentials.APIKeySecret == "" {
+		// Header authentication as sent with webApi.AuthInHeader.
+		credentials.APIKey, credentials.APIKeySecret, _ = strings.Cut(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ":")
+	}
+	if credentials.APIKey != f.APIKey || credentials.APIKeySecret != f.APIKeySecret {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error_message": "Access Denied"})
+		return
+	}
+
+	handlers := map[string]func([]byte) (int, interface{}){
+		"/api/public/create_user":     f.handleCreateUser,
+		"/api/public/get_user":        f.handleGetUser,
+		"/api/public/get_users":       f.handleGetUsers,
+		"/api/public/update_user":     f.handleUpdateUser,
+		"/api/public/delete_user":     f.handleDeleteUser,
+		"/api/public/add_user_group":  f.handleAddUserGroup,
+		"/api/public/get_images":      f.handleGetImages,
+		"/api/public/get_image":       f.handleGetImage,
+		"/api/public/create_image":    f.handleCreateImage,
+		"/api/public/update_image":    f.handleUpdateImage,
+		"/api/public/delete_image":    f.handleDeleteImage,
+		"/api/public/request_kasm":    f.handleRequestKasm,
+		"/api/public/get_kasm_status": f.handleGetKasmStatus,
+		"/api/public/get_kasms":       f.handleGetKasms,
+		"/api/public/keepalive":       f.handleKeepalive,
+		"/api/public/destroy_kasm":    f.handleDestroyKasm,
+		"/api/public/get_groups":      f.handleGetGroups,
+	}
+	handler, ok := handlers[endpoint]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error_message": "Unknown endpoint " + endpoint})
+		return
+	}
+
+	status, response := handler(body)
+	writeJSON(w, status, response)
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// errorResponse builds a Kasm style error response.
+func errorResponse(status int, format string, args ...interface{}) (int, interface{}) {
+	return status, map[string]string{"error_message": fmt.Sprintf(format, args...)}
+}
+
+func (f *FakeKasmServer) createUser(user webApi.TargetUser) webApi.UserResponse {
+	created := webApi.UserResponse{
+		UserID:       f.newID(),
+		Username:     user.Username,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Phone:        user.Phone,
+		Organization: user.Organization,
+		Realm:        "local",
+		Groups:       []webApi.UserGroup{{Name: f.groups[0].Name, GroupID: f.groups[0].GroupID}},
+		Kasms:        []webApi.KasmSession{},
+		Disabled:     user.Disabled,
+		Locked:       user.Locked,
+		Created:      time.Now().UTC().Format(kasmTimeLayout),
+	}
+	f.users[created.UserID] = created
+	return created
+}
+
+// findUser looks a user up by ID or, if no ID is given, by case-insensitive username.
+func (f *FakeKasmServer) findUser(target webApi.TargetUser) (webApi.UserResponse, bool) {
+	if target.UserID != "" {
+		user, ok := f.users[target.UserID]
+		return user, ok
+	}
+	for _, user := range f.users {
+		if target.Username != "" && strings.EqualFold(user.Username, target.Username) {
+			return user, true
+		}
+	}
+	return webApi.UserResponse{}, false
+}
+
+// withSessions returns the user with its current sessions.
+func (f *FakeKasmServer) withSessions(user webApi.UserResponse) webApi.UserResponse {
+	user.Kasms = []webApi.KasmSession{}
+	for _, kasm := range f.sortedKasms() {
+		if kasm.UserID == user.UserID {
+			user.Kasms = append(user.Kasms, webApi.KasmSession{
+				KasmID:         kasm.KasmID,
+				StartDate:      kasm.StartDate,
+				KeepaliveDate:  kasm.KeepaliveDate,
+				ExpirationDate: kasm.ExpirationDate,
+				Server:         webApi.KasmServerInfo{ServerID: "fake-server", Hostname: kasm.Hostname, Port: 443},
+			})
+		}
+	}
+	return user
+}
+
+func (f *FakeKasmServer) handleCreateUser(body []byte) (int, interface{}) {
+	var req webApi.CreateUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	if req.TargetUser.Username == "" {
+		return errorResponse(http.StatusBadRequest, "Username is required")
+	}
+	if _, exists := f.findUser(webApi.TargetUser{Username: req.TargetUser.Username}); exists {
+		return errorResponse(http.StatusBadRequest, "Username already exists")
+	}
+	return http.StatusOK, webApi.GetUserResponse{User: f.createUser(req.TargetUser)}
+}
+
+func (f *FakeKasmServer) handleGetUser(body []byte) (int, interface{}) {
+	var req webApi.GetUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	user, ok := f.findUser(req.TargetUser)
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "User not found")
+	}
+	return http.StatusOK, webApi.GetUserResponse{User: f.withSessions(user)}
+}
+
+func (f *FakeKasmServer) handleGetUsers([]byte) (int, interface{}) {
+	users := make([]webApi.UserResponse, 0, len(f.users))
+	for _, user := range f.users {
+		users = append(users, f.withSessions(user))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return http.StatusOK, webApi.GetUsersResponse{Users: users}
+}
+
+func (f *FakeKasmServer) handleUpdateUser(body []byte) (int, interface{}) {
+	var req webApi.UpdateUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	user, ok := f.users[req.TargetUser.UserID]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "User not found")
+	}
+	user.FirstName = req.TargetUser.FirstName
+	user.LastName = req.TargetUser.LastName
+	user.Organization = req.TargetUser.Organization
+	user.Phone = req.TargetUser.Phone
+	user.Locked = req.TargetUser.Locked
+	user.Disabled = req.TargetUser.Disabled
+	f.users[user.UserID] = user
+	return http.StatusOK, webApi.GetUserResponse{User: f.withSessions(user)}
+}
+
+func (f *FakeKasmServer) handleAddUserGroup(body []byte) (int, interface{}) {
+	var req webApi.AddUserToGroupRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	user, ok := f.users[req.TargetUser.UserID]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "User not found")
+	}
+	for _, group := range f.groups {
+		if group.GroupID == req.TargetGroup.GroupID {
+			user.Groups = append(user.Groups, webApi.UserGroup{Name: group.Name, GroupID: group.GroupID})
+			f.users[user.UserID] = user
+			return http.StatusOK, map[string]interface{}{}
+		}
+	}
+	return errorResponse(http.StatusBadRequest, "Group not found")
+}
+
+func (f *FakeKasmServer) handleDeleteUser(body []byte) (int, interface{}) {
+	var req webApi.DeleteUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	user, ok := f.users[req.TargetUser.UserID]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "User not found")
+	}
+	if sessions := f.withSessions(user).Kasms; len(sessions) > 0 {
+		if !req.Force {
+			return errorResponse(http.StatusBadRequest, "User has %d active sessions, use force to delete", len(sessions))
+		}
+		for _, session := range sessions {
+			delete(f.kasms, session.KasmID)
+		}
+	}
+	delete(f.users, user.UserID)
+	return http.StatusOK, map[string]interface{}{}
+}
+
+func (f *FakeKasmServer) createImage(image webApi.TargetImage) webApi.ImageDetail {
+	detail := imageDetail(f.newID(), image)
+	f.images[detail.ImageID] = detail
+	return detail
+}
+
+// imageDetail converts an image definition into the response representation the fake server stores.
+func imageDetail(imageID string, image webApi.TargetImage) webApi.ImageDetail {
+	detail := webApi.ImageDetail{
+		ImageID:               imageID,
+		Name:                  image.Name,
+		FriendlyName:          image.FriendlyName,
+		Description:           image.Description,
+		Cores:                 image.Cores,
+		Memory:                image.Memory,
+		Enabled:               image.Enabled,
+		Hidden:                image.Hidden,
+		Available:             true,
+		RequireGPU:            image.RequireGPU,
+		GPUCount:              image.GPUCount,
+		ImageType:             image.ImageType,
+		RestrictNetworkNames:  image.RestrictNetworkNames,
+		AllowNetworkSelection: image.AllowNetworkSelection,
+		CPUAllocationMethod:   image.CPUAllocationMethod,
+	}
+	if detail.ImageType == "" {
+		detail.ImageType = "Container"
+	}
+	return detail
+}
+
+func (f *FakeKasmServer) handleGetImages([]byte) (int, interface{}) {
+	// The real API returns every image field, so the details are a superset of webApi.Image.
+	images := make([]webApi.ImageDetail, 0, len(f.images))
+	for _, detail := range f.images {
+		images = append(images, detail)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ImageID < images[j].ImageID })
+	return http.StatusOK, map[string]interface{}{"images": images}
+}
+
+// handleGetImage resolves an image by ID or by a network listed in its restrict_network_names.
+func (f *FakeKasmServer) handleGetImage(body []byte) (int, interface{}) {
+	var req webApi.GetImageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	if detail, ok := f.images[req.TargetImage.ImageID]; ok {
+		return http.StatusOK, map[string]interface{}{"image": detail}
+	}
+	ids := make([]string, 0, len(f.images))
+	for id := range f.images {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for _, network := range f.images[id].RestrictNetworkNames {
+			if req.TargetImage.NetworkName != "" && network == req.TargetImage.NetworkName {
+				return http.StatusOK, map[string]interface{}{"image": f.images[id]}
+			}
+		}
+	}
+	return http.StatusOK, map[string]string{"error_message": "Image not found"}
+}
+
+func (f *FakeKasmServer) handleCreateImage(body []byte) (int, interface{}) {
+	var req webApi.CreateImageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	if req.TargetImage.Name == "" {
+		return errorResponse(http.StatusBadRequest, "Image name is required")
+	}
+	return http.StatusOK, webApi.Response{Image: f.createImage(req.TargetImage)}
+}
+
+func (f *FakeKasmServer) handleUpdateImage(body []byte) (int, interface{}) {
+	var req webApi.CreateImageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	if _, ok := f.images[req.TargetImage.ImageID]; !ok {
+		return errorResponse(http.StatusBadRequest, "Image %s not found", req.TargetImage.ImageID)
+	}
+	detail := imageDetail(req.TargetImage.ImageID, req.TargetImage)
+	f.images[detail.ImageID] = detail
+	return http.StatusOK, webApi.Response{Image: detail}
+}
+
+func (f *FakeKasmServer) handleDeleteImage(body []byte) (int, interface{}) {
+	var req webApi.DeleteImageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	if _, ok := f.images[req.TargetImage.ImageID]; !ok {
+		return errorResponse(http.StatusBadRequest, "Image %s not found", req.TargetImage.ImageID)
+	}
+	delete(f.images, req.TargetImage.ImageID)
+	return http.StatusOK, map[string]interface{}{}
+}
+
+// sortedKasms returns all sessions ordered by ID.
+func (f *FakeKasmServer) sortedKasms() []webApi.KasmInfo {
+	kasms := make([]webApi.KasmInfo, 0, len(f.kasms))
+	for _, kasm := range f.kasms {
+		kasms = append(kasms, kasm)
+	}
+	sort.Slice(kasms, func(i, j int) bool { return kasms[i].KasmID < kasms[j].KasmID })
+	return kasms
+}
+
+func (f *FakeKasmServer) handleRequestKasm(body []byte) (int, interface{}) {
+	var req webApi.RequestKasmRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	user, ok := f.users[req.UserID]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "User not found")
+	}
+	image, ok := f.images[req.ImageID]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "Image not found")
+	}
+
+	now := time.Now().UTC()
+	kasm := webApi.KasmInfo{
+		KasmID:            f.newID(),
+		UserID:            user.UserID,
+		ImageID:           image.ImageID,
+		OperationalStatus: "running",
+		ContainerID:       f.newID(),
+		ContainerIP:       "172.18.0.2",
+		Hostname:          "fake-agent",
+		ShareID:           "",
+		StartDate:         now.Format(kasmTimeLayout),
+		KeepaliveDate:     now.Format(kasmTimeLayout),
+		ExpirationDate:    now.Add(time.Hour).Format(kasmTimeLayout),
+		User:              &webApi.KasmInfoUser{Username: user.Username},
+		Image:             &webApi.KasmInfoImage{FriendlyName: image.FriendlyName},
+	}
+	f.kasms[kasm.KasmID] = kasm
+
+	return http.StatusOK, webApi.RequestKasmResponse{
+		KasmID:       kasm.KasmID,
+		Username:     user.Username,
+		Status:       "starting",
+		UserID:       user.UserID,
+		SessionToken: f.newID(),
+		KasmURL:      "/#/connect/kasm/" + kasm.KasmID + "/" + user.UserID,
+	}
+}
+
+func (f *FakeKasmServer) handleGetKasmStatus(body []byte) (int, interface{}) {
+	var req webApi.GetKasmStatusRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	kasm, ok := f.kasms[req.KasmID]
+	if !ok || kasm.UserID != req.UserID {
+		return errorResponse(http.StatusBadRequest, "Kasm not found")
+	}
+	return http.StatusOK, webApi.GetKasmStatusResponse{
+		OperationalMessage:  "Session ready",
+		OperationalProgress: 100,
+		OperationalStatus:   kasm.OperationalStatus,
+		Kasm:                &kasm,
+	}
+}
+
+func (f *FakeKasmServer) handleGetKasms([]byte) (int, interface{}) {
+	return http.StatusOK, webApi.GetKasmsResponse{
+		Kasms:       f.sortedKasms(),
+		CurrentTime: time.Now().UTC().Format(kasmTimeLayout),
+	}
+}
+
+func (f *FakeKasmServer) handleKeepalive(body []byte) (int, interface{}) {
+	var req webApi.KeepaliveRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	kasm, ok := f.kasms[req.KasmID]
+	if !ok {
+		return http.StatusOK, webApi.KeepaliveResponse{ErrorMessage: "Kasm not found"}
+	}
+	now := time.Now().UTC()
+	kasm.KeepaliveDate = now.Format(kasmTimeLayout)
+	kasm.ExpirationDate = now.Add(time.Hour).Format(kasmTimeLayout)
+	f.kasms[kasm.KasmID] = kasm
+	return http.StatusOK, webApi.KeepaliveResponse{}
+}
+
+func (f *FakeKasmServer) handleDestroyKasm(body []byte) (int, interface{}) {
+	var req webApi.DestroyKasmRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "Invalid request: %v", err)
+	}
+	kasm, ok := f.kasms[req.KasmID]
+	if !ok || kasm.UserID != req.UserID {
+		return http.StatusOK, webApi.DestroyKasmResponse{ErrorMessage: "Kasm not found"}
+	}
+	delete(f.kasms, req.KasmID)
+	return http.StatusOK, webApi.DestroyKasmResponse{}
+}
+
+func (f *FakeKasmServer) handleGetGroups([]byte) (int, interface{}) {
+	return http.StatusOK, webApi.GetGroupsResponse{Groups: f.groups}
+}
@@ -3,8 +3,10 @@ package webApi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"strings"
 )
 
 // CreateUserRequest represents the payload for creating a user.
@@ -39,10 +41,11 @@ type GetUsersResponse struct {
 	Users []UserResponse `json:"users"`
 }
 
-// CreateUser creates a new KASM user.
+// CreateUser creates a new KASM user. The username is normalized with NormalizeUsername.
 // Note: Requires api permission "Users Create"
 func (api *KasmAPI) CreateUser(ctx context.Context, user TargetUser) (*UserResponse, error) {
 	endpoint := "/api/public/create_user"
+	user.Username = api.NormalizeUsername(user.Username)
 	log.Info().
 		Str("method", "POST").
 		Str("endpoint", endpoint).
@@ -51,9 +54,7 @@ func (api *KasmAPI) CreateUser(ctx context.Context, user TargetUser) (*UserRespo
 
 	// Construct request payload
 	requestPayload := CreateUserRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-		TargetUser:   user,
+		TargetUser: user,
 	}
 
 	// Make POST request using the enhanced MakePostRequest method
@@ -89,10 +90,11 @@ func (api *KasmAPI) CreateUser(ctx context.Context, user TargetUser) (*UserRespo
 	return &createdUser, nil
 }
 
-// GetUser retrieves user details by userID or username.
+// GetUser retrieves user details by userID or username. The username is normalized with NormalizeUsername.
 // Note: Requires api key permission "Users View"
 func (api *KasmAPI) GetUser(ctx context.Context, userID, username string) (*UserResponse, error) {
 	endpoint := "/api/public/get_user"
+	username = api.NormalizeUsername(username)
 	log.Info().
 		Str("method", "POST").
 		Str("endpoint", endpoint).
@@ -102,8 +104,6 @@ func (api *KasmAPI) GetUser(ctx context.Context, userID, username string) (*User
 
 	// Construct request payload
 	requestPayload := GetUsersRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: TargetUser{
 			UserID:   userID,
 			Username: username,
@@ -122,29 +122,88 @@ func (api *KasmAPI) GetUser(ctx context.Context, userID, username string) (*User
 		return nil, fmt.Errorf("failed to fetch userGetResponse details: %w", err)
 	}
 
-	// Parse the response into UserResponse struct
-	var userGetResponse GetUserResponse
-	if err := json.Unmarshal(responseBytes, &userGetResponse); err != nil {
+	user, err := decodeUserResponse(responseBytes)
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str("method", "POST").
 			Str("endpoint", endpoint).
 			Str("user_id", userID).
 			Msg("Failed to decode get userGetResponse response")
-		return nil, fmt.Errorf("failed to decode get userGetResponse response: %v", err)
+		return nil, fmt.Errorf("failed to decode get userGetResponse response: %w", err)
 	}
 
-	var user = userGetResponse.User
-
 	log.Info().
 		Str("user_id", user.UserID).
 		Str("username", user.Username).
 		Msg("User details retrieved successfully")
 
 	log.Debug().
-		Str("User response", fmt.Sprintf("%+v", user)).
+		Str("User response", fmt.Sprintf("%+v", *user)).
 		Msg("User response data")
 
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user by username, normalized with NormalizeUsername.
+// Note: Requires api key permission "Users View"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - username: Username of the user, e.g. an email address.
+// Returns:
+// - The user.
+// - An error if the username is empty or the lookup fails; IsNotFound reports a missing user.
+func (api *KasmAPI) GetUserByUsername(ctx context.Context, username string) (*UserResponse, error) {
+	if strings.TrimSpace(username) == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+	return api.GetUser(ctx, "", username)
+}
+
+// UserExists looks up a user by username.
+// Note: Requires api permissions "Users View"
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - username: Username of the user.
+// Returns:
+// - Whether the user exists.
+// - The user if it exists, otherwise nil.
+// - An error if the lookup failed for another reason than a missing user.
+func (api *KasmAPI) UserExists(ctx context.Context, username string) (bool, *UserResponse, error) {
+	user, err := api.GetUserByUsername(ctx, username)
+	if IsNotFound(err) || errors.Is(err, errNoUser) {
+		log.Debug().Str("username", username).Msg("User does not exist")
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, user, nil
+}
+
+// errNoUser is returned by decodeUserResponse for a response without a user, which Kasm sends for some
+// lookups of a missing user.
+var errNoUser = errors.New("response contains no user")
+
+// decodeUserResponse decodes a user response. The API wraps the user in a "user" envelope; a bare user
+// object is accepted as well for compatibility.
+// Returns an error instead of a zero value if the body contains no user.
+func decodeUserResponse(body []byte) (*UserResponse, error) {
+	var envelope GetUserResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.User.UserID != "" || envelope.User.Username != "" {
+		return &envelope.User, nil
+	}
+
+	var user UserResponse
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, err
+	}
+	if user.UserID == "" && user.Username == "" {
+		return nil, errNoUser
+	}
 	return &user, nil
 }
 
@@ -157,10 +216,7 @@ func (api *KasmAPI) GetUsers(ctx context.Context) ([]UserResponse, error) {
 		Msg("Fetching all users")
 
 	// Construct request payload
-	requestPayload := GetUsersRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-	}
+	requestPayload := GetUsersRequest{}
 
 	// Make POST request using the enhanced MakePostRequest method
 	responseBytes, err := api.MakePostRequest(ctx, endpoint, requestPayload)
@@ -201,9 +257,12 @@ type UpdateUserRequest struct {
 
 // UpdateUser updates an existing user's details.
 // Note: Requires api permissions "Users Modify" or to update Global Admin "Users Modify Admin"
-// Requires also username and userId to be present in user TargetUser
+// Requires also username and userId to be present in user TargetUser. The username is normalized with NormalizeUsername.
 func (api *KasmAPI) UpdateUser(ctx context.Context, user TargetUser) (*UserResponse, error) {
 	endpoint := "/api/public/update_user"
+	if user.Username != "" {
+		user.Username = api.NormalizeUsername(user.Username)
+	}
 	log.Info().
 		Str("method", "POST").
 		Str("endpoint", endpoint).
@@ -212,9 +271,7 @@ func (api *KasmAPI) UpdateUser(ctx context.Context, user TargetUser) (*UserRespo
 
 	// Construct request payload
 	requestPayload := UpdateUserRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
-		TargetUser:   user,
+		TargetUser: user,
 	}
 
 	// Make POST request using the enhanced MakePostRequest method
@@ -276,8 +333,6 @@ func (api *KasmAPI) DeleteUser(ctx context.Context, userID string, force bool) e
 
 	// Construct request payload
 	requestPayload := DeleteUserRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: DeleteUserTarget{
 			UserID: userID,
 		},
@@ -331,8 +386,6 @@ func (api *KasmAPI) GetUserAttributes(ctx context.Context, userID string) (*User
 
 	// Construct request payload
 	requestPayload := GetUserAttributesRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: GetUserAttributesTarget{
 			UserID: userID,
 		},
@@ -395,8 +448,6 @@ func (api *KasmAPI) LogoutUser(ctx context.Context, userID string) error {
 
 	// Construct request payload
 	requestPayload := LogoutUserRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: LogoutUserTarget{
 			UserID: userID,
 		},
@@ -441,8 +492,6 @@ func (api *KasmAPI) UpdateUserAttributes(ctx context.Context, attributes UserAtt
 
 	// Construct request payload
 	requestPayload := UpdateUserAttributesRequest{
-		APIKey:               api.APIKey,
-		APIKeySecret:         api.APIKeySecret,
 		TargetUserAttributes: attributes,
 	}
 
@@ -493,8 +542,6 @@ func (api *KasmAPI) AddUserToGroup(ctx context.Context, userID, groupID string)
 
 	// Construct request payload
 	requestPayload := AddUserToGroupRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: AddUserToGroupTarget{
 			UserID: userID,
 		},
@@ -555,8 +602,6 @@ func (api *KasmAPI) RemoveUserFromGroup(ctx context.Context, userID, groupID str
 
 	// Construct request payload
 	requestPayload := RemoveUserFromGroupRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: RemoveUserTargetUser{
 			UserID: userID,
 		},
@@ -616,8 +661,6 @@ func (api *KasmAPI) GenerateLoginLink(ctx context.Context, userID string) (strin
 
 	// Construct request payload
 	requestPayload := GenerateLoginLinkRequest{
-		APIKey:       api.APIKey,
-		APIKeySecret: api.APIKeySecret,
 		TargetUser: GenerateLoginTargetUser{
 			UserID: userID,
 		},
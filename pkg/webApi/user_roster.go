@@ -0,0 +1,226 @@
+package webApi
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// RosterEntry describes a single user in a class roster.
+type RosterEntry struct {
+	Username  string `yaml:"username"`
+	FirstName string `yaml:"first_name"`
+	LastName  string `yaml:"last_name"`
+	GroupID   string `yaml:"group_id"`
+	Password  string `yaml:"password"` // Optional; a strong password is generated when empty.
+}
+
+// Roster is the YAML representation of a roster file.
+type Roster struct {
+	Users []RosterEntry `yaml:"users"`
+}
+
+// RosterCredential holds a password generated while creating a roster user.
+type RosterCredential struct {
+	Username string
+	UserID   string
+	Password string
+}
+
+// LoadRoster reads a roster from a CSV (.csv) or YAML (.yaml/.yml) file.
+// CSV files require a header row with the columns username, first_name, last_name, group_id and password;
+// every column except username is optional.
+func LoadRoster(path string) ([]RosterEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open roster file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var entries []RosterEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = parseRosterCSV(file)
+	case ".yaml", ".yml":
+		var roster Roster
+		err = yaml.NewDecoder(file).Decode(&roster)
+		entries = roster.Users
+	default:
+		return nil, fmt.Errorf("unsupported roster file type %q, expected .csv, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse roster file %s: %w", path, err)
+	}
+
+	for i, entry := range entries {
+		if strings.TrimSpace(entry.Username) == "" {
+			return nil, fmt.Errorf("roster entry %d has no username", i+1)
+		}
+	}
+	return entries, nil
+}
+
+// parseRosterCSV parses roster entries from CSV data with a header row.
+func parseRosterCSV(r io.Reader) ([]RosterEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("header row has no username column")
+	}
+
+	var entries []RosterEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entries = append(entries, RosterEntry{
+			Username:  field("username"),
+			FirstName: field("first_name"),
+			LastName:  field("last_name"),
+			GroupID:   field("group_id"),
+			Password:  field("password"),
+		})
+	}
+	return entries, nil
+}
+
+// CreateUsersFromRoster creates every user of a roster file that does not exist yet.
+// Users without a password in the roster get a generated one; generated passwords are written to
+// credentialsPath (mode 0600) and never logged.
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - rosterPath: Path to the CSV or YAML roster.
+// - credentialsPath: Path of the CSV file receiving the generated credentials.
+// Returns:
+// - The created users.
+// - The generated credentials.
+// - An error if the roster cannot be read, the credentials cannot be written or a user cannot be created.
+func (api *KasmAPI) CreateUsersFromRoster(ctx context.Context, rosterPath, credentialsPath string) ([]UserResponse, []RosterCredential, error) {
+	entries, err := LoadRoster(rosterPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existingUsers, err := api.GetUsers(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch existing users: %w", err)
+	}
+	existing := make(map[string]bool, len(existingUsers))
+	for _, user := range existingUsers {
+		existing[strings.ToLower(user.Username)] = true
+	}
+
+	var (
+		created     []UserResponse
+		credentials []RosterCredential
+		errs        []error
+	)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		key := strings.ToLower(entry.Username)
+		if existing[key] {
+			log.Info().Str("username", entry.Username).Msg("User already exists, skipping")
+			continue
+		}
+
+		password := entry.Password
+		generated := password == ""
+		if generated {
+			password = GeneratePassword(DefaultPasswordPolicy())
+		}
+
+		user, err := api.CreateUser(ctx, TargetUser{
+			Username:  entry.Username,
+			FirstName: entry.FirstName,
+			LastName:  entry.LastName,
+			Password:  password,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create user %s: %w", entry.Username, err))
+			continue
+		}
+		existing[key] = true
+		created = append(created, *user)
+		if generated {
+			credentials = append(credentials, RosterCredential{Username: user.Username, UserID: user.UserID, Password: password})
+		}
+
+		if entry.GroupID != "" {
+			if err := api.AddUserToGroup(ctx, user.UserID, entry.GroupID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to add user %s to group %s: %w", entry.Username, entry.GroupID, err))
+			}
+		}
+	}
+
+	// Always persist the credentials of created users, even if later entries failed.
+	if len(credentials) > 0 {
+		if err := writeRosterCredentials(credentialsPath, credentials); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	log.Info().
+		Int("roster_size", len(entries)).
+		Int("created", len(created)).
+		Int("generated_passwords", len(credentials)).
+		Msg("Roster processed")
+
+	return created, credentials, errors.Join(errs...)
+}
+
+// writeRosterCredentials writes generated credentials as CSV to a file only readable by the owner.
+func writeRosterCredentials(path string, credentials []RosterCredential) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open credentials file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	// OpenFile keeps the mode of an existing file, so tighten it explicitly.
+	if err := file.Chmod(0600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of credentials file %s: %w", path, err)
+	}
+
+	writer := csv.NewWriter(file)
+	records := [][]string{{"username", "user_id", "password"}}
+	for _, credential := range credentials {
+		records = append(records, []string{credential.Username, credential.UserID, credential.Password})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write credentials file %s: %w", path, err)
+	}
+	return file.Sync()
+}
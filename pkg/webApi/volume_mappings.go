@@ -0,0 +1,88 @@
+package webApi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// Volume mount modes accepted by Kasm.
+const (
+	VolumeModeReadOnly  = "ro"
+	VolumeModeReadWrite = "rw"
+)
+
+// VolumeMappings builds the volume_mappings JSON string of a TargetImage, which maps host paths to
+// VolumeMapping entries holding the container path, mode and ownership.
+// Add calls can be chained; the first validation error is reported by Build.
+type VolumeMappings struct {
+	mappings map[string]VolumeMapping
+	err      error
+}
+
+// NewVolumeMappings returns an empty VolumeMappings builder.
+func NewVolumeMappings() *VolumeMappings {
+	return &VolumeMappings{mappings: make(map[string]VolumeMapping)}
+}
+
+// Add mounts hostPath at containerPath.
+// Parameters:
+// - containerPath: Absolute path inside the container.
+// - hostPath: Absolute path on the agent host; each host path can only be mapped once.
+// - mode: VolumeModeReadOnly or VolumeModeReadWrite.
+// - uid: Owner UID of the mount, 0 to use the Kasm default.
+// - gid: Owner GID of the mount, 0 to use the Kasm default.
+// Returns:
+// - The builder, for chaining.
+func (v *VolumeMappings) Add(containerPath, hostPath, mode string, uid, gid int) *VolumeMappings {
+	if v.err != nil {
+		return v
+	}
+
+	switch {
+	case !path.IsAbs(containerPath):
+		v.err = fmt.Errorf("container path %q must be absolute", containerPath)
+	case !path.IsAbs(hostPath):
+		v.err = fmt.Errorf("host path %q must be absolute", hostPath)
+	case mode != VolumeModeReadOnly && mode != VolumeModeReadWrite:
+		v.err = fmt.Errorf("invalid mode %q for %s: must be %s or %s", mode, hostPath, VolumeModeReadOnly, VolumeModeReadWrite)
+	case uid < 0 || gid < 0:
+		v.err = fmt.Errorf("uid and gid of %s must not be negative", hostPath)
+	default:
+		if _, exists := v.mappings[hostPath]; exists {
+			v.err = fmt.Errorf("host path %s is mapped more than once", hostPath)
+			return v
+		}
+		v.mappings[hostPath] = VolumeMapping{Bind: containerPath, Mode: mode, Uid: uid, Gid: gid}
+	}
+	return v
+}
+
+// Build returns the volume_mappings JSON string with keys in sorted order.
+// Returns:
+// - The serialized mappings, or an empty string if none were added.
+// - The first validation error of an Add call.
+func (v *VolumeMappings) Build() (string, error) {
+	if v.err != nil {
+		return "", v.err
+	}
+	if len(v.mappings) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(v.mappings)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize volume mappings: %w", err)
+	}
+	return string(data), nil
+}
+
+// ApplyTo stores the built mappings in target.VolumeMappings.
+func (v *VolumeMappings) ApplyTo(target *TargetImage) error {
+	mappings, err := v.Build()
+	if err != nil {
+		return err
+	}
+	target.VolumeMappings = mappings
+	return nil
+}
@@ -0,0 +1,130 @@
+package webApi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GetWorkspace reads a workspace image back from Kasm as a TargetImage, so a definition can be verified or
+// compared with the live image after it was created.
+// Note: requires api key with "Images View" permission
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - imageID: ID of the workspace image.
+// Returns:
+// - The image in the representation accepted by create_image and update_image; the docker token is empty
+// because Kasm does not return it in clear text.
+// - An error if the image does not exist or the response cannot be decoded.
+func (api *KasmAPI) GetWorkspace(ctx context.Context, imageID string) (*TargetImage, error) {
+	if imageID == "" {
+		return nil, fmt.Errorf("image ID cannot be empty")
+	}
+	fields, err := api.rawImageFields(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := targetImageFields(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert image %s: %w", imageID, err)
+	}
+
+	var workspace TargetImage
+	if err := decodeFieldMap(target, &workspace); err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", imageID, err)
+	}
+	return &workspace, nil
+}
+
+// UpdateWorkspace updates a workspace image so it matches desired, sending only the fields that differ from
+// the live image. Every other field, e.g. launch_config, is sent back exactly as Kasm returned it, so repeated
+// reconciles neither churn unchanged settings nor send an update when nothing changed. Fields that are empty
+// in desired are left as they are, like in DiffTargetImage.
+// Note: requires api key with "Images View" and "Images Modify" permissions
+// Parameters:
+// - ctx: Context for managing cancellation and timeouts.
+// - desired: The desired image definition; its ImageID identifies the image to update.
+// Returns:
+// - The changed fields; empty if the image was already up to date and no update was sent.
+// - An error if the image does not exist or cannot be updated.
+func (api *KasmAPI) UpdateWorkspace(ctx context.Context, desired TargetImage) ([]FieldChange, error) {
+	imageID := desired.ImageID
+	if imageID == "" {
+		return nil, fmt.Errorf("image_id must be set in TargetImage before calling UpdateWorkspace")
+	}
+
+	fields, err := api.rawImageFields(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	var current ImageDetail
+	if err := decodeFieldMap(fields, &current); err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", imageID, err)
+	}
+
+	changes := DiffTargetImage(current, desired)
+	if len(changes) == 0 {
+		log.Info().Str("image_id", imageID).Msg("Workspace image is up to date")
+		return nil, nil
+	}
+
+	target, err := targetImageFields(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert image %s: %w", imageID, err)
+	}
+	desiredFields, err := rawFieldMap(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode desired image %s: %w", imageID, err)
+	}
+	// Converted like the live fields, so e.g. a launch_config object is sent as JSON string as well.
+	desiredTarget, err := targetImageFields(desiredFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert desired image %s: %w", imageID, err)
+	}
+	for _, change := range changes {
+		target[change.Field] = desiredTarget[change.Field]
+		log.Info().
+			Str("image_id", imageID).
+			Str("field", change.Field).
+			Str("old", formatFieldValue(change.Old)).
+			Str("new", formatFieldValue(change.New)).
+			Msg("Updating workspace image field")
+	}
+
+	payload := map[string]interface{}{
+		"target_image": target,
+	}
+	if _, err := api.MakePostRequest(ctx, "/api/public/update_image", payload); err != nil {
+		return nil, fmt.Errorf("failed to update image %s: %w", imageID, err)
+	}
+
+	log.Info().
+		Str("image_id", imageID).
+		Int("changed_fields", len(changes)).
+		Msg("Workspace image updated")
+	return changes, nil
+}
+
+// rawFieldMap converts a struct into a map of its JSON encoded fields keyed by their JSON names.
+func rawFieldMap(value interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// decodeFieldMap decodes fields keyed by their JSON names into target.
+func decodeFieldMap(fields map[string]json.RawMessage, target interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}